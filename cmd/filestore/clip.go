@@ -0,0 +1,112 @@
+package filestore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/mcp"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/clip"
+)
+
+var (
+	clipSelect  []string
+	clipList    bool
+	clipCopy    bool
+	clipTimeout int
+	clipTags    map[string]string
+)
+
+func init() {
+	clipCmd.Flags().StringSliceVarP(&clipSelect, "select", "s", nil, "upload only these files: 1-based indexes or filename glob patterns, e.g. -s 2 -s '*.png'")
+	clipCmd.Flags().BoolVarP(&clipList, "list", "l", false, "only list the files found in the clipboard")
+	clipCmd.Flags().BoolVar(&clipCopy, "copy", true, "copy the resulting URLs to the clipboard")
+	clipCmd.Flags().IntVar(&clipTimeout, "timeout", 5, "clipboard read timeout in seconds")
+	clipCmd.Flags().StringToStringVarP(&clipTags, "tag", "t", nil, "store key=value tags with the uploaded objects, repeatable")
+	rootCmd.AddCommand(clipCmd)
+}
+
+var clipCmd = &cobra.Command{
+	Use:   "clip",
+	Short: "Upload the files in the clipboard",
+	Long: `Detect the files in the clipboard (copied files, URI lists, paths or filenames in copied text),
+upload them to the configured storage and print the URLs, one per line.
+The URLs are also copied back to the clipboard, unless --copy=false.`,
+	Example: `  file-store-mcp clip
+  file-store-mcp clip --list
+  file-store-mcp clip -s '*.pdf' --copy=false`,
+	Args: cobra.NoArgs,
+	RunE: Clip,
+}
+
+func Clip(cmd *cobra.Command, args []string) error {
+	if err := storage.ValidateTags(clipTags); err != nil {
+		return usageError{err}
+	}
+
+	files, err := clip.GetFilesWithSource(clipTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to get files from clipboard: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files found in clipboard")
+	}
+
+	if clipList {
+		if JSONOutput {
+			return printJSON(cmd, files)
+		}
+		for i, file := range files {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d: %s\n", i+1, file.Describe())
+		}
+		return nil
+	}
+
+	if len(clipSelect) > 0 {
+		if files, err = clip.Select(files, clipSelect); err != nil {
+			return err
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
+	// Same checks as the upload_clipboard_files tool
+	store := storage.NewService()
+	defer store.Close()
+	service := mcp.NewService(store)
+
+	validatedPaths, err := service.ValidatePaths(paths)
+	if err != nil {
+		return err
+	}
+
+	ctx := audit.WithCaller(cmd.Context(), audit.Caller{Transport: "cli", Tool: "clip"})
+	ctx = storage.WithTags(ctx, clipTags)
+	warnings, err := service.CheckContents(ctx, validatedPaths)
+	if err != nil {
+		return err
+	}
+
+	results := make([]uploadResult, 0, len(validatedPaths))
+	for i, path := range validatedPaths {
+		uploadCtx, notes := storage.WithNotes(ctx)
+		uploaded, err := store.UploadFileDetailed(uploadCtx, path, filepath.Base(path))
+		results = append(results, newUploadResult(path, uploaded, append([]string{warnings[i]}, notes.List()...), err))
+	}
+
+	printErr := printUploadResults(cmd, results)
+	if urls := uploadedURLs(results); clipCopy && len(urls) > 0 {
+		if err := clip.SetText(strings.Join(urls, "\n")); err != nil {
+			return fmt.Errorf("uploaded, but failed to copy the URLs to the clipboard: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Copied %d URLs to the clipboard\n", len(urls))
+	}
+	return printErr
+}
@@ -0,0 +1,302 @@
+package filestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/mcp"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+var (
+	ConfigFile string
+
+	configInitType   string
+	configInitOutput string
+	configInitForce  bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&ConfigFile, "config", "c", "", "load environment variables from this file, e.g. one written by \"config init\" (env FSM_CONFIG)")
+
+	configInitCmd.Flags().StringVarP(&configInitType, "type", "t", "", "storage type: "+strings.Join(storageTypes(), ", ")+" (prompted if omitted)")
+	configInitCmd.Flags().StringVarP(&configInitOutput, "output", "o", "", "write the config file here instead of printing it")
+	configInitCmd.Flags().BoolVarP(&configInitForce, "force", "f", false, "overwrite an existing config file")
+
+	configCmd.AddCommand(configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage configuration",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a config file and the MCP client configuration",
+	Long: `Generate a commented config file (environment variables) for a storage backend,
+together with the JSON block to paste into the MCP client configuration.
+
+Without --type, the storage type and its settings are asked for interactively.`,
+	Example: `  file-store-mcp config init
+  file-store-mcp config init --type s3 -o ~/.config/file-store-mcp.env`,
+	Args: cobra.NoArgs,
+	RunE: ConfigInit,
+}
+
+func ConfigInit(cmd *cobra.Command, args []string) error {
+	var (
+		backend storage.BackendInfo
+		values  = make(map[string]string)
+		ok      bool
+	)
+
+	if configInitType != "" {
+		backend, ok = storage.LookupBackend(strings.ToLower(configInitType))
+		if !ok {
+			return fmt.Errorf("unknown storage type %q, supported: %s", configInitType, strings.Join(storageTypes(), ", "))
+		}
+	} else {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("--type is required when not running in a terminal")
+		}
+		var err error
+		if backend, values, err = promptBackend(bufio.NewReader(os.Stdin), cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+	}
+
+	envFile := renderEnvFile(backend, values)
+
+	mcpArgs := []string{}
+	mcpEnv := map[string]string{"FSM_STORAGE_TYPE": backend.Type}
+	if configInitOutput != "" {
+		path, err := filepath.Abs(configInitOutput)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil && !configInitForce {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		}
+		// The file holds credentials, keep it private
+		if err := os.WriteFile(path, []byte(envFile), 0600); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Config file written to %s\n\n", path)
+
+		// Let the client point at the file instead of duplicating the credentials
+		mcpArgs = append(mcpArgs, "--config", path)
+		mcpEnv = map[string]string{}
+	} else {
+		fmt.Fprint(cmd.OutOrStdout(), envFile)
+		fmt.Fprintln(cmd.OutOrStdout())
+		for _, setting := range backend.Settings {
+			if value, ok := values[setting.Env]; ok || setting.Required {
+				mcpEnv[setting.Env] = value
+			}
+		}
+	}
+
+	clientConfig, err := renderClientConfig(mcpArgs, mcpEnv)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "# MCP client configuration (e.g. claude_desktop_config.json):\n%s\n", clientConfig)
+	return nil
+}
+
+// promptBackend asks for the storage type and its settings
+func promptBackend(in *bufio.Reader, out io.Writer) (storage.BackendInfo, map[string]string, error) {
+	fmt.Fprintln(out, "Storage types:")
+	for i, backend := range storage.Backends {
+		fmt.Fprintf(out, "  %d) %-7s %s\n", i+1, backend.Type, backend.Name)
+	}
+
+	var backend storage.BackendInfo
+	for {
+		answer, err := prompt(in, out, "Choose a storage type [1-%d]: ", len(storage.Backends))
+		if err != nil {
+			return backend, nil, err
+		}
+		var index int
+		if _, err := fmt.Sscanf(answer, "%d", &index); err == nil && index >= 1 && index <= len(storage.Backends) {
+			backend = storage.Backends[index-1]
+			break
+		}
+		if found, ok := storage.LookupBackend(strings.ToLower(answer)); ok {
+			backend = found
+			break
+		}
+		fmt.Fprintln(out, "Invalid choice.")
+	}
+
+	fmt.Fprintf(out, "\nConfiguring %s. Press Enter to skip optional settings.\n", backend.Name)
+	values := make(map[string]string)
+	for _, setting := range backend.Settings {
+		label := setting.Description
+		if setting.Default != "" {
+			label += fmt.Sprintf(", default %s", setting.Default)
+		}
+		if !setting.Required {
+			label += ", optional"
+		}
+		for {
+			answer, err := prompt(in, out, "%s (%s): ", setting.Env, label)
+			if err != nil {
+				return backend, nil, err
+			}
+			if answer != "" {
+				values[setting.Env] = answer
+				break
+			}
+			if !setting.Required {
+				break
+			}
+			fmt.Fprintln(out, "This setting is required.")
+		}
+	}
+	fmt.Fprintln(out)
+
+	return backend, values, nil
+}
+
+func prompt(in *bufio.Reader, out io.Writer, format string, a ...interface{}) (string, error) {
+	fmt.Fprintf(out, format, a...)
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// renderEnvFile renders the commented config file for backend. Optional settings
+// without a value are commented out, showing their default.
+func renderEnvFile(backend storage.BackendInfo, values map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# file-store-mcp configuration: %s\n", backend.Name)
+	fmt.Fprintf(&b, "# Load it with `file-store-mcp --config <this file>`.\n\n")
+	fmt.Fprintf(&b, "FSM_STORAGE_TYPE=%s\n", backend.Type)
+
+	for _, setting := range backend.Settings {
+		fmt.Fprintf(&b, "\n# %s", setting.Description)
+		if setting.Required {
+			b.WriteString(" (required)")
+		}
+		b.WriteString("\n")
+
+		value, ok := values[setting.Env]
+		switch {
+		case ok:
+			fmt.Fprintf(&b, "%s=%s\n", setting.Env, quoteEnvValue(value))
+		case setting.Required:
+			fmt.Fprintf(&b, "%s=\n", setting.Env)
+		default:
+			fmt.Fprintf(&b, "# %s=%s\n", setting.Env, setting.Default)
+		}
+	}
+	return b.String()
+}
+
+// quoteEnvValue quotes values that would otherwise be misread by the config loader
+func quoteEnvValue(value string) string {
+	if strings.ContainsAny(value, " #\"'\t") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+// renderClientConfig renders the mcpServers block for MCP clients
+func renderClientConfig(args []string, env map[string]string) (string, error) {
+	command, err := os.Executable()
+	if err != nil {
+		command = "file-store-mcp"
+	}
+
+	server := map[string]interface{}{
+		"command": command,
+		"args":    args,
+	}
+	if len(env) > 0 {
+		server["env"] = env
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			mcp.Name: server,
+		},
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// loadConfigFile sets the environment variables from the config file given by --config or
+// FSM_CONFIG. Variables already set in the environment take precedence.
+func loadConfigFile() error {
+	path := ConfigFile
+	if path == "" {
+		path = os.Getenv("FSM_CONFIG")
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("%s:%d: invalid quoted value", path, lineNo)
+			}
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func storageTypes() []string {
+	types := make([]string, 0, len(storage.Backends))
+	for _, backend := range storage.Backends {
+		types = append(types, backend.Type)
+	}
+	return types
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,138 @@
+package filestore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+var (
+	deleteIDs    []string
+	deleteDryRun bool
+)
+
+func init() {
+	deleteCmd.Flags().StringSliceVar(&deleteIDs, "id", nil, "delete the object of the upload with this history ID (or ID prefix), repeatable")
+	deleteCmd.Flags().BoolVarP(&deleteDryRun, "dry-run", "n", false, "only print the objects that would be deleted")
+	rootCmd.AddCommand(deleteCmd)
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [KEY]...",
+	Short: "Delete uploaded files from the storage",
+	Long: `Delete objects from the configured storage, given by object key or by the ID
+of the upload in the history (see "history"). Supported by S3, OSS, COS, Qiniu and GitHub.`,
+	Example: `  file-store-mcp delete 1712345678-report.pdf
+  file-store-mcp delete --id 3f2a9c1e --dry-run`,
+	RunE: Delete,
+}
+
+func Delete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && len(deleteIDs) == 0 {
+		return usageError{fmt.Errorf("no objects given, pass object keys or --id")}
+	}
+
+	config := storage.NewConfigFromEnv()
+	keys := append([]string{}, args...)
+	if len(deleteIDs) > 0 {
+		idKeys, err := resolveHistoryIDs(config, deleteIDs)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, idKeys...)
+	}
+
+	results := make([]deleteResult, 0, len(keys))
+	if deleteDryRun {
+		for _, key := range keys {
+			results = append(results, deleteResult{Key: key, DryRun: true})
+		}
+		return printDeleteResults(cmd, results)
+	}
+
+	store, err := storage.OpenStorage(config)
+	if err != nil {
+		return err
+	}
+	service := &storage.Service{Storage: store, Config: config}
+
+	for _, key := range keys {
+		result := deleteResult{Key: key, Deleted: true}
+		if err := service.Delete(cmd.Context(), key); err != nil {
+			result = deleteResult{Key: key, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return printDeleteResults(cmd, results)
+}
+
+// deleteResult is the outcome of deleting one object
+type deleteResult struct {
+	Key     string `json:"key"`
+	Deleted bool   `json:"deleted"`
+	DryRun  bool   `json:"dry_run,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// printDeleteResults prints the results as text or JSON, returning errReported if any deletion failed
+func printDeleteResults(cmd *cobra.Command, results []deleteResult) error {
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Error != ""
+	}
+
+	if JSONOutput {
+		if err := printJSON(cmd, results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range results {
+			switch {
+			case result.Error != "":
+				fmt.Fprintf(cmd.ErrOrStderr(), "error: %s: %s\n", result.Key, result.Error)
+			case result.DryRun:
+				fmt.Fprintf(cmd.OutOrStdout(), "would delete %s\n", result.Key)
+			default:
+				fmt.Fprintf(cmd.OutOrStdout(), "deleted %s\n", result.Key)
+			}
+		}
+	}
+
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// resolveHistoryIDs looks up the object keys of successful uploads to the configured storage
+func resolveHistoryIDs(config *storage.Config, ids []string) ([]string, error) {
+	if config.AuditLog == "" {
+		return nil, fmt.Errorf("--id needs the upload history, set FSM_AUDIT_LOG")
+	}
+	all, err := audit.ReadAll(config.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := strings.ToLower(config.StorageType)
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		entries := audit.Query{ID: id, Result: audit.ResultSuccess}.Filter(all)
+		switch {
+		case len(entries) == 0:
+			return nil, fmt.Errorf("no successful upload with ID %s", id)
+		case len(entries) > 1:
+			return nil, fmt.Errorf("ID prefix %s matches %d uploads, use a longer prefix", id, len(entries))
+		}
+		entry := entries[0]
+		if entry.Backend != backend {
+			return nil, fmt.Errorf("upload %s was made to %s, configured storage is %s", shortID(entry.ID), entry.Backend, backend)
+		}
+		keys = append(keys, entry.ObjectKey)
+	}
+	return keys, nil
+}
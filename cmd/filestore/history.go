@@ -0,0 +1,233 @@
+package filestore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+var (
+	historySince      string
+	historyUntil      string
+	historyBackend    string
+	historyHash       string
+	historyID         string
+	historyCaller     string
+	historyFailed     bool
+	historyLimit      int
+	historyURLs       bool
+	historyResign     bool
+	historyExpiration time.Duration
+)
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only uploads at or after this time: a date (2006-01-02), RFC 3339 time or age (24h, 7d)")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "only uploads before this time, same formats as --since")
+	historyCmd.Flags().StringVar(&historyBackend, "backend", "", "only uploads to this storage type")
+	historyCmd.Flags().StringVar(&historyHash, "hash", "", "only uploads with this SHA-256 (or hash prefix)")
+	historyCmd.Flags().StringVar(&historyID, "id", "", "only the upload with this ID (or ID prefix)")
+	historyCmd.Flags().StringVar(&historyCaller, "caller", "", "only uploads whose API key, client, session or address contains this")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "only failed uploads")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "show the most recent N uploads, 0 for all")
+	historyCmd.Flags().BoolVar(&historyURLs, "urls", false, "print only the URLs, one per line")
+	historyCmd.Flags().BoolVar(&historyResign, "resign", false, "generate fresh download URLs with the configured storage")
+	historyCmd.Flags().DurationVar(&historyExpiration, "expiration", 0, "validity of re-signed URLs, defaults to the configured URL expiration")
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Query the upload history",
+	Long: `Query the upload history recorded in the audit log (FSM_AUDIT_LOG).
+
+With --resign, fresh download URLs are generated for past uploads to the configured
+storage, replacing expired presigned URLs.`,
+	Example: `  file-store-mcp history --since 7d --backend s3
+  file-store-mcp history --hash 3a7bd3e2 --urls --resign --expiration 24h
+  file-store-mcp history --caller 203.0.113.7`,
+	Args: cobra.NoArgs,
+	RunE: History,
+}
+
+func History(cmd *cobra.Command, args []string) error {
+	config := storage.NewConfigFromEnv()
+	if config.AuditLog == "" {
+		return fmt.Errorf("no upload history, set FSM_AUDIT_LOG to record uploads")
+	}
+
+	query := audit.Query{
+		Backend: historyBackend,
+		SHA256:  historyHash,
+		ID:      historyID,
+		Caller:  historyCaller,
+		Limit:   historyLimit,
+	}
+	if historyFailed {
+		query.Result = audit.ResultFailure
+	}
+	var err error
+	if query.Since, err = parseHistoryTime(historySince); err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	if query.Until, err = parseHistoryTime(historyUntil); err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+
+	all, err := audit.ReadAll(config.AuditLog)
+	if err != nil {
+		return err
+	}
+	entries := query.Filter(all)
+
+	if historyResign {
+		if err := resignEntries(cmd, config, entries); err != nil {
+			return err
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case JSONOutput:
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		return printJSON(cmd, entries)
+	case historyURLs:
+		for _, entry := range entries {
+			if entry.URL != "" {
+				fmt.Fprintln(out, entry.URL)
+			}
+		}
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tID\tBACKEND\tSIZE\tRESULT\tKEY\tCALLER\tEXPIRES\tURL / ERROR")
+		for _, entry := range entries {
+			detail := entry.URL
+			if entry.Result != audit.ResultSuccess {
+				detail = entry.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.Time.Local().Format("2006-01-02 15:04:05"), shortID(entry.ID), entry.Backend,
+				util.FormatSize(entry.Size), entry.Result, entry.ObjectKey, formatCaller(entry), formatExpiry(entry.ExpiresAt), detail)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// resignEntries replaces the URLs of successful uploads to the configured storage with fresh ones
+func resignEntries(cmd *cobra.Command, config *storage.Config, entries []audit.Entry) error {
+	backend := strings.ToLower(config.StorageType)
+	store, err := storage.OpenStorage(config)
+	if err != nil {
+		return err
+	}
+	presigner, ok := store.(storage.Presigner)
+	if !ok {
+		return fmt.Errorf("storage %s doesn't support re-signing URLs", backend)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Result != audit.ResultSuccess || entry.ObjectKey == "" {
+			continue
+		}
+		if entry.Backend != backend {
+			fmt.Fprintf(cmd.ErrOrStderr(), "skipping %s: uploaded to %s, configured storage is %s\n", shortID(entry.ID), entry.Backend, backend)
+			continue
+		}
+		url, err := presigner.Presign(cmd.Context(), entry.ObjectKey, historyExpiration)
+		if err != nil {
+			return fmt.Errorf("failed to re-sign %s: %w", entry.ObjectKey, err)
+		}
+		entry.URL = url
+		entry.ExpiresAt = resignedExpiry(store)
+	}
+	return nil
+}
+
+// resignedExpiry returns when a URL re-signed now expires, zero if it doesn't
+func resignedExpiry(store storage.Storage) time.Time {
+	expirer, ok := store.(storage.URLExpirer)
+	if !ok || expirer.URLExpiration() <= 0 {
+		return time.Time{}
+	}
+	if historyExpiration > 0 {
+		return time.Now().Add(historyExpiration)
+	}
+	return time.Now().Add(expirer.URLExpiration())
+}
+
+// formatCaller describes who requested an upload for the history table,
+// e.g. "team-a claude-code 1.0 @203.0.113.7"
+func formatCaller(entry audit.Entry) string {
+	var parts []string
+	for _, part := range []string{entry.APIKey, entry.Client} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	switch {
+	case entry.ForwardedFor != "":
+		parts = append(parts, "@"+entry.ForwardedFor)
+	case entry.RemoteAddr != "":
+		parts = append(parts, "@"+entry.RemoteAddr)
+	}
+	switch {
+	case len(parts) > 0:
+		return strings.Join(parts, " ")
+	case entry.Transport != "":
+		return entry.Transport
+	default:
+		return "-"
+	}
+}
+
+// formatExpiry describes when a URL expires for the history table
+func formatExpiry(expiresAt time.Time) string {
+	switch {
+	case expiresAt.IsZero():
+		return "-"
+	case expiresAt.Before(time.Now()):
+		return "expired"
+	default:
+		return expiresAt.Local().Format("2006-01-02 15:04")
+	}
+}
+
+// parseHistoryTime parses a date, an RFC 3339 time or an age such as 24h or 7d
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
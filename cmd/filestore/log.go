@@ -1,22 +1,118 @@
 package filestore
 
 import (
+	"io"
+	stdlog "log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+var (
+	Debug    bool
+	LogLevel string
+	Quiet    bool
+
+	// Log file options
+	LogFile           string
+	LogMaxSize        int
+	LogMaxBackups     int
+	LogMaxAge         int
+	LogRotateInterval time.Duration
 )
 
-var Debug bool
+func init() {
+	rootCmd.PersistentFlags().StringVar(&LogLevel, "log-level", "", "log level: trace, debug, info, warn, error (env FSM_LOG_LEVEL, default info)")
+	rootCmd.PersistentFlags().BoolVarP(&Quiet, "quiet", "q", false, "don't log to stderr, only to --log-file if set")
+	rootCmd.PersistentFlags().StringVar(&LogFile, "log-file", "", "also write logs to this file (JSON lines)")
+	rootCmd.PersistentFlags().IntVar(&LogMaxSize, "log-max-size", 100, "max size in megabytes of the log file before it gets rotated")
+	rootCmd.PersistentFlags().IntVar(&LogMaxBackups, "log-max-backups", 5, "max number of rotated log files to keep, 0 keeps all")
+	rootCmd.PersistentFlags().IntVar(&LogMaxAge, "log-max-age", 30, "max days to keep rotated log files, 0 keeps all")
+	rootCmd.PersistentFlags().DurationVar(&LogRotateInterval, "log-rotate-interval", 0, "also rotate the log file at this interval, e.g. 24h")
+}
 
 func initLog(cmd *cobra.Command, args []string) {
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	zerolog.SetGlobalLevel(logLevel())
+
+	// Logs never go to stdout, which carries the JSON-RPC stream in stdio mode
+	writers := make([]io.Writer, 0, 2)
+	if !Quiet {
+		writers = append(writers, zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+	}
+	if LogFile != "" {
+		writers = append(writers, newLogFile())
+	}
+
+	var out io.Writer = io.Discard
+	switch len(writers) {
+	case 1:
+		out = writers[0]
+	case 2:
+		out = zerolog.MultiLevelWriter(writers...)
+	}
+
+	// Keep credentials and URL signatures echoed by SDK errors out of the logs
+	log.Logger = log.Output(util.NewRedactWriter(out))
+
+	// Route the standard library logger, used by dependencies, through zerolog
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(log.Logger)
+
+	// Contexts without a request logger fall back to the global logger
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// logLevel resolves the log level from --log-level, the deprecated --debug flag
+// and the FSM_LOG_LEVEL environment variable, in that order
+func logLevel() zerolog.Level {
+	level := LogLevel
+	if level == "" && Debug {
+		level = "debug"
+	}
+	if level == "" {
+		level = os.Getenv("FSM_LOG_LEVEL")
+	}
+	if level == "" {
+		return zerolog.InfoLevel
+	}
+
+	parsed, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || parsed == zerolog.NoLevel {
+		log.Warn().Str("level", level).Msg("unknown log level, using info")
+		return zerolog.InfoLevel
+	}
+	return parsed
+}
+
+// newLogFile opens the rotating log file
+func newLogFile() io.Writer {
+	logFile := &lumberjack.Logger{
+		Filename:   LogFile,
+		MaxSize:    LogMaxSize,
+		MaxBackups: LogMaxBackups,
+		MaxAge:     LogMaxAge,
+		LocalTime:  true,
+	}
 
-	if Debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	// Rotate on a schedule in addition to the size limit
+	if LogRotateInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(LogRotateInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := logFile.Rotate(); err != nil {
+					log.Err(err).Msg("failed to rotate log file")
+				}
+			}
+		}()
 	}
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+	return logFile
 }
@@ -0,0 +1,155 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1 // The command failed, e.g. an upload or the storage initialization
+	ExitUsage = 2 // Invalid flags or arguments
+)
+
+// JSONOutput makes the commands print JSON on stdout instead of text
+var JSONOutput bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&JSONOutput, "json", false, "print machine-readable JSON on stdout")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+}
+
+// usageError marks errors caused by invalid command line flags or arguments
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error {
+	return e.error
+}
+
+// markUsageErrors wraps the positional argument validators of cmd and its
+// subcommands so their errors count as usage errors
+func markUsageErrors(cmd *cobra.Command) {
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			if err := args(cmd, a); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// exitCode returns the process exit code for the error returned by a command
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var usage usageError
+	if errors.As(err, &usage) {
+		return ExitUsage
+	}
+	return ExitError
+}
+
+// printJSON writes v as indented JSON to the command output
+func printJSON(cmd *cobra.Command, v interface{}) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printError reports the error of a failed command, as JSON on stdout with --json
+func printError(cmd *cobra.Command, err error) {
+	if JSONOutput {
+		_ = printJSON(cmd, map[string]interface{}{
+			"error":     err.Error(),
+			"exit_code": exitCode(err),
+		})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+}
+
+// uploadResult is the outcome of uploading one file or URL
+type uploadResult struct {
+	Source string `json:"source"`
+	// The uploaded object, nil if the upload failed
+	*storage.UploadResult
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// printUploadResults prints the URLs on stdout and the warnings and errors on stderr,
+// or all results as JSON. errReported is returned if any upload failed.
+func printUploadResults(cmd *cobra.Command, results []uploadResult) error {
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Error != ""
+	}
+
+	if JSONOutput {
+		if err := printJSON(cmd, results); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range results {
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s: %s\n", result.Source, warning)
+			}
+			if result.Error != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "error: %s: %s\n", result.Source, result.Error)
+				continue
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), result.URL)
+		}
+	}
+
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// newUploadResult builds the result of an upload, dropping empty warnings
+func newUploadResult(source string, uploaded *storage.UploadResult, warnings []string, err error) uploadResult {
+	result := uploadResult{Source: source, UploadResult: uploaded}
+	for _, warning := range warnings {
+		if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+	if err != nil {
+		result.Error = util.Redact(err.Error())
+	}
+	return result
+}
+
+// uploadedURLs returns the URLs of the successful uploads
+func uploadedURLs(results []uploadResult) []string {
+	urls := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Error == "" {
+			urls = append(urls, result.URL)
+		}
+	}
+	return urls
+}
+
+// errReported is returned by commands that already reported their failures in
+// the output, e.g. per file upload errors, to only set the exit code
+var errReported = errors.New("failures reported in the output")
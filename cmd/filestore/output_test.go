@@ -0,0 +1,154 @@
+package filestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+// withJSONOutput sets the --json flag for the duration of the test
+func withJSONOutput(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := JSONOutput
+	JSONOutput = enabled
+	t.Cleanup(func() { JSONOutput = previous })
+}
+
+// executeRoot runs the root command with args and returns its error
+func executeRoot(t *testing.T, args ...string) error {
+	t.Helper()
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+	return rootCmd.Execute()
+}
+
+func TestExitCode(t *testing.T) {
+	argsCmd := &cobra.Command{Use: "test", Args: cobra.ExactArgs(1), RunE: func(*cobra.Command, []string) error { return nil }}
+	markUsageErrors(argsCmd)
+	argsCmd.SetArgs([]string{})
+	argsCmd.SetOut(&bytes.Buffer{})
+	argsCmd.SetErr(&bytes.Buffer{})
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: ExitOK},
+		{name: "failure", err: errors.New("upload failed"), want: ExitError},
+		{name: "failures in the output", err: errReported, want: ExitError},
+		{name: "usage", err: usageError{errors.New("unknown flag: --bogus")}, want: ExitUsage},
+		{name: "wrapped usage", err: fmt.Errorf("url: %w", usageError{errors.New("bad argument")}), want: ExitUsage},
+		{name: "argument validator", err: argsCmd.Execute(), want: ExitUsage},
+		{name: "unknown flag", err: executeRoot(t, "--no-such-flag"), want: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintErrorJSON(t *testing.T) {
+	withJSONOutput(t, true)
+	tests := []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{name: "failure", err: errors.New("storage not configured"), want: map[string]interface{}{"error": "storage not configured", "exit_code": float64(ExitError)}},
+		{name: "usage", err: usageError{errors.New("accepts 1 arg(s), received 0")}, want: map[string]interface{}{"error": "accepts 1 arg(s), received 0", "exit_code": float64(ExitUsage)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&out)
+			printError(cmd, tt.err)
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("output %q isn't JSON: %v", out.String(), err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("printError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintUploadResults(t *testing.T) {
+	uploaded := newUploadResult("a.txt", &storage.UploadResult{URL: "https://example.com/a.txt", ObjectKey: "a.txt", Backend: "s3", Size: 5}, []string{"", "large file"}, nil)
+	failed := newUploadResult("b.txt", nil, nil, errors.New("access denied"))
+
+	tests := []struct {
+		name    string
+		json    bool
+		results []uploadResult
+		err     error
+		stdout  string
+		stderr  string
+	}{
+		{name: "text", results: []uploadResult{uploaded}, stdout: "https://example.com/a.txt\n", stderr: "warning: a.txt: large file\n"},
+		{name: "text with a failure", results: []uploadResult{uploaded, failed}, err: errReported, stdout: "https://example.com/a.txt\n", stderr: "warning: a.txt: large file\nerror: b.txt: access denied\n"},
+		{name: "JSON with a failure", json: true, results: []uploadResult{uploaded, failed}, err: errReported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withJSONOutput(t, tt.json)
+			var stdout, stderr bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			if err := printUploadResults(cmd, tt.results); err != tt.err {
+				t.Errorf("printUploadResults() = %v, want %v", err, tt.err)
+			}
+			if !tt.json {
+				if stdout.String() != tt.stdout || stderr.String() != tt.stderr {
+					t.Errorf("printed %q and %q, want %q and %q", stdout.String(), stderr.String(), tt.stdout, tt.stderr)
+				}
+				return
+			}
+
+			// Every result is printed, failures with the error and without the object fields
+			var got []map[string]interface{}
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("output %q isn't JSON: %v", stdout.String(), err)
+			}
+			if stderr.Len() != 0 {
+				t.Errorf("printed %q on stderr", stderr.String())
+			}
+			if len(got) != 2 {
+				t.Fatalf("printed %d results, want 2", len(got))
+			}
+			if got[0]["source"] != "a.txt" || got[0]["url"] != "https://example.com/a.txt" || got[0]["backend"] != "s3" || got[0]["error"] != nil {
+				t.Errorf("result = %v, want the uploaded object", got[0])
+			}
+			if warnings, _ := got[0]["warnings"].([]interface{}); len(warnings) != 1 || warnings[0] != "large file" {
+				t.Errorf("warnings = %v, want the non-empty one", got[0]["warnings"])
+			}
+			if got[1]["source"] != "b.txt" || got[1]["error"] != "access denied" || got[1]["url"] != nil {
+				t.Errorf("result = %v, want only the error", got[1])
+			}
+			if !strings.HasSuffix(stdout.String(), "\n") {
+				t.Error("output doesn't end with a newline")
+			}
+		})
+	}
+}
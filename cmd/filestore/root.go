@@ -1,25 +1,43 @@
 package filestore
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"os"
 
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"github.com/sjzar/file-store-mcp/internal/filestore"
 )
 
+var NoDelete bool
+
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&NoDelete, "no-delete", false, "never delete or overwrite stored objects, regardless of the configuration (env FSM_NO_DELETE)")
 	rootCmd.PersistentFlags().BoolVar(&Debug, "debug", false, "debug")
-	rootCmd.PersistentFlags().IntVar(&SSEPort, "sse-port", 0, "sse port")
-	rootCmd.PersistentPreRun = initLog
+	_ = rootCmd.PersistentFlags().MarkDeprecated("debug", "use --log-level debug instead")
+	rootCmd.Flags().IntVar(&SSEPort, "sse-port", 0, "sse port")
+	_ = rootCmd.Flags().MarkDeprecated("sse-port", "use \"serve sse --addr :<port>\" instead")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := loadConfigFile(); err != nil {
+			return err
+		}
+		// The flag wins over the environment and the config file
+		if NoDelete {
+			os.Setenv("FSM_NO_DELETE", "true")
+		}
+		initLog(cmd, args)
+		return nil
+	}
 }
 
 func Execute() {
+	markUsageErrors(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
-		log.Err(err).Msg("command execution failed")
+		if !errors.Is(err, errReported) {
+			printError(rootCmd, err)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -34,26 +52,16 @@ var rootCmd = &cobra.Command{
 	CompletionOptions: cobra.CompletionOptions{
 		HiddenDefaultCmd: true,
 	},
-	Run: Root,
+	RunE:          Root,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
-func Root(cmd *cobra.Command, args []string) {
-
-	fs := filestore.New()
-
+func Root(cmd *cobra.Command, args []string) error {
+	// Kept for compatibility, same as "serve sse --addr :<port>"
 	if SSEPort > 0 {
-		server := fs.NewSSEServer()
-		defer func() { _ = server.Shutdown(cmd.Context()) }()
-		log.Info().Msgf("SSE server started on port %d", SSEPort)
-		if err := server.Start(fmt.Sprintf(":%d", SSEPort)); err != nil {
-			log.Err(err).Msg("failed to start SSE server")
-		}
-		return
-	}
-
-	if err := fs.ServeStdio(); err != nil && !errors.Is(err, context.Canceled) {
-		log.Err(err).Msg("failed to run file store")
-		return
+		return ServeSSE(filestore.SSEOptions{Addr: fmt.Sprintf(":%d", SSEPort)})
 	}
 
+	return ServeStdio()
 }
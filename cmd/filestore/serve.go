@@ -0,0 +1,93 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/filestore"
+)
+
+var (
+	sseOptions  filestore.SSEOptions
+	httpOptions filestore.HTTPOptions
+)
+
+func init() {
+	serveSSECmd.Flags().StringVar(&sseOptions.Addr, "addr", ":8080", "listen address")
+	serveSSECmd.Flags().StringVar(&sseOptions.BaseURL, "base-url", "", "public base URL announced to clients, e.g. when behind a reverse proxy")
+	serveSSECmd.Flags().StringVar(&sseOptions.APIKeysFile, "api-keys", "", "JSON file of API keys required by clients (env FSM_API_KEYS_FILE)")
+
+	serveHTTPCmd.Flags().StringVar(&httpOptions.Addr, "addr", ":8080", "listen address")
+	serveHTTPCmd.Flags().StringVar(&httpOptions.Endpoint, "endpoint", "/mcp", "path of the MCP endpoint")
+	serveHTTPCmd.Flags().BoolVar(&httpOptions.Stateless, "stateless", false, "don't track sessions, e.g. for load-balanced deployments")
+	serveHTTPCmd.Flags().StringVar(&httpOptions.APIKeysFile, "api-keys", "", "JSON file of API keys required by clients (env FSM_API_KEYS_FILE)")
+
+	serveCmd.AddCommand(serveStdioCmd, serveSSECmd, serveHTTPCmd)
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the MCP server with the given transport",
+}
+
+var serveStdioCmd = &cobra.Command{
+	Use:   "stdio",
+	Short: "Serve MCP over stdin/stdout, for clients that launch the server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ServeStdio()
+	},
+}
+
+var serveSSECmd = &cobra.Command{
+	Use:   "sse",
+	Short: "Serve MCP over HTTP with Server-Sent Events",
+	Long: `Serve MCP over HTTP with Server-Sent Events (GET /sse, POST /message).
+Upload statistics are served at /stats.`,
+	Example: `  file-store-mcp serve sse --addr :8080`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ServeSSE(sseOptions)
+	},
+}
+
+var serveHTTPCmd = &cobra.Command{
+	Use:   "http",
+	Short: "Serve MCP over Streamable HTTP",
+	Long: `Serve MCP over the Streamable HTTP transport on a single endpoint (default /mcp).
+Upload statistics are served at /stats.`,
+	Example: `  file-store-mcp serve http --addr 127.0.0.1:8080 --endpoint /mcp`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fs := filestore.New()
+		log.Info().Str("addr", httpOptions.Addr).Str("endpoint", httpOptions.Endpoint).Msg("Streamable HTTP server started")
+		if err := fs.ServeStreamableHTTP(httpOptions); err != nil {
+			return fmt.Errorf("failed to run Streamable HTTP server: %w", err)
+		}
+		return nil
+	},
+}
+
+// ServeStdio serves MCP over stdin/stdout until the client disconnects or the process is interrupted
+func ServeStdio() error {
+	fs := filestore.New()
+	if err := fs.ServeStdio(); err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("failed to run file store: %w", err)
+	}
+	return nil
+}
+
+// ServeSSE serves MCP over SSE until the process is interrupted
+func ServeSSE(opts filestore.SSEOptions) error {
+	fs := filestore.New()
+	log.Info().Str("addr", opts.Addr).Msg("SSE server started")
+	if err := fs.ServeSSE(opts); err != nil {
+		return fmt.Errorf("failed to run SSE server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/kardianos/service"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/filestore"
+)
+
+var (
+	serviceName      string
+	serviceUser      bool
+	serviceTransport string
+	serviceAddr      string
+	serviceEndpoint  string
+)
+
+func init() {
+	serviceCmd.PersistentFlags().StringVar(&serviceName, "name", "file-store-mcp", "service name")
+	serviceCmd.PersistentFlags().BoolVar(&serviceUser, "user", false, "manage a per-user service (systemd --user, launchd LaunchAgent) instead of a system service")
+
+	for _, cmd := range []*cobra.Command{serviceInstallCmd, serviceRunCmd} {
+		cmd.Flags().StringVar(&serviceTransport, "transport", "sse", "network transport: sse or http")
+		cmd.Flags().StringVar(&serviceAddr, "addr", ":8080", "listen address")
+		cmd.Flags().StringVar(&serviceEndpoint, "endpoint", "/mcp", "path of the MCP endpoint for the http transport")
+	}
+
+	serviceCmd.AddCommand(serviceInstallCmd, serviceRunCmd)
+	for _, action := range []string{"uninstall", "start", "stop", "restart"} {
+		serviceCmd.AddCommand(newServiceControlCmd(action))
+	}
+	serviceCmd.AddCommand(serviceStatusCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run the network server as a background service",
+	Long: `Install and control the SSE or Streamable HTTP server as a background service:
+a systemd unit on Linux, a launchd daemon/agent on macOS or a Windows service.
+
+Services don't inherit the shell environment, so pass the storage settings with --config.`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the service",
+	Example: `  file-store-mcp --config /etc/file-store-mcp.env service install --addr :8080
+  file-store-mcp --config ~/.config/file-store-mcp.env service install --user --transport http`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serviceTransport != "sse" && serviceTransport != "http" {
+			return fmt.Errorf("unknown transport %q, use sse or http", serviceTransport)
+		}
+		if ConfigFile == "" {
+			log.Warn().Msg("No --config given, the service will only see the environment of the service manager")
+		}
+
+		s, err := newService()
+		if err != nil {
+			return err
+		}
+		if err := s.Install(); err != nil {
+			return fmt.Errorf("failed to install service: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Service %s installed, start it with \"file-store-mcp service start\"\n", serviceName)
+		return nil
+	},
+}
+
+var serviceRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the server under the service manager",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newService()
+		if err != nil {
+			return err
+		}
+		return s.Run()
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the service is running",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newService()
+		if err != nil {
+			return err
+		}
+		status, err := s.Status()
+		if errors.Is(err, service.ErrNotInstalled) {
+			fmt.Fprintf(cmd.OutOrStdout(), "Service %s is not installed\n", serviceName)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get service status: %w", err)
+		}
+
+		text := "unknown"
+		switch status {
+		case service.StatusRunning:
+			text = "running"
+		case service.StatusStopped:
+			text = "stopped"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Service %s is %s\n", serviceName, text)
+		return nil
+	},
+}
+
+func newServiceControlCmd(action string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action,
+		Short: fmt.Sprintf("%s%s the service", string(action[0]-'a'+'A'), action[1:]),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := newService()
+			if err != nil {
+				return err
+			}
+			if err := service.Control(s, action); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Service %s: %s done\n", serviceName, action)
+			return nil
+		},
+	}
+}
+
+// newService describes the service, including the command line the service manager runs
+func newService() (service.Service, error) {
+	args := []string{"service", "run", "--name", serviceName,
+		"--transport", serviceTransport, "--addr", serviceAddr, "--endpoint", serviceEndpoint}
+	if serviceUser {
+		args = append(args, "--user")
+	}
+	if ConfigFile != "" {
+		path, err := filepath.Abs(ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--config", path)
+	}
+	if LogFile != "" {
+		path, err := filepath.Abs(LogFile)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--log-file", path)
+	}
+	if LogLevel != "" {
+		args = append(args, "--log-level", LogLevel)
+	}
+
+	config := &service.Config{
+		Name:        serviceName,
+		DisplayName: "File Store MCP Server",
+		Description: "MCP server uploading files to cloud storage",
+		Arguments:   args,
+		Option: service.KeyValue{
+			"UserService": serviceUser,
+			"Restart":     "on-failure",
+		},
+	}
+	return service.New(&program{}, config)
+}
+
+// program runs the network server under the service manager
+type program struct {
+	fs *filestore.Manager
+}
+
+func (p *program) Start(s service.Service) error {
+	p.fs = filestore.New()
+	go func() {
+		var err error
+		switch serviceTransport {
+		case "http":
+			err = p.fs.ServeStreamableHTTP(filestore.HTTPOptions{Addr: serviceAddr, Endpoint: serviceEndpoint})
+		default:
+			err = p.fs.ServeSSE(filestore.SSEOptions{Addr: serviceAddr})
+		}
+		if err != nil {
+			log.Err(err).Msg("server stopped")
+		}
+	}()
+	log.Info().Str("transport", serviceTransport).Str("addr", serviceAddr).Msg("Service started")
+	return nil
+}
+
+func (p *program) Stop(s service.Service) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.fs.Shutdown(ctx)
+}
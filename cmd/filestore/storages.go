@@ -0,0 +1,214 @@
+package filestore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+var storagesVerbose bool
+
+func init() {
+	storagesCmd.Flags().BoolVarP(&storagesVerbose, "verbose", "v", false, "also list optional settings")
+	rootCmd.AddCommand(storagesCmd)
+}
+
+var storagesCmd = &cobra.Command{
+	Use:   "storages",
+	Short: "List supported storage types and check the configured one",
+	Long: `List all supported storage types with their settings, showing which are set in the
+environment, and check whether the configured storage type initializes successfully.
+
+Uploads fail with "<type> init failed" when initialization fails; this command
+shows why.`,
+	Args: cobra.NoArgs,
+	RunE: Storages,
+}
+
+func Storages(cmd *cobra.Command, args []string) error {
+	config := storage.NewConfigFromEnv()
+	configured := strings.ToLower(config.StorageType)
+	known := storage.IsRegistered(configured)
+
+	var (
+		store   storage.Storage
+		initErr error
+	)
+	if known {
+		store, initErr = storage.OpenStorage(config)
+	}
+
+	if JSONOutput {
+		if err := printJSON(cmd, newStoragesReport(config, store, initErr)); err != nil {
+			return err
+		}
+	} else {
+		out := cmd.OutOrStdout()
+		for _, backend := range storage.Backends {
+			status := "not selected"
+			if backend.Type == configured {
+				status = "selected, initialization OK"
+				if initErr != nil {
+					status = fmt.Sprintf("selected, initialization FAILED: %s", initErr)
+				}
+			} else if missing := missingSettings(backend); len(missing) == 0 {
+				status = "not selected, required settings present"
+			}
+
+			fmt.Fprintf(out, "%s - %s (%s)\n", backend.Type, backend.Name, status)
+			printSettings(out, backend.Settings)
+			fmt.Fprintln(out)
+		}
+		if _, described := storage.LookupBackend(configured); known && !described {
+			status := "initialization OK"
+			if initErr != nil {
+				status = fmt.Sprintf("initialization FAILED: %s", initErr)
+			}
+			fmt.Fprintf(out, "%s - custom storage (selected, %s)\n\n", configured, status)
+		}
+		if store != nil {
+			service := &storage.Service{Storage: store, Config: config}
+			fmt.Fprintf(out, "Supported operations of %s: %s\n", configured, strings.Join(service.Capabilities().Operations(), ", "))
+			fmt.Fprintf(out, "Limits of %s: %s\n", configured, service.Info().Describe())
+		}
+		if !known {
+			fmt.Fprintf(out, "FSM_STORAGE_TYPE is %q, which is not a supported storage type. Uploads will fail.\n", config.StorageType)
+		}
+	}
+
+	// The JSON report already holds the details, only set the exit code
+	if !known {
+		if JSONOutput {
+			return errReported
+		}
+		return fmt.Errorf("no valid storage type configured")
+	}
+	if initErr != nil {
+		if JSONOutput {
+			return errReported
+		}
+		return fmt.Errorf("storage %s failed to initialize", configured)
+	}
+	return nil
+}
+
+// storagesReport is the JSON form of the storages output
+type storagesReport struct {
+	StorageType string `json:"storage_type"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+	// Capabilities of the configured storage, if it initialized
+	Capabilities *storage.Capabilities `json:"capabilities,omitempty"`
+	// Limits of the configured storage, if it initialized
+	Info     *storage.Info   `json:"info,omitempty"`
+	Backends []backendReport `json:"backends"`
+}
+
+type backendReport struct {
+	Type     string          `json:"type"`
+	Name     string          `json:"name"`
+	Selected bool            `json:"selected"`
+	Missing  []string        `json:"missing,omitempty"` // Required settings that aren't set
+	Settings []settingReport `json:"settings"`
+}
+
+type settingReport struct {
+	Env         string `json:"env"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret,omitempty"`
+	Set         bool   `json:"set"`
+	Value       string `json:"value,omitempty"` // Omitted for secrets
+	Default     string `json:"default,omitempty"`
+}
+
+func newStoragesReport(config *storage.Config, store storage.Storage, initErr error) storagesReport {
+	storageType := config.StorageType
+	configured := strings.ToLower(storageType)
+	report := storagesReport{StorageType: storageType, OK: true}
+	if !storage.IsRegistered(configured) {
+		report.OK = false
+		report.Error = fmt.Sprintf("%q is not a supported storage type", storageType)
+	} else if initErr != nil {
+		report.OK = false
+		report.Error = initErr.Error()
+	}
+	if store != nil {
+		service := &storage.Service{Storage: store, Config: config}
+		capabilities := service.Capabilities()
+		report.Capabilities = &capabilities
+		info := service.Info()
+		report.Info = &info
+	}
+
+	for _, backend := range storage.Backends {
+		b := backendReport{
+			Type:     backend.Type,
+			Name:     backend.Name,
+			Selected: backend.Type == configured,
+			Missing:  missingSettings(backend),
+		}
+		for _, setting := range backend.Settings {
+			value := os.Getenv(setting.Env)
+			r := settingReport{
+				Env:         setting.Env,
+				Description: setting.Description,
+				Required:    setting.Required,
+				Secret:      setting.Secret,
+				Set:         value != "",
+				Default:     setting.Default,
+			}
+			if !setting.Secret {
+				r.Value = value
+			}
+			b.Settings = append(b.Settings, r)
+		}
+		report.Backends = append(report.Backends, b)
+	}
+	return report
+}
+
+// printSettings lists the settings of a backend and whether they are set
+func printSettings(out io.Writer, settings []storage.Setting) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, setting := range settings {
+		if !setting.Required && !storagesVerbose {
+			continue
+		}
+
+		required := "optional"
+		if setting.Required {
+			required = "required"
+		}
+
+		state := "unset"
+		if value := os.Getenv(setting.Env); value != "" {
+			state = "set"
+			if !setting.Secret {
+				state = fmt.Sprintf("set: %s", value)
+			}
+		} else if setting.Default != "" {
+			state = fmt.Sprintf("unset, default %s", setting.Default)
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", setting.Env, required, state, setting.Description)
+	}
+	w.Flush()
+}
+
+// missingSettings returns the required settings of backend that aren't set
+func missingSettings(backend storage.BackendInfo) []string {
+	var missing []string
+	for _, setting := range backend.Settings {
+		if setting.Required && os.Getenv(setting.Env) == "" {
+			missing = append(missing, setting.Env)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,65 @@
+package filestore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/mcp"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/clip"
+)
+
+var (
+	urlCopy bool
+	urlTags map[string]string
+)
+
+func init() {
+	urlCmd.Flags().BoolVar(&urlCopy, "copy", false, "copy the resulting URLs to the clipboard")
+	urlCmd.Flags().StringToStringVarP(&urlTags, "tag", "t", nil, "store key=value tags with the uploaded objects, repeatable")
+	rootCmd.AddCommand(urlCmd)
+}
+
+var urlCmd = &cobra.Command{
+	Use:   "url <URL>...",
+	Short: "Download URLs and upload them to the storage",
+	Long: `Download the given URLs and upload the content to the configured storage,
+the same way as the upload_url_files tool. The new URLs are printed one per line.`,
+	Example: `  file-store-mcp url https://example.com/image.png
+  file-store-mcp url --copy https://example.com/a.pdf https://example.com/b.pdf
+  file-store-mcp url -t project=report https://example.com/a.pdf`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: URL,
+}
+
+func URL(cmd *cobra.Command, args []string) error {
+	if err := storage.ValidateTags(urlTags); err != nil {
+		return usageError{err}
+	}
+
+	// Same pipeline as the upload_url_files tool
+	store := storage.NewService()
+	defer store.Close()
+	service := mcp.NewService(store)
+
+	ctx := audit.WithCaller(cmd.Context(), audit.Caller{Transport: "cli", Tool: "url"})
+	ctx = storage.WithTags(ctx, urlTags)
+
+	results := make([]uploadResult, 0, len(args))
+	for _, source := range args {
+		uploaded, warnings, err := service.UploadURL(ctx, source)
+		results = append(results, newUploadResult(source, uploaded, warnings, err))
+	}
+
+	printErr := printUploadResults(cmd, results)
+	if urls := uploadedURLs(results); urlCopy && len(urls) > 0 {
+		if err := clip.SetText(strings.Join(urls, "\n")); err != nil {
+			return fmt.Errorf("uploaded, but failed to copy the URLs to the clipboard: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Copied %d URLs to the clipboard\n", len(urls))
+	}
+	return printErr
+}
@@ -0,0 +1,36 @@
+package filestore
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sjzar/file-store-mcp/pkg/version"
+)
+
+var versionModules bool
+
+func init() {
+	versionCmd.Flags().BoolVarP(&versionModules, "modules", "m", false, "also print the module dependencies")
+	rootCmd.AddCommand(versionCmd)
+
+	rootCmd.Version = version.Version
+	rootCmd.SetVersionTemplate(version.GetDetails())
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long:  `Print the version, commit, build date and build tags (e.g. cocoa for the native macOS clipboard)`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if JSONOutput {
+			_ = printJSON(cmd, version.GetInfo(versionModules))
+			return
+		}
+		fmt.Print(version.GetDetails())
+		if versionModules {
+			fmt.Print(version.GetMore(true))
+		}
+	},
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result constants
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Entry is a single upload record in the audit log
+type Entry struct {
+	ID           string    `json:"id"`
+	Time         time.Time `json:"time"`
+	Source       string    `json:"source"`               // Local path or URL the content came from
+	ObjectKey    string    `json:"object_key,omitempty"` // Formatted object key
+	Backend      string    `json:"backend"`              // Storage type
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	Transport    string    `json:"transport,omitempty"`     // MCP transport of the caller, e.g. stdio or sse
+	Session      string    `json:"session,omitempty"`       // MCP session ID of the caller
+	RequestID    string    `json:"request_id,omitempty"`    // Correlation ID of the tool call
+	Tool         string    `json:"tool,omitempty"`          // MCP tool that requested the upload
+	Client       string    `json:"client,omitempty"`        // MCP client that requested the upload
+	APIKey       string    `json:"api_key,omitempty"`       // Name of the API key the caller authenticated with
+	RemoteAddr   string    `json:"remote_addr,omitempty"`   // IP address of the caller on network transports
+	ForwardedFor string    `json:"forwarded_for,omitempty"` // First X-Forwarded-For address, as claimed by the caller or a proxy
+	Result       string    `json:"result"`
+	URL          string    `json:"url,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitzero"` // When the URL expires, zero if it doesn't
+	Error        string    `json:"error,omitempty"`
+}
+
+// Logger appends audit entries to a JSONL file
+type Logger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens the audit log at path for appending, creating it if needed
+func Open(path string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Logger{file: file}, nil
+}
+
+// Write appends an entry to the audit log. Writing to a nil Logger is a no-op.
+func (l *Logger) Write(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit log
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// ReadAll reads all entries of the audit log at path
+func ReadAll(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// Skip lines truncated by a crash rather than failing the whole read
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Caller describes who requested an upload
+type Caller struct {
+	Transport string
+	Session   string
+	RequestID string
+	Tool      string // Name of the MCP tool that was called
+	Client    string // Name and version of the MCP client, from the initialize request
+	// IP address of the caller and the client address forwarded by a proxy, on network transports
+	RemoteAddr   string
+	ForwardedFor string
+}
+
+// CallerFromRequest describes the caller of an HTTP request on transport, with the session ID
+func CallerFromRequest(transport string, session string, r *http.Request) Caller {
+	caller := Caller{Transport: transport, Session: session, RemoteAddr: r.RemoteAddr}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		caller.RemoteAddr = host
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		caller.ForwardedFor = strings.TrimSpace(first)
+	}
+	return caller
+}
+
+type callerKey struct{}
+
+type sourceKey struct{}
+
+// WithCaller returns a context carrying the caller of the request
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller carried by ctx
+func CallerFromContext(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// WithSource returns a context recording where uploaded content came from, e.g. a download URL
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the source carried by ctx, or fallback if there is none
+func SourceFromContext(ctx context.Context, fallback string) string {
+	if source, ok := ctx.Value(sourceKey{}).(string); ok && source != "" {
+		return source
+	}
+	return fallback
+}
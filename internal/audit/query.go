@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// Query selects audit entries. Zero fields match everything.
+type Query struct {
+	Since   time.Time
+	Until   time.Time
+	Backend string
+	SHA256  string // Hash or hash prefix
+	ID      string // Entry ID or ID prefix
+	Result  string
+	Caller  string // API key, client, session, remote or forwarded address, or a part of it
+	Limit   int    // Keep only the most recent entries
+}
+
+// Match reports whether entry is selected by the query, ignoring Limit
+func (q Query) Match(entry Entry) bool {
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+		return false
+	}
+	if q.Backend != "" && !strings.EqualFold(entry.Backend, q.Backend) {
+		return false
+	}
+	if q.SHA256 != "" && !strings.HasPrefix(entry.SHA256, strings.ToLower(q.SHA256)) {
+		return false
+	}
+	if q.ID != "" && !strings.HasPrefix(entry.ID, q.ID) {
+		return false
+	}
+	if q.Result != "" && entry.Result != q.Result {
+		return false
+	}
+	if q.Caller != "" && !matchCaller(entry, q.Caller) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the entries selected by the query, in log order
+func (q Query) Filter(entries []Entry) []Entry {
+	var result []Entry
+	for _, entry := range entries {
+		if q.Match(entry) {
+			result = append(result, entry)
+		}
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	return result
+}
+
+// matchCaller reports whether any of the caller fields of entry contains value, ignoring case
+func matchCaller(entry Entry, value string) bool {
+	value = strings.ToLower(value)
+	for _, field := range []string{entry.APIKey, entry.Client, entry.Session, entry.RemoteAddr, entry.ForwardedFor} {
+		if field != "" && strings.Contains(strings.ToLower(field), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package filestore
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// APIKey grants access to the network transports, restricted to a scope
+type APIKey struct {
+	Name   string `json:"name"`             // Identifies the holder in logs and the audit log
+	Key    string `json:"key"`              // Secret sent as "Authorization: Bearer <key>" or "X-API-Key"
+	Prefix string `json:"prefix,omitempty"` // Object key prefix of all uploads, e.g. "team-a/"
+	Quota  string `json:"quota,omitempty"`  // Bytes that may be uploaded per day, e.g. "10GB"
+
+	// Storage types uploads may go to, e.g. ["s3"], all if omitted
+	Storages []string `json:"storages,omitempty"`
+}
+
+// apiKeys authenticates requests by API key
+type apiKeys struct {
+	scopes map[[sha256.Size]byte]storage.Scope
+}
+
+// LoadAPIKeys reads the JSON array of API keys in the file at path
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys in %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// newAPIKeys validates keys and indexes them by the hash of the secret
+func newAPIKeys(keys []APIKey) (*apiKeys, error) {
+	a := &apiKeys{scopes: make(map[[sha256.Size]byte]storage.Scope, len(keys))}
+	names := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		switch {
+		case key.Name == "":
+			return nil, fmt.Errorf("API key %d has no name", i+1)
+		case names[key.Name]:
+			return nil, fmt.Errorf("API key name %s is used twice", key.Name)
+		case len(key.Key) < 16:
+			return nil, fmt.Errorf("API key %s is shorter than 16 characters", key.Name)
+		}
+		names[key.Name] = true
+
+		// The prefix is a directory, so keys like "team-a" can't reach the objects of "team-ab/"
+		scope := storage.Scope{Name: key.Name, Prefix: strings.TrimPrefix(key.Prefix, "/"), Storages: key.Storages}
+		if scope.Prefix != "" && !strings.HasSuffix(scope.Prefix, "/") {
+			scope.Prefix += "/"
+		}
+		if key.Quota != "" {
+			quota, err := util.ParseSize(key.Quota)
+			if err != nil {
+				return nil, fmt.Errorf("API key %s has an invalid quota: %w", key.Name, err)
+			}
+			scope.Quota = quota
+		}
+
+		hash := sha256.Sum256([]byte(key.Key))
+		if _, ok := a.scopes[hash]; ok {
+			return nil, fmt.Errorf("API key %s has the same secret as another key", key.Name)
+		}
+		a.scopes[hash] = scope
+		util.RegisterSecrets(key.Key)
+	}
+	return a, nil
+}
+
+// middleware rejects requests without a valid API key and restricts the others to its scope
+func (a *apiKeys) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+
+		// Looking up the hash doesn't leak the secrets through timing
+		scope, ok := a.scopes[sha256.Sum256([]byte(key))]
+		if key == "" || !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="file-store-mcp"`)
+			http.Error(w, "missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(storage.WithScope(r.Context(), scope)))
+	})
+}
@@ -0,0 +1,95 @@
+package filestore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+func TestNewAPIKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []APIKey
+		err  string
+	}{
+		{name: "valid", keys: []APIKey{{Name: "a", Key: "0123456789abcdef"}, {Name: "b", Key: "fedcba9876543210", Prefix: "b/", Quota: "1GB"}}},
+		{name: "no name", keys: []APIKey{{Key: "0123456789abcdef"}}, err: "has no name"},
+		{name: "duplicate name", keys: []APIKey{{Name: "a", Key: "0123456789abcdef"}, {Name: "a", Key: "fedcba9876543210"}}, err: "used twice"},
+		{name: "short key", keys: []APIKey{{Name: "a", Key: "short"}}, err: "shorter than 16"},
+		{name: "duplicate key", keys: []APIKey{{Name: "a", Key: "0123456789abcdef"}, {Name: "b", Key: "0123456789abcdef"}}, err: "same secret"},
+		{name: "invalid quota", keys: []APIKey{{Name: "a", Key: "0123456789abcdef", Quota: "lots"}}, err: "invalid quota"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAPIKeys(tt.keys)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("newAPIKeys() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("newAPIKeys() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAPIKeysMiddleware(t *testing.T) {
+	keys, err := newAPIKeys([]APIKey{
+		{Name: "team-a", Key: "team-a-secret-0123", Prefix: "/team-a", Quota: "1KB"},
+		{Name: "admin", Key: "admin-secret-01234", Storages: []string{"s3"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scope storage.Scope
+	var scoped bool
+	handler := keys.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, scoped = storage.ScopeFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+		want   storage.Scope
+	}{
+		{name: "no key", status: http.StatusUnauthorized},
+		{name: "wrong key", header: "X-API-Key", value: "wrong-secret-01234", status: http.StatusUnauthorized},
+		{name: "empty bearer", header: "Authorization", value: "Bearer ", status: http.StatusUnauthorized},
+		{name: "bearer", header: "Authorization", value: "Bearer team-a-secret-0123", status: http.StatusOK, want: storage.Scope{Name: "team-a", Prefix: "team-a/", Quota: 1024}},
+		{name: "header", header: "X-API-Key", value: "admin-secret-01234", status: http.StatusOK, want: storage.Scope{Name: "admin", Storages: []string{"s3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, scoped = storage.Scope{}, false
+			r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				if scoped {
+					t.Error("the request reached the handler")
+				}
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("no WWW-Authenticate header")
+				}
+				return
+			}
+			if !scoped || !reflect.DeepEqual(scope, tt.want) {
+				t.Errorf("scope = %+v, want %+v", scope, tt.want)
+			}
+		})
+	}
+}
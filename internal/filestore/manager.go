@@ -1,14 +1,35 @@
 package filestore
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	stdlog "log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
 	"github.com/sjzar/file-store-mcp/internal/mcp"
 	"github.com/sjzar/file-store-mcp/internal/storage"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take when the server stops
+const shutdownTimeout = 10 * time.Second
+
 type Manager struct {
 	storage *storage.Service
 	mcp     *mcp.Service
+
+	mu       sync.Mutex
+	shutdown func(context.Context) error
 }
 
 func New() *Manager {
@@ -24,9 +45,204 @@ func New() *Manager {
 }
 
 func (m *Manager) ServeStdio() error {
-	return server.ServeStdio(m.mcp.Server)
+	defer m.storage.Close()
+
+	// Keep the real stdout for the JSON-RPC stream and send stray writes to stderr,
+	// so nothing but protocol messages ever reaches the client
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	s := server.NewStdioServer(m.mcp.Server)
+	s.SetErrorLogger(stdlog.Default())
+	s.SetContextFunc(func(ctx context.Context) context.Context {
+		return audit.WithCaller(ctx, audit.Caller{Transport: "stdio"})
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	return s.Listen(ctx, os.Stdin, stdout)
+}
+
+// SSEOptions configures the SSE transport
+type SSEOptions struct {
+	Addr string
+	// BaseURL is the public URL of the server, used to build the message endpoint
+	// announced to clients when running behind a reverse proxy
+	BaseURL string
+	// APIKeysFile is the JSON file of API keys required by all requests, see
+	// LoadAPIKeys. Defaults to FSM_API_KEYS_FILE; without keys anyone may connect.
+	APIKeysFile string
+}
+
+// HTTPOptions configures the Streamable HTTP transport
+type HTTPOptions struct {
+	Addr     string
+	Endpoint string // Path of the MCP endpoint, defaults to /mcp
+	// Stateless disables sessions, so any instance behind a load balancer can serve any request
+	Stateless bool
+	// APIKeysFile is the JSON file of API keys, as in SSEOptions
+	APIKeysFile string
+}
+
+func (m *Manager) NewSSEServer(srv *http.Server, opts SSEOptions) *server.SSEServer {
+	sseOpts := []server.SSEOption{
+		server.WithHTTPServer(srv),
+		server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+			return audit.WithCaller(ctx, audit.CallerFromRequest("sse", r.URL.Query().Get("sessionId"), r))
+		}),
+	}
+	if opts.BaseURL != "" {
+		sseOpts = append(sseOpts, server.WithBaseURL(opts.BaseURL))
+	}
+	return server.NewSSEServer(m.mcp.Server, sseOpts...)
+}
+
+func (m *Manager) NewStreamableHTTPServer(srv *http.Server, opts HTTPOptions) *server.StreamableHTTPServer {
+	return server.NewStreamableHTTPServer(m.mcp.Server,
+		server.WithStreamableHTTPServer(srv),
+		server.WithEndpointPath(opts.Endpoint),
+		server.WithStateLess(opts.Stateless),
+		server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+			return audit.WithCaller(ctx, audit.CallerFromRequest("http", r.Header.Get(server.HeaderKeySessionID), r))
+		}),
+	)
+}
+
+// ServeSSE serves the MCP SSE endpoints and the /stats endpoint until interrupted
+func (m *Manager) ServeSSE(opts SSEOptions) error {
+	srv := &http.Server{Addr: opts.Addr}
+	sse := m.NewSSEServer(srv, opts)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", m.handleStats)
+	m.handleFiles(mux)
+	mux.Handle("/", sse)
+	handler, err := m.authenticate(mux, opts.APIKeysFile)
+	if err != nil {
+		return err
+	}
+	srv.Handler = handler
+
+	// Shutting down the SSE server also closes the open sessions
+	return m.serveHTTP(srv, sse.Shutdown)
 }
 
-func (m *Manager) NewSSEServer() *server.SSEServer {
-	return server.NewSSEServer(m.mcp.Server)
+// ServeStreamableHTTP serves the MCP Streamable HTTP endpoint and the /stats endpoint until interrupted
+func (m *Manager) ServeStreamableHTTP(opts HTTPOptions) error {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "/mcp"
+	}
+
+	srv := &http.Server{Addr: opts.Addr}
+	streamable := m.NewStreamableHTTPServer(srv, opts)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", m.handleStats)
+	m.handleFiles(mux)
+	mux.Handle(opts.Endpoint, streamable)
+	handler, err := m.authenticate(mux, opts.APIKeysFile)
+	if err != nil {
+		return err
+	}
+	srv.Handler = handler
+
+	return m.serveHTTP(srv, streamable.Shutdown)
+}
+
+// serveHTTP runs srv until it fails or the process is interrupted, then shuts it down gracefully
+func (m *Manager) serveHTTP(srv *http.Server, shutdown func(context.Context) error) error {
+	defer m.storage.Close()
+
+	m.mu.Lock()
+	m.shutdown = shutdown
+	m.mu.Unlock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		log.Info().Msg("Shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return shutdown(shutdownCtx)
+	}
+}
+
+// Shutdown gracefully stops the running network server
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	shutdown := m.shutdown
+	m.mu.Unlock()
+
+	if shutdown == nil {
+		return nil
+	}
+	return shutdown(ctx)
+}
+
+// authenticate requires the API keys in keysFile, or in FSM_API_KEYS_FILE, for
+// all requests to mux except downloads from /files/
+func (m *Manager) authenticate(mux *http.ServeMux, keysFile string) (http.Handler, error) {
+	if keysFile == "" {
+		keysFile = os.Getenv("FSM_API_KEYS_FILE")
+	}
+	if keysFile == "" {
+		return mux, nil
+	}
+	keys, err := LoadAPIKeys(keysFile)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := newAPIKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Int("keys", len(keys)).Msg("API keys required")
+
+	protected := auth.middleware(mux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The URLs returned by uploads work without a key
+		if strings.HasPrefix(r.URL.Path, "/files/") {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	}), nil
+}
+
+// handleFiles serves the uploads under /files/ if the storage keeps them itself,
+// like the in-memory storage
+func (m *Manager) handleFiles(mux *http.ServeMux) {
+	if handler, ok := m.storage.Storage.(http.Handler); ok {
+		mux.Handle("/files/", http.StripPrefix("/files/", handler))
+	}
+}
+
+// handleStats writes the upload statistics as JSON
+func (m *Manager) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, err := m.storage.Stats()
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to collect upload stats")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
 }
@@ -0,0 +1,297 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Limits of chunked uploads
+const (
+	chunkedUploadTTL       = time.Hour // Uploads not appended to for this long are discarded
+	maxChunkedUploads      = 32        // Chunked uploads in progress at a time
+	defaultChunkedMaxSize  = 1 << 30   // Default size cap of a chunked upload, see FSM_CHUNKED_MAX_SIZE
+	defaultChunkedMaxTotal = 4 << 30   // Default cap of the bytes of all chunked uploads in progress, see FSM_CHUNKED_MAX_TOTAL
+)
+
+// chunkedUpload is a file sent in base64 chunks, assembled in a temp file until committed
+type chunkedUpload struct {
+	id       string
+	filename string
+	tags     map[string]string
+	owner    string // API key of the caller, only it may append and commit
+	expected int64  // Declared total size, zero if unknown
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	updated time.Time
+}
+
+// chunkManager keeps the chunked uploads in progress
+type chunkManager struct {
+	maxSize  int64
+	maxTotal int64        // Cap of the bytes of all uploads in progress, kept on temp disk
+	used     atomic.Int64 // Bytes of all uploads in progress
+
+	mu      sync.Mutex
+	uploads map[string]*chunkedUpload
+}
+
+func newChunkManager(maxSize int64, maxTotal int64) *chunkManager {
+	return &chunkManager{maxSize: maxSize, maxTotal: maxTotal, uploads: make(map[string]*chunkedUpload)}
+}
+
+// begin starts a chunked upload of filename, expected to be size bytes if not zero
+func (m *chunkManager) begin(ctx context.Context, filename string, size int64, tags map[string]string) (*chunkedUpload, error) {
+	if size > m.maxSize {
+		return nil, fmt.Errorf("%s exceeds the chunked upload limit of %s", util.FormatSize(size), util.FormatSize(m.maxSize))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	if len(m.uploads) >= maxChunkedUploads {
+		return nil, fmt.Errorf("too many chunked uploads in progress, commit or wait for them to expire after %s", chunkedUploadTTL)
+	}
+	if used := m.used.Load(); used >= m.maxTotal || used+size > m.maxTotal {
+		return nil, fmt.Errorf("chunked uploads in progress use up the limit of %s, commit or wait for them to expire after %s", util.FormatSize(m.maxTotal), chunkedUploadTTL)
+	}
+
+	file, err := os.CreateTemp("", "chunked-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	scope, _ := storage.ScopeFromContext(ctx)
+	upload := &chunkedUpload{
+		id:       newRequestID(),
+		filename: filename,
+		tags:     tags,
+		owner:    scope.Name,
+		expected: size,
+		file:     file,
+		updated:  time.Now(),
+	}
+	m.uploads[upload.id] = upload
+	return upload, nil
+}
+
+// get returns the chunked upload with the given ID, if it belongs to the caller
+func (m *chunkManager) get(ctx context.Context, id string) (*chunkedUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	upload, ok := m.uploads[id]
+	scope, _ := storage.ScopeFromContext(ctx)
+	if !ok || upload.owner != scope.Name {
+		return nil, fmt.Errorf("unknown chunked upload %q, uploads expire %s after the last chunk", id, chunkedUploadTTL)
+	}
+	return upload, nil
+}
+
+// remove forgets the chunked upload and returns the path of its temp file,
+// which the caller removes
+func (m *chunkManager) remove(upload *chunkedUpload) string {
+	m.mu.Lock()
+	_, ok := m.uploads[upload.id]
+	delete(m.uploads, upload.id)
+	m.mu.Unlock()
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if ok {
+		m.used.Add(-upload.size)
+	}
+	upload.file.Close()
+	return upload.file.Name()
+}
+
+// prune discards uploads not appended to for chunkedUploadTTL. The caller holds m.mu.
+func (m *chunkManager) prune() {
+	for id, upload := range m.uploads {
+		upload.mu.Lock()
+		expired := time.Since(upload.updated) > chunkedUploadTTL
+		if expired {
+			m.used.Add(-upload.size)
+		}
+		upload.mu.Unlock()
+		if expired {
+			upload.file.Close()
+			os.Remove(upload.file.Name())
+			delete(m.uploads, id)
+		}
+	}
+}
+
+// append decodes a base64 chunk and appends it. With an offset, a chunk that was
+// already appended, e.g. by a retried call, is acknowledged without appending it
+// again, if it matches the bytes appended there.
+func (m *chunkManager) append(upload *chunkedUpload, data string, offset int64) (int64, error) {
+	chunk, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+	if err != nil {
+		return 0, fmt.Errorf("data is not valid base64: %w", err)
+	}
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	switch {
+	case offset >= 0 && offset+int64(len(chunk)) <= upload.size:
+		appended := make([]byte, len(chunk))
+		if _, err := upload.file.ReadAt(appended, offset); err != nil {
+			return 0, fmt.Errorf("failed to read chunk at offset %d: %w", offset, err)
+		}
+		if !bytes.Equal(appended, chunk) {
+			return 0, fmt.Errorf("chunk at offset %d differs from the bytes already appended there, the upload has %d bytes", offset, upload.size)
+		}
+		return upload.size, nil
+	case offset >= 0 && offset != upload.size:
+		return 0, fmt.Errorf("chunk at offset %d doesn't continue the upload, which has %d bytes", offset, upload.size)
+	}
+
+	size := upload.size + int64(len(chunk))
+	if size > m.maxSize {
+		return 0, fmt.Errorf("upload exceeds the chunked upload limit of %s", util.FormatSize(m.maxSize))
+	}
+	if upload.expected > 0 && size > upload.expected {
+		return 0, fmt.Errorf("upload exceeds the declared size of %d bytes", upload.expected)
+	}
+	if m.used.Add(int64(len(chunk))) > m.maxTotal {
+		m.used.Add(-int64(len(chunk)))
+		return 0, fmt.Errorf("chunked uploads in progress exceed the limit of %s, commit them or wait for them to expire after %s", util.FormatSize(m.maxTotal), chunkedUploadTTL)
+	}
+	if _, err := upload.file.Write(chunk); err != nil {
+		m.used.Add(-int64(len(chunk)))
+		return 0, fmt.Errorf("failed to write chunk: %w", err)
+	}
+	upload.size = size
+	upload.updated = time.Now()
+	return size, nil
+}
+
+// complete checks that the upload has the declared size and checksum
+func (upload *chunkedUpload) complete(sha256Sum string) error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if upload.expected > 0 && upload.size != upload.expected {
+		return fmt.Errorf("upload has %d of the declared %d bytes", upload.size, upload.expected)
+	}
+	if err := upload.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write upload: %w", err)
+	}
+	if sha256Sum == "" {
+		return nil
+	}
+	_, sum, err := util.HashFile(upload.file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to hash upload: %w", err)
+	}
+	if !strings.EqualFold(sum, sha256Sum) {
+		return fmt.Errorf("checksum mismatch: upload has SHA-256 %s, expected %s", sum, sha256Sum)
+	}
+	return nil
+}
+
+func (s *Service) handleBeginUpload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := withRequestTags(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	filename, ok := request.GetArguments()["filename"].(string)
+	filename = filepath.Base(strings.TrimSpace(filename))
+	if !ok || filename == "" || filename == "." || filename == "/" {
+		return nil, fmt.Errorf("filename must be a non-empty string")
+	}
+	size, _ := request.GetArguments()["size"].(float64)
+
+	upload, err := s.chunks.begin(ctx, filename, int64(size), storage.TagsFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Started chunked upload of %s, upload ID: %s\nSend the content with append_upload, then call commit_upload.", filename, upload.id),
+			},
+		},
+		StructuredContent: map[string]interface{}{"upload_id": upload.id, "max_size": s.chunks.maxSize},
+	}, nil
+}
+
+func (s *Service) handleAppendUpload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, _ := request.GetArguments()["upload_id"].(string)
+	upload, err := s.chunks.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := request.GetArguments()["data"].(string)
+	if !ok {
+		return nil, fmt.Errorf("data must be a base64-encoded string")
+	}
+	offset := int64(-1)
+	if value, ok := request.GetArguments()["offset"].(float64); ok {
+		offset = int64(value)
+	}
+
+	size, err := s.chunks.append(upload, data, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Received %d bytes of %s", size, upload.filename),
+			},
+		},
+		StructuredContent: map[string]interface{}{"upload_id": upload.id, "size": size},
+	}, nil
+}
+
+func (s *Service) handleCommitUpload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, _ := request.GetArguments()["upload_id"].(string)
+	upload, err := s.chunks.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sha256Sum, _ := request.GetArguments()["sha256"].(string)
+	if err := upload.complete(strings.TrimSpace(sha256Sum)); err != nil {
+		return nil, err
+	}
+
+	// The temp file is removed once uploaded, also when uploading in the background
+	path := s.chunks.remove(upload)
+	warning, err := s.storage.CheckContent(ctx, path, upload.filename)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	if len(upload.tags) > 0 {
+		ctx = storage.WithTags(ctx, upload.tags)
+	}
+	task := uploadTask{
+		source: upload.filename,
+		run: func(ctx context.Context) (FileResult, error) {
+			defer os.Remove(path)
+			uploadCtx, notes := storage.WithNotes(audit.WithSource(ctx, "chunked upload "+upload.id))
+			result, err := s.storage.UploadFileDetailed(uploadCtx, path, upload.filename)
+			if err != nil {
+				return FileResult{}, err
+			}
+			return newFileResult(upload.filename, result, append([]string{warning}, notes.List()...)), nil
+		},
+	}
+	return s.runUploads(ctx, request, []uploadTask{task}, "Upload %d files successfully")
+}
@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChunkAppendOffsets(t *testing.T) {
+	m := newChunkManager(1<<20, 1<<20)
+	upload, err := m.begin(context.Background(), "a.txt", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.Remove(m.remove(upload)) }()
+	if _, err := m.append(upload, base64.StdEncoding.EncodeToString([]byte("hello world")), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		chunk  string
+		offset int64
+		size   int64
+		err    string
+	}{
+		{name: "retry", chunk: "hello world", offset: 0, size: 11},
+		{name: "retry of a part", chunk: "world", offset: 6, size: 11},
+		{name: "different bytes", chunk: "HELLO", offset: 0, err: "differs from the bytes already appended"},
+		{name: "gap", chunk: "!", offset: 12, err: "doesn't continue the upload"},
+		{name: "overlapping the end", chunk: "d!", offset: 10, err: "doesn't continue the upload"},
+		{name: "next", chunk: "!", offset: 11, size: 12},
+		{name: "without an offset", chunk: "?", offset: -1, size: 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := m.append(upload, base64.StdEncoding.EncodeToString([]byte(tt.chunk)), tt.offset)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("append() = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || size != tt.size {
+				t.Errorf("append() = %d, %v, want %d", size, err, tt.size)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(upload.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world!?" {
+		t.Errorf("assembled %q, want %q", data, "hello world!?")
+	}
+}
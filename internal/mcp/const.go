@@ -12,15 +12,95 @@ var UploadFilesTool = mcp.NewTool(
 	"upload_files",
 	mcp.WithDescription("Uploads local files to cloud storage and returns HTTP URLs. Use this tool when users mention local file paths or need online access to their files. Ideal for when users want to: analyze PDF content, reference local images for drawing tasks, or process any local files. If input contains absolute paths (like 'C:/Users/file.pdf', '/home/user/image.jpg'), use this tool to obtain web-accessible links."),
 	mcp.WithArray("paths", mcp.Description("array of absolute local file paths to upload"), mcp.Required()),
+	mcp.WithObject("tags", mcp.Description("optional key/value pairs stored with the uploaded objects as metadata, e.g. {\"project\": \"report\"}"), mcp.AdditionalProperties(map[string]interface{}{"type": "string"})),
+	mcp.WithBoolean("async", mcp.Description("upload in the background and return a job ID immediately; call get_upload_status with it for the progress and the URLs. Use for very large files that may take longer than the tool call timeout")),
+	mcp.WithString("pages", mcp.Description("optional pages of PDF files to upload instead of the whole document, e.g. \"1-3,7\"; \"10-\" is page 10 to the end, \"l\" the last page. Use to share just the relevant pages of a large document")),
+	mcp.WithBoolean("split_pages", mcp.Description("upload each page (of the selected pages) of PDF files as a separate file, returning a URL per page")),
+	mcp.WithBoolean("extract", mcp.Description("unpack zip, tar and tar.gz archives and upload the files inside individually, returning a URL per file instead of one link to the archive")),
+	mcp.WithArray("include", mcp.Description("optional glob patterns selecting the archive files to upload with extract, matched against the path in the archive or the file name, e.g. \"*.csv\" or \"docs/*\""), mcp.Items(map[string]interface{}{"type": "string"})),
 )
 
 var UploadClipboardFilesTool = mcp.NewTool(
 	"upload_clipboard_files",
 	mcp.WithDescription("Uploads files from the clipboard to cloud storage and returns HTTP URLs. Only use this tool when users explicitly request to upload files from their clipboard. Useful when users want to share or process clipboard content without saving it locally first. This tool helps users easily convert clipboard files into web-accessible resources."),
+	mcp.WithArray("select", mcp.Description("optional subset of clipboard files to upload, given as 1-based indexes (e.g. \"2\") or filename glob patterns (e.g. \"*.png\"); if the selection matches nothing, the candidate list is returned instead of uploading"), mcp.Items(map[string]interface{}{"type": "string"})),
+	mcp.WithObject("tags", mcp.Description("optional key/value pairs stored with the uploaded objects as metadata, e.g. {\"project\": \"report\"}"), mcp.AdditionalProperties(map[string]interface{}{"type": "string"})),
+	mcp.WithBoolean("async", mcp.Description("upload in the background and return a job ID immediately; call get_upload_status with it for the progress and the URLs. Use for very large files that may take longer than the tool call timeout")),
 )
 
 var UploadUrlFilesTool = mcp.NewTool(
 	"upload_url_files",
 	mcp.WithDescription("Downloads files from provided URLs and uploads them to cloud storage, returning new HTTP URLs. Use this tool when users provide web links to files they want to process or analyze. Ideal for situations where users reference external files that need to be incorporated into the current workflow. This tool simplifies working with content from various online sources."),
-	mcp.WithArray("urls", mcp.Description("array of URLs pointing to files to download and upload"), mcp.Required()),
+	mcp.WithArray("urls", mcp.Description("array of public http or https URLs pointing to files to download and upload"), mcp.Required()),
+	mcp.WithObject("tags", mcp.Description("optional key/value pairs stored with the uploaded objects as metadata, e.g. {\"project\": \"report\"}"), mcp.AdditionalProperties(map[string]interface{}{"type": "string"})),
+	mcp.WithBoolean("async", mcp.Description("upload in the background and return a job ID immediately; call get_upload_status with it for the progress and the URLs. Use for very large files that may take longer than the tool call timeout")),
+	mcp.WithBoolean("extract", mcp.Description("unpack zip, tar and tar.gz archives and upload the files inside individually, returning a URL per file instead of one link to the archive")),
+	mcp.WithArray("include", mcp.Description("optional glob patterns selecting the archive files to upload with extract, matched against the path in the archive or the file name, e.g. \"*.csv\" or \"docs/*\""), mcp.Items(map[string]interface{}{"type": "string"})),
+)
+
+var UploadDirectoryTool = mcp.NewTool(
+	"upload_directory",
+	mcp.WithDescription("Zips a local directory and uploads the archive to cloud storage, returning its HTTP URL, or uploads its files individually with preserve_structure. Use this tool when users want to share a whole folder, e.g. a project, instead of single files. Exclude build output and version control data, e.g. \".git\" and \"node_modules\", unless users want them."),
+	mcp.WithString("path", mcp.Description("absolute path of the local directory to upload"), mcp.Required()),
+	mcp.WithArray("include", mcp.Description("optional glob patterns selecting the files to archive, matched against the path relative to the directory or the file name, e.g. \"*.go\" or \"docs/*\""), mcp.Items(map[string]interface{}{"type": "string"})),
+	mcp.WithArray("exclude", mcp.Description("optional glob patterns of files and directories to leave out, matched like include, e.g. \".git\", \"node_modules\" or \"*.log\""), mcp.Items(map[string]interface{}{"type": "string"})),
+	mcp.WithBoolean("preserve_structure", mcp.Description("upload the files individually instead of a zip archive, below a common prefix that keeps their paths relative to the directory, and return the URL of each file. Use for folders viewed in place, e.g. a static HTML report whose pages link to each other")),
+	mcp.WithString("password", mcp.Description("optional password encrypting the files in the zip archive with AES-256, so the archive can't be read if its URL leaks; file names stay visible. Use when users share sensitive files and ask for a password, and tell them to open the archive with 7-Zip, WinZip or WinRAR. Not with preserve_structure")),
+	mcp.WithObject("tags", mcp.Description("optional key/value pairs stored with the uploaded object as metadata, e.g. {\"project\": \"report\"}"), mcp.AdditionalProperties(map[string]interface{}{"type": "string"})),
+	mcp.WithBoolean("async", mcp.Description("upload in the background and return a job ID immediately; call get_upload_status with it for the progress and the URL. Use for large directories that may take longer than the tool call timeout")),
+)
+
+var DownloadFilesTool = mcp.NewTool(
+	"download_files",
+	mcp.WithDescription("Downloads files previously uploaded by this server to a local directory, given their URLs or object keys, and returns the local paths. Use this tool to bring processed results or shared files back to the user's machine, e.g. when users ask to save an uploaded file locally."),
+	mcp.WithArray("files", mcp.Description("array of URLs returned by the upload tools, looked up in the upload history, or object keys of the configured storage to download with a fresh presigned URL"), mcp.Required(), mcp.Items(map[string]interface{}{"type": "string"})),
+	mcp.WithString("destination", mcp.Description("absolute path of the local directory to write the files to, created if missing; must be inside the download directory of the server if it has one"), mcp.Required()),
+	mcp.WithBoolean("overwrite", mcp.Description("replace files that already exist in the destination instead of failing")),
+)
+
+var DeleteFilesTool = mcp.NewTool(
+	"delete_files",
+	mcp.WithDescription("Permanently deletes files uploaded by this server from cloud storage, given their object keys or URLs. Use this tool when users ask to remove files they uploaded by mistake or that contain sensitive data. Confirm the files with the user before deleting them; deleted files can't be restored."),
+	mcp.WithArray("files", mcp.Description("array of object keys returned by the upload tools (object_key), or the URLs they returned; URLs are looked up in the upload history"), mcp.Required(), mcp.Items(map[string]interface{}{"type": "string"})),
+	mcp.WithDestructiveHintAnnotation(true),
+)
+
+var GetUploadStatusTool = mcp.NewTool(
+	"get_upload_status",
+	mcp.WithDescription("Reports the progress of an upload started with async set, and the URLs of the files uploaded so far. Call it with the job ID returned by the upload tool until the status is succeeded or failed."),
+	mcp.WithString("job_id", mcp.Description("job ID returned by an async upload"), mcp.Required()),
+)
+
+var StorageInfoTool = mcp.NewTool(
+	"storage_info",
+	mcp.WithDescription("Describes the configured storage backend: max file size, whether download URLs expire and when, whether a custom domain is used, and which operations (presign, delete, exists, list) are supported. Use this tool before uploading very large files or when users need permanent links."),
+)
+
+var GetUploadStatsTool = mcp.NewTool(
+	"get_upload_stats",
+	mcp.WithDescription("Returns upload statistics per storage backend: number of uploads, bytes uploaded, average latency, error rate and the last error. Use this tool when users ask how uploads are performing or why uploads are failing."),
+)
+
+var BeginUploadTool = mcp.NewTool(
+	"begin_upload",
+	mcp.WithDescription("Starts a chunked upload of file content held in memory, for content too large to send in one tool call. Send the content with append_upload in base64 chunks of up to a few megabytes, then call commit_upload to upload the file and get its HTTP URL. Returns the upload ID."),
+	mcp.WithString("filename", mcp.Description("name of the file, used for the object key and the content type, e.g. \"report.pdf\""), mcp.Required()),
+	mcp.WithNumber("size", mcp.Description("optional total size of the file in bytes; the upload is refused if the chunks don't add up to it"), mcp.Min(0)),
+	mcp.WithObject("tags", mcp.Description("optional key/value pairs stored with the uploaded object as metadata, e.g. {\"project\": \"report\"}"), mcp.AdditionalProperties(map[string]interface{}{"type": "string"})),
+)
+
+var AppendUploadTool = mcp.NewTool(
+	"append_upload",
+	mcp.WithDescription("Appends a base64-encoded chunk to a chunked upload started with begin_upload. Send the chunks in order; pass the offset of each chunk so a retried call doesn't append it twice. Returns the number of bytes received so far."),
+	mcp.WithString("upload_id", mcp.Description("upload ID returned by begin_upload"), mcp.Required()),
+	mcp.WithString("data", mcp.Description("the next chunk of the file content, base64-encoded"), mcp.Required()),
+	mcp.WithNumber("offset", mcp.Description("optional position of the chunk in the file in bytes, i.e. the number of bytes sent before it"), mcp.Min(0)),
+)
+
+var CommitUploadTool = mcp.NewTool(
+	"commit_upload",
+	mcp.WithDescription("Finishes a chunked upload started with begin_upload: uploads the assembled file to cloud storage and returns its HTTP URL."),
+	mcp.WithString("upload_id", mcp.Description("upload ID returned by begin_upload"), mcp.Required()),
+	mcp.WithString("sha256", mcp.Description("optional hex SHA-256 checksum of the whole file; the upload is refused if the received content doesn't match")),
+	mcp.WithBoolean("async", mcp.Description("upload in the background and return a job ID immediately; call get_upload_status with it for the progress and the URL")),
 )
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+// DeleteResult is the structured result of deleting one file
+type DeleteResult struct {
+	Source    string `json:"source"` // URL or object key given by the caller
+	ObjectKey string `json:"object_key,omitempty"`
+	Deleted   bool   `json:"deleted"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s *Service) handleDeleteFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	_files, ok := request.GetArguments()["files"].([]interface{})
+	if !ok || len(_files) == 0 {
+		return nil, fmt.Errorf("files must be a non-empty array of object keys or URLs")
+	}
+
+	results := make([]DeleteResult, 0, len(_files))
+	list := ""
+	deleted := 0
+	for i, _file := range _files {
+		source := strings.TrimSpace(fmt.Sprint(_file))
+		result := DeleteResult{Source: source}
+		objectKey, err := s.deleteFile(ctx, source)
+		result.ObjectKey = objectKey
+		if err != nil {
+			result.Error = err.Error()
+			list += fmt.Sprintf("%d: %s failed: %s\n", i+1, source, err)
+		} else {
+			result.Deleted = true
+			deleted++
+			list += fmt.Sprintf("%d: %s deleted\n", i+1, objectKey)
+		}
+		results = append(results, result)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Deleted %d of %d files:\n%s", deleted, len(results), list),
+			},
+		},
+		StructuredContent: map[string]interface{}{"files": results},
+		IsError:           deleted < len(results),
+	}, nil
+}
+
+// deleteFile deletes an object of the configured storage by its key, or by the URL
+// an upload returned, and returns the object key. Callers restricted to a key
+// prefix by their API key can only delete objects under it.
+func (s *Service) deleteFile(ctx context.Context, source string) (string, error) {
+	if source == "" {
+		return "", fmt.Errorf("file cannot be empty")
+	}
+
+	objectKey := source
+	if strings.Contains(source, "://") {
+		var err error
+		if objectKey, err = s.storage.ObjectKeyOf(source); err != nil {
+			return "", err
+		}
+	}
+	if err := checkObjectKey(ctx, objectKey); err != nil {
+		return objectKey, fmt.Errorf("deleting %s refused: %w", objectKey, err)
+	}
+	return objectKey, s.storage.Delete(ctx, objectKey)
+}
+
+// checkObjectKey refuses object keys given by the caller that aren't clean paths,
+// e.g. with ".." segments that backends normalizing paths would resolve outside
+// the key prefix of the caller's API key, and keys outside that prefix
+func checkObjectKey(ctx context.Context, objectKey string) error {
+	if objectKey != path.Clean(objectKey) || slices.Contains(strings.Split(objectKey, "/"), "..") {
+		return fmt.Errorf("object key %s isn't a clean path", objectKey)
+	}
+	scope, ok := storage.ScopeFromContext(ctx)
+	if !ok || scope.Prefix == "" {
+		return nil
+	}
+	prefix := strings.TrimSuffix(scope.Prefix, "/") + "/"
+	if !strings.HasPrefix(objectKey, prefix) {
+		return fmt.Errorf("API key %s can only access objects under %s", scope.Name, prefix)
+	}
+	return nil
+}
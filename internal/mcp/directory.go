@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Default limits of uploaded directories
+const (
+	defaultDirectoryMaxFiles = 1000
+	defaultDirectoryMaxSize  = 1 << 30
+)
+
+// directoryLimits returns the limits of uploaded directories, see FSM_DIRECTORY_MAX_FILES and FSM_DIRECTORY_MAX_SIZE
+func directoryLimits() util.ArchiveLimits {
+	maxFiles := defaultDirectoryMaxFiles
+	if value, err := strconv.Atoi(os.Getenv("FSM_DIRECTORY_MAX_FILES")); err == nil && value > 0 {
+		maxFiles = value
+	}
+	return util.ArchiveLimits{MaxFiles: maxFiles, MaxSize: getEnvSize("FSM_DIRECTORY_MAX_SIZE", defaultDirectoryMaxSize)}
+}
+
+// stringsArg returns the strings of an optional array argument
+func stringsArg(request mcp.CallToolRequest, name string) []string {
+	var values []string
+	if items, ok := request.GetArguments()[name].([]interface{}); ok {
+		for _, item := range items {
+			if value := fmt.Sprint(item); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// walkDirectory validates the path argument of a directory tool and lists the
+// files selected by the include and exclude arguments
+func walkDirectory(request mcp.CallToolRequest) (string, []util.DirFile, error) {
+	dir, _ := request.GetArguments()["path"].(string)
+	if dir == "" {
+		return "", nil, fmt.Errorf("path cannot be empty")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid path: %w", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if !info.IsDir() {
+		return "", nil, fmt.Errorf("path must be a directory, use upload_files for files")
+	}
+
+	files, err := util.WalkDir(dir, stringsArg(request, "include"), stringsArg(request, "exclude"), directoryLimits())
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("%s: no files match the include and exclude patterns", dir)
+	}
+	return dir, files, nil
+}
+
+// directoryName returns the name of dir its uploads are named after, "directory"
+// for a root directory, which has none
+func directoryName(dir string) string {
+	name := filepath.Base(dir)
+	if name == "." || name == string(filepath.Separator) || name == "/" {
+		return "directory"
+	}
+	return name
+}
+
+func (s *Service) handleUploadDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := withRequestTags(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, files, err := walkDirectory(request)
+	if err != nil {
+		return nil, err
+	}
+	password, _ := request.GetArguments()["password"].(string)
+	if preserve, _ := request.GetArguments()["preserve_structure"].(bool); preserve {
+		if password != "" {
+			return nil, fmt.Errorf("password encrypts the zip archive, it can't be used with preserve_structure")
+		}
+		return s.runUploads(ctx, request, s.directoryTasks(dir, files), "Uploaded %d files of the directory successfully")
+	}
+
+	// The archive is written when the upload runs, also in the background with async
+	tempDir, err := os.MkdirTemp("", "directory-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	name := directoryName(dir) + ".zip"
+
+	// Encrypted entries can't be inspected once written, so the files are checked now
+	summary := "Zipped %d files and uploaded %%d archive successfully"
+	if password != "" {
+		if err := s.storage.CheckArchivedFiles(name, files); err != nil {
+			os.RemoveAll(tempDir)
+			return nil, err
+		}
+		summary = "Zipped %d files with AES-256 encryption and uploaded %%d archive successfully"
+	}
+	archivePath := filepath.Join(tempDir, name)
+	upload := s.archiveEntryTask(dir, archivePath, name, s.storage.Config.FileFormat, "", "", func() { os.RemoveAll(tempDir) })
+	task := uploadTask{
+		source: dir,
+		run: func(ctx context.Context) (FileResult, error) {
+			archive, err := os.Create(archivePath)
+			if err != nil {
+				return FileResult{}, fmt.Errorf("failed to create archive: %w", err)
+			}
+			err = util.ZipFiles(archive, files, password)
+			if closeErr := archive.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return FileResult{}, fmt.Errorf("failed to archive %s: %w", dir, err)
+			}
+			return upload.run(ctx)
+		},
+		cleanup: upload.cleanup,
+	}
+	return s.runUploads(ctx, request, []uploadTask{task}, fmt.Sprintf(summary, len(files)))
+}
+
+// directoryTasks returns the uploads of the files of dir one by one, below a common
+// object key prefix formatted from the directory name, so their relative paths and
+// links between them are kept, e.g. "1712345678-report/css/style.css"
+func (s *Service) directoryTasks(dir string, files []util.DirFile) []uploadTask {
+	format := s.storage.Config.FileFormat
+	if format == "" {
+		format = storage.DefaultFileFormat
+	}
+	prefix := storage.FormatObjectKey(directoryName(dir), format)
+
+	tasks := make([]uploadTask, 0, len(files))
+	for _, file := range files {
+		tasks = append(tasks, s.archiveEntryTask(dir, file.Path, file.Name, prefix+"/{filename}{ext}", file.Name, "", nil))
+	}
+	return tasks
+}
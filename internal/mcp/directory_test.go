@@ -0,0 +1,24 @@
+package mcp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDirectoryName(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: filepath.FromSlash("/home/user/report"), want: "report"},
+		{dir: filepath.FromSlash("/home/user/report/"), want: "report"},
+		{dir: string(filepath.Separator), want: "directory"},
+		{dir: ".", want: "directory"},
+		{dir: "", want: "directory"},
+	}
+	for _, tt := range tests {
+		if got := directoryName(tt.dir); got != tt.want {
+			t.Errorf("directoryName(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
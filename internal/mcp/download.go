@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+// DownloadResult is the structured result of one downloaded file
+type DownloadResult struct {
+	Source string `json:"source"` // URL or object key the file was downloaded from
+	Path   string `json:"path"`   // Local path the file was written to
+	Size   int64  `json:"size"`
+}
+
+func (s *Service) handleDownloadFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	_files, ok := request.GetArguments()["files"].([]interface{})
+	if !ok || len(_files) == 0 {
+		return nil, fmt.Errorf("files must be a non-empty array of URLs or object keys")
+	}
+	destination, _ := request.GetArguments()["destination"].(string)
+	overwrite, _ := request.GetArguments()["overwrite"].(bool)
+	if overwrite && s.storage.Config.NoDelete {
+		return nil, fmt.Errorf("overwrite refused: %w", storage.ErrNoDelete)
+	}
+	destination, err := downloadDestination(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]DownloadResult, 0, len(_files))
+	list := ""
+	for i, _file := range _files {
+		source := strings.TrimSpace(fmt.Sprint(_file))
+		result, err := s.downloadFile(ctx, source, destination, overwrite)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+		list += fmt.Sprintf("%d: %s -> %s (%d bytes)\n", i+1, source, result.Path, result.Size)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Downloaded %d files successfully:\n%s", len(results), list),
+			},
+		},
+		StructuredContent: map[string]interface{}{"files": results},
+	}, nil
+}
+
+// downloadDestination creates the directory destination and returns its path.
+// Destinations must be inside FSM_DOWNLOAD_DIR if it is set; without it, only
+// callers on stdio, which run on the user's machine, may download.
+func downloadDestination(ctx context.Context, destination string) (string, error) {
+	if destination == "" || !filepath.IsAbs(destination) {
+		return "", fmt.Errorf("destination must be an absolute directory path")
+	}
+	root := os.Getenv("FSM_DOWNLOAD_DIR")
+	if root == "" && audit.CallerFromContext(ctx).Transport != "stdio" {
+		return "", fmt.Errorf("downloading over the network transports needs a download directory, set FSM_DOWNLOAD_DIR")
+	}
+	if root == "" {
+		if err := os.MkdirAll(destination, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create destination directory: %w", err)
+		}
+		return destination, nil
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid download directory %s: %w", root, err)
+	}
+	if !insideDir(root, filepath.Clean(destination)) {
+		return "", fmt.Errorf("destination %s is outside the download directory %s", destination, root)
+	}
+	if err := os.MkdirAll(destination, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	// Check again with links resolved, so a link in the download directory can't lead outside it
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid download directory %s: %w", root, err)
+	}
+	resolved, err := filepath.EvalSymlinks(destination)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination %s: %w", destination, err)
+	}
+	if !insideDir(resolvedRoot, resolved) {
+		return "", fmt.Errorf("destination %s is outside the download directory %s", destination, root)
+	}
+	return resolved, nil
+}
+
+// insideDir reports whether the path p is the directory dir or below it
+func insideDir(dir string, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// downloadFile downloads an object of the configured storage, given its key or a
+// URL an upload returned, into the directory destination. Existing files are
+// only replaced with overwrite. Callers restricted to a key prefix by their API
+// key can only download objects under it.
+func (s *Service) downloadFile(ctx context.Context, source string, destination string, overwrite bool) (*DownloadResult, error) {
+	if source == "" {
+		return nil, fmt.Errorf("file cannot be empty")
+	}
+
+	isURL := strings.Contains(source, "://")
+	objectKey := source
+	if isURL {
+		var err error
+		if objectKey, err = s.storage.ObjectKeyOf(source); err != nil {
+			return nil, fmt.Errorf("refusing to download %s: %w", source, err)
+		}
+	}
+	if err := checkObjectKey(ctx, objectKey); err != nil {
+		return nil, fmt.Errorf("downloading %s refused: %w", objectKey, err)
+	}
+
+	// Fresh presigned URLs of the configured storage are trusted. URLs of storages
+	// that can't presign are guarded like uploads from URLs.
+	rawURL, client := source, s.client
+	presigned, err := s.storage.Presign(ctx, objectKey, 0)
+	switch {
+	case err == nil:
+		rawURL, client = presigned, http.DefaultClient
+	case isURL && errors.Is(err, storage.ErrNotSupported):
+		parsed, err := url.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %s: %w", source, err)
+		}
+		if err := s.guard.check(ctx, parsed); err != nil {
+			return nil, fmt.Errorf("refusing to download %s: %w", source, err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to get download URL of %s: %w", objectKey, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", source, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status code %d", source, resp.StatusCode)
+	}
+
+	filename := filepath.Base(filepath.FromSlash(path.Base(objectKey)))
+	if filename == "." || filename == ".." || filename == string(filepath.Separator) {
+		filename = "download"
+	}
+	target := filepath.Join(destination, filename)
+	if !overwrite {
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("%s already exists, set overwrite to replace it", target)
+		}
+	}
+
+	// Write to a temp file next to the target, so a failed download doesn't leave
+	// a partial file behind
+	tempFile, err := os.CreateTemp(destination, ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	size, err := io.Copy(tempFile, resp.Body)
+	if err == nil {
+		err = tempFile.Chmod(0o644)
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return nil, fmt.Errorf("failed to save %s: %w", target, err)
+	}
+
+	log.Ctx(ctx).Info().Str("source", source).Str("path", target).Int64("size", size).Msg("Downloaded file")
+	return &DownloadResult{Source: source, Path: target, Size: size}, nil
+}
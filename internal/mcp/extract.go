@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Default limits of archives unpacked by the extract argument
+const (
+	defaultExtractMaxFiles = 1000
+	defaultExtractMaxSize  = 1 << 30
+)
+
+// extractArgs returns whether the extract argument is set and the include patterns
+func extractArgs(request mcp.CallToolRequest) (bool, []string) {
+	extract, _ := request.GetArguments()["extract"].(bool)
+	return extract, stringsArg(request, "include")
+}
+
+// archiveLimits returns the limits of unpacked archives, see FSM_EXTRACT_MAX_FILES and FSM_EXTRACT_MAX_SIZE
+func archiveLimits() util.ArchiveLimits {
+	maxFiles := defaultExtractMaxFiles
+	if value, err := strconv.Atoi(os.Getenv("FSM_EXTRACT_MAX_FILES")); err == nil && value > 0 {
+		maxFiles = value
+	}
+	return util.ArchiveLimits{MaxFiles: maxFiles, MaxSize: getEnvSize("FSM_EXTRACT_MAX_SIZE", defaultExtractMaxSize)}
+}
+
+// archiveTasks returns the uploads of the files in the archive at path, which came
+// from source. Files that aren't archives are uploaded whole, with a warning. If
+// removeArchive is set, path is removed along with the extracted files.
+func (s *Service) archiveTasks(source string, path string, filename string, include []string, warning string, removeArchive bool) ([]uploadTask, error) {
+	dir, err := os.MkdirTemp("", "extract-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+		if removeArchive {
+			os.Remove(path)
+		}
+	}
+
+	files, err := util.ExtractArchive(path, dir, include, archiveLimits())
+	if errors.Is(err, util.ErrNotArchive) {
+		os.RemoveAll(dir)
+		task := s.archiveEntryTask(source, path, filename, s.storage.Config.FileFormat, "", joinWarnings(warning, "not an archive, uploaded all of it"), nil)
+		if removeArchive {
+			task.cleanup = func() { os.Remove(path) }
+		}
+		return []uploadTask{task}, nil
+	}
+	if err == nil && len(files) == 0 {
+		err = errors.New("no files in the archive match the include patterns")
+	}
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	tasks := make([]uploadTask, 0, len(files))
+	for _, file := range files {
+		tasks = append(tasks, s.archiveEntryTask(source, file.Path, file.Name, s.storage.Config.FileFormat, file.Name, "", nil))
+	}
+	tasks[len(tasks)-1].cleanup = cleanup
+	return tasks, nil
+}
+
+// archiveEntryTask uploads the file at path under filename, with the object key
+// format. entry is its path in the archive or directory from source, empty for
+// files that weren't unpacked.
+func (s *Service) archiveEntryTask(source string, path string, filename string, format string, entry string, warning string, cleanup func()) uploadTask {
+	auditSource := source
+	if entry != "" {
+		auditSource = source + "!" + entry
+	}
+	return uploadTask{
+		source: auditSource,
+		run: func(ctx context.Context) (FileResult, error) {
+			contentWarning, err := s.storage.CheckContent(ctx, path, filepath.Base(filename))
+			if err != nil {
+				return FileResult{}, fmt.Errorf("%s: %w", auditSource, err)
+			}
+			uploadCtx, notes := storage.WithNotes(audit.WithSource(ctx, auditSource))
+			result, err := s.storage.UploadFileDetailedWithFormat(uploadCtx, path, filename, format)
+			if err != nil {
+				return FileResult{}, fmt.Errorf("failed to upload %s: %w", auditSource, err)
+			}
+			file := newFileResult(source, result, append([]string{warning, contentWarning}, notes.List()...))
+			file.Entry = entry
+			return file, nil
+		},
+		cleanup: cleanup,
+	}
+}
@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Job statuses
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// jobRetention is how long finished jobs can still be queried
+const jobRetention = time.Hour
+
+// Job is an upload running in the background, started by an upload tool with async set
+type Job struct {
+	ID       string       `json:"job_id"`
+	Tool     string       `json:"tool"`
+	Status   string       `json:"status"`
+	Total    int          `json:"total"`             // Number of files to upload
+	Done     int          `json:"done"`              // Number of files uploaded so far
+	Current  string       `json:"current,omitempty"` // Source being uploaded
+	Files    []FileResult `json:"files"`
+	Error    string       `json:"error,omitempty"`
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished,omitzero"`
+
+	owner string // API key of the caller, only it may query the job
+}
+
+// uploadTask uploads one file of a tool call
+type uploadTask struct {
+	source  string
+	run     func(ctx context.Context) (FileResult, error)
+	cleanup func() // Removes temp files of the task once the uploads of the call are over, if set
+}
+
+// cleanupTasks runs the cleanups of tasks, also of those that didn't run
+func cleanupTasks(tasks []uploadTask) {
+	for _, task := range tasks {
+		if task.cleanup != nil {
+			task.cleanup()
+		}
+	}
+}
+
+// jobManager runs upload jobs in the background and keeps their state
+type jobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newJobManager() *jobManager {
+	return &jobManager{jobs: make(map[string]*Job)}
+}
+
+// start runs tasks in the background and returns the job tracking them. The
+// uploads outlive the tool call, so they don't inherit its cancellation.
+func (m *jobManager) start(ctx context.Context, tool string, tasks []uploadTask) *Job {
+	scope, _ := storage.ScopeFromContext(ctx)
+	job := &Job{
+		ID:      newRequestID(),
+		Tool:    tool,
+		Status:  JobRunning,
+		Total:   len(tasks),
+		Files:   []FileResult{},
+		Started: time.Now(),
+		owner:   scope.Name,
+	}
+
+	m.mu.Lock()
+	m.prune()
+	m.jobs[job.ID] = job
+	snapshot := job.snapshot()
+	m.mu.Unlock()
+
+	logger := log.Ctx(ctx).With().Str("job_id", job.ID).Logger()
+	logger.Info().Int("files", len(tasks)).Msg("Upload job started")
+
+	go func() {
+		defer cleanupTasks(tasks)
+		ctx := logger.WithContext(context.WithoutCancel(ctx))
+		for _, task := range tasks {
+			m.update(job, func(job *Job) { job.Current = task.source })
+			file, err := task.run(ctx)
+			if err != nil {
+				logger.Error().Err(err).Str("source", task.source).Msg("Upload job failed")
+				m.update(job, func(job *Job) { job.finish(JobFailed, util.Redact(err.Error())) })
+				return
+			}
+			m.update(job, func(job *Job) {
+				job.Files = append(job.Files, file)
+				job.Done++
+			})
+		}
+		logger.Info().Int("files", len(tasks)).Msg("Upload job finished")
+		m.update(job, func(job *Job) { job.finish(JobSucceeded, "") })
+	}()
+
+	return snapshot
+}
+
+// get returns a copy of the job with the given ID, if it belongs to the caller
+func (m *jobManager) get(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	scope, _ := storage.ScopeFromContext(ctx)
+	if !ok || job.owner != scope.Name {
+		return nil, fmt.Errorf("unknown upload job %q, finished jobs are kept for %s", id, jobRetention)
+	}
+	return job.snapshot(), nil
+}
+
+func (m *jobManager) update(job *Job, fn func(job *Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(job)
+}
+
+// prune forgets jobs that finished more than jobRetention ago. The caller holds m.mu.
+func (m *jobManager) prune() {
+	for id, job := range m.jobs {
+		if !job.Finished.IsZero() && time.Since(job.Finished) > jobRetention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (job *Job) finish(status string, errMsg string) {
+	job.Status = status
+	job.Error = errMsg
+	job.Current = ""
+	job.Finished = time.Now()
+}
+
+func (job *Job) snapshot() *Job {
+	copied := *job
+	copied.Files = append([]FileResult{}, job.Files...)
+	return &copied
+}
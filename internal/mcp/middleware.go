@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the correlation ID of the tool call carried by ctx
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID generates a short correlation ID for a tool call
+func newRequestID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
+
+// requestIDMiddleware assigns a correlation ID to every tool call and attaches a logger
+// carrying it to the context, so all log lines of the call can be matched.
+// If errorsWithID is set, tool errors include the ID as well.
+func requestIDMiddleware(errorsWithID bool) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id := newRequestID()
+			logger := log.With().Str("request_id", id).Str("tool", request.Params.Name).Logger()
+			ctx = logger.WithContext(context.WithValue(ctx, requestIDKey{}, id))
+
+			// Record the ID and the tool in the audit trail and the object metadata as well
+			caller := audit.CallerFromContext(ctx)
+			caller.RequestID = id
+			caller.Tool = request.Params.Name
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				if caller.Session == "" {
+					caller.Session = session.SessionID()
+				}
+				if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+					if info := withInfo.GetClientInfo(); info.Name != "" {
+						caller.Client = strings.TrimSpace(info.Name + " " + info.Version)
+					}
+				}
+			}
+			ctx = audit.WithCaller(ctx, caller)
+
+			start := time.Now()
+			logger.Debug().Msg("Tool call started")
+
+			result, err := next(ctx, request)
+			if err != nil {
+				// SDK errors often echo request URLs with signatures and credentials
+				err = util.RedactError(err)
+				logger.Error().Err(err).Dur("duration", time.Since(start)).Msg("Tool call failed")
+				if errorsWithID {
+					err = fmt.Errorf("%w (request id: %s)", err, id)
+				}
+				return result, err
+			}
+
+			logger.Debug().Dur("duration", time.Since(start)).Msg("Tool call finished")
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// pdfPageTasks returns the uploads of the selected pages of the PDF at path: one
+// file with the pages, or with split one file per page. Files that aren't PDFs are
+// uploaded whole, with a warning.
+func (s *Service) pdfPageTasks(path string, selection string, split bool, warning string) ([]uploadTask, error) {
+	if util.GetFileContentType(path, filepath.Base(path)) != "application/pdf" {
+		task := s.fileTask(path, joinWarnings(warning, "not a PDF, uploaded all of it"), "")
+		return []uploadTask{task}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-pages-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if !split {
+		pagesPath := filepath.Join(dir, base+"-pages-"+pageSelectionName(selection)+".pdf")
+		if err := util.ExtractPDFPages(path, selection, pagesPath); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return []uploadTask{s.pdfPageTask(path, pagesPath, selection, warning, cleanup)}, nil
+	}
+
+	pages, err := util.SplitPDFPages(path, selection, dir)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	tasks := make([]uploadTask, 0, len(pages))
+	for _, page := range pages {
+		tasks = append(tasks, s.pdfPageTask(path, page.Path, strconv.Itoa(page.Number), warning, nil))
+	}
+	tasks[len(tasks)-1].cleanup = cleanup
+	return tasks, nil
+}
+
+// pdfPageTask uploads pagesPath, holding the given pages of the PDF at source
+func (s *Service) pdfPageTask(source string, pagesPath string, pages string, warning string, cleanup func()) uploadTask {
+	return uploadTask{
+		source: source,
+		run: func(ctx context.Context) (FileResult, error) {
+			uploadCtx, notes := storage.WithNotes(audit.WithSource(ctx, source+" (pages "+pages+")"))
+			result, err := s.storage.UploadFileDetailed(uploadCtx, pagesPath, filepath.Base(pagesPath))
+			if err != nil {
+				return FileResult{}, err
+			}
+			file := newFileResult(source, result, append([]string{warning}, notes.List()...))
+			file.Pages = pages
+			return file, nil
+		},
+		cleanup: cleanup,
+	}
+}
+
+// pageSelectionName turns a page selection into a part of a filename, e.g. "1-3,7" into "1-3_7"
+func pageSelectionName(selection string) string {
+	return strings.NewReplacer(",", "_", " ", "", "!", "not").Replace(selection)
+}
+
+// joinWarnings joins non-empty warnings with "; "
+func joinWarnings(warnings ...string) string {
+	var joined []string
+	for _, warning := range warnings {
+		if warning != "" {
+			joined = append(joined, warning)
+		}
+	}
+	return strings.Join(joined, "; ")
+}
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sjzar/file-store-mcp/internal/storage"
+)
+
+func TestCheckObjectKey(t *testing.T) {
+	teamA := storage.WithScope(context.Background(), storage.Scope{Name: "team-a", Prefix: "team-a/"})
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		objectKey string
+		ok        bool
+	}{
+		{name: "unscoped", ctx: context.Background(), objectKey: "team-b/a.txt", ok: true},
+		{name: "under the prefix", ctx: teamA, objectKey: "team-a/2024/a.txt", ok: true},
+		{name: "other prefix", ctx: teamA, objectKey: "team-b/a.txt"},
+		{name: "prefix without the slash", ctx: teamA, objectKey: "team-ab/a.txt"},
+		{name: "prefix itself", ctx: teamA, objectKey: "team-a"},
+		{name: "parent segment", ctx: teamA, objectKey: "team-a/../team-b/a.txt"},
+		{name: "unscoped parent segment", ctx: context.Background(), objectKey: "../a.txt"},
+		{name: "double slash", ctx: teamA, objectKey: "team-a//a.txt"},
+		{name: "dot segment", ctx: teamA, objectKey: "team-a/./a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkObjectKey(tt.ctx, tt.objectKey)
+			if tt.ok != (err == nil) {
+				t.Errorf("checkObjectKey(%q) = %v, want ok %v", tt.objectKey, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestJobOwner(t *testing.T) {
+	m := newJobManager()
+	teamA := storage.WithScope(context.Background(), storage.Scope{Name: "team-a"})
+	teamB := storage.WithScope(context.Background(), storage.Scope{Name: "team-b"})
+
+	done := make(chan struct{})
+	job := m.start(teamA, "upload_files", []uploadTask{{
+		source: "a.txt",
+		run: func(ctx context.Context) (FileResult, error) {
+			close(done)
+			return FileResult{}, nil
+		},
+	}})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job didn't run")
+	}
+
+	if _, err := m.get(teamA, job.ID); err != nil {
+		t.Errorf("get() by the owner = %v", err)
+	}
+	for name, ctx := range map[string]context.Context{"other key": teamB, "unscoped": context.Background()} {
+		if _, err := m.get(ctx, job.ID); err == nil {
+			t.Errorf("get() by %s returned the job", name)
+		}
+	}
+}
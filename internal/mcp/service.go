@@ -5,35 +5,74 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
 
+	"github.com/sjzar/file-store-mcp/internal/audit"
 	"github.com/sjzar/file-store-mcp/internal/storage"
 	"github.com/sjzar/file-store-mcp/pkg/clip"
+	"github.com/sjzar/file-store-mcp/pkg/util"
 	"github.com/sjzar/file-store-mcp/pkg/version"
 )
 
 type Service struct {
 	storage *storage.Service
+	jobs    *jobManager
+	chunks  *chunkManager
+	guard   *urlGuard    // Keeps URL downloads away from internal endpoints
+	client  *http.Client // Downloads URLs, shared to reuse connections
 	Server  *server.MCPServer
 }
 
 func NewService(storage *storage.Service) *Service {
+	return Register(server.NewMCPServer(Name, version.Version,
+		server.WithToolHandlerMiddleware(requestIDMiddleware(util.GetEnvBool("FSM_ERROR_REQUEST_ID", false))),
+	), storage)
+}
+
+// Register adds the upload tools to an existing MCP server
+func Register(srv *server.MCPServer, storage *storage.Service) *Service {
+	guard := newURLGuard(os.Getenv("FSM_URL_ALLOWLIST"))
 	s := &Service{
 		storage: storage,
-		Server:  server.NewMCPServer(Name, version.Version),
+		jobs:    newJobManager(),
+		chunks:  newChunkManager(getEnvSize("FSM_CHUNKED_MAX_SIZE", defaultChunkedMaxSize), getEnvSize("FSM_CHUNKED_MAX_TOTAL", defaultChunkedMaxTotal)),
+		guard:   guard,
+		client:  guard.client(),
+		Server:  srv,
 	}
 	s.Server.AddTool(UploadFilesTool, s.handleUploadFiles)
 	s.Server.AddTool(UploadClipboardFilesTool, s.handleUploadClipboardFiles)
 	s.Server.AddTool(UploadUrlFilesTool, s.handleUploadUrlFiles)
+	s.Server.AddTool(UploadDirectoryTool, s.handleUploadDirectory)
+	s.Server.AddTool(BeginUploadTool, s.handleBeginUpload)
+	s.Server.AddTool(AppendUploadTool, s.handleAppendUpload)
+	s.Server.AddTool(CommitUploadTool, s.handleCommitUpload)
+	s.Server.AddTool(DownloadFilesTool, s.handleDownloadFiles)
+	if !storage.Config.NoDelete {
+		s.Server.AddTool(DeleteFilesTool, s.handleDeleteFiles)
+	}
+	s.Server.AddTool(GetUploadStatusTool, s.handleGetUploadStatus)
+	s.Server.AddTool(GetUploadStatsTool, s.handleGetUploadStats)
+	s.Server.AddTool(StorageInfoTool, s.handleStorageInfo)
 	return s
 }
 
 func (s *Service) handleUploadFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	_paths, ok := request.Params.Arguments["paths"].([]interface{})
+	ctx, err := withRequestTags(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	_paths, ok := request.GetArguments()["paths"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("path must be a string")
 	}
@@ -47,33 +86,55 @@ func (s *Service) handleUploadFiles(ctx context.Context, request mcp.CallToolReq
 		return nil, err
 	}
 
-	urls := ""
+	warnings, err := s.CheckContents(ctx, validatedPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upload only the selected pages of PDFs, or each page on its own
+	selection, _ := request.GetArguments()["pages"].(string)
+	selection = strings.TrimSpace(selection)
+	split, _ := request.GetArguments()["split_pages"].(bool)
+	extract, include := extractArgs(request)
+
+	tasks := make([]uploadTask, 0, len(validatedPaths))
 	for i, path := range validatedPaths {
-		_url, err := s.storage.UploadFile(ctx, path)
+		if extract {
+			archiveTasks, err := s.archiveTasks(path, path, filepath.Base(path), include, "", false)
+			if err != nil {
+				cleanupTasks(tasks)
+				return nil, err
+			}
+			tasks = append(tasks, archiveTasks...)
+			continue
+		}
+		if selection == "" && !split {
+			tasks = append(tasks, s.fileTask(path, warnings[i], ""))
+			continue
+		}
+		pageTasks, err := s.pdfPageTasks(path, selection, split, warnings[i])
 		if err != nil {
+			cleanupTasks(tasks)
 			return nil, err
 		}
-		urls += fmt.Sprintf("%d: %s\n", i+1, _url)
+		tasks = append(tasks, pageTasks...)
 	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: fmt.Sprintf("Upload %d files successfully:\n%s", len(validatedPaths), urls),
-			},
-		},
-	}, nil
+	return s.runUploads(ctx, request, tasks, "Upload %d files successfully")
 }
 
 func (s *Service) handleUploadClipboardFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := withRequestTags(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
 	// 从剪贴板获取文件路径，超时时间设为5秒
-	paths, err := clip.GetFiles(5)
+	files, err := clip.GetFilesWithSource(5)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get files from clipboard: %w", err)
 	}
 
-	if len(paths) == 0 {
+	if len(files) == 0 {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -84,32 +145,201 @@ func (s *Service) handleUploadClipboardFiles(ctx context.Context, request mcp.Ca
 		}, nil
 	}
 
+	if _selectors, ok := request.GetArguments()["select"].([]interface{}); ok && len(_selectors) > 0 {
+		selectors := make([]string, 0, len(_selectors))
+		for _, _selector := range _selectors {
+			selectors = append(selectors, fmt.Sprint(_selector))
+		}
+		selected, err := clip.Select(files, selectors)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("%s. Found %d files in clipboard, nothing was uploaded:\n%s", err, len(files), listClipFiles(files)),
+					},
+				},
+			}, nil
+		}
+		files = selected
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
 	validatedPaths, err := s.ValidatePaths(paths)
 	if err != nil {
 		return nil, err
 	}
 
-	urls := ""
+	warnings, err := s.CheckContents(ctx, validatedPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]uploadTask, 0, len(validatedPaths))
 	for i, path := range validatedPaths {
-		_url, err := s.storage.UploadFile(ctx, path)
+		tasks = append(tasks, s.fileTask(path, warnings[i], files[i].Describe()))
+	}
+	return s.runUploads(ctx, request, tasks, "Upload %d files from clipboard successfully")
+}
+
+// fileTask uploads a local file, with the warning of its content check and
+// how it was detected in the clipboard, if it was
+func (s *Service) fileTask(path string, warning string, detection string) uploadTask {
+	return uploadTask{
+		source: path,
+		run: func(ctx context.Context) (FileResult, error) {
+			uploadCtx, notes := storage.WithNotes(ctx)
+			result, err := s.storage.UploadFileDetailed(uploadCtx, path, filepath.Base(path))
+			if err != nil {
+				return FileResult{}, err
+			}
+			file := newFileResult(path, result, append([]string{warning}, notes.List()...))
+			file.Detection = detection
+			return file, nil
+		},
+	}
+}
+
+// runUploads runs the uploads of a tool call and lists the URLs after summary,
+// a format taking the number of files. With the async argument the uploads run
+// in the background instead and the job ID is returned.
+func (s *Service) runUploads(ctx context.Context, request mcp.CallToolRequest, tasks []uploadTask, summary string) (*mcp.CallToolResult, error) {
+	if async, _ := request.GetArguments()["async"].(bool); async {
+		job := s.jobs.start(ctx, request.Params.Name, tasks)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Started uploading %d files in the background, job ID: %s\nCall get_upload_status with this job ID for the progress and the URLs.", job.Total, job.ID),
+				},
+			},
+			StructuredContent: job,
+		}, nil
+	}
+
+	defer cleanupTasks(tasks)
+	files := make([]FileResult, 0, len(tasks))
+	for _, task := range tasks {
+		file, err := task.run(ctx)
 		if err != nil {
 			return nil, err
 		}
-		urls += fmt.Sprintf("%d: %s\n", i+1, _url)
+		files = append(files, file)
+	}
+	return newUploadToolResult(fmt.Sprintf(summary+":\n%s", len(files), formatFileResults(files)), files), nil
+}
+
+// formatFileResults lists uploaded files, one numbered URL per line
+func formatFileResults(files []FileResult) string {
+	list := ""
+	for i, file := range files {
+		detection := ""
+		if file.Detection != "" {
+			detection = fmt.Sprintf(" (%s)", file.Detection)
+		}
+		if file.Pages != "" {
+			detection += fmt.Sprintf(" (pages %s)", file.Pages)
+		}
+		if file.Entry != "" {
+			detection += fmt.Sprintf(" (%s)", file.Entry)
+		}
+		thumbnail := ""
+		if file.ThumbnailURL != "" {
+			thumbnail = fmt.Sprintf(" (thumbnail: %s)", file.ThumbnailURL)
+		}
+		list += fmt.Sprintf("%d: %s%s%s%s\n", i+1, file.URL, detection, thumbnail, formatWarning(file.Warnings...))
+		for _, mirror := range file.Mirrors {
+			if mirror.URL != "" {
+				list += fmt.Sprintf("   Mirror (%s): %s\n", mirror.Backend, mirror.URL)
+			}
+		}
+		if file.OCRTextURL != "" {
+			list += fmt.Sprintf("   OCR text: %s\n", file.OCRTextURL)
+		}
+		if file.OCRText != "" {
+			list += fmt.Sprintf("   Text in the image:\n%s\n", indent(file.OCRText, "   "))
+		}
+	}
+	return list
+}
+
+// indent prefixes each line of text with prefix
+func indent(text string, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+func (s *Service) handleGetUploadStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, ok := request.GetArguments()["job_id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("job_id must be a non-empty string")
+	}
+
+	job, err := s.jobs.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Job %s (%s): %s, %d of %d files uploaded", job.ID, job.Tool, job.Status, job.Done, job.Total)
+	switch job.Status {
+	case JobRunning:
+		if job.Current != "" {
+			text += fmt.Sprintf(", uploading %s", job.Current)
+		}
+	case JobFailed:
+		text += fmt.Sprintf(", failed: %s", job.Error)
+	}
+	if len(job.Files) > 0 {
+		text += "\n" + formatFileResults(job.Files)
 	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Upload %d files from clipboard successfully:\n%s", len(validatedPaths), urls),
+				Text: text,
 			},
 		},
+		StructuredContent: job,
 	}, nil
 }
 
+// withRequestTags attaches the optional tags argument of an upload tool to ctx,
+// so the backends store them as object metadata
+func withRequestTags(ctx context.Context, request mcp.CallToolRequest) (context.Context, error) {
+	_tags, ok := request.GetArguments()["tags"].(map[string]interface{})
+	if !ok || len(_tags) == 0 {
+		return ctx, nil
+	}
+	tags := make(map[string]string, len(_tags))
+	for key, value := range _tags {
+		tags[key] = fmt.Sprint(value)
+	}
+	if err := storage.ValidateTags(tags); err != nil {
+		return ctx, err
+	}
+	return storage.WithTags(ctx, tags), nil
+}
+
+// listClipFiles formats clipboard files as a numbered candidate list
+func listClipFiles(files []clip.File) string {
+	list := ""
+	for i, file := range files {
+		list += fmt.Sprintf("%d: %s\n", i+1, file.Describe())
+	}
+	return list
+}
+
 func (s *Service) handleUploadUrlFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	_urls, ok := request.Params.Arguments["urls"].([]interface{})
+	ctx, err := withRequestTags(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	_urls, ok := request.GetArguments()["urls"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("urls must be an array of strings")
 	}
@@ -123,55 +353,261 @@ func (s *Service) handleUploadUrlFiles(ctx context.Context, request mcp.CallTool
 		return nil, fmt.Errorf("urls cannot be empty")
 	}
 
-	resultUrls := ""
-	for i, url := range urls {
-		// 创建临时文件来保存下载的内容
-		tempFile, err := os.CreateTemp("", "download-*")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create temp file: %w", err)
+	// Download archives to unpack right away, the uploads of their files are the tasks
+	if extract, include := extractArgs(request); extract {
+		var tasks []uploadTask
+		for _, url := range urls {
+			tempPath, filename, err := s.downloadURL(ctx, url)
+			if err == nil {
+				var archiveTasks []uploadTask
+				archiveTasks, err = s.archiveTasks(url, tempPath, filename, include, "", true)
+				tasks = append(tasks, archiveTasks...)
+			}
+			if err != nil {
+				cleanupTasks(tasks)
+				return nil, err
+			}
 		}
-		tempPath := tempFile.Name()
-		defer os.Remove(tempPath) // 确保临时文件最后被删除
+		return s.runUploads(ctx, request, tasks, "Downloaded and uploaded %d files successfully")
+	}
 
-		// 下载文件
-		resp, err := http.Get(url)
-		if err != nil {
-			tempFile.Close()
-			return nil, fmt.Errorf("failed to download file from %s: %w", url, err)
-		}
-		defer resp.Body.Close()
+	tasks := make([]uploadTask, 0, len(urls))
+	for _, url := range urls {
+		tasks = append(tasks, uploadTask{
+			source: url,
+			run: func(ctx context.Context) (FileResult, error) {
+				result, warnings, err := s.UploadURL(ctx, url)
+				if err != nil {
+					return FileResult{}, err
+				}
+				return newFileResult(url, result, warnings), nil
+			},
+		})
+	}
+	return s.runUploads(ctx, request, tasks, "Downloaded and uploaded %d files successfully")
+}
 
-		if resp.StatusCode != http.StatusOK {
-			tempFile.Close()
-			return nil, fmt.Errorf("failed to download file from %s: status code %d", url, resp.StatusCode)
+// FileResult is the structured result of one uploaded file
+type FileResult struct {
+	Source string `json:"source"` // Local path or URL the content came from
+	storage.UploadResult
+	Detection string   `json:"detection,omitempty"` // How a clipboard file was detected
+	Pages     string   `json:"pages,omitempty"`     // Pages of a PDF that were uploaded, see the pages argument
+	Entry     string   `json:"entry,omitempty"`     // Path of the file in the archive it was unpacked from, or in the uploaded directory
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// newFileResult builds the result of an upload, dropping empty warnings
+func newFileResult(source string, result *storage.UploadResult, warnings []string) FileResult {
+	file := FileResult{Source: source, UploadResult: *result}
+	for _, warning := range warnings {
+		if warning != "" {
+			file.Warnings = append(file.Warnings, warning)
 		}
+	}
+	return file
+}
+
+// newUploadToolResult returns the text summary of an upload tool call, with the
+// uploaded files as structured content for clients that read it
+func newUploadToolResult(text string, files []FileResult) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+		StructuredContent: map[string]interface{}{"files": files},
+	}
+}
+
+// UploadURL downloads url and uploads the content to the storage, returning the
+// uploaded object and any warnings about the content or the upload
+func (s *Service) UploadURL(ctx context.Context, rawURL string) (*storage.UploadResult, []string, error) {
+	tempPath, filename, err := s.downloadURL(ctx, rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(tempPath) // 确保临时文件最后被删除
+
+	// 检查下载内容是否与扩展名一致
+	warning, err := s.storage.CheckContent(ctx, tempPath, filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to upload file from %s: %w", rawURL, err)
+	}
 
-		// 将下载的内容写入临时文件
-		_, err = io.Copy(tempFile, resp.Body)
+	uploadCtx, notes := storage.WithNotes(audit.WithSource(ctx, rawURL))
+	result, err := s.storage.UploadFileDetailed(uploadCtx, tempPath, filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to upload file from %s: %w", rawURL, err)
+	}
+
+	return result, append([]string{warning}, notes.List()...), nil
+}
+
+// downloadURL downloads url into a temp file, which the caller removes. It returns
+// the path of the temp file and the filename taken from the URL.
+func (s *Service) downloadURL(ctx context.Context, rawURL string) (string, string, error) {
+	// Refuse non-HTTP schemes and internal endpoints, so the tool can't be used
+	// to copy them to the bucket
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	if err := s.guard.check(ctx, parsed); err != nil {
+		return "", "", fmt.Errorf("refusing to download %s: %w", rawURL, err)
+	}
+
+	// 创建临时文件来保存下载的内容
+	tempFile, err := os.CreateTemp("", "download-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	// 下载文件
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
 		tempFile.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to save downloaded file: %w", err)
-		}
+		os.Remove(tempPath)
+		return "", "", fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", "", fmt.Errorf("failed to download file from %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
 
-		// 上传临时文件
-		uploadedUrl, err := s.storage.UploadFile(ctx, tempPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to upload file from %s: %w", url, err)
+	if resp.StatusCode != http.StatusOK {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", "", fmt.Errorf("failed to download file from %s: status code %d", rawURL, resp.StatusCode)
+	}
+
+	// 将下载的内容写入临时文件
+	_, err = io.Copy(tempFile, resp.Body)
+	tempFile.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return "", "", fmt.Errorf("failed to save downloaded file: %w", err)
+	}
+
+	// 使用 URL 中的文件名生成对象键
+	return tempPath, urlFilename(rawURL, resp.Header.Get("Content-Type"), filepath.Base(tempPath)), nil
+}
+
+// urlFilename derives an upload filename from a download URL, adding an
+// extension from the response content type when the URL path has none
+func urlFilename(rawURL string, contentType string, fallback string) string {
+	filename := fallback
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(parsed.Path); base != "." && base != "/" {
+			filename = base
 		}
+	}
+
+	if filepath.Ext(filename) == "" && contentType != "" {
+		filename += util.ExtensionByType(contentType)
+	}
+	return filename
+}
+
+func (s *Service) handleStorageInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	info := s.storage.Info()
+
+	name := info.Backend
+	if info.Name != "" {
+		name = fmt.Sprintf("%s (%s)", info.Name, info.Backend)
+	}
+	text := fmt.Sprintf("Storage: %s\nLimits: %s\n", name, info.Describe())
+	if info.Ready {
+		text += fmt.Sprintf("Supported operations: %s\n", strings.Join(info.Capabilities.Operations(), ", "))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+		StructuredContent: info,
+	}, nil
+}
+
+func (s *Service) handleGetUploadStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	stats, err := s.storage.Stats()
+	if err != nil {
+		return nil, err
+	}
 
-		resultUrls += fmt.Sprintf("%d: %s\n", i+1, uploadedUrl)
+	text := fmt.Sprintf("Since server start (%s):\n%s", stats.Since.Format(time.RFC3339), formatBackendStats(stats.SinceStart))
+	if stats.History != nil {
+		text += fmt.Sprintf("\nUpload history:\n%s", formatBackendStats(stats.History))
 	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Downloaded and uploaded %d files successfully:\n%s", len(urls), resultUrls),
+				Text: text,
 			},
 		},
 	}, nil
 }
 
+// formatBackendStats renders one line per backend
+func formatBackendStats(stats []storage.BackendStats) string {
+	if len(stats) == 0 {
+		return "no uploads\n"
+	}
+	text := ""
+	for _, b := range stats {
+		backend := b.Backend
+		if backend == "" {
+			backend = "(none)"
+		}
+		text += fmt.Sprintf("%s: %d uploads, %d bytes, avg latency %.0fms, error rate %.1f%%",
+			backend, b.Uploads, b.Bytes, b.AvgLatencyMS, b.ErrorRate*100)
+		if b.LastError != "" {
+			text += fmt.Sprintf(", last error at %s: %s", b.LastErrorAt.Format(time.RFC3339), b.LastError)
+		}
+		text += "\n"
+	}
+	return text
+}
+
+// CheckContents checks all files before any upload starts, so a rejected file
+// doesn't leave the others half uploaded. It returns one warning per path.
+func (s *Service) CheckContents(ctx context.Context, paths []string) ([]string, error) {
+	warnings := make([]string, len(paths))
+	for i, path := range paths {
+		warning, err := s.storage.CheckContent(ctx, path, filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+		warnings[i] = warning
+	}
+	return warnings, nil
+}
+
+// formatWarning formats a warning as a suffix of a result line
+func formatWarning(warnings ...string) string {
+	nonEmpty := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		if warning != "" {
+			nonEmpty = append(nonEmpty, warning)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [warning: %s]", strings.Join(nonEmpty, "; "))
+}
+
 func (s *Service) ValidatePaths(paths []string) ([]string, error) {
 
 	validatePaths := make([]string, 0, len(paths))
@@ -191,10 +627,24 @@ func (s *Service) ValidatePaths(paths []string) ([]string, error) {
 		}
 
 		if fileInfo.IsDir() {
-			return nil, fmt.Errorf("path cannot be a directory")
+			return nil, fmt.Errorf("path cannot be a directory, use upload_directory for directories")
 		}
 		validatePaths = append(validatePaths, abs)
 	}
 
 	return validatePaths, nil
 }
+
+// getEnvSize parses a size like "500MB" from an environment variable, or returns the default
+func getEnvSize(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	size, err := util.ParseSize(value)
+	if err != nil {
+		log.Warn().Str("key", key).Str("value", value).Msg("Invalid size, using default")
+		return defaultValue
+	}
+	return size
+}
@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errBlockedURL is returned for URLs that may not be downloaded
+var errBlockedURL = errors.New("URL blocked")
+
+// blockedPrefixes are special-purpose ranges not covered by the netip.Addr checks
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, may embed private IPv4 addresses
+}
+
+// urlGuard keeps URL downloads away from internal endpoints: only http and https
+// URLs are accepted, and hosts resolving to loopback, private, link-local (like the
+// cloud metadata service at 169.254.169.254) or other special-purpose addresses
+// are refused unless allowlisted. Addresses are checked again when connecting, so
+// DNS answers changing between the check and the download don't get around it.
+type urlGuard struct {
+	hosts    []string       // Allowed host names; a leading dot allows subdomains
+	prefixes []netip.Prefix // Allowed address ranges
+	resolver *net.Resolver
+}
+
+// newURLGuard creates a guard allowing the comma-separated hosts, ".domain"
+// suffixes, addresses and CIDR ranges of allowlist, e.g. "10.1.0.0/16,.corp.example.com"
+func newURLGuard(allowlist string) *urlGuard {
+	g := &urlGuard{resolver: net.DefaultResolver}
+	for _, entry := range strings.Split(allowlist, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			g.prefixes = append(g.prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			g.prefixes = append(g.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else {
+			g.hosts = append(g.hosts, entry)
+		}
+	}
+	return g
+}
+
+// check validates the scheme of u and the addresses its host resolves to
+func (g *urlGuard) check(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q is not allowed, use http or https", errBlockedURL, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: %s has no host", errBlockedURL, u.Redacted())
+	}
+	if g.hostAllowed(host) {
+		return nil
+	}
+
+	addrs, err := g.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := g.checkAddr(host, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddr refuses special-purpose addresses that aren't allowlisted
+func (g *urlGuard) checkAddr(host string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range g.prefixes {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if reason := blockedReason(addr); reason != "" {
+		if ip, err := netip.ParseAddr(host); err == nil && ip.Unmap() == addr {
+			return fmt.Errorf("%w: %s is a %s address; add it to FSM_URL_ALLOWLIST to allow it", errBlockedURL, host, reason)
+		}
+		return fmt.Errorf("%w: %s resolves to %s address %s; add it to FSM_URL_ALLOWLIST to allow it", errBlockedURL, host, reason, addr)
+	}
+	return nil
+}
+
+// blockedReason describes why addr may not be downloaded from, or is empty if it may
+func blockedReason(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsPrivate():
+		return "private"
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local"
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsMulticast(), addr.IsInterfaceLocalMulticast():
+		return "multicast"
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return "reserved"
+		}
+	}
+	return ""
+}
+
+// hostAllowed reports whether host is allowlisted by name
+func (g *urlGuard) hostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range g.hosts {
+		if host == allowed || strings.HasPrefix(allowed, ".") && (strings.HasSuffix(host, allowed) || host == allowed[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialContext connects like the default transport, checking the address actually
+// connected to unless the host is allowlisted by name
+func (g *urlGuard) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if host, _, err := net.SplitHostPort(address); err != nil || !g.hostAllowed(host) {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return g.checkAddr(host, addrPort.Addr())
+		}
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// client returns an HTTP client for downloads guarded by g, following redirects
+// only to URLs that pass the same checks
+func (g *urlGuard) client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = g.dialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return g.check(req.Context(), req.URL)
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestURLGuardCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist string
+		url       string
+		blocked   bool
+	}{
+		{name: "public address", url: "https://93.184.216.34/file.pdf"},
+		{name: "public IPv6 address", url: "http://[2606:2800:220:1:248:1893:25c8:1946]/"},
+		{name: "loopback", url: "http://127.0.0.1:8080/", blocked: true},
+		{name: "loopback range", url: "http://127.1.2.3/", blocked: true},
+		{name: "IPv6 loopback", url: "http://[::1]/", blocked: true},
+		{name: "IPv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/", blocked: true},
+		{name: "private 10/8", url: "http://10.0.0.1/", blocked: true},
+		{name: "private 172.16/12", url: "http://172.16.5.4/", blocked: true},
+		{name: "private 192.168/16", url: "http://192.168.1.1/", blocked: true},
+		{name: "IPv6 unique local", url: "http://[fd00::1]/", blocked: true},
+		{name: "cloud metadata", url: "http://169.254.169.254/latest/meta-data/", blocked: true},
+		{name: "IPv6 link-local", url: "http://[fe80::1]/", blocked: true},
+		{name: "unspecified", url: "http://0.0.0.0/", blocked: true},
+		{name: "carrier-grade NAT", url: "http://100.64.0.1/", blocked: true},
+		{name: "benchmarking", url: "http://198.18.0.1/", blocked: true},
+		{name: "broadcast", url: "http://255.255.255.255/", blocked: true},
+		{name: "NAT64 embedding a private address", url: "http://[64:ff9b::a00:1]/", blocked: true},
+		{name: "multicast", url: "http://224.0.0.1/", blocked: true},
+		{name: "file scheme", url: "file:///etc/passwd", blocked: true},
+		{name: "ftp scheme", url: "ftp://93.184.216.34/file", blocked: true},
+		{name: "gopher scheme", url: "gopher://127.0.0.1:6379/_INFO", blocked: true},
+		{name: "no host", url: "http:///path", blocked: true},
+		{name: "allowlisted range", allowlist: "10.1.0.0/16", url: "http://10.1.2.3/"},
+		{name: "outside allowlisted range", allowlist: "10.1.0.0/16", url: "http://10.2.0.1/", blocked: true},
+		{name: "allowlisted address", allowlist: "169.254.169.254", url: "http://169.254.169.254/"},
+		{name: "allowlisted IPv4-mapped address", allowlist: "127.0.0.1", url: "http://[::ffff:127.0.0.1]/"},
+		{name: "allowlisted host", allowlist: "intranet.example.com", url: "http://intranet.example.com/"},
+		{name: "allowlisted host with trailing dot", allowlist: "intranet.example.com", url: "http://INTRANET.example.com./"},
+		{name: "allowlisted domain", allowlist: ".corp.example.com", url: "https://files.corp.example.com/a.zip"},
+		{name: "allowlisted domain itself", allowlist: ".corp.example.com", url: "https://corp.example.com/a.zip"},
+		{name: "scheme still checked for allowlisted host", allowlist: "intranet.example.com", url: "file://intranet.example.com/etc/passwd", blocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = newURLGuard(tt.allowlist).check(context.Background(), u)
+			if tt.blocked != errors.Is(err, errBlockedURL) {
+				t.Errorf("check(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+			}
+			if !tt.blocked && err != nil {
+				t.Errorf("check(%s) = %v, want nil", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestURLGuardHostAllowed(t *testing.T) {
+	g := newURLGuard(" Intranet.example.com , .corp.example.com,10.0.0.0/8")
+	tests := map[string]bool{
+		"intranet.example.com":      true,
+		"files.corp.example.com":    true,
+		"a.b.corp.example.com":      true,
+		"corp.example.com":          true,
+		"evilcorp.example.com":      false,
+		"corp.example.com.evil.com": false,
+		"other.example.com":         false,
+		"10.0.0.1":                  false, // Ranges are matched by address, not by name
+	}
+	for host, want := range tests {
+		if got := g.hostAllowed(host); got != want {
+			t.Errorf("hostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestURLGuardClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// The test server listens on a loopback address, which is refused when connecting
+	resp, err := newURLGuard("").client().Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("client connected to a loopback address")
+	}
+	if !errors.Is(err, errBlockedURL) {
+		t.Errorf("Get() = %v, want a blocked URL error", err)
+	}
+
+	// Allowlisted, the server can be downloaded from, but not redirect to internal endpoints
+	client := newURLGuard("127.0.0.1").client()
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() of an allowlisted address = %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(srv.URL + "/redirect")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("client followed a redirect to the metadata service")
+	}
+	if !errors.Is(err, errBlockedURL) {
+		t.Errorf("Get() = %v, want a blocked URL error", err)
+	}
+}
@@ -0,0 +1,226 @@
+package artifactory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// ArtifactoryClient deploys files to a generic repository of JFrog Artifactory
+// or Sonatype Nexus and returns the artifact URLs
+type ArtifactoryClient struct {
+	repoURL  *url.URL // URL of the repository, the artifacts are below it
+	path     string   // Path in the repository, e.g. "ai-uploads/"
+	apiKey   string
+	token    string
+	username string
+	password string
+	client   *http.Client // Shared by all requests to reuse connections
+}
+
+// ArtifactoryConfig contains configuration for the generic repository client
+type ArtifactoryConfig struct {
+	// URL is the base URL the repositories are served under, e.g.
+	// "https://example.jfrog.io/artifactory" or "https://nexus.example.com/repository"
+	URL        string
+	Repository string // Generic repository key, e.g. "generic-local"
+	Path       string // Optional, path in the repository the files are deployed to
+
+	// Credentials, the first one set is used: an Artifactory API key, an access
+	// token (sent as a bearer token), or a username and password (Nexus)
+	APIKey   string
+	Token    string
+	Username string
+	Password string
+}
+
+// NewArtifactoryClient creates a new generic repository client
+func NewArtifactoryClient(cfg ArtifactoryConfig) (*ArtifactoryClient, error) {
+	if cfg.URL == "" || cfg.Repository == "" {
+		return nil, fmt.Errorf("artifactory URL and repository cannot be empty")
+	}
+	if cfg.APIKey == "" && cfg.Token == "" && cfg.Username == "" {
+		return nil, fmt.Errorf("artifactory API key, access token or username cannot be empty")
+	}
+
+	base := strings.TrimSuffix(cfg.URL, "/")
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	repoURL, err := url.Parse(base + "/" + strings.Trim(cfg.Repository, "/"))
+	if err != nil || repoURL.Host == "" {
+		return nil, fmt.Errorf("invalid artifactory URL %q", cfg.URL)
+	}
+
+	// Ensure path format is correct
+	dir := strings.Trim(cfg.Path, "/")
+	if dir != "" {
+		dir = dir + "/"
+	}
+
+	return &ArtifactoryClient{
+		repoURL:  repoURL,
+		path:     dir,
+		apiKey:   cfg.APIKey,
+		token:    cfg.Token,
+		username: cfg.Username,
+		password: cfg.Password,
+		client:   &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// UploadFile deploys a local file and returns the artifact URL
+func (a *ArtifactoryClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	return a.upload(ctx, file, fileInfo.Size(), util.GetFileContentType(_path, filename), filename)
+}
+
+// Upload deploys data from an io.Reader and returns the artifact URL
+func (a *ArtifactoryClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+	return a.upload(ctx, body, -1, contentType, filename)
+}
+
+// upload deploys size bytes of body, or all of it if size is negative, as filename
+// and returns the artifact URL
+func (a *ArtifactoryClient) upload(ctx context.Context, body io.Reader, size int64, contentType string, filename string) (string, error) {
+	// Format the object key using the provided format
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	artifactURL := a.artifactURL(objectKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, artifactURL, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if err := a.do(req); err != nil {
+		return "", fmt.Errorf("failed to deploy artifact: %w", err)
+	}
+	return artifactURL, nil
+}
+
+// Limits reports no known limits, they depend on the server's configuration
+func (a *ArtifactoryClient) Limits() meta.Limits {
+	return meta.Limits{}
+}
+
+// Delete removes an artifact from the repository
+func (a *ArtifactoryClient) Delete(ctx context.Context, objectKey string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, a.artifactURL(objectKey), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := a.do(req); err != nil {
+		return fmt.Errorf("failed to delete artifact: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an artifact exists in the repository
+func (a *ArtifactoryClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, a.artifactURL(objectKey), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := a.do(req); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check artifact: %w", err)
+	}
+	return true, nil
+}
+
+// artifactURL returns the URL of an artifact, relative to the configured path
+func (a *ArtifactoryClient) artifactURL(objectKey string) string {
+	u := *a.repoURL
+	u.Path = path.Join(u.Path, a.path+objectKey)
+	u.RawPath = ""
+	return u.String()
+}
+
+// do sends an authenticated request, discarding the response body
+func (a *ArtifactoryClient) do(req *http.Request) error {
+	switch {
+	case a.apiKey != "":
+		req.Header.Set("X-JFrog-Art-Api", a.apiKey)
+	case a.token != "":
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	default:
+		req.SetBasicAuth(a.username, a.password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// APIError is an error response of the repository server
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("artifactory returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads the error response of a request. Artifactory describes
+// errors as JSON, Nexus as plain text or HTML, which is kept only if short.
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && len(body.Errors) > 0 {
+		e.Message = body.Errors[0].Message
+	} else if text := strings.TrimSpace(string(respBody)); len(text) <= 200 && !strings.HasPrefix(text, "<") {
+		e.Message = text
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
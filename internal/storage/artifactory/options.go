@@ -0,0 +1,45 @@
+package artifactory
+
+// Option sets a field of the ArtifactoryConfig used by New
+type Option func(*ArtifactoryConfig)
+
+// New creates a new generic repository client from options, an alternative to NewArtifactoryClient
+func New(opts ...Option) (*ArtifactoryClient, error) {
+	var cfg ArtifactoryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewArtifactoryClient(cfg)
+}
+
+// WithRepository sets the base URL of the repositories, the repository key and
+// the path in the repository the files are deployed to
+func WithRepository(baseURL, repository, path string) Option {
+	return func(cfg *ArtifactoryConfig) {
+		cfg.URL = baseURL
+		cfg.Repository = repository
+		cfg.Path = path
+	}
+}
+
+// WithAPIKey authenticates with an Artifactory API key
+func WithAPIKey(apiKey string) Option {
+	return func(cfg *ArtifactoryConfig) {
+		cfg.APIKey = apiKey
+	}
+}
+
+// WithToken authenticates with an access token, sent as a bearer token
+func WithToken(token string) Option {
+	return func(cfg *ArtifactoryConfig) {
+		cfg.Token = token
+	}
+}
+
+// WithBasicAuth authenticates with a username and password, e.g. for Nexus
+func WithBasicAuth(username, password string) Option {
+	return func(cfg *ArtifactoryConfig) {
+		cfg.Username = username
+		cfg.Password = password
+	}
+}
@@ -0,0 +1,324 @@
+package bos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// DefaultEndpoint is the endpoint of the Beijing region
+const DefaultEndpoint = "bj.bcebos.com"
+
+// maxUploadSize is the largest object a single PutObject request accepts, 5 GiB
+const maxUploadSize = 5 << 30
+
+// BOSClient is a wrapper for the Baidu Object Storage client
+type BOSClient struct {
+	signer     *signer
+	bucketURL  *url.URL // Virtual-hosted URL of the bucket
+	domain     string   // Custom domain, if any
+	expiration time.Duration
+	// Cache-Control header for uploaded objects
+	cacheControl string
+	client       *http.Client // Shared by all requests to reuse connections
+}
+
+// BOSConfig contains configuration for the BOS client
+type BOSConfig struct {
+	AccessKey     string
+	SecretKey     string
+	Bucket        string
+	Endpoint      string // Region endpoint, e.g. "bj.bcebos.com" or "gz.bcebos.com"; HTTPS unless it has an http:// scheme
+	Domain        string // Optional, custom domain
+	URLExpiration int64  // URL expiration time in seconds
+	CacheControl  string // Optional, Cache-Control header for uploaded objects
+}
+
+// NewBOSClient creates a new BOS client
+func NewBOSClient(cfg BOSConfig) (*BOSClient, error) {
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("BOS access key and secret key cannot be empty")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("BOS bucket cannot be empty")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	bucketURL, err := url.Parse(endpoint)
+	if err != nil || bucketURL.Host == "" {
+		return nil, fmt.Errorf("invalid BOS endpoint %q", cfg.Endpoint)
+	}
+	bucketURL.Host = cfg.Bucket + "." + bucketURL.Host
+	bucketURL.Path = "/"
+
+	// Set default expiration if not provided
+	expiration := time.Hour * 24 * 7 // 7 days default
+	if cfg.URLExpiration > 0 {
+		expiration = time.Duration(cfg.URLExpiration) * time.Second
+	}
+
+	domain := strings.TrimSuffix(cfg.Domain, "/")
+	if domain != "" && !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	return &BOSClient{
+		signer:       &signer{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey},
+		bucketURL:    bucketURL,
+		domain:       domain,
+		expiration:   expiration,
+		cacheControl: cfg.CacheControl,
+		client:       &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// UploadFile uploads a local file to BOS and returns the download URL
+func (b *BOSClient) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.Size() > maxUploadSize {
+		return "", fmt.Errorf("file size %d exceeds the BOS upload limit of 5 GiB", fileInfo.Size())
+	}
+	return b.upload(ctx, file, fileInfo.Size(), util.GetFileContentType(path, filename), filename)
+}
+
+// Upload uploads data from an io.Reader to BOS and returns the download URL.
+// PutObject needs the size, so the data is buffered in a temp file first.
+func (b *BOSClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+
+	tempFile, err := os.CreateTemp("", "bos-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	size, err := io.Copy(tempFile, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to buffer data: %w", err)
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return b.upload(ctx, tempFile, size, contentType, filename)
+}
+
+// upload uploads size bytes of body as filename and returns the download URL
+func (b *BOSClient) upload(ctx context.Context, body io.Reader, size int64, contentType string, filename string) (string, error) {
+	// Format the object key using the provided format
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL(objectKey).String(), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	m := meta.FromContext(ctx)
+	// Set Content-Disposition so downloads get the original filename
+	if m.ContentDisposition != "" {
+		req.Header.Set("Content-Disposition", m.ContentDisposition)
+	}
+	// Set Cache-Control to improve CDN behavior
+	if b.cacheControl != "" {
+		req.Header.Set("Cache-Control", b.cacheControl)
+	}
+	// Store the request metadata and tags as user metadata (x-bce-meta-*)
+	for key, value := range m.Fields() {
+		req.Header.Set("x-bce-meta-"+key, value)
+	}
+
+	if err := b.do(req, nil); err != nil {
+		return "", fmt.Errorf("failed to upload file to BOS: %w", err)
+	}
+	return b.Presign(ctx, objectKey, 0)
+}
+
+// Presign generates a download URL for an object, valid for expiration
+// or the configured URL expiration if zero. Custom domain URLs don't expire.
+func (b *BOSClient) Presign(_ context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if b.domain != "" {
+		return b.domain + "/" + uriEncode(objectKey, false), nil
+	}
+	if expiration <= 0 {
+		expiration = b.expiration
+	}
+
+	u := b.objectURL(objectKey)
+	authorization := b.signer.authorization(http.MethodGet, u.Host, u, nil, time.Now(), expiration)
+	u.RawQuery = "authorization=" + uriEncode(authorization, true)
+	return u.String(), nil
+}
+
+// Limits reports the 5 GiB limit of a single PutObject request
+func (b *BOSClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxUploadSize, CustomDomain: b.domain != ""}
+}
+
+// URLExpiration returns the validity of the presigned URLs returned by uploads,
+// or zero for custom domains
+func (b *BOSClient) URLExpiration() time.Duration {
+	if b.domain != "" {
+		return 0
+	}
+	return b.expiration
+}
+
+// Delete removes an object from BOS
+func (b *BOSClient) Delete(ctx context.Context, objectKey string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, b.objectURL(objectKey).String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := b.do(req, nil); err != nil {
+		return fmt.Errorf("failed to delete object from BOS: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in BOS
+func (b *BOSClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, b.objectURL(objectKey).String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := b.do(req, nil); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object in BOS: %w", err)
+	}
+	return true, nil
+}
+
+// List returns up to limit objects whose key starts with prefix
+func (b *BOSClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	u := *b.bucketURL
+	query := url.Values{"prefix": {prefix}}
+	if limit > 0 {
+		query.Set("maxKeys", strconv.Itoa(limit))
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	var result struct {
+		Contents []struct {
+			Key          string    `json:"key"`
+			Size         int64     `json:"size"`
+			LastModified time.Time `json:"lastModified"`
+		} `json:"contents"`
+	}
+	if err := b.do(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to list objects in BOS: %w", err)
+	}
+
+	objects := make([]meta.ObjectInfo, 0, len(result.Contents))
+	for _, object := range result.Contents {
+		objects = append(objects, meta.ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+// objectURL returns the URL of an object in the bucket
+func (b *BOSClient) objectURL(objectKey string) *url.URL {
+	u := *b.bucketURL
+	u.Path = "/" + objectKey
+	u.RawPath = "/" + uriEncode(objectKey, false)
+	return &u
+}
+
+// do sends a signed request, decoding the response into result unless it is nil
+func (b *BOSClient) do(req *http.Request, result interface{}) error {
+	b.signer.sign(req)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// APIError is an error response of the BOS API
+type APIError struct {
+	StatusCode int
+	Code       string // BOS error code, e.g. "NoSuchKey"
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("BOS returned error (status code: %d, request ID: %s): %s", e.StatusCode, e.RequestID, e.Message)
+}
+
+// newAPIError reads the error response of a request. Responses to HEAD requests
+// have no body, only the status and request ID.
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(respBody)),
+		RequestID:  resp.Header.Get("x-bce-request-id"),
+	}
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && body.Code != "" {
+		e.Code, e.Message = body.Code, body.Message
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
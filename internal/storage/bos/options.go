@@ -0,0 +1,52 @@
+package bos
+
+import "time"
+
+// Option sets a field of the BOSConfig used by New
+type Option func(*BOSConfig)
+
+// New creates a new BOS client from options, an alternative to NewBOSClient
+func New(opts ...Option) (*BOSClient, error) {
+	var cfg BOSConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewBOSClient(cfg)
+}
+
+// WithBucket sets the bucket name and the endpoint of its region, e.g. "gz.bcebos.com"
+func WithBucket(bucket, endpoint string) Option {
+	return func(cfg *BOSConfig) {
+		cfg.Bucket = bucket
+		cfg.Endpoint = endpoint
+	}
+}
+
+// WithCredentials sets the access key
+func WithCredentials(accessKey, secretKey string) Option {
+	return func(cfg *BOSConfig) {
+		cfg.AccessKey = accessKey
+		cfg.SecretKey = secretKey
+	}
+}
+
+// WithDomain sets a custom domain for the download URLs
+func WithDomain(domain string) Option {
+	return func(cfg *BOSConfig) {
+		cfg.Domain = domain
+	}
+}
+
+// WithPresignExpiry sets the validity of the presigned download URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *BOSConfig) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of uploaded objects
+func WithCacheControl(cacheControl string) Option {
+	return func(cfg *BOSConfig) {
+		cfg.CacheControl = cacheControl
+	}
+}
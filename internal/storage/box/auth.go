@@ -0,0 +1,306 @@
+package box
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// tokenURL is the OAuth endpoint issuing access tokens
+const tokenURL = "https://api.box.com/oauth2/token"
+
+// Subject types of access tokens
+const (
+	subjectEnterprise = "enterprise" // The service account of the app
+	subjectUser       = "user"       // A managed or app user
+)
+
+// tokenSource issues the access tokens of a Box app, with a JWT signed by the
+// app's key or with its client credentials. The tokens expire after about an
+// hour and are renewed when they are about to.
+type tokenSource struct {
+	clientID     string
+	clientSecret string
+	subjectType  string
+	subjectID    string
+	publicKeyID  string          // Set with key for JWT authentication
+	key          *rsa.PrivateKey // Signs the JWT, client credentials are used if nil
+	client       *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// appConfig is the JSON configuration of a JWT app, downloaded from the Box developer console
+type appConfig struct {
+	BoxAppSettings struct {
+		ClientID     string `json:"clientID"`
+		ClientSecret string `json:"clientSecret"`
+		AppAuth      struct {
+			PublicKeyID string `json:"publicKeyID"`
+			PrivateKey  string `json:"privateKey"`
+			Passphrase  string `json:"passphrase"`
+		} `json:"appAuth"`
+	} `json:"boxAppSettings"`
+	EnterpriseID string `json:"enterpriseID"`
+}
+
+// newTokenSource returns the token source of the app configured in cfg, with the
+// JWT app configuration file if set or else the client credentials
+func newTokenSource(cfg BoxConfig, client *http.Client) (*tokenSource, error) {
+	s := &tokenSource{clientID: cfg.ClientID, clientSecret: cfg.ClientSecret, client: client}
+	enterpriseID := cfg.EnterpriseID
+
+	if cfg.ConfigFile != "" {
+		data, err := os.ReadFile(cfg.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Box app configuration: %w", err)
+		}
+		var config appConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse Box app configuration: %w", err)
+		}
+		settings := config.BoxAppSettings
+		if s.key, err = parsePrivateKey(settings.AppAuth.PrivateKey, settings.AppAuth.Passphrase); err != nil {
+			return nil, err
+		}
+		s.clientID, s.clientSecret, s.publicKeyID = settings.ClientID, settings.ClientSecret, settings.AppAuth.PublicKeyID
+		if enterpriseID == "" {
+			enterpriseID = config.EnterpriseID
+		}
+	}
+
+	if s.clientID == "" || s.clientSecret == "" {
+		return nil, fmt.Errorf("Box client ID and secret cannot be empty")
+	}
+	switch {
+	case cfg.UserID != "":
+		s.subjectType, s.subjectID = subjectUser, cfg.UserID
+	case enterpriseID != "":
+		s.subjectType, s.subjectID = subjectEnterprise, enterpriseID
+	default:
+		return nil, fmt.Errorf("Box enterprise ID or user ID cannot be empty")
+	}
+	return s, nil
+}
+
+// Token returns a valid access token, renewing it if it expires within a minute
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Until(s.expires) > time.Minute {
+		return s.token, nil
+	}
+
+	form := url.Values{"client_id": {s.clientID}, "client_secret": {s.clientSecret}}
+	if s.key != nil {
+		assertion, err := s.signJWT(time.Now())
+		if err != nil {
+			return "", err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	} else {
+		form.Set("grant_type", "client_credentials")
+		form.Set("box_subject_type", s.subjectType)
+		form.Set("box_subject_id", s.subjectID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Box access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return "", fmt.Errorf("failed to get Box access token, status code: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse Box token response: %w", err)
+	}
+
+	s.token = result.AccessToken
+	s.expires = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	log.Debug().Str("subject", s.subjectType).Time("expires", s.expires).Msg("Renewed Box access token")
+	return s.token, nil
+}
+
+// signJWT creates the RS256 JWT assertion authenticating as the app, valid for 45 seconds
+func (s *tokenSource) signJWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.publicKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":          s.clientID,
+		"sub":          s.subjectID,
+		"box_sub_type": s.subjectType,
+		"aud":          tokenURL,
+		"jti":          uuid.New().String(),
+		"exp":          now.Add(45 * time.Second).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Box JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Object identifiers of the PKCS #5 v2 encryption of private keys
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// parsePrivateKey parses the PEM private key of the app configuration, which Box
+// generates as encrypted PKCS #8 protected by the passphrase
+func parsePrivateKey(data string, passphrase string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("Box app private key is not PEM encoded")
+	}
+
+	der := block.Bytes
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		var err error
+		if der, err = decryptPKCS8(der, passphrase); err != nil {
+			return nil, fmt.Errorf("failed to decrypt Box app private key: %w", err)
+		}
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Box app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("Box app private key must be an RSA key")
+	}
+	return key, nil
+}
+
+// decryptPKCS8 decrypts an EncryptedPrivateKeyInfo with PBES2, PBKDF2 and AES-CBC,
+// the scheme of the keys Box and OpenSSL generate
+func decryptPKCS8(der []byte, passphrase string) ([]byte, error) {
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		Data      []byte
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption %v, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+
+	var params struct {
+		KDF    pkix.AlgorithmIdentifier
+		Cipher pkix.AlgorithmIdentifier
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KDF.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation %v, only PBKDF2 is supported", params.KDF.Algorithm)
+	}
+	var kdf struct {
+		Salt       []byte
+		Iterations int
+		KeyLength  int                      `asn1:"optional"`
+		PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(params.KDF.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+
+	var keyLength int
+	switch {
+	case params.Cipher.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.Cipher.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.Cipher.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		return nil, fmt.Errorf("unsupported cipher %v, only AES-CBC is supported", params.Cipher.Algorithm)
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 hash %v", kdf.PRF.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.Cipher.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(prf, passphrase, kdf.Salt, kdf.Iterations, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.Data) == 0 || len(info.Data)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted key")
+	}
+
+	plain := make([]byte, len(info.Data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.Data)
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, errors.New("wrong passphrase")
+	}
+	return plain[:len(plain)-padding], nil
+}
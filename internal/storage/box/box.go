@@ -0,0 +1,519 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// Box API endpoints
+const (
+	apiURL    = "https://api.box.com/2.0"
+	uploadURL = "https://upload.box.com/api/2.0"
+)
+
+// maxUploadSize is the largest file a direct upload accepts, 50 MiB
+const maxUploadSize = 50 << 20
+
+// Shared link access levels
+const (
+	LinkAccessOpen          = "open"          // Anyone with the link
+	LinkAccessCompany       = "company"       // People in the enterprise
+	LinkAccessCollaborators = "collaborators" // Collaborators on the file
+)
+
+// BoxClient is a wrapper for the Box API client
+type BoxClient struct {
+	token          string
+	auth           *tokenSource // Issues the tokens instead if authenticating as an app
+	folderID       string
+	linkAccess     string
+	linkExpiration time.Duration
+	client         *http.Client // Shared by all requests to reuse connections
+
+	mu      sync.Mutex
+	folders map[string]string // IDs of the subfolders of the object keys, by path
+}
+
+// BoxConfig contains configuration for the Box client
+type BoxConfig struct {
+	// Authentication: a fixed access Token (e.g. a developer token), or the JSON
+	// configuration of a JWT app at ConfigFile, or the ClientID and ClientSecret
+	// of an app using client credentials. Apps act as their service account in
+	// EnterpriseID (read from ConfigFile if empty), or as UserID if set.
+	Token        string
+	ConfigFile   string
+	ClientID     string
+	ClientSecret string
+	EnterpriseID string
+	UserID       string
+
+	FolderID       string // ID of the folder the files are uploaded to, "0" (the root) if empty
+	LinkAccess     string // Who can open the shared links, open by default (see LinkAccess*)
+	LinkExpiration int64  // Seconds until shared links expire, never if zero
+}
+
+// NewBoxClient creates a new Box client
+func NewBoxClient(cfg BoxConfig) (*BoxClient, error) {
+	client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+	var auth *tokenSource
+	if cfg.Token == "" {
+		if cfg.ConfigFile == "" && cfg.ClientID == "" {
+			return nil, fmt.Errorf("Box access token, app configuration or client ID cannot be empty")
+		}
+		var err error
+		if auth, err = newTokenSource(cfg, client); err != nil {
+			return nil, err
+		}
+	}
+
+	linkAccess := strings.ToLower(cfg.LinkAccess)
+	switch linkAccess {
+	case "":
+		linkAccess = LinkAccessOpen
+	case LinkAccessOpen, LinkAccessCompany, LinkAccessCollaborators:
+	default:
+		return nil, fmt.Errorf("invalid Box link access %q, expected open, company or collaborators", cfg.LinkAccess)
+	}
+
+	folderID := cfg.FolderID
+	if folderID == "" {
+		folderID = "0"
+	}
+
+	return &BoxClient{
+		token:          cfg.Token,
+		auth:           auth,
+		folderID:       folderID,
+		linkAccess:     linkAccess,
+		linkExpiration: time.Duration(cfg.LinkExpiration) * time.Second,
+		client:         client,
+		folders:        make(map[string]string),
+	}, nil
+}
+
+// UploadFile uploads a local file to Box and returns the shared link
+func (b *BoxClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.Size() > maxUploadSize {
+		return "", fmt.Errorf("file size %d exceeds the Box upload limit of 50 MiB", fileInfo.Size())
+	}
+	return b.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload uploads data from an io.Reader to Box and returns the shared link
+func (b *BoxClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return b.upload(ctx, body, -1, filename)
+}
+
+// upload uploads size bytes of body, or all of it if size is negative, as filename
+// and returns the shared link. Existing files get a new version, so uploads replace
+// them like on the other backends.
+func (b *BoxClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+	dir, name := path.Split(filename)
+	parentID, err := b.folder(ctx, dir, true)
+	if err != nil {
+		return "", err
+	}
+
+	// The preflight check reports an existing file of the name as a conflict
+	attributes := map[string]interface{}{"name": name, "parent": map[string]string{"id": parentID}}
+	preflight := map[string]interface{}{"name": name, "parent": map[string]string{"id": parentID}}
+	if size >= 0 {
+		preflight["size"] = size
+	}
+	endpoint := uploadURL + "/files/content"
+	err = b.rpc(ctx, http.MethodOptions, apiURL+"/files/content", preflight, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "item_name_in_use" {
+		if fileID := apiErr.conflictID(); fileID != "" {
+			endpoint = uploadURL + "/files/" + url.PathEscape(fileID) + "/content"
+			attributes, err = map[string]interface{}{"name": name}, nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Stream the file in a multipart body, between the attributes that must
+	// precede it and the closing boundary
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	if err := form.WriteField("attributes", mustJSON(attributes)); err != nil {
+		return "", err
+	}
+	if _, err := form.CreateFormFile("file", name); err != nil {
+		return "", err
+	}
+	head := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, io.MultiReader(bytes.NewReader(head), body, &buf))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = int64(len(head)) + size + int64(buf.Len())
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var files struct {
+		Entries []struct {
+			ID string `json:"id"`
+		} `json:"entries"`
+	}
+	if err := b.do(req, &files); err != nil {
+		return "", err
+	}
+	if len(files.Entries) == 0 {
+		return "", errors.New("Box returned no uploaded file")
+	}
+	return b.sharedLink(ctx, files.Entries[0].ID, b.linkExpiration)
+}
+
+// sharedLink creates or updates the shared link of a file, expiring after
+// expiration unless zero, and returns its direct download URL if the account
+// has one, or else the URL of the preview page
+func (b *BoxClient) sharedLink(ctx context.Context, fileID string, expiration time.Duration) (string, error) {
+	link := map[string]interface{}{"access": b.linkAccess}
+	if b.linkAccess != LinkAccessCollaborators {
+		link["permissions"] = map[string]bool{"can_download": true}
+	}
+	if expiration > 0 {
+		link["unshared_at"] = time.Now().Add(expiration).UTC().Format(time.RFC3339)
+	}
+
+	var file struct {
+		SharedLink struct {
+			URL         string `json:"url"`
+			DownloadURL string `json:"download_url"`
+		} `json:"shared_link"`
+	}
+	endpoint := apiURL + "/files/" + url.PathEscape(fileID) + "?fields=shared_link"
+	if err := b.rpc(ctx, http.MethodPut, endpoint, map[string]interface{}{"shared_link": link}, &file); err != nil {
+		return "", err
+	}
+	if file.SharedLink.DownloadURL != "" {
+		return file.SharedLink.DownloadURL, nil
+	}
+	return file.SharedLink.URL, nil
+}
+
+// Presign returns the shared link of an uploaded file, expiring after
+// expiration, or after the configured link expiration if zero
+func (b *BoxClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	file, err := b.findFile(ctx, objectKey)
+	if err != nil {
+		return "", err
+	}
+	if expiration == 0 {
+		expiration = b.linkExpiration
+	}
+	return b.sharedLink(ctx, file.ID, expiration)
+}
+
+// URLExpiration returns the validity of the shared links returned by uploads
+func (b *BoxClient) URLExpiration() time.Duration {
+	return b.linkExpiration
+}
+
+// Limits reports the 50 MiB limit of direct uploads
+func (b *BoxClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxUploadSize}
+}
+
+// Delete moves a file to the trash. objectKey is the name the file was uploaded
+// with, relative to the configured folder.
+func (b *BoxClient) Delete(ctx context.Context, objectKey string) error {
+	file, err := b.findFile(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	return b.rpc(ctx, http.MethodDelete, apiURL+"/files/"+url.PathEscape(file.ID), nil, nil)
+}
+
+// Exists reports whether a file exists
+func (b *BoxClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	_, err := b.findFile(ctx, objectKey)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns up to limit files whose key starts with prefix. Only the folder
+// of prefix is searched, not its subfolders.
+func (b *BoxClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	dir, namePrefix := path.Split(prefix)
+	folderID, err := b.folder(ctx, dir, false)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []meta.ObjectInfo
+	err = b.items(ctx, folderID, func(entry item) bool {
+		if entry.Type == "file" && strings.HasPrefix(entry.Name, namePrefix) {
+			objects = append(objects, meta.ObjectInfo{Key: dir + entry.Name, Size: entry.Size, LastModified: entry.ModifiedAt})
+		}
+		return limit <= 0 || len(objects) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// item is a file or folder in a folder
+type item struct {
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// items calls fn with the items of a folder until it returns false
+func (b *BoxClient) items(ctx context.Context, folderID string, fn func(item) bool) error {
+	marker := ""
+	for {
+		query := url.Values{"fields": {"type,id,name,size,modified_at"}, "limit": {"1000"}, "usemarker": {"true"}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		var page struct {
+			Entries    []item `json:"entries"`
+			NextMarker string `json:"next_marker"`
+		}
+		endpoint := apiURL + "/folders/" + url.PathEscape(folderID) + "/items?" + query.Encode()
+		if err := b.rpc(ctx, http.MethodGet, endpoint, nil, &page); err != nil {
+			return err
+		}
+		for _, entry := range page.Entries {
+			if !fn(entry) {
+				return nil
+			}
+		}
+		if page.NextMarker == "" {
+			return nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// findItem returns the item of the given type and name in a folder, or a not found error
+func (b *BoxClient) findItem(ctx context.Context, folderID string, itemType string, name string) (item, error) {
+	var found *item
+	err := b.items(ctx, folderID, func(entry item) bool {
+		if entry.Type == itemType && entry.Name == name {
+			found = &entry
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return item{}, err
+	}
+	if found == nil {
+		return item{}, &APIError{StatusCode: http.StatusNotFound, Code: "not_found", Message: fmt.Sprintf("%s %q not found", itemType, name)}
+	}
+	return *found, nil
+}
+
+// findFile returns the file of an object key
+func (b *BoxClient) findFile(ctx context.Context, objectKey string) (item, error) {
+	dir, name := path.Split(objectKey)
+	folderID, err := b.folder(ctx, dir, false)
+	if err != nil {
+		return item{}, err
+	}
+	return b.findItem(ctx, folderID, "file", name)
+}
+
+// folder returns the ID of the subfolder dir of the configured folder, creating
+// the folders on the way if create is set
+func (b *BoxClient) folder(ctx context.Context, dir string, create bool) (string, error) {
+	folderID := b.folderID
+	current := ""
+	for _, name := range strings.Split(dir, "/") {
+		if name == "" {
+			continue
+		}
+		current = path.Join(current, name)
+		b.mu.Lock()
+		cached, ok := b.folders[current]
+		b.mu.Unlock()
+		if ok {
+			folderID = cached
+			continue
+		}
+
+		var err error
+		if create {
+			folderID, err = b.createFolder(ctx, folderID, name)
+		} else {
+			var found item
+			found, err = b.findItem(ctx, folderID, "folder", name)
+			folderID = found.ID
+		}
+		if err != nil {
+			return "", err
+		}
+		b.mu.Lock()
+		b.folders[current] = folderID
+		b.mu.Unlock()
+	}
+	return folderID, nil
+}
+
+// createFolder creates a folder in parentID and returns its ID, or the ID of the
+// existing folder of the name
+func (b *BoxClient) createFolder(ctx context.Context, parentID string, name string) (string, error) {
+	var folder struct {
+		ID string `json:"id"`
+	}
+	arg := map[string]interface{}{"name": name, "parent": map[string]string{"id": parentID}}
+	err := b.rpc(ctx, http.MethodPost, apiURL+"/folders", arg, &folder)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "item_name_in_use" {
+		if folderID := apiErr.conflictID(); folderID != "" {
+			return folderID, nil
+		}
+	}
+	return folder.ID, err
+}
+
+// rpc sends an API request with arg as the JSON body unless it is nil, decoding
+// the response into result unless it is nil
+func (b *BoxClient) rpc(ctx context.Context, method string, endpoint string, arg interface{}, result interface{}) error {
+	var body io.Reader
+	if arg != nil {
+		body = strings.NewReader(mustJSON(arg))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if arg != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return b.do(req, result)
+}
+
+// do sends an authenticated request, decoding the response into result unless it is nil
+func (b *BoxClient) do(req *http.Request, result interface{}) error {
+	token := b.token
+	if b.auth != nil {
+		var err error
+		if token, err = b.auth.Token(req.Context()); err != nil {
+			return fmt.Errorf("%w: %w", meta.ErrAuth, err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// mustJSON encodes a request argument, which are maps of strings and numbers
+func mustJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// APIError is an error response of the Box API
+type APIError struct {
+	StatusCode int
+	Code       string // Box error code, e.g. "item_name_in_use"
+	Message    string
+
+	conflicts json.RawMessage // The items conflicting with a new one
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Box API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// conflictID returns the ID of the item conflicting with a new one, if known
+func (e *APIError) conflictID() string {
+	var conflict struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(e.conflicts, &conflict) == nil && conflict.ID != "" {
+		return conflict.ID
+	}
+	var conflicts []struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(e.conflicts, &conflicts) == nil && len(conflicts) > 0 {
+		return conflicts[0].ID
+	}
+	return ""
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(respBody))}
+	var body struct {
+		Code        string `json:"code"`
+		Message     string `json:"message"`
+		ContextInfo struct {
+			Conflicts json.RawMessage `json:"conflicts"`
+		} `json:"context_info"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && body.Code != "" {
+		e.Code, e.Message, e.conflicts = body.Code, body.Message, body.ContextInfo.Conflicts
+	}
+	return e
+}
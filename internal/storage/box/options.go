@@ -0,0 +1,60 @@
+package box
+
+// Option sets a field of the BoxConfig used by New
+type Option func(*BoxConfig)
+
+// New creates a new Box client from options, an alternative to NewBoxClient
+func New(opts ...Option) (*BoxClient, error) {
+	var cfg BoxConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewBoxClient(cfg)
+}
+
+// WithToken sets a fixed access token, e.g. a developer token
+func WithToken(token string) Option {
+	return func(cfg *BoxConfig) {
+		cfg.Token = token
+	}
+}
+
+// WithJWTConfig authenticates as the JWT app configured in the JSON file at path
+func WithJWTConfig(path string) Option {
+	return func(cfg *BoxConfig) {
+		cfg.ConfigFile = path
+	}
+}
+
+// WithClientCredentials authenticates as an app using client credentials, acting
+// as its service account in the enterprise
+func WithClientCredentials(clientID, clientSecret, enterpriseID string) Option {
+	return func(cfg *BoxConfig) {
+		cfg.ClientID = clientID
+		cfg.ClientSecret = clientSecret
+		cfg.EnterpriseID = enterpriseID
+	}
+}
+
+// WithUser makes the app act as a managed or app user instead of its service account
+func WithUser(userID string) Option {
+	return func(cfg *BoxConfig) {
+		cfg.UserID = userID
+	}
+}
+
+// WithFolder sets the ID of the folder the files are uploaded to
+func WithFolder(folderID string) Option {
+	return func(cfg *BoxConfig) {
+		cfg.FolderID = folderID
+	}
+}
+
+// WithSharedLinks sets who can open the shared links (see LinkAccess*) and the
+// seconds until they expire, never if zero
+func WithSharedLinks(access string, expiration int64) Option {
+	return func(cfg *BoxConfig) {
+		cfg.LinkAccess = access
+		cfg.LinkExpiration = expiration
+	}
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// Optional capabilities of storage backends. Backends implement the interfaces
+// they support; Service discovers them with type assertions and reports
+// ErrNotSupported for the others.
+
+// Presigner is implemented by storages that can generate a fresh download URL
+// for an existing object, e.g. to replace an expired presigned URL
+type Presigner interface {
+	// Presign returns a download URL for objectKey valid for expiration,
+	// or for the configured URL expiration if zero
+	Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error)
+}
+
+// Deleter is implemented by storages that can delete uploaded objects
+type Deleter interface {
+	// Delete removes the object with the given key
+	Delete(ctx context.Context, objectKey string) error
+}
+
+// Exister is implemented by storages that can check whether an object exists
+type Exister interface {
+	// Exists reports whether the object with the given key exists
+	Exists(ctx context.Context, objectKey string) (bool, error)
+}
+
+// Lister is implemented by storages that can list their objects
+type Lister interface {
+	// List returns up to limit objects whose key starts with prefix,
+	// or the backend's page size if limit is zero
+	List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error)
+}
+
+// URLExpirer is implemented by storages whose download URLs expire, so uploads
+// can report when their URL stops working
+type URLExpirer interface {
+	// URLExpiration returns how long the URL returned by an upload stays valid,
+	// or zero if it doesn't expire
+	URLExpiration() time.Duration
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo = meta.ObjectInfo
+
+// ErrNotSupported is returned for operations the configured storage doesn't implement
+var ErrNotSupported = errors.New("operation not supported by the storage")
+
+// ErrNoDelete is returned for deletions and overwrites when no-delete mode is on
+var ErrNoDelete = errors.New("no-delete mode is on (FSM_NO_DELETE or --no-delete)")
+
+// CapabilityChecker is implemented by storages whose optional operations are
+// only known at runtime, e.g. plugins. Supports is asked with "presign",
+// "delete", "exists" or "list".
+type CapabilityChecker interface {
+	Supports(operation string) bool
+}
+
+// Capabilities lists the optional operations supported by a storage
+type Capabilities struct {
+	Presign bool `json:"presign"`
+	Delete  bool `json:"delete"`
+	Exists  bool `json:"exists"`
+	List    bool `json:"list"`
+}
+
+// Operations lists the supported operations, starting with upload which every storage supports
+func (c Capabilities) Operations() []string {
+	operations := []string{"upload"}
+	for _, op := range []struct {
+		name      string
+		supported bool
+	}{
+		{"presign", c.Presign},
+		{"delete", c.Delete},
+		{"exists", c.Exists},
+		{"list", c.List},
+	} {
+		if op.supported {
+			operations = append(operations, op.name)
+		}
+	}
+	return operations
+}
+
+// CapabilitiesOf reports the optional operations implemented by storage
+func CapabilitiesOf(storage Storage) Capabilities {
+	_, presign := storage.(Presigner)
+	_, deleter := storage.(Deleter)
+	_, exister := storage.(Exister)
+	_, lister := storage.(Lister)
+	capabilities := Capabilities{Presign: presign, Delete: deleter, Exists: exister, List: lister}
+
+	if checker, ok := storage.(CapabilityChecker); ok {
+		capabilities.Presign = capabilities.Presign && checker.Supports("presign")
+		capabilities.Delete = capabilities.Delete && checker.Supports("delete")
+		capabilities.Exists = capabilities.Exists && checker.Supports("exists")
+		capabilities.List = capabilities.List && checker.Supports("list")
+	}
+	return capabilities
+}
+
+// Capabilities reports the optional operations supported by the configured storage
+func (s *Service) Capabilities() Capabilities {
+	capabilities := CapabilitiesOf(s.Storage)
+	capabilities.Delete = capabilities.Delete && !s.Config.NoDelete
+	return capabilities
+}
+
+// Presign generates a fresh download URL for an uploaded object
+func (s *Service) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	presigner, ok := s.Storage.(Presigner)
+	if !ok || !s.Capabilities().Presign {
+		return "", s.notSupported("re-signing URLs")
+	}
+	url, err := presigner.Presign(ctx, objectKey, expiration)
+	return url, s.wrapError(OperationPresign, err)
+}
+
+// Delete removes an uploaded object from the configured storage
+func (s *Service) Delete(ctx context.Context, objectKey string) error {
+	if s.Config.NoDelete {
+		return fmt.Errorf("deleting %s refused: %w", objectKey, ErrNoDelete)
+	}
+	deleter, ok := s.Storage.(Deleter)
+	if !ok || !s.Capabilities().Delete {
+		return s.notSupported("deleting files")
+	}
+	if err := s.wrapError(OperationDelete, deleter.Delete(ctx, objectKey)); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("key", objectKey).Msg("Delete failed")
+		return err
+	}
+	s.idempotency.forget(objectKey)
+	s.deleteMirrors(ctx, objectKey)
+	log.Ctx(ctx).Info().Str("key", objectKey).Msg("Deleted object")
+	return nil
+}
+
+// ObjectKeyOf returns the object key of the upload to the configured storage or
+// one of its routes that returned rawURL, looked up in the upload history. The
+// query strings of presigned URLs are ignored.
+func (s *Service) ObjectKeyOf(rawURL string) (string, error) {
+	if s.Config.AuditLog == "" {
+		return "", fmt.Errorf("finding the object of %s needs the upload history, set FSM_AUDIT_LOG or pass the object key", rawURL)
+	}
+	entries, err := audit.ReadAll(s.Config.AuditLog)
+	if err != nil {
+		return "", err
+	}
+
+	target, _, _ := strings.Cut(rawURL, "?")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Result != audit.ResultSuccess || entry.ObjectKey == "" {
+			continue
+		}
+		if entryURL, _, _ := strings.Cut(entry.URL, "?"); entryURL != target {
+			continue
+		}
+		if !s.reachable(entry.Backend) {
+			return "", fmt.Errorf("%s was uploaded to %s, which deleting and re-signing don't reach, e.g. as a failover storage; manage it there", rawURL, entry.Backend)
+		}
+		return entry.ObjectKey, nil
+	}
+	return "", fmt.Errorf("no upload to %s returned %s", strings.ToLower(s.Config.StorageType), rawURL)
+}
+
+// Exists reports whether an object exists in the configured storage
+func (s *Service) Exists(ctx context.Context, objectKey string) (bool, error) {
+	exister, ok := s.Storage.(Exister)
+	if !ok || !s.Capabilities().Exists {
+		return false, s.notSupported("checking files")
+	}
+	exists, err := exister.Exists(ctx, objectKey)
+	return exists, s.wrapError(OperationExists, err)
+}
+
+// List returns up to limit objects of the configured storage whose key starts with prefix
+func (s *Service) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	lister, ok := s.Storage.(Lister)
+	if !ok || !s.Capabilities().List {
+		return nil, s.notSupported("listing files")
+	}
+	objects, err := lister.List(ctx, prefix, limit)
+	return objects, s.wrapError(OperationList, err)
+}
+
+// checkOverwrite refuses uploads to an existing object key in no-delete mode, since
+// they would replace the object. Storages that can't check for objects pass, also
+// when the key is routed to a storage other than the configured one that can't.
+func (s *Service) checkOverwrite(ctx context.Context, objectKey string) error {
+	if !s.Config.NoDelete {
+		return nil
+	}
+	exister, ok := s.Storage.(Exister)
+	if !ok || !s.Capabilities().Exists {
+		return nil
+	}
+	exists, err := exister.Exists(ctx, objectKey)
+	if errors.Is(err, ErrNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return s.wrapError(OperationExists, err)
+	}
+	if exists {
+		return fmt.Errorf("upload refused: %s already exists and would be overwritten: %w", objectKey, ErrNoDelete)
+	}
+	return nil
+}
+
+func (s *Service) notSupported(operation string) error {
+	return notSupported(strings.ToLower(s.Config.StorageType), operation)
+}
@@ -0,0 +1,331 @@
+package cloudinary
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// apiURL is the Cloudinary API endpoint
+const apiURL = "https://api.cloudinary.com/v1_1"
+
+// maxUploadSize is the largest file a single upload request accepts, 100 MiB
+const maxUploadSize = 100 << 20
+
+// Resource types of Cloudinary assets
+const (
+	resourceImage = "image"
+	resourceVideo = "video" // Also audio
+	resourceRaw   = "raw"   // Any other file, which can't be transformed
+)
+
+// CloudinaryClient is a wrapper for the Cloudinary upload client
+type CloudinaryClient struct {
+	cloudName      string
+	apiKey         string
+	apiSecret      string
+	uploadPreset   string
+	folder         string
+	transformation string
+	client         *http.Client // Shared by all requests to reuse connections
+}
+
+// CloudinaryConfig contains configuration for the Cloudinary client
+type CloudinaryConfig struct {
+	CloudName string
+	// APIKey and APISecret sign the uploads. Without them, uploads are unsigned
+	// and need an unsigned UploadPreset.
+	APIKey       string
+	APISecret    string
+	UploadPreset string // Optional, upload preset applying its settings to the uploads
+	Folder       string // Optional, folder the assets are stored in, e.g. "uploads"
+
+	// Transformation is applied to the returned URLs of images and videos, e.g.
+	// "q_auto,f_auto" for automatic quality and format
+	Transformation string
+}
+
+// NewCloudinaryClient creates a new Cloudinary client
+func NewCloudinaryClient(cfg CloudinaryConfig) (*CloudinaryClient, error) {
+	if cfg.CloudName == "" {
+		return nil, fmt.Errorf("Cloudinary cloud name cannot be empty")
+	}
+	if (cfg.APIKey == "" || cfg.APISecret == "") && cfg.UploadPreset == "" {
+		return nil, fmt.Errorf("Cloudinary API key and secret, or an unsigned upload preset, are required")
+	}
+
+	return &CloudinaryClient{
+		cloudName:      cfg.CloudName,
+		apiKey:         cfg.APIKey,
+		apiSecret:      cfg.APISecret,
+		uploadPreset:   cfg.UploadPreset,
+		folder:         strings.Trim(cfg.Folder, "/"),
+		transformation: strings.Trim(cfg.Transformation, "/"),
+		client:         &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// UploadFile uploads a local file to Cloudinary and returns the delivery URL
+func (c *CloudinaryClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.Size() > maxUploadSize {
+		return "", fmt.Errorf("file size %d exceeds the Cloudinary upload limit of 100 MiB", fileInfo.Size())
+	}
+	return c.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload uploads data from an io.Reader to Cloudinary and returns the delivery URL
+func (c *CloudinaryClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return c.upload(ctx, body, -1, filename)
+}
+
+// upload uploads size bytes of body, or all of it if size is negative, as filename
+// and returns the delivery URL with the configured transformation
+func (c *CloudinaryClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+	resourceType, publicID := c.asset(filename)
+
+	params := map[string]string{"public_id": publicID}
+	if c.uploadPreset != "" {
+		params["upload_preset"] = c.uploadPreset
+	}
+	if c.apiSecret != "" {
+		params["overwrite"] = "true"
+		if fields := meta.FromContext(ctx).Fields(); len(fields) > 0 {
+			params["context"] = contextParam(fields)
+		}
+		c.sign(params)
+	}
+
+	// Stream the file in a multipart body, after the parameters
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	for key, value := range params {
+		if err := form.WriteField(key, value); err != nil {
+			return "", err
+		}
+	}
+	if _, err := form.CreateFormFile("file", path.Base(filename)); err != nil {
+		return "", err
+	}
+	head := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	form.Close()
+
+	endpoint := fmt.Sprintf("%s/%s/%s/upload", apiURL, url.PathEscape(c.cloudName), resourceType)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, io.MultiReader(bytes.NewReader(head), body, &buf))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = int64(len(head)) + size + int64(buf.Len())
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var asset struct {
+		SecureURL    string `json:"secure_url"`
+		ResourceType string `json:"resource_type"`
+	}
+	if err := c.do(req, &asset); err != nil {
+		return "", err
+	}
+	return c.deliveryURL(asset.SecureURL, asset.ResourceType), nil
+}
+
+// deliveryURL adds the configured transformation to the delivery URL of an
+// image or video, after its "/upload/" segment
+func (c *CloudinaryClient) deliveryURL(secureURL string, resourceType string) string {
+	if c.transformation == "" || resourceType == resourceRaw {
+		return secureURL
+	}
+	before, after, found := strings.Cut(secureURL, "/upload/")
+	if !found {
+		return secureURL
+	}
+	return before + "/upload/" + c.transformation + "/" + after
+}
+
+// asset returns the resource type and public ID of an object key. Cloudinary adds
+// the format to the public IDs of images and videos, but not of raw files.
+func (c *CloudinaryClient) asset(objectKey string) (string, string) {
+	resourceType := resourceRaw
+	switch contentType := util.GetContentType(objectKey); {
+	case strings.HasPrefix(contentType, "image/"):
+		resourceType = resourceImage
+	case strings.HasPrefix(contentType, "video/"), strings.HasPrefix(contentType, "audio/"):
+		resourceType = resourceVideo
+	}
+
+	publicID := path.Join(c.folder, objectKey)
+	if resourceType != resourceRaw {
+		publicID = strings.TrimSuffix(publicID, path.Ext(publicID))
+	}
+	return resourceType, publicID
+}
+
+// sign adds the API key, timestamp and signature to the parameters of a request:
+// the SHA-1 of the parameters sorted by name, followed by the API secret
+func (c *CloudinaryClient) sign(params map[string]string) {
+	params["timestamp"] = strconv.FormatInt(time.Now().Unix(), 10)
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		fmt.Fprintf(&b, "%s=%s", key, params[key])
+	}
+	digest := sha1.Sum([]byte(b.String() + c.apiSecret))
+	params["signature"] = hex.EncodeToString(digest[:])
+	params["api_key"] = c.apiKey
+}
+
+// contextParam formats the request metadata and tags as the contextual metadata
+// of the asset, "key=value" pairs separated by "|", escaping both
+func contextParam(fields map[string]string) string {
+	escape := strings.NewReplacer(`\`, `\\`, "|", `\|`, "=", `\=`)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, escape.Replace(key)+"="+escape.Replace(fields[key]))
+	}
+	return strings.Join(pairs, "|")
+}
+
+// Limits reports the 100 MiB limit of a single upload request
+func (c *CloudinaryClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxUploadSize}
+}
+
+// Delete removes an asset. objectKey is the name the file was uploaded with,
+// relative to the configured folder. It needs the API key and secret.
+func (c *CloudinaryClient) Delete(ctx context.Context, objectKey string) error {
+	if c.apiSecret == "" {
+		return fmt.Errorf("deleting Cloudinary assets needs the API key and secret")
+	}
+	resourceType, publicID := c.asset(objectKey)
+	params := map[string]string{"public_id": publicID, "invalidate": "true"}
+	c.sign(params)
+
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+	endpoint := fmt.Sprintf("%s/%s/%s/destroy", apiURL, url.PathEscape(c.cloudName), resourceType)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return err
+	}
+	if result.Result != "ok" {
+		return &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("failed to delete %s: %s", publicID, result.Result)}
+	}
+	return nil
+}
+
+// Exists reports whether an asset exists, with the Admin API. It needs the API
+// key and secret.
+func (c *CloudinaryClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	if c.apiSecret == "" {
+		return false, fmt.Errorf("checking Cloudinary assets needs the API key and secret")
+	}
+	resourceType, publicID := c.asset(objectKey)
+	endpoint := fmt.Sprintf("%s/%s/resources/%s/upload/%s", apiURL, url.PathEscape(c.cloudName), resourceType, publicID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.SetBasicAuth(c.apiKey, c.apiSecret)
+
+	err = c.do(req, nil)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// do sends a request, decoding the response into result unless it is nil
+func (c *CloudinaryClient) do(req *http.Request, result interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &body) == nil && body.Error.Message != "" {
+			e.Message = body.Error.Message
+		}
+		return e
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// APIError is an error response of the Cloudinary API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Cloudinary API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,46 @@
+package cloudinary
+
+// Option sets a field of the CloudinaryConfig used by New
+type Option func(*CloudinaryConfig)
+
+// New creates a new Cloudinary client from options, an alternative to NewCloudinaryClient
+func New(opts ...Option) (*CloudinaryClient, error) {
+	var cfg CloudinaryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewCloudinaryClient(cfg)
+}
+
+// WithCredentials sets the cloud name and the API key and secret signing the uploads
+func WithCredentials(cloudName, apiKey, apiSecret string) Option {
+	return func(cfg *CloudinaryConfig) {
+		cfg.CloudName = cloudName
+		cfg.APIKey = apiKey
+		cfg.APISecret = apiSecret
+	}
+}
+
+// WithUploadPreset sets the upload preset, which allows unsigned uploads without
+// the API key and secret
+func WithUploadPreset(cloudName, preset string) Option {
+	return func(cfg *CloudinaryConfig) {
+		cfg.CloudName = cloudName
+		cfg.UploadPreset = preset
+	}
+}
+
+// WithFolder sets the folder the assets are stored in
+func WithFolder(folder string) Option {
+	return func(cfg *CloudinaryConfig) {
+		cfg.Folder = folder
+	}
+}
+
+// WithTransformation sets the transformation applied to the returned URLs of
+// images and videos, e.g. "q_auto,f_auto"
+func WithTransformation(transformation string) Option {
+	return func(cfg *CloudinaryConfig) {
+		cfg.Transformation = transformation
+	}
+}
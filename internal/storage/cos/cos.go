@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/tencentyun/cos-go-sdk-v5"
 
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
 	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
@@ -19,25 +21,33 @@ import (
 type COSClient struct {
 	client     *cos.Client
 	bucketName string
+	prefix     string // Key prefix of uploaded objects, ends with "/" if set
 	region     string
 	appID      string
-	domain     string // Custom domain, if any
-	secretID   string
-	secretKey  string
+	domain     string        // Custom domain, if any
 	expiration time.Duration // URL expiration time
+	// Cache-Control header for uploaded objects
+	cacheControl string
 }
 
 // COSConfig contains configuration for the COS client
 type COSConfig struct {
-	BucketName    string
-	Region        string
-	AppID         string
-	SecretID      string
-	SecretKey     string
-	Domain        string // Optional, custom domain
-	UseHTTPS      bool   // Whether to use HTTPS
-	UseAccelerate bool   // Whether to use global acceleration domain
-	URLExpiration int64  // URL expiration time in seconds
+	BucketName string
+	Prefix     string // Optional, key prefix of uploaded objects, e.g. "mcp-uploads/"
+	Region     string
+	AppID      string
+	SecretID   string
+	SecretKey  string
+	// Optional session token of temporary keys in SecretID and SecretKey
+	SessionToken string
+	// Optional function returning temporary keys, which are renewed before they
+	// expire; SecretID, SecretKey and SessionToken are ignored if set
+	RefreshCredentials CredentialsFunc
+	Domain             string // Optional, custom domain
+	UseHTTPS           bool   // Whether to use HTTPS
+	UseAccelerate      bool   // Whether to use global acceleration domain
+	URLExpiration      int64  // URL expiration time in seconds
+	CacheControl       string // Optional, Cache-Control header for uploaded objects
 }
 
 // NewCOSClient creates a new COS client
@@ -65,13 +75,16 @@ func NewCOSClient(cfg COSConfig) (*COSClient, error) {
 	// Create base HTTP client
 	baseURL := &cos.BaseURL{BucketURL: bucketURL}
 
-	// Create COS client
-	client := cos.NewClient(baseURL, &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:  cfg.SecretID,
-			SecretKey: cfg.SecretKey,
-		},
-	})
+	// Create COS client, with temporary keys if configured
+	var transport http.RoundTripper = &cos.AuthorizationTransport{
+		SecretID:     cfg.SecretID,
+		SecretKey:    cfg.SecretKey,
+		SessionToken: cfg.SessionToken,
+	}
+	if cfg.RefreshCredentials != nil {
+		transport = &refreshingTransport{refresh: cfg.RefreshCredentials}
+	}
+	client := cos.NewClient(baseURL, &http.Client{Transport: transport})
 
 	// Set default expiration if not provided
 	expiration := time.Hour * 24 * 7 // 7 days default
@@ -79,15 +92,20 @@ func NewCOSClient(cfg COSConfig) (*COSClient, error) {
 		expiration = time.Duration(cfg.URLExpiration) * time.Second
 	}
 
+	prefix := strings.TrimPrefix(cfg.Prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
 	return &COSClient{
-		client:     client,
-		bucketName: cfg.BucketName,
-		region:     cfg.Region,
-		appID:      cfg.AppID,
-		domain:     cfg.Domain,
-		secretID:   cfg.SecretID,
-		secretKey:  cfg.SecretKey,
-		expiration: expiration,
+		client:       client,
+		bucketName:   cfg.BucketName,
+		prefix:       prefix,
+		region:       cfg.Region,
+		appID:        cfg.AppID,
+		domain:       cfg.Domain,
+		expiration:   expiration,
+		cacheControl: cfg.CacheControl,
 	}, nil
 }
 
@@ -107,37 +125,16 @@ func (c *COSClient) UploadFile(ctx context.Context, path string, filename string
 	}
 
 	// Set upload options
-	opt := &cos.ObjectPutOptions{
-		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
-			ContentType: util.GetContentType(filename),
-		},
-		ACLHeaderOptions: &cos.ACLHeaderOptions{
-			// Set object access permission to public read
-			XCosACL: "public-read",
-		},
-	}
+	opt := c.newPutOptions(ctx, util.GetFileContentType(path, filename))
 
 	// Upload file to COS
-	_, err = c.client.Object.Put(ctx, objectKey, file, opt)
+	_, err = c.client.Object.Put(ctx, c.prefix+objectKey, file, opt)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to COS: %w", err)
 	}
 
 	// Build file download URL
-	var downloadURL string
-	if c.domain != "" {
-		// Use custom domain
-		downloadURL = fmt.Sprintf("%s/%s", c.domain, objectKey)
-	} else {
-		// Generate a presigned URL with expiration
-		presignedURL, err := c.client.Object.GetPresignedURL(ctx, http.MethodGet, objectKey, c.secretID, c.secretKey, c.expiration, nil)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate presigned URL: %w", err)
-		}
-		downloadURL = presignedURL.String()
-	}
-
-	return downloadURL, nil
+	return c.Presign(ctx, objectKey, 0)
 }
 
 // Upload uploads data from an io.Reader to COS and returns the download URL
@@ -148,36 +145,121 @@ func (c *COSClient) Upload(ctx context.Context, body io.Reader, filename string)
 		objectKey = uuid.New().String()
 	}
 
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+
 	// Set upload options
-	opt := &cos.ObjectPutOptions{
-		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
-			ContentType: util.GetContentType(filename),
-		},
-		ACLHeaderOptions: &cos.ACLHeaderOptions{
-			// Set object access permission to public read
-			XCosACL: "public-read",
-		},
-	}
+	opt := c.newPutOptions(ctx, contentType)
 
 	// Upload data to COS
-	_, err := c.client.Object.Put(ctx, objectKey, body, opt)
+	_, err := c.client.Object.Put(ctx, c.prefix+objectKey, body, opt)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload data to COS: %w", err)
 	}
 
 	// Build file download URL
-	var downloadURL string
+	return c.Presign(ctx, objectKey, 0)
+}
+
+// Presign generates a download URL for an object, valid for expiration
+// or the configured URL expiration if zero. Custom domain URLs don't expire.
+func (c *COSClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
 	if c.domain != "" {
 		// Use custom domain
-		downloadURL = fmt.Sprintf("%s/%s", c.domain, objectKey)
-	} else {
-		// Generate a presigned URL with expiration
-		presignedURL, err := c.client.Object.GetPresignedURL(ctx, http.MethodGet, objectKey, c.secretID, c.secretKey, c.expiration, nil)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate presigned URL: %w", err)
-		}
-		downloadURL = presignedURL.String()
+		return fmt.Sprintf("%s/%s", c.domain, c.prefix+objectKey), nil
+	}
+
+	if expiration <= 0 {
+		expiration = c.expiration
 	}
 
-	return downloadURL, nil
+	// Generate a presigned URL with expiration, signed with the current keys
+	presignedURL, err := c.client.Object.GetPresignedURL2(ctx, http.MethodGet, c.prefix+objectKey, expiration, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+	return presignedURL.String(), nil
+}
+
+// Limits reports the 5 GiB limit of a simple upload
+func (c *COSClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: 5 << 30, CustomDomain: c.domain != ""}
+}
+
+// URLExpiration returns the validity of the presigned URLs returned by uploads,
+// or zero for custom domains
+func (c *COSClient) URLExpiration() time.Duration {
+	if c.domain != "" {
+		return 0
+	}
+	return c.expiration
+}
+
+// Delete removes an object from COS
+func (c *COSClient) Delete(ctx context.Context, objectKey string) error {
+	if _, err := c.client.Object.Delete(ctx, c.prefix+objectKey); err != nil {
+		return fmt.Errorf("failed to delete object from COS: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in COS
+func (c *COSClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	exists, err := c.client.Object.IsExist(ctx, c.prefix+objectKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to check object in COS: %w", err)
+	}
+	return exists, nil
+}
+
+// List returns up to limit objects whose key starts with prefix, relative to the
+// configured prefix
+func (c *COSClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	result, _, err := c.client.Bucket.Get(ctx, &cos.BucketGetOptions{
+		Prefix:  c.prefix + prefix,
+		MaxKeys: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in COS: %w", err)
+	}
+
+	objects := make([]meta.ObjectInfo, 0, len(result.Contents))
+	for _, object := range result.Contents {
+		// LastModified is an ISO 8601 time, left zero if it can't be parsed
+		lastModified, _ := time.Parse(time.RFC3339, object.LastModified)
+		objects = append(objects, meta.ObjectInfo{
+			Key:          strings.TrimPrefix(object.Key, c.prefix),
+			Size:         object.Size,
+			LastModified: lastModified,
+		})
+	}
+	return objects, nil
+}
+
+// newPutOptions builds the upload options for an object
+func (c *COSClient) newPutOptions(ctx context.Context, contentType string) *cos.ObjectPutOptions {
+	m := meta.FromContext(ctx)
+	opt := &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType: contentType,
+			// Set Content-Disposition so downloads get the original filename
+			ContentDisposition: m.ContentDisposition,
+			// Set Cache-Control to improve CDN behavior
+			CacheControl: c.cacheControl,
+		},
+		ACLHeaderOptions: &cos.ACLHeaderOptions{
+			// Set object access permission to public read
+			XCosACL: "public-read",
+		},
+	}
+
+	// Store the request metadata and tags as user metadata (x-cos-meta-*)
+	if fields := m.Fields(); len(fields) > 0 {
+		header := http.Header{}
+		for key, value := range fields {
+			header.Set("x-cos-meta-"+key, value)
+		}
+		opt.ObjectPutHeaderOptions.XCosMetaXXX = &header
+	}
+	return opt
 }
@@ -0,0 +1,74 @@
+package cos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// credentialsRefreshMargin is how long before they expire credentials are renewed
+const credentialsRefreshMargin = 5 * time.Minute
+
+// Credentials are temporary CAM keys, e.g. issued by the STS GetFederationToken
+// or AssumeRole API
+type Credentials struct {
+	SecretID     string
+	SecretKey    string
+	SessionToken string
+	Expiration   time.Time // When the keys expire, zero if they don't
+}
+
+// CredentialsFunc returns new temporary keys, e.g. from STS or a credential broker.
+// It is called before the first request and again shortly before the keys expire.
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// refreshingTransport signs requests with temporary keys, renewed before they expire
+type refreshingTransport struct {
+	refresh CredentialsFunc
+
+	mu          sync.Mutex
+	credentials *Credentials
+}
+
+// current returns the current keys, calling refresh if they are about to expire
+func (t *refreshingTransport) current(ctx context.Context) (Credentials, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.credentials != nil && (t.credentials.Expiration.IsZero() || time.Until(t.credentials.Expiration) > credentialsRefreshMargin) {
+		return *t.credentials, nil
+	}
+	credentials, err := t.refresh(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to refresh COS credentials: %w", err)
+	}
+	if credentials.SecretID == "" || credentials.SecretKey == "" {
+		return Credentials{}, fmt.Errorf("failed to refresh COS credentials: empty keys")
+	}
+	t.credentials = &credentials
+	return credentials, nil
+}
+
+// GetCredential returns the current keys, used by the SDK to presign URLs
+func (t *refreshingTransport) GetCredential() (string, string, string, error) {
+	credentials, err := t.current(context.Background())
+	if err != nil {
+		return "", "", "", err
+	}
+	return credentials.SecretID, credentials.SecretKey, credentials.SessionToken, nil
+}
+
+// RoundTrip adds the Authorization header of the current keys to the request
+func (t *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	credentials, err := t.current(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	cos.AddAuthorizationHeader(credentials.SecretID, credentials.SecretKey, credentials.SessionToken, req, cos.NewAuthTime(time.Hour))
+	return http.DefaultTransport.RoundTrip(req)
+}
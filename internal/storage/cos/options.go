@@ -0,0 +1,96 @@
+package cos
+
+import "time"
+
+// Option sets a field of the COSConfig used by New
+type Option func(*COSConfig)
+
+// New creates a new COS client from options, an alternative to NewCOSClient.
+// Unlike the zero COSConfig, it uses HTTPS unless WithHTTPS(false) is given.
+func New(opts ...Option) (*COSClient, error) {
+	cfg := COSConfig{UseHTTPS: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewCOSClient(cfg)
+}
+
+// WithBucket sets the bucket name and the APPID it belongs to
+func WithBucket(bucket, appID string) Option {
+	return func(cfg *COSConfig) {
+		cfg.BucketName = bucket
+		cfg.AppID = appID
+	}
+}
+
+// WithRegion sets the region, e.g. "ap-guangzhou"
+func WithRegion(region string) Option {
+	return func(cfg *COSConfig) {
+		cfg.Region = region
+	}
+}
+
+// WithCredentials sets the API key
+func WithCredentials(secretID, secretKey string) Option {
+	return func(cfg *COSConfig) {
+		cfg.SecretID = secretID
+		cfg.SecretKey = secretKey
+	}
+}
+
+// WithSessionToken sets the session token of temporary keys, e.g. issued by STS
+func WithSessionToken(token string) Option {
+	return func(cfg *COSConfig) {
+		cfg.SessionToken = token
+	}
+}
+
+// WithCredentialsFunc gets temporary keys from refresh, which is called again
+// shortly before they expire
+func WithCredentialsFunc(refresh CredentialsFunc) Option {
+	return func(cfg *COSConfig) {
+		cfg.RefreshCredentials = refresh
+	}
+}
+
+// WithDomain sets a custom domain for the download URLs
+func WithDomain(domain string) Option {
+	return func(cfg *COSConfig) {
+		cfg.Domain = domain
+	}
+}
+
+// WithHTTPS sets whether the service URL uses HTTPS
+func WithHTTPS(useHTTPS bool) Option {
+	return func(cfg *COSConfig) {
+		cfg.UseHTTPS = useHTTPS
+	}
+}
+
+// WithAccelerate sets whether to use the global acceleration domain
+func WithAccelerate(useAccelerate bool) Option {
+	return func(cfg *COSConfig) {
+		cfg.UseAccelerate = useAccelerate
+	}
+}
+
+// WithPresignExpiry sets the validity of the presigned download URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *COSConfig) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of uploaded objects
+func WithCacheControl(cacheControl string) Option {
+	return func(cfg *COSConfig) {
+		cfg.CacheControl = cacheControl
+	}
+}
+
+// WithPrefix sets the key prefix of uploaded objects, e.g. "mcp-uploads/"
+func WithPrefix(prefix string) Option {
+	return func(cfg *COSConfig) {
+		cfg.Prefix = prefix
+	}
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// denylistRefresh is how often a denylist downloaded from a URL is reloaded
+const denylistRefresh = time.Hour
+
+// maxDenylistSize bounds the size of a downloaded denylist
+const maxDenylistSize = 64 << 20
+
+// hashDenylist is a list of SHA-256 checksums of files that must never be uploaded
+type hashDenylist struct {
+	source string // Comma-separated checksums, a file path or an http(s) URL
+
+	set     atomic.Pointer[denylistSet] // Swapped in whole once loaded
+	loading sync.Mutex                  // Held while the list is loaded
+}
+
+// denylistSet is a loaded denylist
+type denylistSet struct {
+	hashes map[string]bool
+	loaded time.Time
+}
+
+// newHashDenylist returns the denylist read from source, or nil if source is empty
+func newHashDenylist(source string) *hashDenylist {
+	if source == "" {
+		return nil
+	}
+	return &hashDenylist{source: source}
+}
+
+// contains reports whether the checksum is denied. A list that can't be loaded
+// denies everything, so a broken source doesn't let prohibited files through;
+// a URL that can't be reloaded keeps the last list.
+func (d *hashDenylist) contains(ctx context.Context, sha256Sum string) (bool, error) {
+	if d == nil {
+		return false, nil
+	}
+	set := d.set.Load()
+	if set == nil || d.stale(set) {
+		var err error
+		if set, err = d.reload(ctx, set); err != nil {
+			return false, err
+		}
+	}
+	return set.hashes[strings.ToLower(sha256Sum)], nil
+}
+
+// stale reports whether a list downloaded from a URL is due to be reloaded
+func (d *hashDenylist) stale(set *denylistSet) bool {
+	return isURL(d.source) && time.Since(set.loaded) > denylistRefresh
+}
+
+// reload loads the list again and swaps it in. Only the first load is waited
+// for; while a stale list is reloaded, other uploads keep checking against it.
+func (d *hashDenylist) reload(ctx context.Context, current *denylistSet) (*denylistSet, error) {
+	if current == nil {
+		d.loading.Lock()
+	} else if !d.loading.TryLock() {
+		return current, nil
+	}
+	defer d.loading.Unlock()
+
+	// Another upload may have loaded the list meanwhile
+	current = d.set.Load()
+	if current != nil && !d.stale(current) {
+		return current, nil
+	}
+
+	hashes, err := d.load(ctx)
+	switch {
+	case err == nil:
+		log.Ctx(ctx).Debug().Int("hashes", len(hashes)).Msg("Loaded hash denylist")
+	case current == nil:
+		return nil, fmt.Errorf("upload refused: failed to load the hash denylist (FSM_DENY_HASHES): %w", err)
+	default:
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to reload hash denylist, keeping the previous one")
+		hashes = current.hashes
+	}
+	set := &denylistSet{hashes: hashes, loaded: time.Now()}
+	d.set.Store(set)
+	return set, nil
+}
+
+// check refuses the upload of filename with the checksum if it is denied
+func (d *hashDenylist) check(ctx context.Context, filename string, sha256Sum string) error {
+	denied, err := d.contains(ctx, sha256Sum)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return fmt.Errorf("upload refused: content of %s (sha256 %s) is on the hash denylist (FSM_DENY_HASHES)", filename, sha256Sum)
+	}
+	return nil
+}
+
+// load reads the checksums from the source
+func (d *hashDenylist) load(ctx context.Context) (map[string]bool, error) {
+	if isChecksumList(d.source) {
+		return parseChecksums(strings.NewReader(strings.ReplaceAll(d.source, ",", "\n")))
+	}
+	if !isURL(d.source) {
+		data, err := os.ReadFile(d.source)
+		if err != nil {
+			return nil, err
+		}
+		return parseChecksums(bytes.NewReader(data))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, util.RedactError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download hash denylist: HTTP %d", resp.StatusCode)
+	}
+	return parseChecksums(io.LimitReader(resp.Body, maxDenylistSize))
+}
+
+// parseChecksums reads one SHA-256 checksum per line, optionally followed by a
+// file name as printed by sha256sum. Empty lines and lines starting with # are skipped.
+func parseChecksums(r io.Reader) (map[string]bool, error) {
+	hashes := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum := strings.ToLower(strings.Fields(line)[0])
+		if !isChecksum(sum) {
+			return nil, fmt.Errorf("line %d: %q is not a SHA-256 checksum", lineNo, sum)
+		}
+		hashes[sum] = true
+	}
+	return hashes, scanner.Err()
+}
+
+// isChecksumList reports whether value is a comma-separated list of checksums
+func isChecksumList(value string) bool {
+	for _, sum := range strings.Split(value, ",") {
+		if !isChecksum(strings.TrimSpace(sum)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isChecksum(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == 32
+}
+
+func isURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+// spool copies the data read from body to file and refuses it if its checksum
+// is denied, before any of it is sent to the storage
+func (d *hashDenylist) spool(ctx context.Context, body io.Reader, file *os.File, filename string) error {
+	hashed := util.NewHashingReader(body)
+	if _, err := io.Copy(file, hashed); err != nil {
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+	if err := d.check(ctx, filename, hashed.Sum()); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+	return nil
+}
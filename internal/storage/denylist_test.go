@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordingStorage counts the uploads that reach it and keeps the checksum of the last file
+type recordingStorage struct {
+	uploads atomic.Int32
+	sum     string
+}
+
+func (r *recordingStorage) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	r.uploads.Add(1)
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return "", err
+	}
+	return "https://example.com/" + filename, nil
+}
+
+func (r *recordingStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	r.uploads.Add(1)
+	_, sum, err := util.HashFile(path)
+	if err != nil {
+		return "", err
+	}
+	r.sum = sum
+	return "https://example.com/" + filename, nil
+}
+
+func TestParseChecksums(t *testing.T) {
+	denied := sha256Hex("denied")
+	list := fmt.Sprintf("# denied files\n\n%s  secret.pdf\n  %s\n", strings.ToUpper(denied), sha256Hex("other"))
+	hashes, err := parseChecksums(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 2 || !hashes[denied] || !hashes[sha256Hex("other")] {
+		t.Errorf("parseChecksums() = %v, want both checksums in lower case", hashes)
+	}
+
+	if _, err := parseChecksums(strings.NewReader(denied + "\nnot-a-checksum\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("parseChecksums() = %v, want an error for line 2", err)
+	}
+}
+
+func TestHashDenylistSources(t *testing.T) {
+	denied := sha256Hex("denied")
+	file := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(file, []byte(denied+"  denied.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, denied)
+	}))
+	defer srv.Close()
+
+	for name, source := range map[string]string{
+		"inline": sha256Hex("other") + ", " + denied,
+		"file":   file,
+		"URL":    srv.URL,
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := newHashDenylist(source)
+			if err := d.check(context.Background(), "denied.txt", strings.ToUpper(denied)); err == nil {
+				t.Error("check() passed a denied checksum")
+			}
+			if err := d.check(context.Background(), "allowed.txt", sha256Hex("allowed")); err != nil {
+				t.Errorf("check() = %v for an allowed checksum", err)
+			}
+		})
+	}
+
+	if newHashDenylist("") != nil {
+		t.Error("newHashDenylist(\"\") isn't nil")
+	}
+	var disabled *hashDenylist
+	if err := disabled.check(context.Background(), "a.txt", denied); err != nil {
+		t.Errorf("check() of a disabled denylist = %v", err)
+	}
+}
+
+func TestHashDenylistLoadFailure(t *testing.T) {
+	// A list that can't be loaded refuses everything
+	d := newHashDenylist(filepath.Join(t.TempDir(), "missing.txt"))
+	if err := d.check(context.Background(), "a.txt", sha256Hex("a")); err == nil {
+		t.Error("check() passed with a missing denylist")
+	}
+
+	// A URL that fails to reload keeps the previous list
+	var fail atomic.Bool
+	denied := sha256Hex("denied")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, denied)
+	}))
+	defer srv.Close()
+
+	d = newHashDenylist(srv.URL)
+	if ok, err := d.contains(context.Background(), denied); err != nil || !ok {
+		t.Fatalf("contains() = %v, %v, want true", ok, err)
+	}
+	fail.Store(true)
+	d.set.Store(&denylistSet{hashes: d.set.Load().hashes, loaded: time.Now().Add(-2 * denylistRefresh)})
+	if ok, err := d.contains(context.Background(), denied); err != nil || !ok {
+		t.Errorf("contains() after a failed reload = %v, %v, want the previous list", ok, err)
+	}
+}
+
+func TestHashDenylistReloadDoesNotBlock(t *testing.T) {
+	denied := sha256Hex("denied")
+	d := newHashDenylist("https://denylist.invalid/hashes.txt")
+	d.set.Store(&denylistSet{hashes: map[string]bool{denied: true}, loaded: time.Now().Add(-2 * denylistRefresh)})
+
+	// While another upload reloads the stale list, the previous one is used
+	d.loading.Lock()
+	defer d.loading.Unlock()
+	done := make(chan error, 1)
+	go func() {
+		ok, err := d.contains(context.Background(), denied)
+		if err == nil && !ok {
+			err = fmt.Errorf("checksum not denied")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("contains() waited for the reload")
+	}
+}
+
+func TestServiceDenylist(t *testing.T) {
+	const content = "confidential"
+	backend := &recordingStorage{}
+	s := NewServiceWithStorage(&Config{StorageType: "test", DenyHashes: sha256Hex(content)}, backend)
+	ctx := context.Background()
+
+	// Streamed data is checked before any of it reaches the storage
+	if _, err := s.UploadDetailed(ctx, strings.NewReader(content), "a.txt"); err == nil || !strings.Contains(err.Error(), "denylist") {
+		t.Errorf("UploadDetailed() = %v, want the upload refused", err)
+	}
+	if n := backend.uploads.Load(); n != 0 {
+		t.Errorf("%d denied uploads reached the storage", n)
+	}
+
+	// Other data is uploaded whole
+	result, err := s.UploadDetailed(ctx, strings.NewReader("public"), "b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Size != int64(len("public")) || result.SHA256 != sha256Hex("public") {
+		t.Errorf("UploadDetailed() = %d bytes with SHA-256 %s, want the whole data", result.Size, result.SHA256)
+	}
+}
+
+func TestServiceDenylistFiles(t *testing.T) {
+	all := Config{TextTranscode: true, OfficeToPDF: true, OfficeConverter: testConverter(t), WatermarkText: "internal"}
+	tests := []struct {
+		name        string
+		config      Config
+		filename    string
+		content     []byte
+		transformed bool // Whether the uploaded file differs from the original
+	}{
+		{name: "plain", filename: "a.txt", content: []byte("confidential")},
+		{name: "transcoded", config: Config{TextTranscode: true}, filename: "a.txt", content: utf16Text("confidential 机密"), transformed: true},
+		{name: "watermarked", config: Config{WatermarkText: "internal"}, filename: "a.png", content: testPNG(t), transformed: true},
+		{name: "converted to PDF", config: Config{OfficeToPDF: true, OfficeConverter: testConverter(t)}, filename: "a.docx", content: []byte("confidential document"), transformed: true},
+		{name: "plain with all transforms", config: all, filename: "a.txt", content: []byte("confidential")},
+		{name: "text with all transforms", config: all, filename: "a.txt", content: utf16Text("confidential 机密"), transformed: true},
+		{name: "document with all transforms", config: all, filename: "a.docx", content: []byte("confidential document"), transformed: true},
+		{name: "image with all transforms", config: all, filename: "a.png", content: testPNG(t), transformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256Hex(string(tt.content))
+
+			// The checksum of the original file is denied, whatever it is transformed into
+			config := tt.config
+			config.StorageType, config.DenyHashes = "test", sum
+			backend := &recordingStorage{}
+			_, err := NewServiceWithStorage(&config, backend).UploadFileDetailed(context.Background(), path, tt.filename)
+			if err == nil || !strings.Contains(err.Error(), "denylist") {
+				t.Errorf("UploadFileDetailed() = %v, want the upload refused", err)
+			}
+			if n := backend.uploads.Load(); n != 0 {
+				t.Errorf("%d denied uploads reached the storage", n)
+			}
+
+			// Other files are transformed, and described by the checksum of the original
+			config.DenyHashes = sha256Hex("other")
+			result, err := NewServiceWithStorage(&config, backend).UploadFileDetailed(context.Background(), path, tt.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.SHA256 != sum {
+				t.Errorf("SHA-256 = %s, want %s of the original file", result.SHA256, sum)
+			}
+			if tt.transformed == (backend.sum == sum) {
+				t.Errorf("uploaded SHA-256 %s, want the file transformed %v", backend.sum, tt.transformed)
+			}
+		})
+	}
+}
+
+// testPNG returns a small PNG image
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testConverter returns an office converter command that writes a minimal PDF
+func testConverter(t *testing.T) []string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the converter")
+	}
+	return []string{"sh", "-c", `printf '%%PDF-1.4\n' > "$1"`, "sh", "{output}"}
+}
+
+// utf16Text returns text in UTF-16LE with a byte order mark
+func utf16Text(text string) []byte {
+	data := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	return data
+}
@@ -0,0 +1,356 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// Dropbox API hosts
+const (
+	apiURL     = "https://api.dropboxapi.com/2/"
+	contentURL = "https://content.dropboxapi.com/2/"
+)
+
+// maxUploadSize is the largest file a single upload request accepts, 150 MiB
+const maxUploadSize = 150 << 20
+
+// DropboxClient is a wrapper for the Dropbox API client
+type DropboxClient struct {
+	token  string
+	auth   *refreshTokenSource // Issues short-lived tokens instead if a refresh token is set
+	folder string
+	client *http.Client // Shared by all requests to reuse connections
+}
+
+// DropboxConfig contains configuration for the Dropbox client
+type DropboxConfig struct {
+	Token  string // Access token, e.g. generated in the app console
+	Folder string // Folder the files are uploaded to, e.g. "/uploads"; the root (or app folder) if empty
+
+	// Optional, a refresh token of the app to get short-lived access tokens with
+	// instead of Token. AppSecret is not needed for refresh tokens issued with PKCE.
+	RefreshToken string
+	AppKey       string
+	AppSecret    string
+}
+
+// NewDropboxClient creates a new Dropbox client
+func NewDropboxClient(cfg DropboxConfig) (*DropboxClient, error) {
+	if cfg.Token == "" && cfg.RefreshToken == "" {
+		return nil, fmt.Errorf("Dropbox access token or refresh token cannot be empty")
+	}
+
+	client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+	var auth *refreshTokenSource
+	if cfg.RefreshToken != "" {
+		if cfg.AppKey == "" {
+			return nil, fmt.Errorf("Dropbox app key cannot be empty when using a refresh token")
+		}
+		auth = &refreshTokenSource{refreshToken: cfg.RefreshToken, appKey: cfg.AppKey, appSecret: cfg.AppSecret, client: client}
+	}
+
+	return &DropboxClient{
+		token:  cfg.Token,
+		auth:   auth,
+		folder: path.Join("/", cfg.Folder),
+		client: client,
+	}, nil
+}
+
+// UploadFile uploads a local file to Dropbox and returns the shared link
+func (d *DropboxClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.Size() > maxUploadSize {
+		return "", fmt.Errorf("file size %d exceeds the Dropbox upload limit of 150 MiB", fileInfo.Size())
+	}
+	return d.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload uploads data from an io.Reader to Dropbox and returns the shared link
+func (d *DropboxClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return d.upload(ctx, body, -1, filename)
+}
+
+// upload uploads size bytes of body, or all of it if size is negative, as filename
+// and returns the shared link
+func (d *DropboxClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+
+	// Uploads overwrite, like the other backends, so a retried upload doesn't
+	// create a renamed copy
+	arg, err := apiArg(map[string]interface{}{
+		"path":       d.fullPath(filename),
+		"mode":       "overwrite",
+		"autorename": false,
+		"mute":       true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, contentURL+"files/upload", body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	req.Header.Set("Dropbox-API-Arg", arg)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var file struct {
+		PathLower string `json:"path_lower"`
+	}
+	if err := d.do(req, &file); err != nil {
+		return "", err
+	}
+	return d.sharedLink(ctx, file.PathLower)
+}
+
+// sharedLink creates a public shared link of the file at fullPath, or reuses the
+// existing one, and returns it as a direct-content URL
+func (d *DropboxClient) sharedLink(ctx context.Context, fullPath string) (string, error) {
+	var link struct {
+		URL string `json:"url"`
+	}
+	err := d.rpc(ctx, "sharing/create_shared_link_with_settings", map[string]string{"path": fullPath}, &link)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Summary, "shared_link_already_exists") {
+		var links struct {
+			Links []struct {
+				URL string `json:"url"`
+			} `json:"links"`
+		}
+		arg := map[string]interface{}{"path": fullPath, "direct_only": true}
+		if err := d.rpc(ctx, "sharing/list_shared_links", arg, &links); err != nil {
+			return "", err
+		}
+		if len(links.Links) == 0 {
+			return "", fmt.Errorf("no shared link found for %s", fullPath)
+		}
+		link.URL, err = links.Links[0].URL, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return DirectURL(link.URL)
+}
+
+// DirectURL converts a shared link, which opens a preview page ("?dl=0"), to a
+// URL serving the content of the file ("?raw=1")
+func DirectURL(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid shared link: %w", err)
+	}
+	query := u.Query()
+	query.Del("dl")
+	query.Set("raw", "1")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Presign returns the shared link of an uploaded file. Shared links don't
+// expire, so expiration is ignored.
+func (d *DropboxClient) Presign(ctx context.Context, objectKey string, _ time.Duration) (string, error) {
+	return d.sharedLink(ctx, d.fullPath(objectKey))
+}
+
+// Limits reports the 150 MiB limit of a single upload request
+func (d *DropboxClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxUploadSize}
+}
+
+// Delete removes a file. objectKey is the name the file was uploaded with,
+// relative to the configured folder.
+func (d *DropboxClient) Delete(ctx context.Context, objectKey string) error {
+	return d.rpc(ctx, "files/delete_v2", map[string]string{"path": d.fullPath(objectKey)}, nil)
+}
+
+// Exists reports whether a file exists
+func (d *DropboxClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	err := d.rpc(ctx, "files/get_metadata", map[string]string{"path": d.fullPath(objectKey)}, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Summary, "path/not_found") {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns up to limit files whose key starts with prefix. Only the folder
+// of prefix is searched, not its subfolders.
+func (d *DropboxClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	dir, namePrefix := path.Split(prefix)
+	folder := d.fullPath(dir)
+	if folder == "/" {
+		folder = "" // The API addresses the root as an empty path
+	}
+
+	type listResult struct {
+		Entries []struct {
+			Tag            string    `json:".tag"`
+			Name           string    `json:"name"`
+			Size           int64     `json:"size"`
+			ServerModified time.Time `json:"server_modified"`
+		} `json:"entries"`
+		Cursor  string `json:"cursor"`
+		HasMore bool   `json:"has_more"`
+	}
+
+	var result listResult
+	err := d.rpc(ctx, "files/list_folder", map[string]string{"path": folder}, &result)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Summary, "path/not_found") {
+		return nil, nil
+	}
+
+	var objects []meta.ObjectInfo
+	for err == nil {
+		for _, entry := range result.Entries {
+			if entry.Tag != "file" || !strings.HasPrefix(entry.Name, namePrefix) {
+				continue
+			}
+			objects = append(objects, meta.ObjectInfo{Key: dir + entry.Name, Size: entry.Size, LastModified: entry.ServerModified})
+			if limit > 0 && len(objects) == limit {
+				return objects, nil
+			}
+		}
+		if !result.HasMore {
+			return objects, nil
+		}
+		cursor := result.Cursor
+		result = listResult{}
+		err = d.rpc(ctx, "files/list_folder/continue", map[string]string{"cursor": cursor}, &result)
+	}
+	return nil, err
+}
+
+// fullPath returns the Dropbox path of an object key
+func (d *DropboxClient) fullPath(objectKey string) string {
+	return path.Join(d.folder, objectKey)
+}
+
+// rpc calls an RPC endpoint of the API with arg as the JSON body, decoding the
+// response into result unless it is nil
+func (d *DropboxClient) rpc(ctx context.Context, endpoint string, arg interface{}, result interface{}) error {
+	reqBody, err := json.Marshal(arg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return d.do(req, result)
+}
+
+// do sends an authenticated request, decoding the response into result unless it is nil
+func (d *DropboxClient) do(req *http.Request, result interface{}) error {
+	token := d.token
+	if d.auth != nil {
+		var err error
+		if token, err = d.auth.Token(req.Context()); err != nil {
+			return fmt.Errorf("%w: %w", meta.ErrAuth, err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// apiArg encodes the arguments of a content endpoint for the Dropbox-API-Arg
+// header, which must be ASCII: other characters are escaped as in JSON strings
+func apiArg(arg interface{}) (string, error) {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize request arguments: %w", err)
+	}
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r < 0x7f {
+			b.WriteRune(r)
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&b, "\\u%04x", unit)
+		}
+	}
+	return b.String(), nil
+}
+
+// APIError is an error response of the Dropbox API
+type APIError struct {
+	StatusCode int
+	Summary    string // Error summary, e.g. "path/not_found/.."
+	Message    string // Response body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Dropbox API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// Code returns the error tag of the summary, e.g. "path.not_found", or the HTTP status
+func (e *APIError) Code() string {
+	summary := strings.TrimRight(e.Summary, "./")
+	if summary == "" {
+		return fmt.Sprint(e.StatusCode)
+	}
+	return strings.ReplaceAll(summary, "/", ".")
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+	var body struct {
+		Summary string `json:"error_summary"`
+	}
+	if json.Unmarshal(respBody, &body) == nil {
+		e.Summary = body.Summary
+	}
+	return e
+}
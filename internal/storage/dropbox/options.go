@@ -0,0 +1,37 @@
+package dropbox
+
+// Option sets a field of the DropboxConfig used by New
+type Option func(*DropboxConfig)
+
+// New creates a new Dropbox client from options, an alternative to NewDropboxClient
+func New(opts ...Option) (*DropboxClient, error) {
+	var cfg DropboxConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewDropboxClient(cfg)
+}
+
+// WithToken sets the access token
+func WithToken(token string) Option {
+	return func(cfg *DropboxConfig) {
+		cfg.Token = token
+	}
+}
+
+// WithRefreshToken gets short-lived access tokens with the refresh token of the
+// app instead of using a fixed token. appSecret may be empty for PKCE refresh tokens.
+func WithRefreshToken(refreshToken, appKey, appSecret string) Option {
+	return func(cfg *DropboxConfig) {
+		cfg.RefreshToken = refreshToken
+		cfg.AppKey = appKey
+		cfg.AppSecret = appSecret
+	}
+}
+
+// WithFolder sets the folder the files are uploaded to, e.g. "/uploads"
+func WithFolder(folder string) Option {
+	return func(cfg *DropboxConfig) {
+		cfg.Folder = folder
+	}
+}
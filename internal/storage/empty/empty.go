@@ -18,6 +18,11 @@ func New(info string) *EmptyStorage {
 	}
 }
 
+// InitError reports why the storage wasn't initialized
+func (e *EmptyStorage) InitError() error {
+	return errors.New(e.Info)
+}
+
 // UploadFile implements the Storage interface but always returns an error
 func (e *EmptyStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
 	return "", errors.New("storage service not configured or initialization failed. " + e.Info)
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	qiniuclient "github.com/qiniu/go-sdk/v7/client"
+	"github.com/tencentyun/cos-go-sdk-v5"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/artifactory"
+	"github.com/sjzar/file-store-mcp/internal/storage/bos"
+	"github.com/sjzar/file-store-mcp/internal/storage/box"
+	"github.com/sjzar/file-store-mcp/internal/storage/cloudinary"
+	"github.com/sjzar/file-store-mcp/internal/storage/dropbox"
+	"github.com/sjzar/file-store-mcp/internal/storage/firebase"
+	"github.com/sjzar/file-store-mcp/internal/storage/github"
+	"github.com/sjzar/file-store-mcp/internal/storage/ipfs"
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/internal/storage/oci"
+	"github.com/sjzar/file-store-mcp/internal/storage/onedrive"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Operations a storage error can occur in
+const (
+	OperationInit    = "init"    // Initializing the storage from the configuration
+	OperationAuth    = "auth"    // Authenticating with the provider
+	OperationConnect = "connect" // Reaching the provider
+	OperationPut     = "put"     // Uploading an object
+	OperationPresign = "presign"
+	OperationDelete  = "delete"
+	OperationExists  = "exists"
+	OperationList    = "list"
+)
+
+// BackendError describes a failed storage operation: the backend, the operation,
+// the provider's error code and a hint on how to fix it
+type BackendError struct {
+	Backend   string `json:"backend"`
+	Operation string `json:"operation"`
+	Code      string `json:"code,omitempty"` // Provider error code or HTTP status, e.g. "AccessDenied"
+	Hint      string `json:"hint,omitempty"`
+	Err       error  `json:"-"`
+}
+
+func (e *BackendError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s failed", e.Backend, e.Operation)
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	if e.Hint != "" {
+		fmt.Fprintf(&b, ". Hint: %s", e.Hint)
+	}
+	return util.Redact(b.String())
+}
+
+func (e *BackendError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError describes an error of operation on the configured storage, unless it
+// is already described or the operation isn't supported
+func (s *Service) wrapError(operation string, err error) error {
+	var backendErr *BackendError
+	if err == nil || errors.As(err, &backendErr) || errors.Is(err, ErrNotSupported) {
+		return err
+	}
+	return newBackendError(strings.ToLower(s.Config.StorageType), s.Storage, operation, err)
+}
+
+// newBackendError classifies err of operation on storage of type backend
+func newBackendError(backend string, storage Storage, operation string, err error) *BackendError {
+	e := &BackendError{Backend: backend, Operation: operation, Err: err}
+	if backend == "" {
+		e.Backend = StorageTypeEmpty
+	}
+
+	// Uploads to a storage that failed to initialize report why it did
+	if failed, ok := storage.(initFailer); ok {
+		if initErr := failed.InitError(); initErr != nil {
+			e.Operation, e.Err = OperationInit, initErr
+			if IsRegistered(backend) {
+				e.Hint = fmt.Sprintf("check the %s settings (%s); \"file-store-mcp storages\" shows the configuration", backend, strings.Join(settingNames(backend, false), ", "))
+			} else {
+				e.Hint = fmt.Sprintf("set FSM_STORAGE_TYPE to one of %s and its settings; \"file-store-mcp storages\" lists them", strings.Join(Registered(), ", "))
+			}
+			return e
+		}
+	}
+
+	code, status := providerCode(err)
+	e.Code = sanitizeCode(code)
+	switch {
+	case errors.Is(err, meta.ErrAuth) || isAuthError(code, status):
+		e.Operation = OperationAuth
+		e.Hint = fmt.Sprintf("check the credentials (%s) and that they may write to the target", strings.Join(settingNames(backend, true), ", "))
+	case isNotFound(code, status):
+		e.Hint = fmt.Sprintf("check that the bucket or repository exists and matches the settings (%s)", strings.Join(settingNames(backend, false), ", "))
+	case code == "EntityTooLarge" || status == 413:
+		e.Hint = "the file exceeds the size limit of the storage, see the storage_info tool"
+	case code == "RequestTimeTooSkewed":
+		e.Hint = "the system clock is off, synchronize it"
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Hint = "the request timed out, retry or check the network connection"
+	case isNetworkError(err):
+		e.Operation = OperationConnect
+		e.Hint = "check the network connection and the endpoint settings"
+	}
+	return e
+}
+
+// providerCode extracts the error code and HTTP status of a provider SDK error, if any
+func providerCode(err error) (string, int) {
+	var (
+		ossErr        oss.ServiceError
+		ossErrPtr     *oss.ServiceError
+		cosErr        *cos.ErrorResponse
+		qiniuErr      *qiniuclient.ErrorInfo
+		githubErr     *github.APIError
+		dropboxErr    *dropbox.APIError
+		graphErr      *onedrive.APIError
+		boxErr        *box.APIError
+		ipfsErr       *ipfs.APIError
+		cloudinaryErr *cloudinary.APIError
+		firebaseErr   *firebase.APIError
+		ociErr        *oci.APIError
+		bosErr        *bos.APIError
+		artifactErr   *artifactory.APIError
+		apiErr        smithy.APIError
+		respErr       *awshttp.ResponseError
+	)
+	switch {
+	case errors.As(err, &ossErr):
+		return ossErr.Code, ossErr.StatusCode
+	case errors.As(err, &ossErrPtr):
+		return ossErrPtr.Code, ossErrPtr.StatusCode
+	case errors.As(err, &cosErr):
+		status := 0
+		if cosErr.Response != nil {
+			status = cosErr.Response.StatusCode
+		}
+		return cosErr.Code, status
+	case errors.As(err, &qiniuErr):
+		if qiniuErr.ErrorCode != "" {
+			return qiniuErr.ErrorCode, qiniuErr.Code
+		}
+		return strconv.Itoa(qiniuErr.Code), qiniuErr.Code
+	case errors.As(err, &githubErr):
+		return strconv.Itoa(githubErr.StatusCode), githubErr.StatusCode
+	case errors.As(err, &dropboxErr):
+		return dropboxErr.Code(), dropboxErr.StatusCode
+	case errors.As(err, &graphErr):
+		if graphErr.Code != "" {
+			return graphErr.Code, graphErr.StatusCode
+		}
+		return strconv.Itoa(graphErr.StatusCode), graphErr.StatusCode
+	case errors.As(err, &boxErr):
+		if boxErr.Code != "" {
+			return boxErr.Code, boxErr.StatusCode
+		}
+		return strconv.Itoa(boxErr.StatusCode), boxErr.StatusCode
+	case errors.As(err, &ipfsErr):
+		return strconv.Itoa(ipfsErr.StatusCode), ipfsErr.StatusCode
+	case errors.As(err, &cloudinaryErr):
+		return strconv.Itoa(cloudinaryErr.StatusCode), cloudinaryErr.StatusCode
+	case errors.As(err, &firebaseErr):
+		return strconv.Itoa(firebaseErr.StatusCode), firebaseErr.StatusCode
+	case errors.As(err, &ociErr):
+		if ociErr.Code != "" {
+			return ociErr.Code, ociErr.StatusCode
+		}
+		return strconv.Itoa(ociErr.StatusCode), ociErr.StatusCode
+	case errors.As(err, &bosErr):
+		if bosErr.Code != "" {
+			return bosErr.Code, bosErr.StatusCode
+		}
+		return strconv.Itoa(bosErr.StatusCode), bosErr.StatusCode
+	case errors.As(err, &artifactErr):
+		return strconv.Itoa(artifactErr.StatusCode), artifactErr.StatusCode
+	case errors.As(err, &apiErr):
+		status := 0
+		if errors.As(err, &respErr) {
+			status = respErr.HTTPStatusCode()
+		}
+		return apiErr.ErrorCode(), status
+	}
+	return "", 0
+}
+
+func isAuthError(code string, status int) bool {
+	switch code {
+	case "InvalidAccessKeyId", "SignatureDoesNotMatch", "AccessDenied", "InvalidToken", "ExpiredToken",
+		"InvalidSecretId", "InvalidAccessKeyId.NotFound", "AccessForbidden", "bad token":
+		return true
+	}
+	return status == 401 || status == 403
+}
+
+func isNotFound(code string, status int) bool {
+	return code == "NoSuchBucket" || status == 404 || status == 631 // Qiniu's "no such bucket"
+}
+
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sanitizeCode keeps provider error codes short and free of anything but
+// letters, digits and punctuation safe to show in tool results
+func sanitizeCode(code string) string {
+	code = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		case r == ' ':
+			return '_'
+		}
+		return -1
+	}, code)
+	if len(code) > 64 {
+		code = code[:64]
+	}
+	return code
+}
+
+// settingNames lists the environment variables of a backend, only the secret
+// ones or only the required ones that aren't secret
+func settingNames(backend string, secret bool) []string {
+	info, ok := LookupBackend(backend)
+	if !ok {
+		return []string{"FSM_STORAGE_TYPE"}
+	}
+	var names []string
+	for _, setting := range info.Settings {
+		if secret && setting.Secret || !secret && setting.Required && !setting.Secret {
+			names = append(names, setting.Env)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"FSM_" + strings.ToUpper(backend) + "_*"}
+	}
+	return names
+}
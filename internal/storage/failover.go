@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// uploadFailover retries an upload that failed on the configured storage with err on
+// the failover storages the scope of the caller allows, in order. It returns the URL and the storage of the
+// first upload that succeeds, or err if all of them fail. Refused uploads and
+// canceled requests aren't retried.
+func (s *Service) uploadFailover(ctx context.Context, objectKey string, err error, upload func(Storage) (string, error)) (string, *namedStorage, error) {
+	var backendErr *BackendError
+	if len(s.failover) == 0 || ctx.Err() != nil || !errors.As(err, &backendErr) {
+		return "", nil, err
+	}
+
+	scope, _ := ScopeFromContext(ctx)
+	for i := range s.failover {
+		f := &s.failover[i]
+		if !scope.allowsStorage(f.backend) {
+			continue
+		}
+		log.Ctx(ctx).Warn().Err(err).Str("key", objectKey).Str("backend", f.backend).Msg("Upload failed, failing over")
+
+		start := time.Now()
+		url, failoverErr := upload(f.storage)
+		if failoverErr == nil {
+			addNote(ctx, fmt.Sprintf("uploaded to %s instead: %v", f.backend, err))
+			return url, f, nil
+		}
+		failoverErr = newBackendError(f.backend, f.storage, OperationPut, failoverErr)
+		s.stats.record(f.backend, 0, time.Since(start), failoverErr)
+		log.Ctx(ctx).Error().Err(failoverErr).Str("key", objectKey).Msg("Failover upload failed")
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", nil, err
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// namedStorage is a storage used besides the configured one, e.g. a mirror
+// that uploads are copied to or a failover storage
+type namedStorage struct {
+	backend string
+	storage Storage
+}
+
+// MirrorResult describes the copy of an upload on a mirror storage
+type MirrorResult struct {
+	Backend string `json:"backend"`
+	URL     string `json:"url,omitempty"`
+	Error   string `json:"error,omitempty"` // Why the copy failed, the upload itself succeeded
+}
+
+// splitStorageTypes splits a list of storage types, e.g. "s3,github", into the
+// configured storage type and the types of its mirrors
+func splitStorageTypes(value string) (string, []string) {
+	var types []string
+	for _, storageType := range strings.Split(value, ",") {
+		if storageType = strings.ToLower(strings.TrimSpace(storageType)); storageType != "" {
+			types = append(types, storageType)
+		}
+	}
+	if len(types) == 0 {
+		return StorageTypeEmpty, nil
+	}
+	return types[0], types[1:]
+}
+
+// openStorages initializes storages of the given types besides the configured one,
+// with the settings of their types. Storages that fail to initialize fail their uploads.
+func openStorages(config *Config, types []string) []namedStorage {
+	storages := make([]namedStorage, 0, len(types))
+	for _, backend := range types {
+		cfg := *config
+		cfg.StorageType, cfg.Mirrors, cfg.Failover, cfg.Routes = strings.ToLower(backend), nil, nil, nil
+		storages = append(storages, namedStorage{backend: cfg.StorageType, storage: NewStorage(&cfg)})
+	}
+	return storages
+}
+
+// uploadMirrors copies the local file at path to the mirrors the scope of the caller
+// allows concurrently, under the object key the configured storage stored it with
+func (s *Service) uploadMirrors(ctx context.Context, path string, objectKey string, filename string, idempotencyKey string) []MirrorResult {
+	scope, _ := ScopeFromContext(ctx)
+	var mirrors []namedStorage
+	for _, m := range s.mirrors {
+		if scope.allowsStorage(m.backend) {
+			mirrors = append(mirrors, m)
+		}
+	}
+	if len(mirrors) == 0 {
+		return nil
+	}
+
+	results := make([]MirrorResult, len(mirrors))
+	var wg sync.WaitGroup
+	for i, m := range mirrors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			url, err := m.storage.UploadFile(s.newUploadContext(ctx, filename, idempotencyKey), path, objectKey)
+			s.stats.record(m.backend, 0, time.Since(start), err)
+			results[i] = MirrorResult{Backend: m.backend, URL: url}
+			if err != nil {
+				err = newBackendError(m.backend, m.storage, OperationPut, err)
+				log.Ctx(ctx).Warn().Err(err).Str("backend", m.backend).Str("key", objectKey).Msg("Failed to copy upload to mirror")
+				addNote(ctx, fmt.Sprintf("not mirrored to %s: %v", m.backend, err))
+				results[i] = MirrorResult{Backend: m.backend, Error: err.Error()}
+				return
+			}
+			log.Ctx(ctx).Debug().Str("backend", m.backend).Str("key", objectKey).Msg("Copied upload to mirror")
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// deleteMirrors removes an object from the mirrors that can delete objects,
+// logging the failures since the object is already gone from the configured storage
+func (s *Service) deleteMirrors(ctx context.Context, objectKey string) {
+	for _, m := range s.mirrors {
+		deleter, ok := m.storage.(Deleter)
+		if !ok || !CapabilitiesOf(m.storage).Delete {
+			continue
+		}
+		if err := deleter.Delete(ctx, objectKey); err != nil {
+			log.Ctx(ctx).Warn().Err(newBackendError(m.backend, m.storage, OperationDelete, err)).Str("key", objectKey).Msg("Failed to delete object from mirror")
+		}
+	}
+}
+
+// closeStorages releases the resources held by the mirrors and failover storages
+func (s *Service) closeStorages() {
+	for _, storages := range [][]namedStorage{s.mirrors, s.failover} {
+		for _, m := range storages {
+			if closer, ok := m.storage.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					log.Warn().Err(err).Str("backend", m.backend).Msg("Failed to close storage")
+				}
+			}
+		}
+	}
+}
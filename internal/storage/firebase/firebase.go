@@ -0,0 +1,309 @@
+package firebase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// apiURL is the Firebase Storage REST endpoint
+const apiURL = "https://firebasestorage.googleapis.com/v0"
+
+// maxObjectSize is the largest object Cloud Storage accepts, 5 TiB
+const maxObjectSize = 5 << 40
+
+// FirebaseClient is a wrapper for the Firebase Storage REST client
+type FirebaseClient struct {
+	auth   *tokenSource
+	bucket string
+	prefix string
+	client *http.Client // Shared by all requests to reuse connections
+}
+
+// FirebaseConfig contains configuration for the Firebase Storage client
+type FirebaseConfig struct {
+	CredentialsFile string // Path of the JSON key file of a service account
+	Bucket          string // Storage bucket, e.g. "my-project.appspot.com" or "my-project.firebasestorage.app"
+	Prefix          string // Optional, path prefix of the object names, e.g. "uploads"
+}
+
+// NewFirebaseClient creates a new Firebase Storage client
+func NewFirebaseClient(cfg FirebaseConfig) (*FirebaseClient, error) {
+	if cfg.CredentialsFile == "" {
+		return nil, fmt.Errorf("Firebase service account key file cannot be empty")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("Firebase storage bucket cannot be empty")
+	}
+
+	client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	auth, err := newTokenSource(cfg.CredentialsFile, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FirebaseClient{
+		auth:   auth,
+		bucket: strings.TrimPrefix(cfg.Bucket, "gs://"),
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		client: client,
+	}, nil
+}
+
+// UploadFile uploads a local file to Firebase Storage and returns the download URL
+func (f *FirebaseClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	return f.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload uploads data from an io.Reader to Firebase Storage and returns the download URL
+func (f *FirebaseClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return f.upload(ctx, body, -1, filename)
+}
+
+// upload uploads size bytes of body, or all of it if size is negative, as filename
+// and returns the download URL with its access token
+func (f *FirebaseClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+	name := f.objectName(filename)
+	contentType := util.GetContentType(filename)
+
+	// Uploads authenticated as a service account may get no download token, so one
+	// is set in the custom metadata, where Firebase keeps them
+	custom := map[string]string{"firebaseStorageDownloadTokens": uuid.New().String()}
+	m := meta.FromContext(ctx)
+	for key, value := range m.Fields() {
+		custom[key] = value
+	}
+	metadata := map[string]interface{}{"name": name, "contentType": contentType, "metadata": custom}
+	if m.ContentDisposition != "" {
+		metadata["contentDisposition"] = m.ContentDisposition
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+
+	// Stream the file in a multipart/related body, after the metadata part
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=utf-8"}})
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(metadataJSON); err != nil {
+		return "", err
+	}
+	if _, err := form.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}}); err != nil {
+		return "", err
+	}
+	head := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	form.Close()
+
+	endpoint := f.bucketURL() + "/o?name=" + url.QueryEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, io.MultiReader(bytes.NewReader(head), body, &buf))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = int64(len(head)) + size + int64(buf.Len())
+	}
+	req.Header.Set("Content-Type", "multipart/related; boundary="+form.Boundary())
+	req.Header.Set("X-Goog-Upload-Protocol", "multipart")
+
+	var object objectMetadata
+	if err := f.do(req, &object); err != nil {
+		return "", err
+	}
+	if object.DownloadTokens == "" {
+		object.DownloadTokens = custom["firebaseStorageDownloadTokens"]
+	}
+	return f.downloadURL(name, object.DownloadTokens), nil
+}
+
+// objectMetadata is the metadata of an object, as returned by Firebase Storage
+type objectMetadata struct {
+	Name           string `json:"name"`
+	DownloadTokens string `json:"downloadTokens"` // Comma-separated
+}
+
+// downloadURL returns the download URL of an object with the first of its tokens
+func (f *FirebaseClient) downloadURL(name string, downloadTokens string) string {
+	token, _, _ := strings.Cut(downloadTokens, ",")
+	return f.objectURL(name) + "?alt=media&token=" + url.QueryEscape(token)
+}
+
+// Presign returns the download URL of an uploaded file. Download tokens don't
+// expire until revoked, so expiration is ignored.
+func (f *FirebaseClient) Presign(ctx context.Context, objectKey string, _ time.Duration) (string, error) {
+	name := f.objectName(objectKey)
+	var object objectMetadata
+	if err := f.rpc(ctx, http.MethodGet, f.objectURL(name), &object); err != nil {
+		return "", err
+	}
+	if object.DownloadTokens == "" {
+		return "", fmt.Errorf("Firebase object %s has no download token", name)
+	}
+	return f.downloadURL(name, object.DownloadTokens), nil
+}
+
+// Limits reports the 5 TiB limit of Cloud Storage objects
+func (f *FirebaseClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxObjectSize}
+}
+
+// Delete removes an object. objectKey is the name the file was uploaded with,
+// relative to the configured prefix.
+func (f *FirebaseClient) Delete(ctx context.Context, objectKey string) error {
+	return f.rpc(ctx, http.MethodDelete, f.objectURL(f.objectName(objectKey)), nil)
+}
+
+// Exists reports whether an object exists
+func (f *FirebaseClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	err := f.rpc(ctx, http.MethodGet, f.objectURL(f.objectName(objectKey)), nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns up to limit objects whose key starts with prefix. Only the folder
+// of prefix is searched, not its subfolders. Firebase doesn't list the sizes.
+func (f *FirebaseClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	query := url.Values{"prefix": {f.objectName(prefix)}, "delimiter": {"/"}}
+
+	var objects []meta.ObjectInfo
+	for {
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := f.rpc(ctx, http.MethodGet, f.bucketURL()+"/o?"+query.Encode(), &page); err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			key := item.Name
+			if f.prefix != "" {
+				key = strings.TrimPrefix(key, f.prefix+"/")
+			}
+			objects = append(objects, meta.ObjectInfo{Key: key})
+			if limit > 0 && len(objects) == limit {
+				return objects, nil
+			}
+		}
+		if page.NextPageToken == "" {
+			return objects, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// objectName returns the name of the object of a key, under the configured prefix
+func (f *FirebaseClient) objectName(objectKey string) string {
+	if f.prefix == "" {
+		return objectKey
+	}
+	return f.prefix + "/" + objectKey
+}
+
+// bucketURL returns the REST URL of the bucket
+func (f *FirebaseClient) bucketURL() string {
+	return apiURL + "/b/" + url.PathEscape(f.bucket)
+}
+
+// objectURL returns the REST URL of an object, whose name is escaped as one
+// path segment including its slashes
+func (f *FirebaseClient) objectURL(name string) string {
+	return f.bucketURL() + "/o/" + url.PathEscape(name)
+}
+
+// rpc sends a request without a body, decoding the response into result unless it is nil
+func (f *FirebaseClient) rpc(ctx context.Context, method string, apiURL string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	return f.do(req, result)
+}
+
+// do sends an authenticated request, decoding the response into result unless it is nil
+func (f *FirebaseClient) do(req *http.Request, result interface{}) error {
+	token, err := f.auth.Token(req.Context())
+	if err != nil {
+		return fmt.Errorf("%w: %w", meta.ErrAuth, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// APIError is an error response of the Firebase Storage API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Firebase Storage API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && body.Error.Message != "" {
+		e.Message = body.Error.Message
+	}
+	return e
+}
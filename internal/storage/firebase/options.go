@@ -0,0 +1,34 @@
+package firebase
+
+// Option sets a field of the FirebaseConfig used by New
+type Option func(*FirebaseConfig)
+
+// New creates a new Firebase Storage client from options, an alternative to NewFirebaseClient
+func New(opts ...Option) (*FirebaseClient, error) {
+	var cfg FirebaseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewFirebaseClient(cfg)
+}
+
+// WithServiceAccount sets the path of the JSON key file of the service account
+func WithServiceAccount(path string) Option {
+	return func(cfg *FirebaseConfig) {
+		cfg.CredentialsFile = path
+	}
+}
+
+// WithBucket sets the storage bucket
+func WithBucket(bucket string) Option {
+	return func(cfg *FirebaseConfig) {
+		cfg.Bucket = bucket
+	}
+}
+
+// WithPrefix sets the path prefix of the object names
+func WithPrefix(prefix string) Option {
+	return func(cfg *FirebaseConfig) {
+		cfg.Prefix = prefix
+	}
+}
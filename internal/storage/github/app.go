@@ -0,0 +1,191 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// appTokenRefreshMargin is how long before expiry installation tokens are renewed
+const appTokenRefreshMargin = 5 * time.Minute
+
+// appTokenSource issues installation access tokens of a GitHub App, which
+// expire after an hour, and renews them in the background before they do
+type appTokenSource struct {
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	client         *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newAppTokenSource reads the private key of the app and fetches the first token
+func newAppTokenSource(appID, installationID, privateKeyPath string, client *http.Client) (*appTokenSource, error) {
+	if installationID == "" {
+		return nil, fmt.Errorf("GitHub App installation ID cannot be empty")
+	}
+	key, err := readPrivateKey(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		client:         client,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	if _, err := s.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	go s.refreshLoop()
+	return s, nil
+}
+
+// Token returns a valid installation token, renewing it now if the background
+// refresh hasn't
+func (s *appTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	token, expires := s.token, s.expires
+	s.mu.Unlock()
+	if time.Until(expires) > time.Minute {
+		return token, nil
+	}
+	return s.refresh(ctx)
+}
+
+// Close stops the background refresh
+func (s *appTokenSource) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *appTokenSource) refreshLoop() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		wait := time.Until(s.expires) - appTokenRefreshMargin
+		s.mu.Unlock()
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(max(wait, 0)):
+		}
+
+		if _, err := s.refresh(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to renew GitHub App token, retrying in a minute")
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(time.Minute):
+			}
+		}
+	}
+}
+
+// refresh exchanges a JWT signed with the app's key for a new installation token
+func (s *appTokenSource) refresh(ctx context.Context) (string, error) {
+	jwt, err := s.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	apiURL := fmt.Sprintf("https://api.github.com/app/installations/%s/access_tokens", s.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request GitHub App token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get GitHub App token, status code: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse GitHub App token response: %w", err)
+	}
+
+	s.mu.Lock()
+	s.token, s.expires = result.Token, result.ExpiresAt
+	s.mu.Unlock()
+	log.Debug().Str("installation", s.installationID).Time("expires", result.ExpiresAt).Msg("Renewed GitHub App token")
+	return result.Token, nil
+}
+
+// signJWT creates the RS256 JWT authenticating as the app, valid for 9 minutes
+func (s *appTokenSource) signJWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(), // Allow for clock drift
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// readPrivateKey reads the PEM private key downloaded from the app settings
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key must be an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,162 @@
+package github
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// refUpdateAttempts is how often a commit is retried when the branch moved on
+// while it was created, e.g. by a concurrent upload
+const refUpdateAttempts = 3
+
+// uploadGitData commits size bytes of body as fullPath with the Git Data API and
+// returns the download URL. Unlike the contents API, the content is streamed to
+// a blob instead of being encoded into a single JSON body in memory.
+func (g *GitHubClient) uploadGitData(ctx context.Context, body io.ReadSeeker, size int64, fullPath string) (string, error) {
+	// A retry of an upload whose commit went through finds the file already there,
+	// return it instead of failing or committing again
+	if meta.FromContext(ctx).IdempotencyKey != "" {
+		sha, err := readerBlobSHA(body, size)
+		if err != nil {
+			return "", err
+		}
+		committed, err := g.hasBlob(ctx, fullPath, sha)
+		if err != nil {
+			return "", err
+		}
+		if committed {
+			return g.downloadURL(fullPath), nil
+		}
+	}
+
+	blobSHA, err := g.createBlob(ctx, body, size)
+	if err != nil {
+		return "", err
+	}
+
+	refURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/%s", g.owner, g.repo, url.PathEscape(g.branch))
+	message := commitMessage(ctx, filepath.Base(fullPath))
+	for attempt := 1; ; attempt++ {
+		var ref struct {
+			Object struct {
+				SHA string `json:"sha"`
+			} `json:"object"`
+		}
+		if err := g.rpc(ctx, http.MethodGet, refURL, nil, &ref); err != nil {
+			return "", err
+		}
+		var parent struct {
+			Tree struct {
+				SHA string `json:"sha"`
+			} `json:"tree"`
+		}
+		if err := g.rpc(ctx, http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/%s/git/commits/%s", g.owner, g.repo, ref.Object.SHA), nil, &parent); err != nil {
+			return "", err
+		}
+
+		var tree struct {
+			SHA string `json:"sha"`
+		}
+		err := g.rpc(ctx, http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees", g.owner, g.repo), map[string]interface{}{
+			"base_tree": parent.Tree.SHA,
+			"tree": []map[string]string{
+				{"path": strings.TrimPrefix(fullPath, "/"), "mode": "100644", "type": "blob", "sha": blobSHA},
+			},
+		}, &tree)
+		if err != nil {
+			return "", err
+		}
+
+		var commit struct {
+			SHA string `json:"sha"`
+		}
+		err = g.rpc(ctx, http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/%s/git/commits", g.owner, g.repo), map[string]interface{}{
+			"message": message,
+			"tree":    tree.SHA,
+			"parents": []string{ref.Object.SHA},
+		}, &commit)
+		if err != nil {
+			return "", err
+		}
+
+		// Fails with 422 if the branch is no longer at the parent commit
+		err = g.rpc(ctx, http.MethodPatch, refURL, map[string]interface{}{"sha": commit.SHA, "force": false}, nil)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity && attempt < refUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return g.downloadURL(fullPath), nil
+	}
+}
+
+// createBlob streams size bytes of body to a new blob and returns its SHA
+func (g *GitHubClient) createBlob(ctx context.Context, body io.ReadSeeker, size int64) (string, error) {
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+
+	// Encode the content into the JSON body as it is sent
+	const head, tail = `{"encoding":"base64","content":"`, `"}`
+	pr, pw := io.Pipe()
+	go func() {
+		encoder := base64.NewEncoder(base64.StdEncoding, pw)
+		_, err := io.WriteString(pw, head)
+		if err == nil {
+			_, err = io.CopyN(encoder, body, size)
+		}
+		if err == nil {
+			err = encoder.Close()
+		}
+		if err == nil {
+			_, err = io.WriteString(pw, tail)
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/%s/git/blobs", g.owner, g.repo), pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.ContentLength = int64(len(head)+len(tail)) + int64(base64.StdEncoding.EncodedLen(int(size)))
+	if err := g.setHeaders(req); err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var blob struct {
+		SHA string `json:"sha"`
+	}
+	if err := g.do(req, &blob); err != nil {
+		return "", err
+	}
+	return blob.SHA, nil
+}
+
+// readerBlobSHA computes the SHA-1 git identifies size bytes of body by, reading
+// them in chunks
+func readerBlobSHA(body io.ReadSeeker, size int64) (string, error) {
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", size)
+	if _, err := io.CopyN(h, body, size); err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -1,28 +1,47 @@
 package github
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
 )
 
 // GitHubClient is a wrapper for the GitHub image hosting client
 type GitHubClient struct {
 	token        string
+	app          *appTokenSource // Issues the tokens instead if authenticating as a GitHub App
 	owner        string
 	repo         string
 	branch       string
 	path         string
 	customDomain string
+	release      string       // Tag of the release the files are uploaded to as assets, if set
+	client       *http.Client // Shared by all requests to reuse connections
+
+	// Files of at least gitDataThreshold bytes are committed with the Git Data API
+	gitDataThreshold int64
+	lfs              bool // Store the files in Git LFS and commit pointers to them
+
+	mu        sync.Mutex
+	releaseID int64 // ID of the release, looked up by the first upload
 }
 
 // GitHubConfig contains configuration for the GitHub image hosting client
@@ -33,11 +52,35 @@ type GitHubConfig struct {
 	Branch       string // Branch name, defaults to main
 	Path         string // File storage path, e.g. "images/"
 	CustomDomain string // Optional, custom domain such as CDN
+
+	// Optional, tag of a release to upload the files to as assets instead of
+	// committing them, created on Branch if missing. Assets can be up to 2 GiB
+	// and don't grow the repository; Path and CustomDomain don't apply to them.
+	Release string
+
+	// Files of at least GitDataThreshold bytes are streamed to a blob and committed
+	// with the Git Data API instead of being sent to the contents API as a single
+	// base64 JSON body, disabled if zero
+	GitDataThreshold int64
+
+	// Optional, store the files in Git LFS and commit pointers to them, for files
+	// up to 2 GiB that don't grow the repository. Path must be tracked by LFS in
+	// the .gitattributes of the repository for the files to be served.
+	LFS bool
+
+	Timeout    time.Duration // Timeout of a single request, including the upload of its body; none if zero
+	MaxRetries int           // How often requests failed with a server error or rate limit are retried, 0 to disable
+
+	// Optional, authenticate as a GitHub App installation instead of with Token.
+	// Its tokens expire after an hour and are renewed in the background.
+	AppID             string
+	AppInstallationID string
+	AppPrivateKey     string // Path of the app's PEM private key
 }
 
 // NewGitHubClient creates a new GitHub image hosting client
 func NewGitHubClient(cfg GitHubConfig) (*GitHubClient, error) {
-	if cfg.Token == "" {
+	if cfg.Token == "" && cfg.AppID == "" {
 		return nil, fmt.Errorf("GitHub access token cannot be empty")
 	}
 
@@ -45,6 +88,20 @@ func NewGitHubClient(cfg GitHubConfig) (*GitHubClient, error) {
 		return nil, fmt.Errorf("repository owner and name cannot be empty")
 	}
 
+	var transport http.RoundTripper = http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxRetries > 0 {
+		transport = &retryTransport{base: transport, maxRetries: cfg.MaxRetries}
+	}
+	client := &http.Client{Transport: transport, Timeout: cfg.Timeout}
+
+	var app *appTokenSource
+	if cfg.AppID != "" {
+		var err error
+		if app, err = newAppTokenSource(cfg.AppID, cfg.AppInstallationID, cfg.AppPrivateKey, client); err != nil {
+			return nil, err
+		}
+	}
+
 	// Set default branch
 	branch := cfg.Branch
 	if branch == "" {
@@ -59,22 +116,93 @@ func NewGitHubClient(cfg GitHubConfig) (*GitHubClient, error) {
 
 	return &GitHubClient{
 		token:        cfg.Token,
+		app:          app,
 		owner:        cfg.Owner,
 		repo:         cfg.Repo,
 		branch:       branch,
 		path:         path,
 		customDomain: cfg.CustomDomain,
+		release:      cfg.Release,
+		client:       client,
+
+		gitDataThreshold: cfg.GitDataThreshold,
+		lfs:              cfg.LFS,
 	}, nil
 }
 
 // UploadFile uploads a local file to GitHub and returns the download URL
 func (g *GitHubClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	if g.release != "" {
+		file, err := os.Open(_path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+		fileInfo, err := file.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to get file info: %w", err)
+		}
+		if fileInfo.Size() > maxAssetSize {
+			return "", fmt.Errorf("file size %d exceeds the GitHub release asset limit of 2 GiB", fileInfo.Size())
+		}
+		return g.uploadAsset(ctx, file, fileInfo.Size(), g.objectKey(filename))
+	}
+
+	if g.lfs || g.gitDataThreshold > 0 {
+		file, err := os.Open(_path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+		fileInfo, err := file.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to get file info: %w", err)
+		}
+		if g.lfs {
+			return g.uploadLFS(ctx, file, fileInfo.Size(), filename)
+		}
+		if fileInfo.Size() >= g.gitDataThreshold {
+			return g.uploadGitData(ctx, file, fileInfo.Size(), path.Join(g.path, g.objectKey(filename)))
+		}
+	}
+
 	// Read file content
 	fileContent, err := os.ReadFile(_path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	return g.upload(ctx, fileContent, filename)
+}
+
+// Upload uploads data from an io.Reader to GitHub and returns the download URL
+func (g *GitHubClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	// Read all data from the reader
+	fileContent, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	if g.release != "" {
+		return g.uploadAsset(ctx, bytes.NewReader(fileContent), int64(len(fileContent)), g.objectKey(filename))
+	}
+	if g.lfs {
+		return g.uploadLFS(ctx, bytes.NewReader(fileContent), int64(len(fileContent)), filename)
+	}
+	if g.gitDataThreshold > 0 && int64(len(fileContent)) >= g.gitDataThreshold {
+		return g.uploadGitData(ctx, bytes.NewReader(fileContent), int64(len(fileContent)), path.Join(g.path, g.objectKey(filename)))
+	}
+	return g.upload(ctx, fileContent, filename)
+}
+
+// objectKey returns filename, or a random name if it is empty
+func (g *GitHubClient) objectKey(filename string) string {
+	if len(filename) == 0 {
+		return uuid.New().String()
+	}
+	return filename
+}
 
+// upload commits the content as filename and returns the download URL
+func (g *GitHubClient) upload(ctx context.Context, fileContent []byte, filename string) (string, error) {
 	if len(filename) == 0 {
 		filename = uuid.New().String()
 	}
@@ -82,6 +210,18 @@ func (g *GitHubClient) UploadFile(ctx context.Context, _path string, filename st
 	fullPath := path.Join(g.path, filename)
 	uniqueFileName := filepath.Base(fullPath)
 
+	// A retry of an upload whose commit went through finds the file already there,
+	// return it instead of failing or committing again
+	if meta.FromContext(ctx).IdempotencyKey != "" {
+		committed, err := g.hasBlob(ctx, fullPath, blobSHA(fileContent))
+		if err != nil {
+			return "", err
+		}
+		if committed {
+			return g.downloadURL(fullPath), nil
+		}
+	}
+
 	// Encode file content as Base64
 	encodedContent := base64.StdEncoding.EncodeToString(fileContent)
 
@@ -93,7 +233,7 @@ func (g *GitHubClient) UploadFile(ctx context.Context, _path string, filename st
 	}
 
 	reqContent := RequestContent{
-		Message: fmt.Sprintf("Upload %s", uniqueFileName),
+		Message: commitMessage(ctx, uniqueFileName),
 		Content: encodedContent,
 		Branch:  g.branch,
 	}
@@ -113,13 +253,13 @@ func (g *GitHubClient) UploadFile(ctx context.Context, _path string, filename st
 	}
 
 	// Set request headers
-	req.Header.Set("Authorization", "token "+g.token)
+	if err := g.setHeaders(req); err != nil {
+		return "", err
+	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
 	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -127,130 +267,273 @@ func (g *GitHubClient) UploadFile(ctx context.Context, _path string, filename st
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API returned error (status code: %d): %s", resp.StatusCode, string(respBody))
+		return "", newAPIError(resp)
 	}
 
-	// Parse response
-	type ResponseContent struct {
-		Content struct {
-			DownloadURL string `json:"download_url"`
-		} `json:"content"`
+	return g.downloadURL(fullPath), nil
+}
+
+// downloadURL builds the download URL of a file, on the custom domain if set.
+// raw.githubusercontent.com serves the pointers of LFS files, their content is
+// served by media.githubusercontent.com.
+func (g *GitHubClient) downloadURL(fullPath string) string {
+	if g.customDomain != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(g.customDomain, "/"), fullPath)
+	}
+	if g.lfs {
+		return fmt.Sprintf("https://media.githubusercontent.com/media/%s/%s/%s/%s", g.owner, g.repo, g.branch, fullPath)
 	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", g.owner, g.repo, g.branch, fullPath)
+}
 
-	var respContent ResponseContent
-	if err := json.NewDecoder(resp.Body).Decode(&respContent); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+// hasBlob reports whether the file at fullPath exists with the content of the
+// git blob SHA
+func (g *GitHubClient) hasBlob(ctx context.Context, fullPath string, sha string) (bool, error) {
+	resp, err := g.getContents(ctx, fullPath)
+	if err != nil {
+		return false, err
 	}
+	defer resp.Body.Close()
 
-	// Build file download URL
-	var downloadURL string
-	if g.customDomain != "" {
-		// Use custom domain
-		domain := g.customDomain
-		if domain[len(domain)-1] == '/' {
-			domain = domain[:len(domain)-1]
-		}
-		downloadURL = fmt.Sprintf("%s/%s", domain, fullPath)
-	} else {
-		// Use GitHub raw domain
-		downloadURL = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
-			g.owner, g.repo, g.branch, fullPath)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, newAPIError(resp)
 	}
 
-	return downloadURL, nil
+	var file struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return file.SHA == sha, nil
 }
 
-// Upload uploads data from an io.Reader to GitHub and returns the download URL
-func (g *GitHubClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
-	// Read all data from the reader
-	fileContent, err := io.ReadAll(body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read data: %w", err)
+// blobSHA computes the SHA-1 git identifies content by
+func blobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Limits reports the 100 MiB limit GitHub puts on files in a repository, or the
+// 2 GiB limit of release assets and LFS files
+func (g *GitHubClient) Limits() meta.Limits {
+	if g.release != "" {
+		return meta.Limits{MaxFileSize: maxAssetSize}
+	}
+	if g.lfs {
+		return meta.Limits{MaxFileSize: maxLFSFileSize, CustomDomain: g.customDomain != ""}
 	}
+	return meta.Limits{MaxFileSize: 100 << 20, CustomDomain: g.customDomain != ""}
+}
 
-	if len(filename) == 0 {
-		filename = uuid.New().String()
+// Delete removes a file from the repository, or its release asset. objectKey is
+// the name the file was uploaded with, relative to the configured path.
+func (g *GitHubClient) Delete(ctx context.Context, objectKey string) error {
+	if g.release != "" {
+		return g.deleteAsset(ctx, objectKey)
 	}
+	fullPath := path.Join(g.path, objectKey)
+	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", g.owner, g.repo, fullPath)
 
-	fullPath := path.Join(g.path, filename)
-	uniqueFileName := filepath.Base(fullPath)
+	// The contents API needs the blob SHA of the file to delete
+	resp, err := g.getContents(ctx, fullPath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	// Encode file content as Base64
-	encodedContent := base64.StdEncoding.EncodeToString(fileContent)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
 
-	// Build request body
-	type RequestContent struct {
-		Message string `json:"message"`
-		Content string `json:"content"`
-		Branch  string `json:"branch"`
+	var file struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	reqContent := RequestContent{
-		Message: fmt.Sprintf("Upload %s", uniqueFileName),
-		Content: encodedContent,
-		Branch:  g.branch,
+	reqBody, err := json.Marshal(map[string]string{
+		"message": fmt.Sprintf("Delete %s", filepath.Base(fullPath)),
+		"sha":     file.SHA,
+		"branch":  g.branch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize request body: %w", err)
 	}
 
-	reqBody, err := json.Marshal(reqContent)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", apiURL, strings.NewReader(string(reqBody)))
 	if err != nil {
-		return "", fmt.Errorf("failed to serialize request body: %w", err)
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := g.setHeaders(req); err != nil {
+		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	// Build API URL
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", g.owner, g.repo, fullPath)
+	deleteResp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer deleteResp.Body.Close()
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "PUT", apiURL, strings.NewReader(string(reqBody)))
+	if deleteResp.StatusCode < 200 || deleteResp.StatusCode >= 300 {
+		return newAPIError(deleteResp)
+	}
+	return nil
+}
+
+// Exists reports whether a file exists in the repository, or as a release asset
+func (g *GitHubClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	if g.release != "" {
+		return g.assetExists(ctx, objectKey)
+	}
+	resp, err := g.getContents(ctx, path.Join(g.path, objectKey))
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return false, err
 	}
+	defer resp.Body.Close()
 
-	// Set request headers
-	req.Header.Set("Authorization", "token "+g.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return false, newAPIError(resp)
+	}
+	return true, nil
+}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// List returns up to limit files whose key starts with prefix. GitHub lists one
+// directory at a time, so only the directory of prefix is searched. Release
+// assets are listed by their names, with slashes replaced by dashes.
+func (g *GitHubClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	if g.release != "" {
+		return g.listAssets(ctx, prefix, limit)
+	}
+	dir, namePrefix := path.Split(prefix)
+	resp, err := g.getContents(ctx, strings.TrimSuffix(path.Join(g.path, dir), "/"))
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Check response status
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API returned error (status code: %d): %s", resp.StatusCode, string(respBody))
+		return nil, newAPIError(resp)
 	}
 
-	// Parse response
-	type ResponseContent struct {
-		Content struct {
-			DownloadURL string `json:"download_url"`
-		} `json:"content"`
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Size int64  `json:"size"`
 	}
-
-	var respContent ResponseContent
-	if err := json.NewDecoder(resp.Body).Decode(&respContent); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Build file download URL
-	var downloadURL string
-	if g.customDomain != "" {
-		// Use custom domain
-		domain := g.customDomain
-		if domain[len(domain)-1] == '/' {
-			domain = domain[:len(domain)-1]
+	var objects []meta.ObjectInfo
+	for _, entry := range entries {
+		if entry.Type != "file" || !strings.HasPrefix(entry.Name, namePrefix) {
+			continue
 		}
-		downloadURL = fmt.Sprintf("%s/%s", domain, fullPath)
-	} else {
-		// Use GitHub raw domain
-		downloadURL = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
-			g.owner, g.repo, g.branch, fullPath)
+		objects = append(objects, meta.ObjectInfo{Key: dir + entry.Name, Size: entry.Size})
+		if limit > 0 && len(objects) == limit {
+			break
+		}
+	}
+	return objects, nil
+}
+
+// getContents requests a file or directory from the contents API on the configured branch
+func (g *GitHubClient) getContents(ctx context.Context, fullPath string) (*http.Response, error) {
+	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s", g.owner, g.repo, fullPath, url.QueryEscape(g.branch))
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := g.setHeaders(req); err != nil {
+		return nil, err
 	}
 
-	return downloadURL, nil
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, nil
+}
+
+// commitMessage builds the commit message of an upload, with the request
+// metadata and tags as trailers
+func commitMessage(ctx context.Context, filename string) string {
+	fields := meta.FromContext(ctx).Fields()
+	if len(fields) == 0 {
+		return fmt.Sprintf("Upload %s", filename)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Upload %s\n\n", filename)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %s\n", key, fields[key])
+	}
+	return b.String()
+}
+
+// APIError is an error response of the GitHub API
+type APIError struct {
+	StatusCode int
+	Message    string // Response body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(resp.Body)
+	return &APIError{StatusCode: resp.StatusCode, Message: string(respBody)}
+}
+
+// setHeaders sets the authentication and API version headers
+func (g *GitHubClient) setHeaders(req *http.Request) error {
+	token, err := g.accessToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	return nil
+}
+
+// accessToken returns the configured token, or the current token of the GitHub App
+func (g *GitHubClient) accessToken(ctx context.Context) (string, error) {
+	if g.app == nil {
+		return g.token, nil
+	}
+	token, err := g.app.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", meta.ErrAuth, err)
+	}
+	return token, nil
+}
+
+// Close stops renewing GitHub App tokens
+func (g *GitHubClient) Close() error {
+	if g.app != nil {
+		g.app.Close()
+	}
+	return nil
 }
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxLFSFileSize is the largest file Git LFS on GitHub accepts on all plans, 2 GiB
+const maxLFSFileSize = 2 << 30
+
+// lfsAction is an action of the LFS batch API, a request to send for an object
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+// lfsObject is an object of a response of the LFS batch API
+type lfsObject struct {
+	OID     string               `json:"oid"`
+	Size    int64                `json:"size"`
+	Actions map[string]lfsAction `json:"actions"` // None if the object is stored already
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// uploadLFS stores size bytes of body in the Git LFS storage of the repository
+// and commits a pointer to it as filename
+func (g *GitHubClient) uploadLFS(ctx context.Context, body io.ReadSeeker, size int64, filename string) (string, error) {
+	if size > maxLFSFileSize {
+		return "", fmt.Errorf("file size %d exceeds the Git LFS limit of 2 GiB", size)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	oid := hex.EncodeToString(h.Sum(nil))
+
+	object, err := g.lfsBatch(ctx, oid, size)
+	if err != nil {
+		return "", err
+	}
+	if upload, ok := object.Actions["upload"]; ok {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to read data: %w", err)
+		}
+		if err := g.lfsRequest(ctx, http.MethodPut, upload, body, size); err != nil {
+			return "", err
+		}
+		if verify, ok := object.Actions["verify"]; ok {
+			reqBody := fmt.Sprintf(`{"oid":%q,"size":%d}`, oid, size)
+			if err := g.lfsRequest(ctx, http.MethodPost, verify, strings.NewReader(reqBody), int64(len(reqBody))); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size)
+	return g.upload(ctx, []byte(pointer), filename)
+}
+
+// lfsBatch requests the upload of an object from the LFS batch API
+func (g *GitHubClient) lfsBatch(ctx context.Context, oid string, size int64) (*lfsObject, error) {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := fmt.Sprintf(`{"operation":"upload","transfers":["basic"],"ref":{"name":%q},"objects":[{"oid":%q,"size":%d}]}`, "refs/heads/"+g.branch, oid, size)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://github.com/%s/%s.git/info/lfs/objects/batch", g.owner, g.repo), strings.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.SetBasicAuth("x-access-token", token)
+	req.Header.Set("Accept", "application/vnd.git-lfs+json")
+	req.Header.Set("Content-Type", "application/vnd.git-lfs+json")
+
+	var batch struct {
+		Objects []lfsObject `json:"objects"`
+	}
+	if err := g.do(req, &batch); err != nil {
+		return nil, err
+	}
+	if len(batch.Objects) != 1 {
+		return nil, fmt.Errorf("LFS batch response has %d objects", len(batch.Objects))
+	}
+	object := &batch.Objects[0]
+	if object.Error != nil {
+		return nil, &APIError{StatusCode: object.Error.Code, Message: object.Error.Message}
+	}
+	return object, nil
+}
+
+// lfsRequest sends size bytes of body to the href of an action, with its headers
+func (g *GitHubClient) lfsRequest(ctx context.Context, method string, action lfsAction, body io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, method, action.Href, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.ContentLength = size
+	for key, value := range action.Header {
+		req.Header.Set(key, value)
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	} else {
+		req.Header.Set("Accept", "application/vnd.git-lfs+json")
+		req.Header.Set("Content-Type", "application/vnd.git-lfs+json")
+	}
+	return g.do(req, nil)
+}
@@ -0,0 +1,93 @@
+package github
+
+import "time"
+
+// Option sets a field of the GitHubConfig used by New
+type Option func(*GitHubConfig)
+
+// New creates a new GitHub client from options, an alternative to NewGitHubClient
+func New(opts ...Option) (*GitHubClient, error) {
+	var cfg GitHubConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewGitHubClient(cfg)
+}
+
+// WithToken sets the personal access token
+func WithToken(token string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Token = token
+	}
+}
+
+// WithRepo sets the repository owner and name
+func WithRepo(owner, repo string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Owner = owner
+		cfg.Repo = repo
+	}
+}
+
+// WithBranch sets the branch, main by default
+func WithBranch(branch string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Branch = branch
+	}
+}
+
+// WithPath sets the directory the files are stored in, e.g. "images/"
+func WithPath(path string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Path = path
+	}
+}
+
+// WithRelease uploads the files as assets of the release with the tag instead of
+// committing them, creating the release if missing
+func WithRelease(tag string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Release = tag
+	}
+}
+
+// WithApp authenticates as a GitHub App installation instead of with a token,
+// with the app's PEM private key at privateKeyPath
+func WithApp(appID, installationID, privateKeyPath string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.AppID = appID
+		cfg.AppInstallationID = installationID
+		cfg.AppPrivateKey = privateKeyPath
+	}
+}
+
+// WithCustomDomain sets a custom domain, such as a CDN, for the download URLs
+func WithCustomDomain(domain string) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.CustomDomain = domain
+	}
+}
+
+// WithGitDataThreshold sets the size from which files are committed with the Git
+// Data API instead of the contents API, 0 to always use the contents API
+func WithGitDataThreshold(threshold int64) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.GitDataThreshold = threshold
+	}
+}
+
+// WithRetries sets the timeout of a single request and how often requests failed
+// with a server error or rate limit are retried
+func WithRetries(timeout time.Duration, maxRetries int) Option {
+	return func(cfg *GitHubConfig) {
+		cfg.Timeout = timeout
+		cfg.MaxRetries = maxRetries
+	}
+}
+
+// WithLFS stores the files in Git LFS and commits pointers to them
+func WithLFS() Option {
+	return func(cfg *GitHubConfig) {
+		cfg.LFS = true
+	}
+}
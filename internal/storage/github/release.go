@@ -0,0 +1,242 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// maxAssetSize is the largest release asset GitHub accepts, 2 GiB
+const maxAssetSize = 2 << 30
+
+// releaseAsset is an asset of a release, as returned by the releases API
+type releaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	Digest             string `json:"digest"` // "sha256:<hex>", empty for assets uploaded before GitHub computed them
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// assetName returns the name of the asset of an object key. Asset names can't
+// contain slashes, so they are replaced by dashes.
+func assetName(objectKey string) string {
+	return strings.ReplaceAll(strings.Trim(objectKey, "/"), "/", "-")
+}
+
+// ensureRelease returns the ID of the release of the configured tag, creating
+// the release on the configured branch if there is none
+func (g *GitHubClient) ensureRelease(ctx context.Context) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.releaseID != 0 {
+		return g.releaseID, nil
+	}
+
+	var release struct {
+		ID int64 `json:"id"`
+	}
+	err := g.rpc(ctx, http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", g.owner, g.repo, url.PathEscape(g.release)), nil, &release)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		err = g.rpc(ctx, http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", g.owner, g.repo), map[string]string{
+			"tag_name":         g.release,
+			"target_commitish": g.branch,
+			"name":             g.release,
+			"body":             "Files uploaded by file-store-mcp",
+		}, &release)
+	}
+	if err != nil {
+		return 0, err
+	}
+	g.releaseID = release.ID
+	return release.ID, nil
+}
+
+// uploadAsset uploads size bytes of body as a release asset and returns its
+// download URL. An existing asset of the name is replaced.
+func (g *GitHubClient) uploadAsset(ctx context.Context, body io.ReadSeeker, size int64, filename string) (string, error) {
+	releaseID, err := g.ensureRelease(ctx)
+	if err != nil {
+		return "", err
+	}
+	name := assetName(filename)
+
+	existing, err := g.findAsset(ctx, releaseID, name)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		// A retry of an upload that went through finds the asset already there,
+		// return it instead of uploading again
+		if meta.FromContext(ctx).IdempotencyKey != "" && existing.Digest != "" {
+			h := sha256.New()
+			if _, err := io.Copy(h, body); err != nil {
+				return "", fmt.Errorf("failed to read data: %w", err)
+			}
+			if existing.Digest == "sha256:"+hex.EncodeToString(h.Sum(nil)) {
+				return existing.BrowserDownloadURL, nil
+			}
+			if _, err := body.Seek(0, io.SeekStart); err != nil {
+				return "", err
+			}
+		}
+		if err := g.rpc(ctx, http.MethodDelete, fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/assets/%d", g.owner, g.repo, existing.ID), nil, nil); err != nil {
+			return "", err
+		}
+	}
+
+	uploadURL := fmt.Sprintf("https://uploads.github.com/repos/%s/%s/releases/%d/assets?name=%s", g.owner, g.repo, releaseID, url.QueryEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.ContentLength = size
+	if err := g.setHeaders(req); err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var asset releaseAsset
+	if err := g.do(req, &asset); err != nil {
+		return "", err
+	}
+	return asset.BrowserDownloadURL, nil
+}
+
+// assets returns the assets of the release, or only the first one match accepts
+// if match is set
+func (g *GitHubClient) assets(ctx context.Context, releaseID int64, match func(releaseAsset) bool) ([]releaseAsset, error) {
+	var assets []releaseAsset
+	for page := 1; ; page++ {
+		var batch []releaseAsset
+		apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/%d/assets?per_page=100&page=%d", g.owner, g.repo, releaseID, page)
+		if err := g.rpc(ctx, http.MethodGet, apiURL, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, asset := range batch {
+			if match != nil && match(asset) {
+				return []releaseAsset{asset}, nil
+			}
+			if match == nil {
+				assets = append(assets, asset)
+			}
+		}
+		if len(batch) < 100 {
+			return assets, nil
+		}
+	}
+}
+
+// findAsset returns the asset of the release with the name, or nil if there is none
+func (g *GitHubClient) findAsset(ctx context.Context, releaseID int64, name string) (*releaseAsset, error) {
+	found, err := g.assets(ctx, releaseID, func(asset releaseAsset) bool { return asset.Name == name })
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+// deleteAsset removes the release asset of an object key
+func (g *GitHubClient) deleteAsset(ctx context.Context, objectKey string) error {
+	releaseID, err := g.ensureRelease(ctx)
+	if err != nil {
+		return err
+	}
+	asset, err := g.findAsset(ctx, releaseID, assetName(objectKey))
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("no asset %s in release %s", assetName(objectKey), g.release)}
+	}
+	return g.rpc(ctx, http.MethodDelete, fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/assets/%d", g.owner, g.repo, asset.ID), nil, nil)
+}
+
+// assetExists reports whether the release has the asset of an object key
+func (g *GitHubClient) assetExists(ctx context.Context, objectKey string) (bool, error) {
+	releaseID, err := g.ensureRelease(ctx)
+	if err != nil {
+		return false, err
+	}
+	asset, err := g.findAsset(ctx, releaseID, assetName(objectKey))
+	return asset != nil, err
+}
+
+// listAssets returns up to limit assets whose name starts with the asset name of prefix
+func (g *GitHubClient) listAssets(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	releaseID, err := g.ensureRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	namePrefix := assetName(prefix)
+	all, err := g.assets(ctx, releaseID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []meta.ObjectInfo
+	for _, asset := range all {
+		if !strings.HasPrefix(asset.Name, namePrefix) {
+			continue
+		}
+		objects = append(objects, meta.ObjectInfo{Key: asset.Name, Size: asset.Size})
+		if limit > 0 && len(objects) == limit {
+			break
+		}
+	}
+	return objects, nil
+}
+
+// rpc sends an API request with arg as the JSON body unless it is nil, decoding
+// the response into result unless it is nil
+func (g *GitHubClient) rpc(ctx context.Context, method string, apiURL string, arg interface{}, result interface{}) error {
+	var body io.Reader
+	if arg != nil {
+		reqBody, err := json.Marshal(arg)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request body: %w", err)
+		}
+		body = strings.NewReader(string(reqBody))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := g.setHeaders(req); err != nil {
+		return err
+	}
+	if arg != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return g.do(req, result)
+}
+
+// do sends a request with its headers set, decoding the response into result unless it is nil
+func (g *GitHubClient) do(req *http.Request, result interface{}) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
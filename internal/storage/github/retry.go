@@ -0,0 +1,82 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryWait is the longest a retry waits, longer rate limits fail the request
+const maxRetryWait = time.Minute
+
+// retryTransport retries requests that failed with a server error or hit a rate
+// limit, waiting as long as the Retry-After or rate limit reset headers say, or
+// backing off exponentially. Requests whose body can't be replayed, e.g. streamed
+// uploads, are sent once.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxRetries || req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		wait, retry := retryWait(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryWait returns how long to wait before retrying a request that got resp or
+// failed with err, and whether to retry it at all
+func retryWait(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := time.Second << attempt
+	if err != nil {
+		return backoff, true
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"):
+		// Secondary rate limits, and primary ones once the quota is used up
+	default:
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		backoff = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			backoff = time.Until(time.Unix(reset, 0)) + time.Second
+		}
+	}
+	if backoff > maxRetryWait {
+		return 0, false
+	}
+	return max(backoff, 0), true
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IdempotencyKey derives the key identifying an upload of content with the given
+// SHA-256 checksum to target, e.g. the backend and requested file name, with the
+// metadata stored with the object, e.g. its tags. Retries of the same upload get
+// the same key, so they reuse the object key of the first attempt.
+func IdempotencyKey(sha256Sum string, target string, metadata map[string]string) string {
+	h := sha256.New()
+	h.Write([]byte(sha256Sum + "\x00" + target))
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.Write([]byte("\x00" + key + "=" + metadata[key]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyEntry is an upload attempted under an idempotency key. Its lock is
+// held while uploading, so concurrent duplicates wait for the first to finish.
+type idempotencyEntry struct {
+	mu        sync.Mutex
+	key       string
+	objectKey string        // Object key chosen by the first attempt
+	result    *UploadResult // Set once an attempt succeeded
+	expires   time.Time
+}
+
+// idempotencyCache remembers the uploads of the last window by idempotency key
+type idempotencyCache struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+// newIdempotencyCache creates a cache, or returns nil if window is zero
+func newIdempotencyCache(window time.Duration) *idempotencyCache {
+	if window <= 0 {
+		return nil
+	}
+	return &idempotencyCache{
+		window:  window,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// acquire returns the locked entry of key, creating it with objectKey if there is
+// none. It returns nil if the cache is disabled or key is empty; release the
+// entry when done.
+func (c *idempotencyCache) acquire(key string, objectKey string) *idempotencyEntry {
+	if c == nil || key == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &idempotencyEntry{key: key, objectKey: objectKey, expires: now.Add(c.window)}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	return entry
+}
+
+// forget drops the entries of uploads to objectKey, e.g. once the object was
+// deleted, so uploading the same content again creates a new object
+func (c *idempotencyCache) forget(objectKey string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.objectKey == objectKey {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// release unlocks the entry, recording result if the upload succeeded
+func (e *idempotencyEntry) release(result *UploadResult) {
+	if e == nil {
+		return
+	}
+	if result != nil && e.result == nil {
+		copied := *result
+		e.result = &copied
+	}
+	e.mu.Unlock()
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Limits describes the limits of a storage backend
+type Limits = meta.Limits
+
+// LimitsReporter is implemented by storages that know their limits
+type LimitsReporter interface {
+	Limits() Limits
+}
+
+// initFailer is implemented by storages standing in for a backend that may have
+// failed to initialize
+type initFailer interface {
+	InitError() error
+}
+
+// Info describes the configured storage, so callers can choose how to upload
+type Info struct {
+	Backend string `json:"backend"`        // Storage type
+	Name    string `json:"name,omitempty"` // Human-readable backend name
+	Ready   bool   `json:"ready"`          // Whether the storage initialized
+	Error   string `json:"error,omitempty"`
+	// Largest file a single upload accepts, in bytes; zero if unknown
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+	URLsExpire  bool  `json:"urls_expire"`
+	// Validity of the URLs returned by uploads, in seconds
+	URLExpiration int64        `json:"url_expiration_seconds,omitempty"`
+	CustomDomain  bool         `json:"custom_domain"`
+	Capabilities  Capabilities `json:"capabilities"`
+	// Deleting and overwriting objects is disabled
+	NoDelete bool `json:"no_delete,omitempty"`
+}
+
+// InfoOf describes storage, initialized for storageType
+func InfoOf(storageType string, storage Storage) Info {
+	info := Info{
+		Backend: strings.ToLower(storageType),
+		Ready:   true,
+	}
+	if backend, ok := LookupBackend(info.Backend); ok {
+		info.Name = backend.Name
+	}
+	if failed, ok := storage.(initFailer); ok {
+		if err := failed.InitError(); err != nil {
+			info.Ready = false
+			info.Error = err.Error()
+			return info
+		}
+	}
+
+	if reporter, ok := storage.(LimitsReporter); ok {
+		limits := reporter.Limits()
+		info.MaxFileSize = limits.MaxFileSize
+		info.CustomDomain = limits.CustomDomain
+	}
+	if expirer, ok := storage.(URLExpirer); ok {
+		if expiration := expirer.URLExpiration(); expiration > 0 {
+			info.URLsExpire = true
+			info.URLExpiration = int64(expiration.Seconds())
+		}
+	}
+	info.Capabilities = CapabilitiesOf(storage)
+	return info
+}
+
+// Info describes the configured storage and its limits
+func (s *Service) Info() Info {
+	info := InfoOf(s.Config.StorageType, s.Storage)
+	info.NoDelete = s.Config.NoDelete
+	info.Capabilities.Delete = info.Capabilities.Delete && !s.Config.NoDelete
+	return info
+}
+
+// Describe summarizes the limits, e.g. "max file size 5.0 GB, URLs expire after 7 days, no custom domain"
+func (i Info) Describe() string {
+	if !i.Ready {
+		return fmt.Sprintf("not ready: %s", i.Error)
+	}
+
+	parts := []string{"max file size unknown"}
+	if i.MaxFileSize > 0 {
+		parts[0] = fmt.Sprintf("max file size %s", util.FormatSize(i.MaxFileSize))
+	}
+	if i.URLsExpire {
+		parts = append(parts, fmt.Sprintf("URLs expire after %s", formatExpiration(time.Duration(i.URLExpiration)*time.Second)))
+	} else {
+		parts = append(parts, "URLs don't expire")
+	}
+	if i.CustomDomain {
+		parts = append(parts, "custom domain")
+	} else {
+		parts = append(parts, "no custom domain")
+	}
+	if i.NoDelete {
+		parts = append(parts, "no-delete mode")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatExpiration formats whole days as such, e.g. "7 days", and other durations like "1h30m0s"
+func formatExpiration(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d == day:
+		return "1 day"
+	case d > day && d%day == 0:
+		return fmt.Sprintf("%d days", d/day)
+	default:
+		return d.String()
+	}
+}
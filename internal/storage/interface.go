@@ -4,17 +4,33 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/sjzar/file-store-mcp/internal/storage/artifactory"
+	"github.com/sjzar/file-store-mcp/internal/storage/bos"
+	"github.com/sjzar/file-store-mcp/internal/storage/box"
+	"github.com/sjzar/file-store-mcp/internal/storage/cloudinary"
 	"github.com/sjzar/file-store-mcp/internal/storage/cos"
+	"github.com/sjzar/file-store-mcp/internal/storage/dropbox"
 	"github.com/sjzar/file-store-mcp/internal/storage/empty"
+	"github.com/sjzar/file-store-mcp/internal/storage/firebase"
 	"github.com/sjzar/file-store-mcp/internal/storage/github"
+	"github.com/sjzar/file-store-mcp/internal/storage/ipfs"
+	"github.com/sjzar/file-store-mcp/internal/storage/memory"
+	"github.com/sjzar/file-store-mcp/internal/storage/oci"
+	"github.com/sjzar/file-store-mcp/internal/storage/onedrive"
 	"github.com/sjzar/file-store-mcp/internal/storage/oss"
+	"github.com/sjzar/file-store-mcp/internal/storage/plugin"
 	"github.com/sjzar/file-store-mcp/internal/storage/qiniu"
 	"github.com/sjzar/file-store-mcp/internal/storage/s3"
+	"github.com/sjzar/file-store-mcp/internal/storage/storj"
+	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
 // Storage defines the interface for storage services
@@ -25,12 +41,42 @@ type Storage interface {
 
 // Storage type constants
 const (
-	StorageTypeEmpty  = "empty"
-	StorageTypeS3     = "s3"
-	StorageTypeOSS    = "oss"
-	StorageTypeCOS    = "cos"
-	StorageTypeQiniu  = "qiniu"
-	StorageTypeGitHub = "github"
+	StorageTypeEmpty       = "empty"
+	StorageTypeS3          = "s3"
+	StorageTypeR2          = "r2"
+	StorageTypeOSS         = "oss"
+	StorageTypeCOS         = "cos"
+	StorageTypeQiniu       = "qiniu"
+	StorageTypeGitHub      = "github"
+	StorageTypeDropbox     = "dropbox"
+	StorageTypeOneDrive    = "onedrive"
+	StorageTypeBox         = "box"
+	StorageTypeIPFS        = "ipfs"
+	StorageTypeCloudinary  = "cloudinary"
+	StorageTypeFirebase    = "firebase"
+	StorageTypeOCI         = "oci"
+	StorageTypeBOS         = "bos"
+	StorageTypeStorj       = "storj"
+	StorageTypeArtifactory = "artifactory"
+	StorageTypePlugin      = "plugin"
+	StorageTypeExec        = "exec"
+	StorageTypeMemory      = "memory"
+)
+
+// officeConvertTimeout bounds the conversion of an office document to PDF
+const officeConvertTimeout = 2 * time.Minute
+
+// defaultWatermarkOpacity is the default opacity of watermarks
+const defaultWatermarkOpacity = 0.5
+
+// defaultThumbnailSize is the default bounding box of thumbnails in pixels
+const defaultThumbnailSize = 320
+
+// Content mismatch policy constants
+const (
+	MismatchPolicyOff    = "off"
+	MismatchPolicyWarn   = "warn"
+	MismatchPolicyReject = "reject"
 )
 
 // Config contains all configuration for storage services
@@ -38,9 +84,99 @@ type Config struct {
 	// General configuration
 	StorageType string
 
+	// Mirrors lists storage types every upload is copied to after StorageType,
+	// e.g. for redundancy or as a CDN fallback. FSM_STORAGE_TYPE sets them as a
+	// list, "s3,github" uploads to S3 and mirrors to GitHub.
+	Mirrors []string
+
+	// Failover lists storage types that uploads failing on StorageType are retried
+	// on, in order, e.g. during an outage or when the quota is used up
+	Failover []string
+
+	// Routes send uploads of some types to other storages than StorageType, e.g.
+	// images to Qiniu and PDFs to GitHub. The first matching route wins.
+	Routes []Route
+
+	// FileFormat is the object key format, e.g. "{timestamp}-{filename}{ext}" (see FormatObjectKey)
+	FileFormat string
+
+	// MimeTypes overrides extension to content type mappings, e.g. ".md" -> "text/markdown"
+	MimeTypes map[string]string
+
+	// ContentMismatch is the policy for files whose content doesn't match their extension (off, warn, reject)
+	ContentMismatch string
+
+	// AllowTypes and DenyTypes restrict the content types that may be uploaded, e.g.
+	// "image/*" or "executable". Denied types are also refused inside archives.
+	AllowTypes []string
+	DenyTypes  []string
+
+	// DenyHashes lists the SHA-256 checksums of files that must never be uploaded:
+	// comma-separated, or one per line in a file or at an http(s) URL
+	DenyHashes string
+
+	// ContentDisposition sets a Content-Disposition header with the original filename (inline or attachment)
+	// on backends that support it
+	ContentDisposition string
+
+	// AuditLog is the path of the JSONL audit log of uploads, disabled if empty
+	AuditLog string
+
+	// SlowUploadThreshold is the upload duration above which a warning is emitted, disabled if zero
+	SlowUploadThreshold time.Duration
+
+	// LargeFileThreshold is the file size in bytes above which a warning is emitted, disabled if zero
+	LargeFileThreshold int64
+
+	// UploadNotes adds slow upload and large file warnings to tool results, not just the logs
+	UploadNotes bool
+
+	// IdempotencyWindow is how long the result of a file upload is remembered, so a
+	// retry with the same content, name and tags returns it instead of uploading
+	// again. Keep it about as long as clients retry failed calls. Disabled if zero.
+	IdempotencyWindow time.Duration
+
+	// NoDelete disables deleting objects and refuses uploads that would overwrite one
+	NoDelete bool
+
+	// LazyInit defers initializing the storage until it is first used, retrying if it fails
+	LazyInit bool
+
+	// OfficeToPDF converts word processing, spreadsheet and presentation documents to PDF
+	// before upload, with OfficeConverter if set (see util.ConvertToPDF) or LibreOffice
+	OfficeToPDF     bool
+	OfficeConverter []string
+
+	// WatermarkText or WatermarkImage (the path of an image, e.g. a PNG logo) is
+	// stamped onto JPEG and PNG images before upload, at WatermarkPosition (see
+	// util.Watermark) with WatermarkOpacity between 0 and 1
+	WatermarkText     string
+	WatermarkImage    string
+	WatermarkPosition string
+	WatermarkOpacity  float64
+
+	// Thumbnails uploads a thumbnail next to each image and video file upload, scaled
+	// down to fit into ThumbnailSize x ThumbnailSize pixels. Video thumbnails need ffmpeg.
+	Thumbnails    bool
+	ThumbnailSize int
+
+	// OCR recognizes the text in uploaded images and returns it with the upload:
+	// "tesseract" runs the local command, an http(s) URL is an OCR API receiving
+	// the image (see ocrAPI). OCRUploadText also uploads it as a text file.
+	OCR           string
+	OCRLanguages  string
+	OCRAPIKey     string
+	OCRUploadText bool
+
+	// TextTranscode converts text files in other encodings (GBK, Shift-JIS, UTF-16) to UTF-8 before upload
+	TextTranscode bool
+
 	// S3 configuration
 	S3 s3.S3Config
 
+	// Cloudflare R2 configuration
+	R2 s3.R2Config
+
 	// OSS configuration
 	OSS oss.OSSConfig
 
@@ -52,47 +188,161 @@ type Config struct {
 
 	// GitHub configuration
 	GitHub github.GitHubConfig
+
+	// Dropbox configuration
+	Dropbox dropbox.DropboxConfig
+
+	// OneDrive and SharePoint configuration
+	OneDrive onedrive.OneDriveConfig
+
+	// Box configuration
+	Box box.BoxConfig
+
+	// IPFS pinning service configuration
+	IPFS ipfs.IPFSConfig
+
+	// Cloudinary configuration
+	Cloudinary cloudinary.CloudinaryConfig
+
+	// Firebase Cloud Storage configuration
+	Firebase firebase.FirebaseConfig
+
+	// Oracle OCI Object Storage configuration
+	OCI oci.OCIConfig
+
+	// Baidu Object Storage configuration
+	BOS bos.BOSConfig
+
+	// Storj DCS configuration
+	Storj storj.StorjConfig
+
+	// Artifactory and Nexus generic repository configuration
+	Artifactory artifactory.ArtifactoryConfig
+
+	// External plugin configuration
+	Plugin plugin.PluginConfig
+
+	// Per-upload command configuration
+	Exec plugin.ExecConfig
+
+	// In-memory storage configuration
+	Memory memory.MemoryConfig
+
+	// Settings of the backends registered with RegisterSettings, by storage type.
+	// They override the FSM_<TYPE>_* environment variables.
+	Settings map[string]map[string]string
 }
 
 // NewConfigFromEnv creates a new configuration from environment variables
 func NewConfigFromEnv() *Config {
+	storageType, mirrors := splitStorageTypes(getEnv("FSM_STORAGE_TYPE", StorageTypeEmpty))
 	return &Config{
-		StorageType: getEnv("FSM_STORAGE_TYPE", StorageTypeEmpty),
+		StorageType:         storageType,
+		Mirrors:             mirrors,
+		Failover:            parseTypeList(getEnv("FSM_FAILOVER", "")),
+		Routes:              parseRoutes(getEnv("FSM_ROUTES", "")),
+		FileFormat:          getEnv("FSM_FILE_FORMAT", ""),
+		MimeTypes:           util.ParseContentTypes(getEnv("FSM_MIME_TYPES", "")),
+		ContentMismatch:     getEnv("FSM_CONTENT_MISMATCH", MismatchPolicyWarn),
+		AllowTypes:          parseTypeList(getEnv("FSM_ALLOW_TYPES", "")),
+		DenyTypes:           parseTypeList(getEnv("FSM_DENY_TYPES", "")),
+		DenyHashes:          getEnv("FSM_DENY_HASHES", ""),
+		TextTranscode:       util.GetEnvBool("FSM_TEXT_TRANSCODE", false),
+		OfficeToPDF:         util.GetEnvBool("FSM_OFFICE_TO_PDF", false),
+		OfficeConverter:     strings.Fields(getEnv("FSM_OFFICE_CONVERTER", "")),
+		WatermarkText:       getEnv("FSM_WATERMARK_TEXT", ""),
+		WatermarkImage:      getEnv("FSM_WATERMARK_IMAGE", ""),
+		WatermarkPosition:   getEnv("FSM_WATERMARK_POSITION", util.WatermarkBottomRight),
+		WatermarkOpacity:    getEnvFloat("FSM_WATERMARK_OPACITY", defaultWatermarkOpacity),
+		Thumbnails:          util.GetEnvBool("FSM_THUMBNAILS", false),
+		OCR:                 getEnv("FSM_OCR", ""),
+		OCRLanguages:        getEnv("FSM_OCR_LANG", ""),
+		OCRAPIKey:           getEnv("FSM_OCR_API_KEY", ""),
+		OCRUploadText:       util.GetEnvBool("FSM_OCR_UPLOAD_TEXT", false),
+		ThumbnailSize:       int(getEnvInt64("FSM_THUMBNAIL_SIZE", defaultThumbnailSize)),
+		LazyInit:            util.GetEnvBool("FSM_LAZY_INIT", false),
+		NoDelete:            util.GetEnvBool("FSM_NO_DELETE", false),
+		ContentDisposition:  getEnv("FSM_CONTENT_DISPOSITION", ""),
+		AuditLog:            getEnv("FSM_AUDIT_LOG", ""),
+		SlowUploadThreshold: getEnvDuration("FSM_SLOW_UPLOAD_THRESHOLD", 30*time.Second),
+		LargeFileThreshold:  getEnvSize("FSM_LARGE_FILE_THRESHOLD", 100<<20), // Default 100 MB
+		UploadNotes:         util.GetEnvBool("FSM_UPLOAD_NOTES", true),
+		IdempotencyWindow:   getEnvDuration("FSM_IDEMPOTENCY_WINDOW", 0),
 		S3: s3.S3Config{
+			Provider:      getEnv("FSM_S3_PROVIDER", ""),
 			BucketName:    getEnv("FSM_S3_BUCKET", ""),
+			Prefix:        getEnv("FSM_S3_PREFIX", ""),
 			Region:        getEnv("FSM_S3_REGION", ""),
 			Endpoint:      getEnv("FSM_S3_ENDPOINT", ""),
 			AccessKeyID:   getEnv("FSM_S3_ACCESS_KEY", ""),
 			SecretKey:     getEnv("FSM_S3_SECRET_KEY", ""),
 			Session:       getEnv("FSM_S3_SESSION", ""),
 			URLExpiration: getEnvInt64("FSM_S3_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			CacheControl:  getEnv("FSM_S3_CACHE_CONTROL", ""),
+			UsePathStyle:  util.GetEnvBool("FSM_S3_FORCE_PATH_STYLE", false),
+			UseAccelerate: util.GetEnvBool("FSM_S3_USE_ACCELERATE", false),
+			RequesterPays: util.GetEnvBool("FSM_S3_REQUESTER_PAYS", false),
+			Headers:       parseHeaders(getEnv("FSM_S3_HEADERS", "")),
+			PublicDomain:  getEnv("FSM_S3_PUBLIC_URL", ""),
+			PublicBucket:  !util.GetEnvBool("FSM_S3_USE_PRESIGN", true),
+			SSE:           getEnv("FSM_S3_SSE", ""),
+			KMSKeyID:      getEnv("FSM_S3_KMS_KEY_ID", ""),
+			StorageClass:  getEnv("FSM_S3_STORAGE_CLASS", ""),
+		},
+		R2: s3.R2Config{
+			AccountID:     getEnv("FSM_R2_ACCOUNT_ID", ""),
+			AccessKeyID:   getEnv("FSM_R2_ACCESS_KEY", ""),
+			SecretKey:     getEnv("FSM_R2_SECRET_KEY", ""),
+			BucketName:    getEnv("FSM_R2_BUCKET", ""),
+			Jurisdiction:  getEnv("FSM_R2_JURISDICTION", ""),
+			PublicDomain:  getEnv("FSM_R2_PUBLIC_DOMAIN", ""),
+			URLExpiration: getEnvInt64("FSM_R2_URL_EXPIRATION", 604800), // Default 7 days (in seconds), the R2 maximum
+			CacheControl:  getEnv("FSM_R2_CACHE_CONTROL", ""),
 		},
 		OSS: oss.OSSConfig{
-			Endpoint:        getEnv("FSM_OSS_ENDPOINT", ""),
-			AccessKeyID:     getEnv("FSM_OSS_ACCESS_KEY", ""),
-			AccessKeySecret: getEnv("FSM_OSS_SECRET_KEY", ""),
-			BucketName:      getEnv("FSM_OSS_BUCKET", ""),
-			Domain:          getEnv("FSM_OSS_DOMAIN", ""),
-			URLExpiration:   getEnvInt64("FSM_OSS_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			Endpoint:           getEnv("FSM_OSS_ENDPOINT", ""),
+			PublicEndpoint:     getEnv("FSM_OSS_PUBLIC_ENDPOINT", ""),
+			AccessKeyID:        getEnv("FSM_OSS_ACCESS_KEY", ""),
+			AccessKeySecret:    getEnv("FSM_OSS_SECRET_KEY", ""),
+			BucketName:         getEnv("FSM_OSS_BUCKET", ""),
+			Domain:             getEnv("FSM_OSS_DOMAIN", ""),
+			DomainIsPublic:     util.GetEnvBool("FSM_OSS_DOMAIN_IS_PUBLIC", true),
+			URLExpiration:      getEnvInt64("FSM_OSS_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			CacheControl:       getEnv("FSM_OSS_CACHE_CONTROL", ""),
+			SecurityToken:      getEnv("FSM_OSS_STS_TOKEN", ""),
+			RoleArn:            getEnv("FSM_OSS_ROLE_ARN", ""),
+			RoleSessionName:    getEnv("FSM_OSS_ROLE_SESSION_NAME", ""),
+			RoleDuration:       getEnvDuration("FSM_OSS_ROLE_DURATION", time.Hour),
+			STSEndpoint:        getEnv("FSM_OSS_STS_ENDPOINT", ""),
+			MultipartThreshold: getEnvSize("FSM_OSS_MULTIPART_THRESHOLD", 100<<20), // Default 100 MB
+			PartSize:           getEnvSize("FSM_OSS_PART_SIZE", 10<<20),
+			CheckpointDir:      getEnv("FSM_OSS_CHECKPOINT_DIR", ""),
 		},
 		COS: cos.COSConfig{
 			BucketName:    getEnv("FSM_COS_BUCKET", ""),
+			Prefix:        getEnv("FSM_COS_PREFIX", ""),
 			Region:        getEnv("FSM_COS_REGION", ""),
 			AppID:         getEnv("FSM_COS_APP_ID", ""),
 			SecretID:      getEnv("FSM_COS_ACCESS_KEY", ""),
 			SecretKey:     getEnv("FSM_COS_SECRET_KEY", ""),
+			SessionToken:  getEnv("FSM_COS_SESSION_TOKEN", ""),
 			Domain:        getEnv("FSM_COS_DOMAIN", ""),
-			UseHTTPS:      getEnvBool("FSM_COS_USE_HTTPS", true),
-			UseAccelerate: getEnvBool("FSM_COS_USE_ACCELERATE", false),
+			UseHTTPS:      util.GetEnvBool("FSM_COS_USE_HTTPS", true),
+			UseAccelerate: util.GetEnvBool("FSM_COS_USE_ACCELERATE", false),
 			URLExpiration: getEnvInt64("FSM_COS_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			CacheControl:  getEnv("FSM_COS_CACHE_CONTROL", ""),
 		},
 		Qiniu: qiniu.QiniuConfig{
-			AccessKey:     getEnv("FSM_QINIU_ACCESS_KEY", ""),
-			SecretKey:     getEnv("FSM_QINIU_SECRET_KEY", ""),
-			BucketName:    getEnv("FSM_QINIU_BUCKET", ""),
-			Domain:        getEnv("FSM_QINIU_DOMAIN", ""),
-			Region:        getEnv("FSM_QINIU_REGION", "z0"),                // Default to East China
-			URLExpiration: getEnvInt64("FSM_QINIU_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			AccessKey:          getEnv("FSM_QINIU_ACCESS_KEY", ""),
+			SecretKey:          getEnv("FSM_QINIU_SECRET_KEY", ""),
+			BucketName:         getEnv("FSM_QINIU_BUCKET", ""),
+			Prefix:             getEnv("FSM_QINIU_PREFIX", ""),
+			Domain:             getEnv("FSM_QINIU_DOMAIN", ""),
+			Region:             getEnv("FSM_QINIU_REGION", "z0"),                // Default to East China
+			URLExpiration:      getEnvInt64("FSM_QINIU_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			Public:             !util.GetEnvBool("FSM_QINIU_PRIVATE", true),
+			MultipartThreshold: getEnvSize("FSM_QINIU_MULTIPART_THRESHOLD", 100<<20), // Default 100 MB
+			PartSize:           getEnvSize("FSM_QINIU_PART_SIZE", 8<<20),
 		},
 		GitHub: github.GitHubConfig{
 			Token:        getEnv("FSM_GITHUB_TOKEN", ""),
@@ -101,6 +351,115 @@ func NewConfigFromEnv() *Config {
 			Branch:       getEnv("FSM_GITHUB_BRANCH", "main"),
 			Path:         getEnv("FSM_GITHUB_PATH", ""),
 			CustomDomain: getEnv("FSM_GITHUB_DOMAIN", ""),
+			Release:      getEnv("FSM_GITHUB_RELEASE", ""),
+
+			GitDataThreshold: getEnvSize("FSM_GITHUB_GIT_DATA_THRESHOLD", 10<<20), // Default 10 MB
+			LFS:              util.GetEnvBool("FSM_GITHUB_LFS", false),
+			Timeout:          getEnvDuration("FSM_GITHUB_TIMEOUT", 10*time.Minute),
+			MaxRetries:       int(getEnvInt64("FSM_GITHUB_MAX_RETRIES", 3)),
+
+			AppID:             getEnv("FSM_GITHUB_APP_ID", ""),
+			AppInstallationID: getEnv("FSM_GITHUB_APP_INSTALLATION_ID", ""),
+			AppPrivateKey:     getEnv("FSM_GITHUB_APP_PRIVATE_KEY", ""),
+		},
+		Dropbox: dropbox.DropboxConfig{
+			Token:        getEnv("FSM_DROPBOX_TOKEN", ""),
+			Folder:       getEnv("FSM_DROPBOX_FOLDER", ""),
+			RefreshToken: getEnv("FSM_DROPBOX_REFRESH_TOKEN", ""),
+			AppKey:       getEnv("FSM_DROPBOX_APP_KEY", ""),
+			AppSecret:    getEnv("FSM_DROPBOX_APP_SECRET", ""),
+		},
+		OneDrive: onedrive.OneDriveConfig{
+			DriveID:      getEnv("FSM_ONEDRIVE_DRIVE_ID", ""),
+			SiteID:       getEnv("FSM_ONEDRIVE_SITE_ID", ""),
+			Folder:       getEnv("FSM_ONEDRIVE_FOLDER", ""),
+			Token:        getEnv("FSM_ONEDRIVE_TOKEN", ""),
+			TenantID:     getEnv("FSM_ONEDRIVE_TENANT_ID", "common"),
+			ClientID:     getEnv("FSM_ONEDRIVE_CLIENT_ID", ""),
+			ClientSecret: getEnv("FSM_ONEDRIVE_CLIENT_SECRET", ""),
+			RefreshToken: getEnv("FSM_ONEDRIVE_REFRESH_TOKEN", ""),
+			LinkScope:    getEnv("FSM_ONEDRIVE_LINK_SCOPE", onedrive.LinkScopeAnonymous),
+		},
+		Box: box.BoxConfig{
+			Token:          getEnv("FSM_BOX_TOKEN", ""),
+			ConfigFile:     getEnv("FSM_BOX_CONFIG", ""),
+			ClientID:       getEnv("FSM_BOX_CLIENT_ID", ""),
+			ClientSecret:   getEnv("FSM_BOX_CLIENT_SECRET", ""),
+			EnterpriseID:   getEnv("FSM_BOX_ENTERPRISE_ID", ""),
+			UserID:         getEnv("FSM_BOX_USER_ID", ""),
+			FolderID:       getEnv("FSM_BOX_FOLDER_ID", "0"),
+			LinkAccess:     getEnv("FSM_BOX_LINK_ACCESS", box.LinkAccessOpen),
+			LinkExpiration: getEnvInt64("FSM_BOX_LINK_EXPIRATION", 0), // Default never
+		},
+		IPFS: ipfs.IPFSConfig{
+			Endpoint: getEnv("FSM_IPFS_ENDPOINT", ipfs.DefaultEndpoint),
+			Raw:      util.GetEnvBool("FSM_IPFS_RAW_UPLOAD", false),
+			Token:    getEnv("FSM_IPFS_TOKEN", ""),
+			Gateway:  getEnv("FSM_IPFS_GATEWAY", ipfs.DefaultGateway),
+		},
+		Cloudinary: cloudinary.CloudinaryConfig{
+			CloudName:      getEnv("FSM_CLOUDINARY_CLOUD_NAME", ""),
+			APIKey:         getEnv("FSM_CLOUDINARY_API_KEY", ""),
+			APISecret:      getEnv("FSM_CLOUDINARY_API_SECRET", ""),
+			UploadPreset:   getEnv("FSM_CLOUDINARY_UPLOAD_PRESET", ""),
+			Folder:         getEnv("FSM_CLOUDINARY_FOLDER", ""),
+			Transformation: getEnv("FSM_CLOUDINARY_TRANSFORMATION", ""),
+		},
+		Firebase: firebase.FirebaseConfig{
+			CredentialsFile: getEnv("FSM_FIREBASE_CREDENTIALS", getEnv("GOOGLE_APPLICATION_CREDENTIALS", "")),
+			Bucket:          getEnv("FSM_FIREBASE_BUCKET", ""),
+			Prefix:          getEnv("FSM_FIREBASE_PREFIX", ""),
+		},
+		OCI: oci.OCIConfig{
+			TenancyID:     getEnv("FSM_OCI_TENANCY", ""),
+			UserID:        getEnv("FSM_OCI_USER", ""),
+			Fingerprint:   getEnv("FSM_OCI_FINGERPRINT", ""),
+			KeyFile:       getEnv("FSM_OCI_KEY_FILE", ""),
+			Region:        getEnv("FSM_OCI_REGION", ""),
+			Namespace:     getEnv("FSM_OCI_NAMESPACE", ""),
+			Bucket:        getEnv("FSM_OCI_BUCKET", ""),
+			Endpoint:      getEnv("FSM_OCI_ENDPOINT", ""),
+			URLExpiration: getEnvInt64("FSM_OCI_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+		},
+		BOS: bos.BOSConfig{
+			AccessKey:     getEnv("FSM_BOS_ACCESS_KEY", ""),
+			SecretKey:     getEnv("FSM_BOS_SECRET_KEY", ""),
+			Bucket:        getEnv("FSM_BOS_BUCKET", ""),
+			Endpoint:      getEnv("FSM_BOS_ENDPOINT", bos.DefaultEndpoint),
+			Domain:        getEnv("FSM_BOS_DOMAIN", ""),
+			URLExpiration: getEnvInt64("FSM_BOS_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+			CacheControl:  getEnv("FSM_BOS_CACHE_CONTROL", ""),
+		},
+		Storj: storj.StorjConfig{
+			AccessGrant:   getEnv("FSM_STORJ_ACCESS_GRANT", ""),
+			Bucket:        getEnv("FSM_STORJ_BUCKET", ""),
+			Prefix:        getEnv("FSM_STORJ_PREFIX", ""),
+			AuthService:   getEnv("FSM_STORJ_AUTH_SERVICE", storj.DefaultAuthService),
+			Linkshare:     getEnv("FSM_STORJ_LINKSHARE", storj.DefaultLinkshare),
+			URLExpiration: getEnvInt64("FSM_STORJ_URL_EXPIRATION", 604800), // Default 7 days (in seconds)
+		},
+		Artifactory: artifactory.ArtifactoryConfig{
+			URL:        getEnv("FSM_ARTIFACTORY_URL", ""),
+			Repository: getEnv("FSM_ARTIFACTORY_REPOSITORY", ""),
+			Path:       getEnv("FSM_ARTIFACTORY_PATH", ""),
+			APIKey:     getEnv("FSM_ARTIFACTORY_API_KEY", ""),
+			Token:      getEnv("FSM_ARTIFACTORY_TOKEN", ""),
+			Username:   getEnv("FSM_ARTIFACTORY_USERNAME", ""),
+			Password:   getEnv("FSM_ARTIFACTORY_PASSWORD", ""),
+		},
+		Plugin: plugin.PluginConfig{
+			Command: getEnv("FSM_PLUGIN_COMMAND", ""),
+			Args:    strings.Fields(getEnv("FSM_PLUGIN_ARGS", "")),
+			Timeout: getEnvDuration("FSM_PLUGIN_TIMEOUT", 60*time.Second),
+		},
+		Exec: plugin.ExecConfig{
+			Command: getEnv("FSM_EXEC_COMMAND", ""),
+			Args:    strings.Fields(getEnv("FSM_EXEC_ARGS", "")),
+			Timeout: getEnvDuration("FSM_EXEC_TIMEOUT", 60*time.Second),
+			Stdin:   util.GetEnvBool("FSM_EXEC_STDIN", false),
+		},
+		Memory: memory.MemoryConfig{
+			BaseURL: getEnv("FSM_MEMORY_BASE_URL", "http://localhost:8080/files"),
 		},
 	}
 }
@@ -114,81 +473,259 @@ func InitStorage() Storage {
 	return NewStorage(config)
 }
 
-// NewStorage initializes a storage service based on the provided configuration
+// NewStorage initializes a storage service based on the provided configuration.
+// If initialization fails, an empty storage reporting the error on upload is returned.
 func NewStorage(config *Config) Storage {
-	// Initialize the appropriate storage service based on type
-	switch strings.ToLower(config.StorageType) {
-	case StorageTypeS3:
-		return initS3StorageWithConfig(config.S3)
-	case StorageTypeOSS:
-		return initOSSStorageWithConfig(config.OSS)
-	case StorageTypeCOS:
-		return initCOSStorageWithConfig(config.COS)
-	case StorageTypeQiniu:
-		return initQiniuStorageWithConfig(config.Qiniu)
-	case StorageTypeGitHub:
-		return initGitHubStorageWithConfig(config.GitHub)
-	case StorageTypeEmpty:
-		fallthrough
-	default:
-		log.Debug().Str("type", config.StorageType).Msg("Using empty storage")
-		return empty.New("")
+	storage := newConfiguredStorage(config)
+	if len(config.Routes) > 0 {
+		return newRouterStorage(config, storage)
+	}
+	return storage
+}
+
+// newConfiguredStorage initializes the storage of the configured type, see NewStorage
+func newConfiguredStorage(config *Config) Storage {
+	if config.LazyInit && IsRegistered(config.StorageType) {
+		log.Info().Str("type", config.StorageType).Msg("Storage will be initialized on first use")
+		return newLazyStorage(config)
+	}
+
+	storage, err := OpenStorage(config)
+	if err != nil {
+		if !IsRegistered(config.StorageType) {
+			log.Warn().Str("type", config.StorageType).Msg("No valid storage type configured, uploads will fail. Set FSM_STORAGE_TYPE")
+			return empty.New(err.Error())
+		}
+		log.Error().Err(err).Msg("Failed to initialize storage, falling back to empty storage")
+		return empty.New(err.Error())
 	}
+	return storage
+}
+
+// OpenStorage initializes the storage service of the configured type, returning
+// the error instead of falling back to an empty storage
+func OpenStorage(config *Config) (Storage, error) {
+	// Register user-defined content type mappings
+	if err := util.RegisterContentTypes(config.MimeTypes); err != nil {
+		log.Warn().Err(err).Msg("Failed to register custom MIME types")
+	}
+
+	// Redact the credentials wherever they show up in logs and errors
+	registerSecrets(config)
+
+	// Initialize the storage service registered for the type
+	factory, ok := lookupFactory(config.StorageType)
+	if !ok {
+		return nil, fmt.Errorf("no valid storage type configured (%q), set FSM_STORAGE_TYPE", config.StorageType)
+	}
+	return factory(config)
+}
+
+// registerSecrets registers the configured credentials for redaction
+func registerSecrets(config *Config) {
+	util.RegisterSecrets(
+		config.S3.AccessKeyID, config.S3.SecretKey, config.S3.Session,
+		config.R2.AccessKeyID, config.R2.SecretKey,
+		config.OSS.AccessKeyID, config.OSS.AccessKeySecret, config.OSS.SecurityToken,
+		config.COS.SecretID, config.COS.SecretKey, config.COS.SessionToken,
+		config.Qiniu.AccessKey, config.Qiniu.SecretKey,
+		config.GitHub.Token,
+		config.Dropbox.Token, config.Dropbox.RefreshToken, config.Dropbox.AppSecret,
+		config.OneDrive.Token, config.OneDrive.ClientSecret, config.OneDrive.RefreshToken,
+		config.Box.Token, config.Box.ClientSecret,
+		config.IPFS.Token,
+		config.Cloudinary.APISecret,
+		config.BOS.AccessKey, config.BOS.SecretKey,
+		config.Storj.AccessGrant,
+		config.Artifactory.APIKey, config.Artifactory.Token, config.Artifactory.Password,
+		config.OCRAPIKey,
+	)
 }
 
 // initS3StorageWithConfig initializes AWS S3 storage service with the provided configuration
-func initS3StorageWithConfig(cfg s3.S3Config) Storage {
+func initS3StorageWithConfig(cfg s3.S3Config) (Storage, error) {
 	client, err := s3.NewS3Client(cfg)
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to initialize S3 storage, falling back to empty storage")
-		return empty.New(err.Error())
+		return nil, fmt.Errorf("failed to initialize S3 storage: %w", err)
 	}
-	log.Debug().Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("S3 storage initialized")
-	return client
+	log.Info().Str("provider", cfg.Provider).Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("S3 storage initialized")
+	return client, nil
+}
+
+// initR2StorageWithConfig initializes Cloudflare R2 storage service with the provided configuration
+func initR2StorageWithConfig(cfg s3.R2Config) (Storage, error) {
+	client, err := s3.NewR2Client(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize R2 storage: %w", err)
+	}
+	log.Info().Str("bucket", cfg.BucketName).Str("public_domain", cfg.PublicDomain).Msg("R2 storage initialized")
+	return client, nil
 }
 
 // initOSSStorageWithConfig initializes Aliyun OSS storage service with the provided configuration
-func initOSSStorageWithConfig(cfg oss.OSSConfig) Storage {
+func initOSSStorageWithConfig(cfg oss.OSSConfig) (Storage, error) {
 	client, err := oss.NewOSSClient(cfg)
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to initialize Aliyun OSS storage, falling back to empty storage")
-		return empty.New(err.Error())
+		return nil, fmt.Errorf("failed to initialize Aliyun OSS storage: %w", err)
 	}
-	log.Debug().Str("bucket", cfg.BucketName).Str("endpoint", cfg.Endpoint).Msg("Aliyun OSS storage initialized")
-	return client
+	log.Info().Str("bucket", cfg.BucketName).Str("endpoint", cfg.Endpoint).Msg("Aliyun OSS storage initialized")
+	return client, nil
 }
 
 // initCOSStorageWithConfig initializes Tencent COS storage service with the provided configuration
-func initCOSStorageWithConfig(cfg cos.COSConfig) Storage {
+func initCOSStorageWithConfig(cfg cos.COSConfig) (Storage, error) {
 	client, err := cos.NewCOSClient(cfg)
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to initialize Tencent COS storage, falling back to empty storage")
-		return empty.New(err.Error())
+		return nil, fmt.Errorf("failed to initialize Tencent COS storage: %w", err)
 	}
-	log.Debug().Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("Tencent COS storage initialized")
-	return client
+	log.Info().Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("Tencent COS storage initialized")
+	return client, nil
 }
 
 // initQiniuStorageWithConfig initializes Qiniu Kodo storage service with the provided configuration
-func initQiniuStorageWithConfig(cfg qiniu.QiniuConfig) Storage {
+func initQiniuStorageWithConfig(cfg qiniu.QiniuConfig) (Storage, error) {
 	client, err := qiniu.NewQiniuClient(cfg)
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to initialize Qiniu storage, falling back to empty storage")
-		return empty.New(err.Error())
+		return nil, fmt.Errorf("failed to initialize Qiniu storage: %w", err)
 	}
-	log.Debug().Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("Qiniu storage initialized")
-	return client
+	log.Info().Str("bucket", cfg.BucketName).Str("region", cfg.Region).Msg("Qiniu storage initialized")
+	return client, nil
 }
 
 // initGitHubStorageWithConfig initializes GitHub storage service with the provided configuration
-func initGitHubStorageWithConfig(cfg github.GitHubConfig) Storage {
+func initGitHubStorageWithConfig(cfg github.GitHubConfig) (Storage, error) {
 	client, err := github.NewGitHubClient(cfg)
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to initialize GitHub storage, falling back to empty storage")
-		return empty.New(err.Error())
+		return nil, fmt.Errorf("failed to initialize GitHub storage: %w", err)
+	}
+	log.Info().Str("owner", cfg.Owner).Str("repo", cfg.Repo).Str("branch", cfg.Branch).Str("release", cfg.Release).Msg("GitHub storage initialized")
+	return client, nil
+}
+
+// initDropboxStorageWithConfig initializes Dropbox storage service with the provided configuration
+func initDropboxStorageWithConfig(cfg dropbox.DropboxConfig) (Storage, error) {
+	client, err := dropbox.NewDropboxClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Dropbox storage: %w", err)
+	}
+	log.Info().Str("folder", cfg.Folder).Msg("Dropbox storage initialized")
+	return client, nil
+}
+
+// initOneDriveStorageWithConfig initializes OneDrive storage service with the provided configuration
+func initOneDriveStorageWithConfig(cfg onedrive.OneDriveConfig) (Storage, error) {
+	client, err := onedrive.NewOneDriveClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OneDrive storage: %w", err)
+	}
+	log.Info().Str("drive", cfg.DriveID).Str("site", cfg.SiteID).Str("folder", cfg.Folder).Msg("OneDrive storage initialized")
+	return client, nil
+}
+
+// initBoxStorageWithConfig initializes Box storage service with the provided configuration
+func initBoxStorageWithConfig(cfg box.BoxConfig) (Storage, error) {
+	client, err := box.NewBoxClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Box storage: %w", err)
+	}
+	log.Info().Str("folder", cfg.FolderID).Str("link_access", cfg.LinkAccess).Msg("Box storage initialized")
+	return client, nil
+}
+
+// initIPFSStorageWithConfig initializes IPFS storage service with the provided configuration
+func initIPFSStorageWithConfig(cfg ipfs.IPFSConfig) (Storage, error) {
+	client, err := ipfs.NewIPFSClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize IPFS storage: %w", err)
+	}
+	log.Info().Str("endpoint", cfg.Endpoint).Str("gateway", cfg.Gateway).Msg("IPFS storage initialized")
+	return client, nil
+}
+
+// initCloudinaryStorageWithConfig initializes Cloudinary storage service with the provided configuration
+func initCloudinaryStorageWithConfig(cfg cloudinary.CloudinaryConfig) (Storage, error) {
+	client, err := cloudinary.NewCloudinaryClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Cloudinary storage: %w", err)
+	}
+	log.Info().Str("cloud_name", cfg.CloudName).Str("transformation", cfg.Transformation).Msg("Cloudinary storage initialized")
+	return client, nil
+}
+
+// initFirebaseStorageWithConfig initializes Firebase Cloud Storage service with the provided configuration
+func initFirebaseStorageWithConfig(cfg firebase.FirebaseConfig) (Storage, error) {
+	client, err := firebase.NewFirebaseClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Firebase storage: %w", err)
+	}
+	log.Info().Str("bucket", cfg.Bucket).Str("prefix", cfg.Prefix).Msg("Firebase storage initialized")
+	return client, nil
+}
+
+// initOCIStorageWithConfig initializes Oracle OCI Object Storage service with the provided configuration
+func initOCIStorageWithConfig(cfg oci.OCIConfig) (Storage, error) {
+	client, err := oci.NewOCIClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OCI storage: %w", err)
+	}
+	log.Info().Str("region", cfg.Region).Str("bucket", cfg.Bucket).Msg("OCI storage initialized")
+	return client, nil
+}
+
+// initBOSStorageWithConfig initializes Baidu Object Storage service with the provided configuration
+func initBOSStorageWithConfig(cfg bos.BOSConfig) (Storage, error) {
+	client, err := bos.NewBOSClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize BOS storage: %w", err)
+	}
+	log.Info().Str("endpoint", cfg.Endpoint).Str("bucket", cfg.Bucket).Msg("BOS storage initialized")
+	return client, nil
+}
+
+// initStorjStorageWithConfig initializes Storj DCS storage service with the provided configuration
+func initStorjStorageWithConfig(cfg storj.StorjConfig) (Storage, error) {
+	client, err := storj.NewStorjClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Storj storage: %w", err)
+	}
+	log.Info().Str("bucket", cfg.Bucket).Str("prefix", cfg.Prefix).Str("linkshare", cfg.Linkshare).Msg("Storj storage initialized")
+	return client, nil
+}
+
+// initArtifactoryStorageWithConfig initializes the Artifactory or Nexus generic repository storage with the provided configuration
+func initArtifactoryStorageWithConfig(cfg artifactory.ArtifactoryConfig) (Storage, error) {
+	client, err := artifactory.NewArtifactoryClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Artifactory storage: %w", err)
+	}
+	log.Info().Str("url", cfg.URL).Str("repository", cfg.Repository).Str("path", cfg.Path).Msg("Artifactory storage initialized")
+	return client, nil
+}
+
+// initPluginStorageWithConfig starts the external plugin backend with the provided configuration
+func initPluginStorageWithConfig(cfg plugin.PluginConfig) (Storage, error) {
+	client, err := plugin.NewPluginClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize plugin storage: %w", err)
 	}
-	log.Debug().Str("owner", cfg.Owner).Str("repo", cfg.Repo).Str("branch", cfg.Branch).Msg("GitHub storage initialized")
-	return client
+	log.Info().Str("command", cfg.Command).Msg("Plugin storage initialized")
+	return client, nil
+}
+
+// initExecStorageWithConfig initializes the per-upload command backend with the provided configuration
+func initExecStorageWithConfig(cfg plugin.ExecConfig) (Storage, error) {
+	client, err := plugin.NewExecClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize exec storage: %w", err)
+	}
+	log.Info().Str("command", cfg.Command).Msg("Exec storage initialized")
+	return client, nil
+}
+
+// initMemoryStorageWithConfig initializes the in-memory storage with the provided configuration
+func initMemoryStorageWithConfig(cfg memory.MemoryConfig) (Storage, error) {
+	log.Warn().Str("base_url", cfg.BaseURL).Msg("In-memory storage initialized, uploads are lost when the server exits")
+	return memory.NewMemoryStorage(cfg), nil
 }
 
 // getEnv gets an environment variable or returns a default value
@@ -200,25 +737,80 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// getEnvBool gets a boolean environment variable or returns a default value
-func getEnvBool(key string, defaultValue bool) bool {
+// getEnvInt64 gets an int64 environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
 	value := os.Getenv(key)
 	if value == "" {
 		return defaultValue
 	}
-	return strings.ToLower(value) == "true" || value == "1" || value == "yes"
+	var result int64
+	_, err := fmt.Sscanf(value, "%d", &result)
+	if err != nil {
+		return defaultValue
+	}
+	return result
 }
 
-// getEnvInt64 gets an int64 environment variable or returns a default value
-func getEnvInt64(key string, defaultValue int64) int64 {
+// getEnvFloat gets a floating point environment variable or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
 	value := os.Getenv(key)
 	if value == "" {
 		return defaultValue
 	}
-	var result int64
-	_, err := fmt.Sscanf(value, "%d", &result)
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Warn().Str("key", key).Str("value", value).Msg("Invalid number, using default")
+		return defaultValue
+	}
+	return result
+}
+
+// getEnvDuration gets a duration environment variable, e.g. "90s" or "2m", or returns a default value.
+// Plain numbers are taken as seconds.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	result, err := time.ParseDuration(value)
 	if err != nil {
+		log.Warn().Str("key", key).Str("value", value).Msg("Invalid duration, using default")
 		return defaultValue
 	}
 	return result
 }
+
+// getEnvSize gets a size environment variable, e.g. "100MB", or returns a default value
+func getEnvSize(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := util.ParseSize(value)
+	if err != nil {
+		log.Warn().Str("key", key).Str("value", value).Msg("Invalid size, using default")
+		return defaultValue
+	}
+	return result
+}
+
+// parseHeaders parses a comma-separated list of request headers, e.g.
+// "x-amz-tagging=team%3Dai,x-custom=value", skipping invalid entries
+func parseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, header := range strings.Split(value, ",") {
+		name, value, ok := strings.Cut(header, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			if header = strings.TrimSpace(header); header != "" {
+				log.Warn().Str("header", header).Msg("Ignoring invalid header, expected <name>=<value>")
+			}
+			continue
+		}
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers
+}
@@ -0,0 +1,202 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// Defaults of the pinning service and gateway
+const (
+	DefaultEndpoint = "https://api.pinata.cloud/pinning/pinFileToIPFS"
+	DefaultGateway  = "https://ipfs.io"
+)
+
+// IPFSClient uploads files to a pinning service and returns their gateway URLs
+type IPFSClient struct {
+	endpoint string
+	token    string
+	gateway  string
+	raw      bool
+	client   *http.Client // Shared by all requests to reuse connections
+}
+
+// IPFSConfig contains configuration for the IPFS pinning service client
+type IPFSConfig struct {
+	// Endpoint is the upload URL of the pinning service, which receives the file
+	// as the "file" field of a multipart form (Pinata, a Kubo node's /api/v0/add),
+	// or as the request body if Raw is set (web3.storage)
+	Endpoint string
+	Raw      bool
+	Token    string // Bearer token of the pinning service, e.g. a Pinata JWT
+
+	Gateway string // Gateway the returned URLs point to, e.g. "https://gateway.pinata.cloud"
+}
+
+// NewIPFSClient creates a new IPFS pinning service client
+func NewIPFSClient(cfg IPFSConfig) (*IPFSClient, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if cfg.Token == "" && endpoint == DefaultEndpoint {
+		return nil, fmt.Errorf("IPFS pinning service token cannot be empty")
+	}
+
+	gateway := strings.TrimSuffix(cfg.Gateway, "/")
+	if gateway == "" {
+		gateway = DefaultGateway
+	}
+	if !strings.Contains(gateway, "://") {
+		gateway = "https://" + gateway
+	}
+
+	return &IPFSClient{
+		endpoint: endpoint,
+		token:    cfg.Token,
+		gateway:  gateway,
+		raw:      cfg.Raw,
+		client:   &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// UploadFile pins a local file and returns its gateway URL
+func (c *IPFSClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	return c.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload pins data from an io.Reader and returns its gateway URL
+func (c *IPFSClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return c.upload(ctx, body, -1, filename)
+}
+
+// upload pins size bytes of body, or all of it if size is negative, named
+// filename and returns the gateway URL of its CID
+func (c *IPFSClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+
+	contentType := "application/octet-stream"
+	if !c.raw {
+		// Stream the file in a multipart body, between its part header and the closing boundary
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		if _, err := form.CreateFormFile("file", path.Base(filename)); err != nil {
+			return "", err
+		}
+		head := bytes.Clone(buf.Bytes())
+		buf.Reset()
+		form.Close()
+		body = io.MultiReader(bytes.NewReader(head), body, &buf)
+		if size >= 0 {
+			size += int64(len(head) + buf.Len())
+		}
+		contentType = form.FormDataContentType()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Name", path.Base(filename)) // Name of raw uploads on web3.storage
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+	}
+	cid, err := parseCID(respBody)
+	if err != nil {
+		return "", err
+	}
+	return c.gateway + "/ipfs/" + cid, nil
+}
+
+// parseCID returns the CID in the response of a pinning service: "IpfsHash" of
+// Pinata, "data.cid" of its v3 API, "cid" of web3.storage or "Hash" of Kubo
+func parseCID(respBody []byte) (string, error) {
+	var result struct {
+		IpfsHash string          `json:"IpfsHash"`
+		Hash     string          `json:"Hash"`
+		CID      json.RawMessage `json:"cid"`
+		Data     struct {
+			CID string `json:"cid"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	cid := result.IpfsHash
+	if cid == "" {
+		cid = result.Data.CID
+	}
+	if cid == "" {
+		cid = result.Hash
+	}
+	if cid == "" && len(result.CID) > 0 {
+		// A string, or a DAG-JSON link {"/": "..."}
+		var link struct {
+			CID string `json:"/"`
+		}
+		if json.Unmarshal(result.CID, &cid) != nil && json.Unmarshal(result.CID, &link) == nil {
+			cid = link.CID
+		}
+	}
+	if cid == "" {
+		return "", fmt.Errorf("no CID in the response of the pinning service: %s", string(respBody))
+	}
+	return cid, nil
+}
+
+// Limits reports no known limits, they depend on the pinning service and plan
+func (c *IPFSClient) Limits() meta.Limits {
+	return meta.Limits{CustomDomain: c.gateway != DefaultGateway}
+}
+
+// APIError is an error response of the pinning service
+type APIError struct {
+	StatusCode int
+	Message    string // Response body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("IPFS pinning service returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
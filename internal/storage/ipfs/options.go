@@ -0,0 +1,36 @@
+package ipfs
+
+// Option sets a field of the IPFSConfig used by New
+type Option func(*IPFSConfig)
+
+// New creates a new IPFS client from options, an alternative to NewIPFSClient
+func New(opts ...Option) (*IPFSClient, error) {
+	var cfg IPFSConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewIPFSClient(cfg)
+}
+
+// WithPinningService sets the upload URL and bearer token of the pinning service,
+// Pinata by default
+func WithPinningService(endpoint, token string) Option {
+	return func(cfg *IPFSConfig) {
+		cfg.Endpoint = endpoint
+		cfg.Token = token
+	}
+}
+
+// WithRawUpload sends the file as the request body instead of a multipart form
+func WithRawUpload() Option {
+	return func(cfg *IPFSConfig) {
+		cfg.Raw = true
+	}
+}
+
+// WithGateway sets the gateway the returned URLs point to, ipfs.io by default
+func WithGateway(gateway string) Option {
+	return func(cfg *IPFSConfig) {
+		cfg.Gateway = gateway
+	}
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// lazyRetryInterval is how long a failed initialization is reported before it is retried
+const lazyRetryInterval = 30 * time.Second
+
+// lazyStorage initializes the configured storage on first use instead of at startup,
+// so a server can start before the network or credentials are available. A failed
+// initialization is retried on a later call.
+type lazyStorage struct {
+	config *Config
+
+	mu       sync.Mutex
+	storage  Storage
+	err      error
+	failedAt time.Time
+}
+
+func newLazyStorage(config *Config) *lazyStorage {
+	return &lazyStorage{config: config}
+}
+
+// open returns the initialized storage, initializing it if needed
+func (l *lazyStorage) open() (Storage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.storage != nil {
+		return l.storage, nil
+	}
+	if l.err != nil && time.Since(l.failedAt) < lazyRetryInterval {
+		return nil, l.err
+	}
+
+	storage, err := OpenStorage(l.config)
+	if err != nil {
+		log.Error().Err(err).Str("type", l.config.StorageType).Msg("Failed to initialize storage, retrying on a later upload")
+		l.err = fmt.Errorf("storage initialization failed: %w", err)
+		l.failedAt = time.Now()
+		return nil, l.err
+	}
+	log.Info().Str("type", l.config.StorageType).Msg("Storage initialized")
+	l.storage, l.err = storage, nil
+	return storage, nil
+}
+
+// InitError initializes the storage and reports whether it failed
+func (l *lazyStorage) InitError() error {
+	_, err := l.open()
+	return err
+}
+
+func (l *lazyStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	storage, err := l.open()
+	if err != nil {
+		return "", err
+	}
+	return storage.UploadFile(ctx, path, filename)
+}
+
+func (l *lazyStorage) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	storage, err := l.open()
+	if err != nil {
+		return "", err
+	}
+	return storage.Upload(ctx, body, filename)
+}
+
+// Supports reports the optional operations of the initialized storage
+func (l *lazyStorage) Supports(operation string) bool {
+	storage, err := l.open()
+	if err != nil {
+		return false
+	}
+	capabilities := CapabilitiesOf(storage)
+	switch operation {
+	case "presign":
+		return capabilities.Presign
+	case "delete":
+		return capabilities.Delete
+	case "exists":
+		return capabilities.Exists
+	case "list":
+		return capabilities.List
+	}
+	return false
+}
+
+func (l *lazyStorage) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	storage, err := l.open()
+	if err != nil {
+		return "", err
+	}
+	return storage.(Presigner).Presign(ctx, objectKey, expiration)
+}
+
+func (l *lazyStorage) Delete(ctx context.Context, objectKey string) error {
+	storage, err := l.open()
+	if err != nil {
+		return err
+	}
+	return storage.(Deleter).Delete(ctx, objectKey)
+}
+
+func (l *lazyStorage) Exists(ctx context.Context, objectKey string) (bool, error) {
+	storage, err := l.open()
+	if err != nil {
+		return false, err
+	}
+	return storage.(Exister).Exists(ctx, objectKey)
+}
+
+func (l *lazyStorage) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	storage, err := l.open()
+	if err != nil {
+		return nil, err
+	}
+	return storage.(Lister).List(ctx, prefix, limit)
+}
+
+func (l *lazyStorage) URLExpiration() time.Duration {
+	storage, err := l.open()
+	if err != nil {
+		return 0
+	}
+	if expirer, ok := storage.(URLExpirer); ok {
+		return expirer.URLExpiration()
+	}
+	return 0
+}
+
+func (l *lazyStorage) Limits() Limits {
+	storage, err := l.open()
+	if err != nil {
+		return Limits{}
+	}
+	if reporter, ok := storage.(LimitsReporter); ok {
+		return reporter.Limits()
+	}
+	return Limits{}
+}
+
+// Close closes the storage if it was initialized, without initializing it
+func (l *lazyStorage) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if closer, ok := l.storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Object is an uploaded object held in memory
+type Object struct {
+	Key          string
+	Data         []byte
+	ContentType  string
+	OriginalName string
+	Metadata     map[string]string // Request metadata and tags, see meta.Meta.Fields
+	LastModified time.Time
+}
+
+// MemoryStorage keeps uploads in memory and serves them over HTTP, for tests and
+// trying out the server without cloud credentials. Objects are lost on exit.
+type MemoryStorage struct {
+	baseURL string
+
+	mu      sync.RWMutex
+	objects map[string]*Object
+}
+
+// MemoryConfig contains configuration for the in-memory storage
+type MemoryConfig struct {
+	BaseURL string // URL the objects are served at, e.g. "http://localhost:8080/files"
+}
+
+// NewMemoryStorage creates an empty in-memory storage
+func NewMemoryStorage(cfg MemoryConfig) *MemoryStorage {
+	return &MemoryStorage{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		objects: make(map[string]*Object),
+	}
+}
+
+// UploadFile reads a local file into memory and returns its URL
+func (m *MemoryStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return m.put(ctx, filename, data, util.GetFileContentType(path, filename)), nil
+}
+
+// Upload reads the data into memory and returns its URL
+func (m *MemoryStorage) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	contentType, body := util.PeekContentType(body, filename)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	return m.put(ctx, filename, data, contentType), nil
+}
+
+func (m *MemoryStorage) put(ctx context.Context, objectKey string, data []byte, contentType string) string {
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	md := meta.FromContext(ctx)
+	object := &Object{
+		Key:          objectKey,
+		Data:         data,
+		ContentType:  contentType,
+		OriginalName: md.OriginalName,
+		Metadata:     md.Fields(),
+		LastModified: time.Now(),
+	}
+
+	m.mu.Lock()
+	m.objects[objectKey] = object
+	m.mu.Unlock()
+	return m.url(objectKey)
+}
+
+// url builds the download URL of an object
+func (m *MemoryStorage) url(objectKey string) string {
+	return m.baseURL + "/" + (&url.URL{Path: objectKey}).EscapedPath()
+}
+
+// Presign returns the URL of an object, which doesn't expire
+func (m *MemoryStorage) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if _, ok := m.Object(objectKey); !ok {
+		return "", fmt.Errorf("object %s not found", objectKey)
+	}
+	return m.url(objectKey), nil
+}
+
+// Delete removes an object
+func (m *MemoryStorage) Delete(ctx context.Context, objectKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, objectKey)
+	return nil
+}
+
+// Exists reports whether an object exists
+func (m *MemoryStorage) Exists(ctx context.Context, objectKey string) (bool, error) {
+	_, ok := m.Object(objectKey)
+	return ok, nil
+}
+
+// List returns up to limit objects whose key starts with prefix, sorted by key
+func (m *MemoryStorage) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	objects := make([]meta.ObjectInfo, 0)
+	for _, object := range m.Objects() {
+		if !strings.HasPrefix(object.Key, prefix) {
+			continue
+		}
+		if limit > 0 && len(objects) >= limit {
+			break
+		}
+		objects = append(objects, meta.ObjectInfo{
+			Key:          object.Key,
+			Size:         int64(len(object.Data)),
+			LastModified: object.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+// Object returns a copy of the object with the given key
+func (m *MemoryStorage) Object(objectKey string) (Object, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object, ok := m.objects[objectKey]
+	if !ok {
+		return Object{}, false
+	}
+	return *object, true
+}
+
+// Objects returns copies of all objects, sorted by key
+func (m *MemoryStorage) Objects() []Object {
+	m.mu.RLock()
+	objects := make([]Object, 0, len(m.objects))
+	for _, object := range m.objects {
+		objects = append(objects, *object)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects
+}
+
+// Reset removes all objects
+func (m *MemoryStorage) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects = make(map[string]*Object)
+}
+
+// ServeHTTP serves the object named by the request path, relative to the handler's
+// mount point; use http.StripPrefix to mount it below the base URL path
+func (m *MemoryStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	object, ok := m.Object(strings.TrimPrefix(r.URL.Path, "/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", object.ContentType)
+	http.ServeContent(w, r, object.OriginalName, object.LastModified, bytes.NewReader(object.Data))
+}
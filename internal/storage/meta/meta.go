@@ -0,0 +1,112 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Meta carries per-upload metadata from the storage service to the backends
+type Meta struct {
+	// OriginalName is the human-readable file name before the object key was formatted
+	OriginalName string
+
+	// ContentDisposition is the Content-Disposition header to store with the object, if any
+	ContentDisposition string
+
+	// Request that caused the upload, empty if unknown
+	Tool      string // MCP tool name
+	Session   string // MCP session ID
+	Client    string // MCP client name and version
+	RequestID string // Correlation ID of the tool call
+
+	// Tags are user-supplied key/value pairs to store with the object
+	Tags map[string]string
+
+	// IdempotencyKey identifies the content and target of the upload across retries,
+	// empty if unknown. Backends that create more than an object, like commits, use
+	// it to avoid doing so twice.
+	IdempotencyKey string
+}
+
+// Fields returns the request metadata and tags as key/value pairs to store with
+// the object, e.g. as S3 user metadata. Request fields are prefixed with "fsm-".
+// Keys are lowercased with characters other than letters, digits, '-' and '_'
+// replaced by '-'; values that aren't printable ASCII are percent-encoded, so
+// both are safe to send as HTTP headers.
+func (m *Meta) Fields() map[string]string {
+	fields := make(map[string]string)
+	for key, value := range m.Tags {
+		if value != "" {
+			fields[headerKey(key)] = headerValue(value)
+		}
+	}
+	for key, value := range map[string]string{
+		"fsm-tool":            m.Tool,
+		"fsm-session":         m.Session,
+		"fsm-client":          m.Client,
+		"fsm-request-id":      m.RequestID,
+		"fsm-idempotency-key": m.IdempotencyKey,
+	} {
+		if value != "" {
+			fields[key] = headerValue(value)
+		}
+	}
+	return fields
+}
+
+func headerKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, key)
+}
+
+func headerValue(value string) string {
+	for _, r := range value {
+		if r < ' ' || r > '~' {
+			return url.QueryEscape(value)
+		}
+	}
+	return value
+}
+
+// ErrAuth is wrapped by backends in errors authenticating with the provider,
+// e.g. failing to get a token, so the error can point at the credentials
+var ErrAuth = errors.New("authentication failed")
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the upload metadata
+func NewContext(ctx context.Context, m *Meta) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the upload metadata carried by ctx, or an empty Meta if there is none
+func FromContext(ctx context.Context) *Meta {
+	if m, ok := ctx.Value(contextKey{}).(*Meta); ok && m != nil {
+		return m
+	}
+	return &Meta{}
+}
+
+// ObjectInfo describes a stored object, as returned by listing a storage
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified,omitzero"` // Zero if the storage doesn't report it
+}
+
+// Limits describes the limits of a storage backend
+type Limits struct {
+	MaxFileSize  int64 // Largest object a single upload accepts, in bytes; zero if unknown
+	CustomDomain bool  // Whether download URLs use a custom domain
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// Notes collects user-facing remarks about uploads, e.g. slow upload warnings,
+// so callers can pass them on alongside the upload result
+type Notes struct {
+	mu    sync.Mutex
+	notes []string
+}
+
+type notesKey struct{}
+
+// WithNotes returns a context that collects the notes of the uploads made with it
+func WithNotes(ctx context.Context) (context.Context, *Notes) {
+	n := &Notes{}
+	return context.WithValue(ctx, notesKey{}, n), n
+}
+
+// List returns the collected notes
+func (n *Notes) List() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.notes...)
+}
+
+// addNote adds a note to the collector of ctx, if any
+func addNote(ctx context.Context, note string) {
+	n, ok := ctx.Value(notesKey{}).(*Notes)
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notes = append(n.notes, note)
+}
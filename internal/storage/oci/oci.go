@@ -0,0 +1,360 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// maxUploadSize is the largest object a single PutObject request accepts, 50 GiB
+const maxUploadSize = 50 << 30
+
+// OCIClient is a wrapper for the OCI Object Storage client
+type OCIClient struct {
+	signer     *signer
+	endpoint   string
+	bucket     string
+	expiration time.Duration
+	client     *http.Client // Shared by all requests to reuse connections
+
+	mu        sync.Mutex
+	namespace string // Looked up by the first request if not configured
+}
+
+// OCIConfig contains configuration for the OCI Object Storage client
+type OCIConfig struct {
+	// API key of a user: the OCIDs of the tenancy and user, the fingerprint of the
+	// key and the path of its PEM private key
+	TenancyID   string
+	UserID      string
+	Fingerprint string
+	KeyFile     string
+
+	Region    string // e.g. "us-ashburn-1"
+	Namespace string // Object Storage namespace of the tenancy, looked up if empty
+	Bucket    string
+	Endpoint  string // Optional, defaults to https://objectstorage.<region>.oraclecloud.com
+
+	URLExpiration int64 // Seconds until the pre-authenticated request URLs expire
+}
+
+// NewOCIClient creates a new OCI Object Storage client
+func NewOCIClient(cfg OCIConfig) (*OCIClient, error) {
+	if cfg.TenancyID == "" || cfg.UserID == "" || cfg.Fingerprint == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("OCI tenancy, user, key fingerprint and key file cannot be empty")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("OCI bucket cannot be empty")
+	}
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if endpoint == "" {
+		if cfg.Region == "" {
+			return nil, fmt.Errorf("OCI region cannot be empty")
+		}
+		endpoint = "https://objectstorage." + cfg.Region + ".oraclecloud.com"
+	}
+
+	s, err := newSigner(cfg.TenancyID, cfg.UserID, cfg.Fingerprint, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default expiration if not provided
+	expiration := time.Hour * 24 * 7 // 7 days default
+	if cfg.URLExpiration > 0 {
+		expiration = time.Duration(cfg.URLExpiration) * time.Second
+	}
+
+	return &OCIClient{
+		signer:     s,
+		endpoint:   endpoint,
+		namespace:  cfg.Namespace,
+		bucket:     cfg.Bucket,
+		expiration: expiration,
+		client:     &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}, nil
+}
+
+// UploadFile uploads a local file to OCI Object Storage and returns a
+// pre-authenticated download URL
+func (o *OCIClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileInfo.Size() > maxUploadSize {
+		return "", fmt.Errorf("file size %d exceeds the OCI upload limit of 50 GiB", fileInfo.Size())
+	}
+	return o.upload(ctx, file, fileInfo.Size(), util.GetFileContentType(_path, filename), filename)
+}
+
+// Upload uploads data from an io.Reader to OCI Object Storage and returns a
+// pre-authenticated download URL. PutObject needs the size, so the data is
+// buffered in a temp file first.
+func (o *OCIClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	contentType, body := util.PeekContentType(body, filename)
+
+	tempFile, err := os.CreateTemp("", "oci-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	size, err := io.Copy(tempFile, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to buffer data: %w", err)
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return o.upload(ctx, tempFile, size, contentType, filename)
+}
+
+// upload uploads size bytes of body as filename and returns a pre-authenticated
+// download URL
+func (o *OCIClient) upload(ctx context.Context, body io.Reader, size int64, contentType string, filename string) (string, error) {
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+	objectURL, err := o.objectURL(ctx, objectKey)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	m := meta.FromContext(ctx)
+	if m.ContentDisposition != "" {
+		req.Header.Set("Content-Disposition", m.ContentDisposition)
+	}
+	// Store the request metadata and tags as user metadata (opc-meta-*)
+	for key, value := range m.Fields() {
+		req.Header.Set("Opc-Meta-"+key, value)
+	}
+	if err := o.do(req, false, nil); err != nil {
+		return "", err
+	}
+	return o.Presign(ctx, objectKey, 0)
+}
+
+// Presign creates a pre-authenticated request granting read access to an object
+// and returns its URL, valid for expiration or the configured URL expiration if
+// zero. The requests are listed in the bucket until they expire.
+func (o *OCIClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if expiration <= 0 {
+		expiration = o.expiration
+	}
+	bucketURL, err := o.bucketURL(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	arg, _ := json.Marshal(map[string]string{
+		"name":        "file-store-mcp-" + uuid.New().String(),
+		"objectName":  objectKey,
+		"accessType":  "ObjectRead",
+		"timeExpires": time.Now().Add(expiration).UTC().Format(time.RFC3339),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bucketURL+"/p/", bytes.NewReader(arg))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var par struct {
+		AccessURI string `json:"accessUri"` // Path of the URL, from the endpoint
+	}
+	if err := o.do(req, true, &par); err != nil {
+		return "", err
+	}
+	return o.endpoint + par.AccessURI, nil
+}
+
+// Limits reports the 50 GiB limit of a single PutObject request
+func (o *OCIClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxUploadSize}
+}
+
+// URLExpiration returns the validity of the pre-authenticated request URLs returned by uploads
+func (o *OCIClient) URLExpiration() time.Duration {
+	return o.expiration
+}
+
+// Delete removes an object. Its pre-authenticated requests remain until they expire.
+func (o *OCIClient) Delete(ctx context.Context, objectKey string) error {
+	return o.rpc(ctx, http.MethodDelete, objectKey, nil)
+}
+
+// Exists reports whether an object exists
+func (o *OCIClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	err := o.rpc(ctx, http.MethodHead, objectKey, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns up to limit objects whose key starts with prefix
+func (o *OCIClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	bucketURL, err := o.bucketURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"prefix": {prefix}, "fields": {"name,size,timeModified"}}
+
+	var objects []meta.ObjectInfo
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, bucketURL+"/o?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		var page struct {
+			Objects []struct {
+				Name         string    `json:"name"`
+				Size         int64     `json:"size"`
+				TimeModified time.Time `json:"timeModified"`
+			} `json:"objects"`
+			NextStartWith string `json:"nextStartWith"`
+		}
+		if err := o.do(req, false, &page); err != nil {
+			return nil, err
+		}
+
+		for _, object := range page.Objects {
+			objects = append(objects, meta.ObjectInfo{Key: object.Name, Size: object.Size, LastModified: object.TimeModified})
+			if limit > 0 && len(objects) == limit {
+				return objects, nil
+			}
+		}
+		if page.NextStartWith == "" {
+			return objects, nil
+		}
+		query.Set("start", page.NextStartWith)
+	}
+}
+
+// bucketURL returns the URL of the bucket, looking up the namespace of the
+// tenancy the first time if it isn't configured
+func (o *OCIClient) bucketURL(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.namespace == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.endpoint+"/n/", nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		var namespace string
+		if err := o.do(req, false, &namespace); err != nil {
+			return "", fmt.Errorf("failed to get OCI namespace: %w", err)
+		}
+		o.namespace = namespace
+	}
+	return o.endpoint + "/n/" + url.PathEscape(o.namespace) + "/b/" + url.PathEscape(o.bucket), nil
+}
+
+// objectURL returns the URL of an object. The object name is escaped as one
+// path segment, including its slashes.
+func (o *OCIClient) objectURL(ctx context.Context, objectKey string) (string, error) {
+	bucketURL, err := o.bucketURL(ctx)
+	if err != nil {
+		return "", err
+	}
+	return bucketURL + "/o/" + url.PathEscape(objectKey), nil
+}
+
+// rpc sends a request without a body to an object, decoding the response into
+// result unless it is nil
+func (o *OCIClient) rpc(ctx context.Context, method string, objectKey string, result interface{}) error {
+	objectURL, err := o.objectURL(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	return o.do(req, false, result)
+}
+
+// do sends a signed request, decoding the response into result unless it is nil
+func (o *OCIClient) do(req *http.Request, signBody bool, result interface{}) error {
+	if err := o.signer.sign(req, signBody); err != nil {
+		return err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// APIError is an error response of the OCI Object Storage API
+type APIError struct {
+	StatusCode int
+	Code       string // OCI error code, e.g. "BucketNotFound"
+	Message    string
+	RequestID  string // opc-request-id, for Oracle support
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("OCI Object Storage returned error (status code: %d, request ID: %s): %s", e.StatusCode, e.RequestID, e.Message)
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(respBody)),
+		RequestID:  resp.Header.Get("opc-request-id"),
+	}
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && body.Code != "" {
+		e.Code, e.Message = body.Code, body.Message
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
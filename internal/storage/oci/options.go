@@ -0,0 +1,47 @@
+package oci
+
+// Option sets a field of the OCIConfig used by New
+type Option func(*OCIConfig)
+
+// New creates a new OCI Object Storage client from options, an alternative to NewOCIClient
+func New(opts ...Option) (*OCIClient, error) {
+	var cfg OCIConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewOCIClient(cfg)
+}
+
+// WithAPIKey sets the API key of the user signing the requests
+func WithAPIKey(tenancyID, userID, fingerprint, keyFile string) Option {
+	return func(cfg *OCIConfig) {
+		cfg.TenancyID = tenancyID
+		cfg.UserID = userID
+		cfg.Fingerprint = fingerprint
+		cfg.KeyFile = keyFile
+	}
+}
+
+// WithBucket sets the region, namespace and bucket. The namespace is looked up
+// if empty.
+func WithBucket(region, namespace, bucket string) Option {
+	return func(cfg *OCIConfig) {
+		cfg.Region = region
+		cfg.Namespace = namespace
+		cfg.Bucket = bucket
+	}
+}
+
+// WithEndpoint sets a custom Object Storage endpoint
+func WithEndpoint(endpoint string) Option {
+	return func(cfg *OCIConfig) {
+		cfg.Endpoint = endpoint
+	}
+}
+
+// WithURLExpiration sets the seconds until the pre-authenticated request URLs expire
+func WithURLExpiration(seconds int64) Option {
+	return func(cfg *OCIConfig) {
+		cfg.URLExpiration = seconds
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Limits of OCR
+const (
+	ocrTimeout      = time.Minute
+	maxOCRImageSize = 20 << 20 // Larger images are not sent to an OCR API
+	maxOCRText      = 16 << 10 // Text longer than this is truncated in the upload result
+	maxOCRResponse  = 1 << 20  // Bound of the response of an OCR API
+)
+
+// OCREngineTesseract runs the local tesseract command for OCR, see Config.OCR
+const OCREngineTesseract = "tesseract"
+
+// extractText recognizes the text of an uploaded image and adds it to the upload
+// result, uploading it as a text file next to the image if enabled. Failures don't
+// fail the upload, they are left out of the result with a note.
+func (s *Service) extractText(ctx context.Context, r *uploadRecord, filename string) {
+	if s.Config.OCR == "" || r.err != nil || r.path == "" {
+		return
+	}
+	contentType := util.GetFileContentType(r.path, filename)
+	if !strings.HasPrefix(contentType, "image/") {
+		return
+	}
+
+	ocrCtx, cancel := context.WithTimeout(ctx, ocrTimeout)
+	defer cancel()
+	start := time.Now()
+	var text string
+	var err error
+	if s.Config.OCR == OCREngineTesseract {
+		text, err = util.OCRImage(ocrCtx, r.path, s.Config.OCRLanguages)
+	} else {
+		text, err = ocrAPI(ocrCtx, s.Config.OCR, s.Config.OCRAPIKey, r.path, contentType)
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", r.objectKey).Msg("Failed to extract text from image")
+		addNote(ctx, fmt.Sprintf("no OCR text: %v", util.RedactError(err)))
+		return
+	}
+	log.Ctx(ctx).Debug().Str("key", r.objectKey).Int("length", len(text)).Dur("duration", time.Since(start)).Msg("Extracted text from image")
+	if text == "" {
+		return
+	}
+
+	if s.Config.OCRUploadText {
+		key := strings.TrimSuffix(r.objectKey, filepath.Ext(r.objectKey)) + ".ocr.txt"
+		err := s.checkStorage(ctx, s.routeOf(key, "text/plain"))
+		if err == nil {
+			err = s.checkOverwrite(ctx, key)
+		}
+		if err == nil {
+			textName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".ocr.txt"
+			r.ocrTextURL, err = s.Storage.Upload(s.newUploadContext(ctx, textName, ""), strings.NewReader(text), key)
+			err = s.wrapError(OperationPut, err)
+		}
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to upload OCR text")
+			addNote(ctx, fmt.Sprintf("OCR text not uploaded: %v", err))
+			r.ocrTextURL = ""
+		}
+	}
+	r.ocrText = truncateText(text, maxOCRText)
+}
+
+// ocrAPI sends the image at path to an OCR HTTP API and returns the recognized text.
+// The API answers with the text, either as plain text or as JSON with a "text" field.
+func ocrAPI(ctx context.Context, endpoint string, apiKey string, path string, contentType string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if fileInfo, err := file.Stat(); err == nil && fileInfo.Size() > maxOCRImageSize {
+		return "", fmt.Errorf("image exceeds the OCR limit of %s", util.FormatSize(maxOCRImageSize))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, file)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", util.RedactError(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOCRResponse))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OCR API returned HTTP %d: %s", resp.StatusCode, truncateText(strings.TrimSpace(string(body)), 200))
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var result struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return "", fmt.Errorf("invalid OCR API response: %w", err)
+		}
+		return strings.TrimSpace(result.Text), nil
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// truncateText shortens text to at most limit bytes without splitting a character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	for len(text) > 0 {
+		if r, size := utf8.DecodeLastRuneInString(text); r != utf8.RuneError || size > 1 {
+			break
+		}
+		text = text[:len(text)-1]
+	}
+	return text + "…"
+}
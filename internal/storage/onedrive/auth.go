@@ -0,0 +1,94 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Scopes of the requested access tokens
+const (
+	appScope       = "https://graph.microsoft.com/.default"
+	delegatedScope = "Files.ReadWrite.All offline_access"
+)
+
+// tokenSource issues the access tokens of an app registration, which expire
+// after about an hour, and renews them when they are about to
+type tokenSource struct {
+	tenant       string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+
+	mu           sync.Mutex
+	refreshToken string // Rotated by each renewal
+	token        string
+	expires      time.Time
+}
+
+// Token returns a valid access token, renewing it if it expires within a minute
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Until(s.expires) > time.Minute {
+		return s.token, nil
+	}
+
+	// With a refresh token the app acts for the user who signed in, otherwise
+	// as itself with the client credentials
+	form := url.Values{"client_id": {s.clientID}}
+	if s.clientSecret != "" {
+		form.Set("client_secret", s.clientSecret)
+	}
+	if s.refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", s.refreshToken)
+		form.Set("scope", delegatedScope)
+	} else {
+		form.Set("grant_type", "client_credentials")
+		form.Set("scope", appScope)
+	}
+
+	tokenURL := fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", url.PathEscape(s.tenant))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Microsoft access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return "", fmt.Errorf("failed to get Microsoft access token, status code: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse Microsoft token response: %w", err)
+	}
+
+	s.token = result.AccessToken
+	s.expires = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	if s.refreshToken != "" && result.RefreshToken != "" {
+		s.refreshToken = result.RefreshToken
+	}
+	log.Debug().Time("expires", s.expires).Msg("Renewed Microsoft Graph access token")
+	return s.token, nil
+}
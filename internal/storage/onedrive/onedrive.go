@@ -0,0 +1,419 @@
+package onedrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+)
+
+// graphURL is the Microsoft Graph API endpoint
+const graphURL = "https://graph.microsoft.com/v1.0"
+
+// Upload sizes
+const (
+	simpleUploadLimit = 4 << 20   // Larger files are uploaded in an upload session
+	uploadChunkSize   = 10 << 20  // Size of the chunks of an upload session, a multiple of 320 KiB
+	maxFileSize       = 250 << 30 // Largest file OneDrive and SharePoint accept
+)
+
+// Sharing link scopes
+const (
+	LinkScopeAnonymous    = "anonymous"    // Anyone with the link
+	LinkScopeOrganization = "organization" // Anyone in the organization signed in
+)
+
+// OneDriveClient is a wrapper for the Microsoft Graph client of a OneDrive or SharePoint drive
+type OneDriveClient struct {
+	token     string
+	auth      *tokenSource // Issues the tokens instead if authenticating with the app registration
+	drive     string       // Graph URL of the drive
+	folder    string
+	linkScope string
+	client    *http.Client // Shared by all requests to reuse connections
+}
+
+// OneDriveConfig contains configuration for the OneDrive client
+type OneDriveConfig struct {
+	// Target drive: DriveID, or the document library of SiteID for SharePoint, or
+	// the drive of the signed-in user if both are empty
+	DriveID string
+	SiteID  string
+	Folder  string // Folder the files are uploaded to, e.g. "uploads"; the root if empty
+
+	// Authentication: a fixed access Token, or else the app registration (TenantID,
+	// ClientID, ClientSecret) getting tokens for the user of RefreshToken if set, or
+	// for the app itself, which needs DriveID or SiteID. ClientSecret may be empty
+	// with a refresh token of a public client.
+	Token        string
+	TenantID     string // Defaults to "common"
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+
+	LinkScope string // Scope of the sharing links, anonymous by default (see LinkScope*)
+}
+
+// NewOneDriveClient creates a new OneDrive client
+func NewOneDriveClient(cfg OneDriveConfig) (*OneDriveClient, error) {
+	if cfg.Token == "" && cfg.ClientID == "" {
+		return nil, fmt.Errorf("OneDrive access token or client ID cannot be empty")
+	}
+	if cfg.Token == "" && cfg.RefreshToken == "" && cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("OneDrive client secret or refresh token cannot be empty")
+	}
+
+	drive := graphURL + "/me/drive"
+	switch {
+	case cfg.DriveID != "":
+		drive = graphURL + "/drives/" + url.PathEscape(cfg.DriveID)
+	case cfg.SiteID != "":
+		drive = graphURL + "/sites/" + url.PathEscape(cfg.SiteID) + "/drive"
+	case cfg.Token == "" && cfg.RefreshToken == "":
+		return nil, fmt.Errorf("OneDrive drive ID or site ID is required when authenticating as the app")
+	}
+
+	linkScope := strings.ToLower(cfg.LinkScope)
+	switch linkScope {
+	case "":
+		linkScope = LinkScopeAnonymous
+	case LinkScopeAnonymous, LinkScopeOrganization:
+	default:
+		return nil, fmt.Errorf("invalid OneDrive link scope %q, expected anonymous or organization", cfg.LinkScope)
+	}
+
+	client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+	var auth *tokenSource
+	if cfg.Token == "" {
+		tenant := cfg.TenantID
+		if tenant == "" {
+			tenant = "common"
+		}
+		auth = &tokenSource{
+			tenant:       tenant,
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			refreshToken: cfg.RefreshToken,
+			client:       client,
+		}
+	}
+
+	return &OneDriveClient{
+		token:     cfg.Token,
+		auth:      auth,
+		drive:     drive,
+		folder:    strings.Trim(cfg.Folder, "/"),
+		linkScope: linkScope,
+		client:    client,
+	}, nil
+}
+
+// UploadFile uploads a local file to OneDrive and returns the sharing link
+func (o *OneDriveClient) UploadFile(ctx context.Context, _path string, filename string) (string, error) {
+	file, err := os.Open(_path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	return o.upload(ctx, file, fileInfo.Size(), filename)
+}
+
+// Upload uploads data from an io.Reader to OneDrive and returns the sharing link.
+// Data larger than a simple upload is buffered in a temp file first, since upload
+// sessions need the size.
+func (o *OneDriveClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(body, simpleUploadLimit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	if len(data) <= simpleUploadLimit {
+		return o.upload(ctx, bytes.NewReader(data), int64(len(data)), filename)
+	}
+
+	tempFile, err := os.CreateTemp("", "onedrive-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	size, err := io.Copy(tempFile, io.MultiReader(bytes.NewReader(data), body))
+	if err != nil {
+		return "", fmt.Errorf("failed to buffer data: %w", err)
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return o.upload(ctx, tempFile, size, filename)
+}
+
+// upload uploads size bytes of body as filename and returns the sharing link.
+// Existing files are replaced, like on the other backends.
+func (o *OneDriveClient) upload(ctx context.Context, body io.Reader, size int64, filename string) (string, error) {
+	if len(filename) == 0 {
+		filename = uuid.New().String()
+	}
+	if size > maxFileSize {
+		return "", fmt.Errorf("file size %d exceeds the OneDrive limit of 250 GiB", size)
+	}
+
+	itemURL := o.itemURL(filename)
+	if size <= simpleUploadLimit {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, itemURL+":/content", body)
+		if err != nil {
+			return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if err := o.do(req, nil); err != nil {
+			return "", err
+		}
+	} else if err := o.uploadSession(ctx, itemURL, body, size); err != nil {
+		return "", err
+	}
+	return o.sharingLink(ctx, itemURL)
+}
+
+// uploadSession uploads a large file in chunks through an upload session
+func (o *OneDriveClient) uploadSession(ctx context.Context, itemURL string, body io.Reader, size int64) error {
+	var session struct {
+		UploadURL string `json:"uploadUrl"`
+	}
+	arg := map[string]interface{}{"item": map[string]string{"@microsoft.graph.conflictBehavior": "replace"}}
+	if err := o.rpc(ctx, http.MethodPost, itemURL+":/createUploadSession", arg, &session); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, uploadChunkSize)
+	for offset := int64(0); offset < size; {
+		n, err := io.ReadFull(body, chunk[:min(int64(len(chunk)), size-offset)])
+		if err != nil {
+			o.cancelSession(session.UploadURL)
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		// The upload URL is pre-authenticated, it must not get the Authorization header
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, session.UploadURL, bytes.NewReader(chunk[:n]))
+		if err != nil {
+			return fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(n)-1, size))
+		resp, err := o.client.Do(req)
+		if err != nil {
+			o.cancelSession(session.UploadURL)
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err := newAPIError(resp)
+			resp.Body.Close()
+			o.cancelSession(session.UploadURL)
+			return err
+		}
+		resp.Body.Close()
+		offset += int64(n)
+	}
+	return nil
+}
+
+// cancelSession discards the chunks uploaded in a failed upload session
+func (o *OneDriveClient) cancelSession(uploadURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uploadURL, nil)
+	if err != nil {
+		return
+	}
+	if resp, err := o.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// sharingLink creates a view link of the item, or returns the existing one of
+// the configured scope
+func (o *OneDriveClient) sharingLink(ctx context.Context, itemURL string) (string, error) {
+	var permission struct {
+		Link struct {
+			WebURL string `json:"webUrl"`
+		} `json:"link"`
+	}
+	arg := map[string]string{"type": "view", "scope": o.linkScope}
+	if err := o.rpc(ctx, http.MethodPost, itemURL+":/createLink", arg, &permission); err != nil {
+		return "", err
+	}
+	return permission.Link.WebURL, nil
+}
+
+// Presign returns the sharing link of an uploaded file. Sharing links don't
+// expire, so expiration is ignored.
+func (o *OneDriveClient) Presign(ctx context.Context, objectKey string, _ time.Duration) (string, error) {
+	return o.sharingLink(ctx, o.itemURL(objectKey))
+}
+
+// Limits reports the 250 GiB limit of OneDrive files
+func (o *OneDriveClient) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: maxFileSize}
+}
+
+// Delete moves a file to the recycle bin. objectKey is the name the file was
+// uploaded with, relative to the configured folder.
+func (o *OneDriveClient) Delete(ctx context.Context, objectKey string) error {
+	return o.rpc(ctx, http.MethodDelete, o.itemURL(objectKey), nil, nil)
+}
+
+// Exists reports whether a file exists
+func (o *OneDriveClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	err := o.rpc(ctx, http.MethodGet, o.itemURL(objectKey), nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns up to limit files whose key starts with prefix. Only the folder
+// of prefix is searched, not its subfolders.
+func (o *OneDriveClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	dir, namePrefix := path.Split(prefix)
+	next := o.itemURL(dir) + ":/children?$select=name,size,file,lastModifiedDateTime"
+	if o.folder == "" && strings.Trim(dir, "/") == "" {
+		next = o.drive + "/root/children?$select=name,size,file,lastModifiedDateTime"
+	}
+
+	var objects []meta.ObjectInfo
+	for next != "" {
+		var page struct {
+			Value []struct {
+				Name         string    `json:"name"`
+				Size         int64     `json:"size"`
+				File         *struct{} `json:"file"` // Set for files, not folders
+				LastModified time.Time `json:"lastModifiedDateTime"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		err := o.rpc(ctx, http.MethodGet, next, nil, &page)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Value {
+			if item.File == nil || !strings.HasPrefix(item.Name, namePrefix) {
+				continue
+			}
+			objects = append(objects, meta.ObjectInfo{Key: dir + item.Name, Size: item.Size, LastModified: item.LastModified})
+			if limit > 0 && len(objects) == limit {
+				return objects, nil
+			}
+		}
+		next = page.NextLink
+	}
+	return objects, nil
+}
+
+// itemURL returns the Graph URL addressing the item of an object key by path
+func (o *OneDriveClient) itemURL(objectKey string) string {
+	var escaped []string
+	for _, segment := range strings.Split(path.Join(o.folder, objectKey), "/") {
+		if segment != "" {
+			escaped = append(escaped, url.PathEscape(segment))
+		}
+	}
+	return o.drive + "/root:/" + strings.Join(escaped, "/")
+}
+
+// rpc sends a Graph request with arg as the JSON body unless it is nil, decoding
+// the response into result unless it is nil
+func (o *OneDriveClient) rpc(ctx context.Context, method string, apiURL string, arg interface{}, result interface{}) error {
+	var body io.Reader
+	if arg != nil {
+		reqBody, err := json.Marshal(arg)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request body: %w", err)
+		}
+		body = bytes.NewReader(reqBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if arg != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return o.do(req, result)
+}
+
+// do sends an authenticated request, decoding the response into result unless it is nil
+func (o *OneDriveClient) do(req *http.Request, result interface{}) error {
+	token := o.token
+	if o.auth != nil {
+		var err error
+		if token, err = o.auth.Token(req.Context()); err != nil {
+			return fmt.Errorf("%w: %w", meta.ErrAuth, err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// APIError is an error response of the Microsoft Graph API
+type APIError struct {
+	StatusCode int
+	Code       string // Graph error code, e.g. "itemNotFound"
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Microsoft Graph API returned error (status code: %d): %s", e.StatusCode, e.Message)
+}
+
+// newAPIError reads the error response of a request
+func newAPIError(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(respBody, &body) == nil && body.Error.Code != "" {
+		e.Code, e.Message = body.Error.Code, body.Error.Message
+	}
+	return e
+}
@@ -0,0 +1,65 @@
+package onedrive
+
+// Option sets a field of the OneDriveConfig used by New
+type Option func(*OneDriveConfig)
+
+// New creates a new OneDrive client from options, an alternative to NewOneDriveClient
+func New(opts ...Option) (*OneDriveClient, error) {
+	var cfg OneDriveConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewOneDriveClient(cfg)
+}
+
+// WithToken sets a fixed access token instead of an app registration
+func WithToken(token string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.Token = token
+	}
+}
+
+// WithApp authenticates with an app registration, as the app itself
+func WithApp(tenantID, clientID, clientSecret string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.TenantID = tenantID
+		cfg.ClientID = clientID
+		cfg.ClientSecret = clientSecret
+	}
+}
+
+// WithRefreshToken uploads as the user who signed in to the app registration
+// instead of as the app
+func WithRefreshToken(refreshToken string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.RefreshToken = refreshToken
+	}
+}
+
+// WithDrive sets the ID of the drive the files are uploaded to
+func WithDrive(driveID string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.DriveID = driveID
+	}
+}
+
+// WithSite uploads to the document library of a SharePoint site
+func WithSite(siteID string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.SiteID = siteID
+	}
+}
+
+// WithFolder sets the folder the files are uploaded to, e.g. "uploads"
+func WithFolder(folder string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.Folder = folder
+	}
+}
+
+// WithLinkScope sets the scope of the sharing links, LinkScopeAnonymous by default
+func WithLinkScope(scope string) Option {
+	return func(cfg *OneDriveConfig) {
+		cfg.LinkScope = scope
+	}
+}
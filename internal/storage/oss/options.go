@@ -0,0 +1,93 @@
+package oss
+
+import "time"
+
+// Option sets a field of the OSSConfig used by New
+type Option func(*OSSConfig)
+
+// New creates a new OSS client from options, an alternative to NewOSSClient
+func New(opts ...Option) (*OSSClient, error) {
+	var cfg OSSConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewOSSClient(cfg)
+}
+
+// WithBucket sets the bucket name
+func WithBucket(bucket string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.BucketName = bucket
+	}
+}
+
+// WithEndpoint sets the endpoint, e.g. "oss-cn-hangzhou.aliyuncs.com"
+func WithEndpoint(endpoint string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.Endpoint = endpoint
+	}
+}
+
+// WithPublicEndpoint sets the endpoint of download URLs, if the endpoint of
+// uploads is an internal one
+func WithPublicEndpoint(endpoint string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.PublicEndpoint = endpoint
+	}
+}
+
+// WithCredentials sets the access key
+func WithCredentials(accessKeyID, accessKeySecret string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.AccessKeyID = accessKeyID
+		cfg.AccessKeySecret = accessKeySecret
+	}
+}
+
+// WithDomain sets a custom domain for the download URLs. If public, e.g. a CDN,
+// the URLs aren't signed; otherwise they are signed for the custom domain.
+func WithDomain(domain string, public bool) Option {
+	return func(cfg *OSSConfig) {
+		cfg.Domain = domain
+		cfg.DomainIsPublic = public
+	}
+}
+
+// WithPresignExpiry sets the validity of the presigned download URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *OSSConfig) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of uploaded objects
+func WithCacheControl(cacheControl string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.CacheControl = cacheControl
+	}
+}
+
+// WithMultipart uploads files of at least threshold bytes in parts of partSize
+// bytes (10 MiB if zero), resuming interrupted uploads of the same file
+func WithMultipart(threshold, partSize int64) Option {
+	return func(cfg *OSSConfig) {
+		cfg.MultipartThreshold = threshold
+		cfg.PartSize = partSize
+	}
+}
+
+// WithSecurityToken sets the security token of temporary STS credentials
+func WithSecurityToken(token string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.SecurityToken = token
+	}
+}
+
+// WithRole assumes a RAM role with the credentials, renewing its temporary
+// credentials before they expire
+func WithRole(roleArn, sessionName string) Option {
+	return func(cfg *OSSConfig) {
+		cfg.RoleArn = roleArn
+		cfg.RoleSessionName = sessionName
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/google/uuid"
 
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
 	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
@@ -19,24 +23,68 @@ type OSSClient struct {
 	bucket        *oss.Bucket
 	bucketName    string
 	endpoint      string
-	domain        string // Custom domain, if any
+	domain        string      // Custom domain with scheme, if any
+	domainPublic  bool        // The custom domain serves objects without signatures, e.g. a CDN
+	signBucket    *oss.Bucket // Signs URLs on the custom domain of a private bucket or the public endpoint
 	urlExpiration time.Duration
+	cacheControl  string // Cache-Control header for uploaded objects
+
+	// Multipart uploads of files above multipartThreshold, resumed from checkpoints in checkpointDir
+	multipartThreshold int64
+	partSize           int64
+	checkpointDir      string
 }
 
 // OSSConfig contains configuration for the OSS client
 type OSSConfig struct {
-	Endpoint        string
+	Endpoint string
+	// Optional public endpoint of download URLs if Endpoint is internal, e.g.
+	// "oss-cn-hangzhou.aliyuncs.com" for uploads to "oss-cn-hangzhou-internal.aliyuncs.com"
+	// from inside Alibaba Cloud. Derived from an internal Endpoint if empty.
+	PublicEndpoint  string
 	AccessKeyID     string
 	AccessKeySecret string
 	BucketName      string
-	Domain          string // Optional, custom domain
-	URLExpiration   int64  // URL expiration time in seconds
+	Domain          string // Optional, custom domain, https if no scheme is given
+	// The custom domain serves objects without signatures, e.g. a CDN or a public-read
+	// bucket. If false, URLs on the custom domain are signed (CNAME signing).
+	DomainIsPublic bool
+	URLExpiration  int64  // URL expiration time in seconds
+	CacheControl   string // Optional, Cache-Control header for uploaded objects
+
+	// Optional security token of temporary STS credentials in AccessKeyID and AccessKeySecret
+	SecurityToken string
+	// Optional ARN of a RAM role to assume with the AccessKey, e.g.
+	// "acs:ram::123456789012****:role/uploader". Its credentials are renewed before they expire.
+	RoleArn         string
+	RoleSessionName string        // Session name of the assumed role, "file-store-mcp" by default
+	RoleDuration    time.Duration // Validity of the role credentials, 1 hour by default
+	STSEndpoint     string        // STS endpoint, sts.aliyuncs.com by default
+
+	// Files of at least MultipartThreshold bytes are uploaded in parts of PartSize
+	// bytes, resuming interrupted uploads of the same file; disabled if zero
+	MultipartThreshold int64
+	PartSize           int64  // Part size of multipart uploads, 10 MiB by default
+	CheckpointDir      string // Directory of the checkpoints of multipart uploads, in the temp directory by default
 }
 
+const (
+	defaultPartSize = 10 << 20
+	maxParts        = 10000 // Maximum number of parts of a multipart upload
+	partRoutines    = 3     // Parts uploaded concurrently
+)
+
 // NewOSSClient creates a new OSS client
 func NewOSSClient(cfg OSSConfig) (*OSSClient, error) {
-	// Create OSS client
-	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret)
+	// Create OSS client, with temporary credentials if configured
+	var clientOptions []oss.ClientOption
+	if cfg.RoleArn != "" {
+		// Shared by the clients of the endpoint and the custom domain
+		clientOptions = append(clientOptions, oss.SetCredentialsProvider(newAssumeRoleProvider(cfg)))
+	} else if cfg.SecurityToken != "" {
+		clientOptions = append(clientOptions, oss.SecurityToken(cfg.SecurityToken))
+	}
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret, clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OSS client: %w", err)
 	}
@@ -47,22 +95,74 @@ func NewOSSClient(cfg OSSConfig) (*OSSClient, error) {
 		return nil, fmt.Errorf("failed to get OSS bucket: %w", err)
 	}
 
+	// Sign URLs of private buckets on the custom domain, which must be bound to the
+	// bucket, or else on the public endpoint if uploads use the internal one
+	domain := strings.TrimSuffix(cfg.Domain, "/")
+	if domain != "" && !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	publicEndpoint := cfg.PublicEndpoint
+	if publicEndpoint == "" {
+		publicEndpoint = strings.Replace(cfg.Endpoint, "-internal.", ".", 1)
+	}
+	var signBucket *oss.Bucket
+	if domain != "" && !cfg.DomainIsPublic {
+		signBucket, err = newSignBucket(domain, cfg, append(clientOptions, oss.UseCname(true)))
+	} else if domain == "" && publicEndpoint != cfg.Endpoint {
+		signBucket, err = newSignBucket(publicEndpoint, cfg, clientOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	// Set default expiration if not provided
 	expiration := time.Hour * 24 * 7 // 7 days default
 	if cfg.URLExpiration > 0 {
 		expiration = time.Duration(cfg.URLExpiration) * time.Second
 	}
 
+	partSize := cfg.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < oss.MinPartSize || partSize > oss.MaxPartSize {
+		return nil, fmt.Errorf("part size must be between 100 KiB and 5 GiB")
+	}
+	checkpointDir := cfg.CheckpointDir
+	if checkpointDir == "" {
+		checkpointDir = filepath.Join(os.TempDir(), "file-store-mcp", "oss-checkpoints")
+	}
+
 	return &OSSClient{
 		client:        client,
 		bucket:        bucket,
 		bucketName:    cfg.BucketName,
 		endpoint:      cfg.Endpoint,
-		domain:        cfg.Domain,
+		domain:        domain,
+		domainPublic:  cfg.DomainIsPublic,
+		signBucket:    signBucket,
 		urlExpiration: expiration,
+		cacheControl:  cfg.CacheControl,
+
+		multipartThreshold: cfg.MultipartThreshold,
+		partSize:           partSize,
+		checkpointDir:      checkpointDir,
 	}, nil
 }
 
+// newSignBucket returns the bucket of cfg on another endpoint, used to sign download URLs
+func newSignBucket(endpoint string, cfg OSSConfig, clientOptions []oss.ClientOption) (*oss.Bucket, error) {
+	client, err := oss.New(endpoint, cfg.AccessKeyID, cfg.AccessKeySecret, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OSS client for %s: %w", endpoint, err)
+	}
+	bucket, err := client.Bucket(cfg.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OSS bucket: %w", err)
+	}
+	return bucket, nil
+}
+
 // UploadFile uploads a local file to OSS and returns the download URL
 func (o *OSSClient) UploadFile(ctx context.Context, path string, filename string) (string, error) {
 	// Open the file
@@ -85,10 +185,17 @@ func (o *OSSClient) UploadFile(ctx context.Context, path string, filename string
 	}
 
 	// Set file metadata
-	options := []oss.Option{
-		oss.ContentType(util.GetContentType(filename)),
-		oss.ContentLength(fileInfo.Size()),
+	options := o.putOptions(ctx, util.GetFileContentType(path, filename))
+
+	// Upload large files in parts, which are retried on their own and resumed by
+	// a later upload of the same file if the upload is interrupted
+	if o.multipartThreshold > 0 && fileInfo.Size() >= o.multipartThreshold {
+		if err := o.uploadMultipart(ctx, objectKey, path, fileInfo.Size(), options); err != nil {
+			return "", fmt.Errorf("failed to upload file to OSS: %w", err)
+		}
+		return o.Presign(ctx, objectKey, 0)
 	}
+	options = append(options, oss.ContentLength(fileInfo.Size()))
 
 	// Upload file to OSS
 	err = o.bucket.PutObject(objectKey, file, options...)
@@ -97,32 +204,7 @@ func (o *OSSClient) UploadFile(ctx context.Context, path string, filename string
 	}
 
 	// Build the file download URL
-	var downloadURL string
-	if o.domain != "" {
-		// If custom domain is provided and we want to use it directly without signing
-		// This is useful when the bucket is configured with CDN or public read access
-		if isPublicDomain(o.domain) {
-			downloadURL = fmt.Sprintf("%s/%s", o.domain, objectKey)
-		} else {
-			// Generate signed URL with custom domain
-			signedURL, err := o.bucket.SignURL(objectKey, oss.HTTPGet, int64(o.urlExpiration.Seconds()))
-			if err != nil {
-				return "", fmt.Errorf("failed to generate signed URL: %w", err)
-			}
-			// Replace the default endpoint with custom domain in the signed URL
-			defaultEndpoint := fmt.Sprintf("https://%s.%s", o.bucketName, o.endpoint)
-			downloadURL = replaceEndpoint(signedURL, defaultEndpoint, o.domain)
-		}
-	} else {
-		// Generate signed URL with default endpoint
-		signedURL, err := o.bucket.SignURL(objectKey, oss.HTTPGet, int64(o.urlExpiration.Seconds()))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate signed URL: %w", err)
-		}
-		downloadURL = signedURL
-	}
-
-	return downloadURL, nil
+	return o.Presign(ctx, objectKey, 0)
 }
 
 // Upload uploads data from an io.Reader to OSS and returns the download URL
@@ -133,10 +215,11 @@ func (o *OSSClient) Upload(ctx context.Context, body io.Reader, filename string)
 		objectKey = uuid.New().String()
 	}
 
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+
 	// Set file metadata
-	options := []oss.Option{
-		oss.ContentType(util.GetContentType(filename)),
-	}
+	options := o.putOptions(ctx, contentType)
 
 	// Upload data to OSS
 	err := o.bucket.PutObject(objectKey, body, options...)
@@ -145,44 +228,128 @@ func (o *OSSClient) Upload(ctx context.Context, body io.Reader, filename string)
 	}
 
 	// Build the file download URL
-	var downloadURL string
-	if o.domain != "" {
-		// If custom domain is provided and we want to use it directly without signing
-		// This is useful when the bucket is configured with CDN or public read access
-		if isPublicDomain(o.domain) {
-			downloadURL = fmt.Sprintf("%s/%s", o.domain, objectKey)
-		} else {
-			// Generate signed URL with custom domain
-			signedURL, err := o.bucket.SignURL(objectKey, oss.HTTPGet, int64(o.urlExpiration.Seconds()))
-			if err != nil {
-				return "", fmt.Errorf("failed to generate signed URL: %w", err)
-			}
-			// Replace the default endpoint with custom domain in the signed URL
-			defaultEndpoint := fmt.Sprintf("https://%s.%s", o.bucketName, o.endpoint)
-			downloadURL = replaceEndpoint(signedURL, defaultEndpoint, o.domain)
-		}
-	} else {
-		// Generate signed URL with default endpoint
-		signedURL, err := o.bucket.SignURL(objectKey, oss.HTTPGet, int64(o.urlExpiration.Seconds()))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate signed URL: %w", err)
-		}
-		downloadURL = signedURL
+	return o.Presign(ctx, objectKey, 0)
+}
+
+// uploadMultipart uploads a local file in parts, recording the uploaded parts in
+// a checkpoint file so that an interrupted upload of the same file is resumed
+func (o *OSSClient) uploadMultipart(ctx context.Context, objectKey string, path string, size int64, options []oss.Option) error {
+	if err := os.MkdirAll(o.checkpointDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	// Keep within the maximum number of parts
+	partSize := max(o.partSize, (size+maxParts-1)/maxParts)
+	options = append(options,
+		oss.WithContext(ctx),
+		oss.Routines(partRoutines),
+		oss.CheckpointDir(true, o.checkpointDir),
+	)
+	return o.bucket.UploadFile(objectKey, path, partSize, options...)
+}
+
+// Presign generates a download URL for an object, valid for expiration
+// or the configured URL expiration if zero
+func (o *OSSClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if expiration <= 0 {
+		expiration = o.urlExpiration
+	}
+
+	// Public custom domains, e.g. a CDN or a public-read bucket, need no signature
+	if o.domain != "" && o.domainPublic {
+		return o.domain + "/" + (&url.URL{Path: objectKey}).EscapedPath(), nil
 	}
 
-	return downloadURL, nil
+	// Sign on the custom domain or the public endpoint if there is one, or else on the endpoint
+	bucket := o.bucket
+	if o.signBucket != nil {
+		bucket = o.signBucket
+	}
+	signedURL, err := bucket.SignURL(objectKey, oss.HTTPGet, int64(expiration.Seconds()))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+	return signedURL, nil
 }
 
-// isPublicDomain checks if a domain should be treated as public (no signing needed)
-// This can be determined by configuration or domain pattern
-func isPublicDomain(domain string) bool {
-	// For now, assume all custom domains are CDN domains that need no signing
-	// In a real implementation, this could be controlled by a config flag
-	return true
+// Limits reports the 5 GiB limit of a single PutObject request, or the 48.8 TiB
+// limit of an object with multipart uploads
+func (o *OSSClient) Limits() meta.Limits {
+	maxFileSize := int64(5 << 30)
+	if o.multipartThreshold > 0 {
+		maxFileSize = 48800 << 30
+	}
+	return meta.Limits{MaxFileSize: maxFileSize, CustomDomain: o.domain != ""}
 }
 
-// replaceEndpoint replaces the default endpoint in a signed URL with a custom domain
-func replaceEndpoint(signedURL, defaultEndpoint, customDomain string) string {
-	// Simple string replacement - in a real implementation, this might need more robust URL parsing
-	return signedURL
+// URLExpiration returns the validity of the signed URLs returned by uploads,
+// or zero for public custom domains
+func (o *OSSClient) URLExpiration() time.Duration {
+	if o.domain != "" && o.domainPublic {
+		return 0
+	}
+	return o.urlExpiration
+}
+
+// Delete removes an object from OSS
+func (o *OSSClient) Delete(ctx context.Context, objectKey string) error {
+	if err := o.bucket.DeleteObject(objectKey); err != nil {
+		return fmt.Errorf("failed to delete object from OSS: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in OSS
+func (o *OSSClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	exists, err := o.bucket.IsObjectExist(objectKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to check object in OSS: %w", err)
+	}
+	return exists, nil
+}
+
+// List returns up to limit objects whose key starts with prefix
+func (o *OSSClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	options := []oss.Option{oss.Prefix(prefix)}
+	if limit > 0 {
+		options = append(options, oss.MaxKeys(limit))
+	}
+
+	result, err := o.bucket.ListObjectsV2(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in OSS: %w", err)
+	}
+
+	objects := make([]meta.ObjectInfo, 0, len(result.Objects))
+	for _, object := range result.Objects {
+		objects = append(objects, meta.ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+// putOptions builds the object metadata options for an upload
+func (o *OSSClient) putOptions(ctx context.Context, contentType string) []oss.Option {
+	options := []oss.Option{
+		oss.ContentType(contentType),
+	}
+
+	// Set Content-Disposition so downloads get the original filename
+	if disposition := meta.FromContext(ctx).ContentDisposition; disposition != "" {
+		options = append(options, oss.ContentDisposition(disposition))
+	}
+
+	// Set Cache-Control to improve CDN behavior
+	if o.cacheControl != "" {
+		options = append(options, oss.CacheControl(o.cacheControl))
+	}
+
+	// Store the request metadata and tags as user metadata (x-oss-meta-*)
+	for key, value := range meta.FromContext(ctx).Fields() {
+		options = append(options, oss.Meta(key, value))
+	}
+
+	return options
 }
@@ -0,0 +1,205 @@
+package oss
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSTSEndpoint is the endpoint of the Alibaba Cloud STS API
+	DefaultSTSEndpoint = "sts.aliyuncs.com"
+
+	// DefaultRoleSessionName names the sessions of assumed roles in the audit logs of RAM
+	DefaultRoleSessionName = "file-store-mcp"
+
+	// credentialsRefreshMargin is how long before they expire credentials are renewed
+	credentialsRefreshMargin = 5 * time.Minute
+)
+
+// stsCredentials are temporary credentials issued by STS
+type stsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	AccessKeySecret string `json:"AccessKeySecret"`
+	SecurityToken   string `json:"SecurityToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+func (c *stsCredentials) GetAccessKeyID() string     { return c.AccessKeyID }
+func (c *stsCredentials) GetAccessKeySecret() string { return c.AccessKeySecret }
+func (c *stsCredentials) GetSecurityToken() string   { return c.SecurityToken }
+
+// STSError is an error response of the STS API
+type STSError struct {
+	StatusCode int
+	Code       string `json:"Code"`
+	Message    string `json:"Message"`
+	RequestID  string `json:"RequestId"`
+}
+
+func (e *STSError) Error() string {
+	return fmt.Sprintf("STS error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// assumeRoleProvider provides the credentials of a RAM role, assumed with the
+// AccessKey of a RAM user and renewed before they expire
+type assumeRoleProvider struct {
+	accessKeyID     string
+	accessKeySecret string
+	roleArn         string
+	sessionName     string
+	duration        time.Duration
+	endpoint        string
+	httpClient      *http.Client
+
+	mu          sync.Mutex
+	credentials *stsCredentials
+	expiration  time.Time
+}
+
+var _ oss.CredentialsProviderE = (*assumeRoleProvider)(nil)
+
+// newAssumeRoleProvider creates a provider of the credentials of the role of cfg
+func newAssumeRoleProvider(cfg OSSConfig) *assumeRoleProvider {
+	sessionName := cfg.RoleSessionName
+	if sessionName == "" {
+		sessionName = DefaultRoleSessionName
+	}
+	duration := cfg.RoleDuration
+	if duration <= 0 {
+		duration = time.Hour
+	}
+	endpoint := cfg.STSEndpoint
+	if endpoint == "" {
+		endpoint = DefaultSTSEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &assumeRoleProvider{
+		accessKeyID:     cfg.AccessKeyID,
+		accessKeySecret: cfg.AccessKeySecret,
+		roleArn:         cfg.RoleArn,
+		sessionName:     sessionName,
+		duration:        duration,
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetCredentials returns the credentials of the role, empty if they can't be assumed
+func (p *assumeRoleProvider) GetCredentials() oss.Credentials {
+	credentials, err := p.GetCredentialsE()
+	if err != nil {
+		return &stsCredentials{}
+	}
+	return credentials
+}
+
+// GetCredentialsE returns the credentials of the role, assuming it again if they
+// are about to expire
+func (p *assumeRoleProvider) GetCredentialsE() (oss.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.credentials != nil && time.Until(p.expiration) > credentialsRefreshMargin {
+		return p.credentials, nil
+	}
+	credentials, err := p.assumeRole(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume role %s: %w", p.roleArn, err)
+	}
+	expiration, err := time.Parse(time.RFC3339, credentials.Expiration)
+	if err != nil {
+		// Renew at the latest when the requested duration is over
+		expiration = time.Now().Add(p.duration)
+	}
+	p.credentials, p.expiration = credentials, expiration
+	return credentials, nil
+}
+
+// assumeRole calls the AssumeRole action of the STS API
+func (p *assumeRoleProvider) assumeRole(ctx context.Context) (*stsCredentials, error) {
+	params := map[string]string{
+		"Action":           "AssumeRole",
+		"Format":           "JSON",
+		"Version":          "2015-04-01",
+		"AccessKeyId":      p.accessKeyID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   uuid.New().String(),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"RoleArn":          p.roleArn,
+		"RoleSessionName":  p.sessionName,
+		"DurationSeconds":  strconv.Itoa(int(p.duration.Seconds())),
+	}
+	query := canonicalQuery(params)
+	mac := hmac.New(sha1.New, []byte(p.accessKeySecret+"&"))
+	mac.Write([]byte(http.MethodGet + "&" + percentEncode("/") + "&" + percentEncode(query)))
+	query += "&Signature=" + percentEncode(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/?"+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		stsErr := &STSError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(stsErr); err != nil {
+			stsErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, stsErr
+	}
+
+	var result struct {
+		Credentials stsCredentials `json:"Credentials"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	if result.Credentials.AccessKeyID == "" {
+		return nil, fmt.Errorf("STS response has no credentials")
+	}
+	return &result.Credentials, nil
+}
+
+// canonicalQuery encodes params sorted by name, as signed by RPC-style APIs
+func canonicalQuery(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, percentEncode(name)+"="+percentEncode(params[name]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// percentEncode encodes a string as RFC 3986 requires for signatures
+func percentEncode(value string) string {
+	encoded := url.QueryEscape(value)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	return strings.ReplaceAll(encoded, "%7E", "~")
+}
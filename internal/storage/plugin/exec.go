@@ -0,0 +1,182 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// ExecClient is a storage backend that runs an external command once per upload.
+// The command gets the upload as a JSON request on stdin, or the file content if
+// Stdin is set, and the request fields as environment variables; it prints a JSON
+// result with the URL on stdout. See the README for the protocol.
+type ExecClient struct {
+	command string
+	args    []string
+	timeout time.Duration
+	stdin   bool
+}
+
+// ExecConfig contains configuration for the exec backend
+type ExecConfig struct {
+	Command string        // Path of the executable
+	Args    []string      // Arguments passed to the command
+	Timeout time.Duration // Timeout of a single upload
+	Stdin   bool          // Pass the file content on stdin instead of the JSON request
+}
+
+// execResult is the JSON the command prints on stdout
+type execResult struct {
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+// NewExecClient creates a new exec backend, checking that the command can be found
+func NewExecClient(cfg ExecConfig) (*ExecClient, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("exec command cannot be empty")
+	}
+	if _, err := exec.LookPath(cfg.Command); err != nil {
+		return nil, fmt.Errorf("exec command %s not found: %w", cfg.Command, err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &ExecClient{
+		command: cfg.Command,
+		args:    cfg.Args,
+		timeout: timeout,
+		stdin:   cfg.Stdin,
+	}, nil
+}
+
+// UploadFile runs the command for the local file and returns the URL it prints
+func (e *ExecClient) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	m := meta.FromContext(ctx)
+	params := uploadParams{
+		Path:               path,
+		ObjectKey:          objectKey,
+		ContentType:        util.GetFileContentType(path, filename),
+		ContentDisposition: m.ContentDisposition,
+		OriginalName:       m.OriginalName,
+		Metadata:           m.Fields(),
+		IdempotencyKey:     m.IdempotencyKey,
+	}
+	request, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize exec request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.command, e.args...)
+	// The command inherits the environment to read its own settings
+	cmd.Env = append(os.Environ(),
+		"FSM_EXEC_PATH="+params.Path,
+		"FSM_EXEC_OBJECT_KEY="+params.ObjectKey,
+		"FSM_EXEC_CONTENT_TYPE="+params.ContentType,
+		"FSM_EXEC_ORIGINAL_NAME="+params.OriginalName,
+		"FSM_EXEC_REQUEST="+string(request),
+	)
+	if e.stdin {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+		cmd.Stdin = file
+	} else {
+		cmd.Stdin = bytes.NewReader(request)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &limitedBuffer{buf: &stderr, limit: 4096})
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("exec command: %w", ctx.Err())
+	}
+	result, parseErr := parseExecResult(stdout.Bytes())
+	switch {
+	case result.Error != "":
+		return "", fmt.Errorf("exec command: %s", result.Error)
+	case runErr != nil:
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && stderr.Len() > 0 {
+			return "", fmt.Errorf("exec command failed (%v): %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("exec command failed: %w", runErr)
+	case parseErr != nil:
+		return "", parseErr
+	}
+	return result.URL, nil
+}
+
+// Upload writes the data to a temporary file for the command and returns the URL it prints
+func (e *ExecClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	tempFile, err := os.CreateTemp("", "exec-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = io.Copy(tempFile, body)
+	tempFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	return e.UploadFile(ctx, tempFile.Name(), filename)
+}
+
+// parseExecResult parses the stdout of the command: a JSON object with the url
+// or an error, or just the URL on the last line for simple scripts
+func parseExecResult(stdout []byte) (execResult, error) {
+	var result execResult
+	output := strings.TrimSpace(string(stdout))
+	if strings.HasPrefix(output, "{") {
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			return result, fmt.Errorf("invalid exec command output: %w", err)
+		}
+	} else if i := strings.LastIndexByte(output, '\n'); i >= 0 {
+		result.URL = strings.TrimSpace(output[i+1:])
+	} else {
+		result.URL = output
+	}
+	if result.URL == "" && result.Error == "" {
+		return result, fmt.Errorf("exec command printed no URL")
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it, e.g. of a command's stderr
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		b.buf.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
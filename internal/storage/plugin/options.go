@@ -0,0 +1,29 @@
+package plugin
+
+import "time"
+
+// Option sets a field of the PluginConfig used by New
+type Option func(*PluginConfig)
+
+// New starts the plugin command with options, an alternative to NewPluginClient
+func New(command string, opts ...Option) (*PluginClient, error) {
+	cfg := PluginConfig{Command: command}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewPluginClient(cfg)
+}
+
+// WithArgs sets the arguments passed to the plugin
+func WithArgs(args ...string) Option {
+	return func(cfg *PluginConfig) {
+		cfg.Args = args
+	}
+}
+
+// WithTimeout sets the timeout of a single request, 60 seconds by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *PluginConfig) {
+		cfg.Timeout = timeout
+	}
+}
@@ -0,0 +1,344 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// ProtocolVersion is the version of the plugin protocol, checked in the handshake
+const ProtocolVersion = 1
+
+// PluginClient is a storage backend implemented by an external executable.
+// Requests and responses are JSON lines on the plugin's stdin and stdout; see
+// the README for the protocol.
+type PluginClient struct {
+	command string
+	args    []string
+	timeout time.Duration
+
+	mu           sync.Mutex // Serializes requests, the plugin handles one at a time
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdout       *bufio.Reader
+	nextID       int64
+	capabilities map[string]bool
+	maxFileSize  int64
+}
+
+// PluginConfig contains configuration for the plugin backend
+type PluginConfig struct {
+	Command string        // Path of the plugin executable
+	Args    []string      // Arguments passed to the plugin
+	Timeout time.Duration // Timeout of a single request, e.g. an upload
+}
+
+type request struct {
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type handshakeResult struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+	MaxFileSize     int64    `json:"max_file_size,omitempty"`
+}
+
+type uploadParams struct {
+	Path               string            `json:"path"`
+	ObjectKey          string            `json:"object_key"`
+	ContentType        string            `json:"content_type"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	OriginalName       string            `json:"original_name,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"` // Request metadata and tags
+	IdempotencyKey     string            `json:"idempotency_key,omitempty"`
+}
+
+type objectParams struct {
+	ObjectKey         string `json:"object_key"`
+	ExpirationSeconds int64  `json:"expiration_seconds,omitempty"`
+}
+
+type listParams struct {
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// NewPluginClient starts the plugin and performs the handshake
+func NewPluginClient(cfg PluginConfig) (*PluginClient, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("plugin command cannot be empty")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	p := &PluginClient{
+		command: cfg.Command,
+		args:    cfg.Args,
+		timeout: timeout,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// start launches the plugin process and performs the handshake. The caller holds p.mu.
+func (p *PluginClient) start() error {
+	cmd := exec.Command(p.command, p.args...)
+	// The plugin inherits the environment to read its own settings, and logs to our stderr
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.command, err)
+	}
+
+	p.cmd, p.stdin, p.stdout = cmd, stdin, bufio.NewReader(stdout)
+
+	var result handshakeResult
+	if err := p.roundTrip(context.Background(), "handshake", map[string]int{"protocol_version": ProtocolVersion}, &result); err != nil {
+		p.stop()
+		return fmt.Errorf("plugin handshake failed: %w", err)
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		p.stop()
+		return fmt.Errorf("plugin speaks protocol version %d, expected %d", result.ProtocolVersion, ProtocolVersion)
+	}
+
+	p.maxFileSize = result.MaxFileSize
+	p.capabilities = make(map[string]bool, len(result.Capabilities))
+	for _, capability := range result.Capabilities {
+		p.capabilities[capability] = true
+	}
+	log.Debug().Str("command", p.command).Int("pid", cmd.Process.Pid).Strs("capabilities", result.Capabilities).Msg("Plugin started")
+	return nil
+}
+
+// stop terminates the plugin process. The caller holds p.mu.
+func (p *PluginClient) stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		p.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		p.cmd.Process.Kill()
+		<-done
+	}
+	p.cmd = nil
+}
+
+// Close stops the plugin
+func (p *PluginClient) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop()
+	return nil
+}
+
+// Supports reports whether the plugin declared the optional operation in the handshake
+func (p *PluginClient) Supports(operation string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.capabilities[operation]
+}
+
+// Limits reports the max file size the plugin declared in the handshake
+func (p *PluginClient) Limits() meta.Limits {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return meta.Limits{MaxFileSize: p.maxFileSize}
+}
+
+// call sends a request to the plugin, restarting it if it exited, and decodes the result
+func (p *PluginClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return err
+		}
+	}
+	return p.roundTrip(ctx, method, params, result)
+}
+
+// roundTrip writes one request and reads its response. The plugin is stopped if
+// it doesn't answer in time or the stream breaks, and restarted by the next call.
+func (p *PluginClient) roundTrip(ctx context.Context, method string, params interface{}, result interface{}) error {
+	p.nextID++
+	data, err := json.Marshal(request{ID: p.nextID, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to serialize plugin request: %w", err)
+	}
+
+	type lineResult struct {
+		line []byte
+		err  error
+	}
+	done := make(chan lineResult, 1)
+	go func() {
+		if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+			done <- lineResult{err: err}
+			return
+		}
+		line, err := p.stdout.ReadBytes('\n')
+		done <- lineResult{line, err}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var res lineResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		p.cmd.Process.Kill()
+		p.stop()
+		<-done
+		return fmt.Errorf("plugin %s request: %w", method, ctx.Err())
+	}
+	if res.err != nil {
+		p.stop()
+		return fmt.Errorf("plugin %s request failed, the plugin may have exited: %w", method, res.err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(res.line, &resp); err != nil {
+		p.stop()
+		return fmt.Errorf("invalid plugin response: %w", err)
+	}
+	if resp.ID != p.nextID {
+		p.stop()
+		return fmt.Errorf("plugin answered request %d, expected %d", resp.ID, p.nextID)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("plugin: %s", resp.Error)
+	}
+	if result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("invalid plugin %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// UploadFile passes the local file to the plugin and returns the download URL
+func (p *PluginClient) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	m := meta.FromContext(ctx)
+	var result struct {
+		URL string `json:"url"`
+	}
+	err := p.call(ctx, "upload", uploadParams{
+		Path:               path,
+		ObjectKey:          objectKey,
+		ContentType:        util.GetFileContentType(path, filename),
+		ContentDisposition: m.ContentDisposition,
+		OriginalName:       m.OriginalName,
+		Metadata:           m.Fields(),
+		IdempotencyKey:     m.IdempotencyKey,
+	}, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file with plugin: %w", err)
+	}
+	return result.URL, nil
+}
+
+// Upload writes the data to a temporary file for the plugin and returns the download URL
+func (p *PluginClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	tempFile, err := os.CreateTemp("", "plugin-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = io.Copy(tempFile, body)
+	tempFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+	return p.UploadFile(ctx, tempFile.Name(), filename)
+}
+
+// Presign asks the plugin for a download URL valid for expiration, or its default if zero
+func (p *PluginClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	var result struct {
+		URL string `json:"url"`
+	}
+	err := p.call(ctx, "presign", objectParams{ObjectKey: objectKey, ExpirationSeconds: int64(expiration.Seconds())}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.URL, nil
+}
+
+// Delete asks the plugin to remove an object
+func (p *PluginClient) Delete(ctx context.Context, objectKey string) error {
+	return p.call(ctx, "delete", objectParams{ObjectKey: objectKey}, nil)
+}
+
+// Exists asks the plugin whether an object exists
+func (p *PluginClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	var result struct {
+		Exists bool `json:"exists"`
+	}
+	if err := p.call(ctx, "exists", objectParams{ObjectKey: objectKey}, &result); err != nil {
+		return false, err
+	}
+	return result.Exists, nil
+}
+
+// List asks the plugin for up to limit objects whose key starts with prefix
+func (p *PluginClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	var result struct {
+		Objects []meta.ObjectInfo `json:"objects"`
+	}
+	if err := p.call(ctx, "list", listParams{Prefix: prefix, Limit: limit}, &result); err != nil {
+		return nil, err
+	}
+	return result.Objects, nil
+}
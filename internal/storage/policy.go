@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// maxArchiveEntries is the number of archive entries checked against the deny list
+const maxArchiveEntries = 10000
+
+// typePolicy blocks uploads by content type, whichever tool they come from.
+// Patterns are media types, wildcards like "image/*" or "executable".
+type typePolicy struct {
+	allow []string
+	deny  []string
+}
+
+// newTypePolicy returns the policy for the allowed and denied types, or nil if
+// neither is configured
+func newTypePolicy(allow []string, deny []string) *typePolicy {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	return &typePolicy{allow: allow, deny: deny}
+}
+
+// checkFile checks the local file at path uploaded as filename. The content type
+// sniffed from the content and the one implied by the extension must both pass,
+// and archives must not contain denied files.
+func (p *typePolicy) checkFile(path string, filename string) error {
+	if p == nil {
+		return nil
+	}
+	sniffed, err := util.SniffFileContentType(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := p.check(filename, sniffed); err != nil {
+		return err
+	}
+	if len(p.deny) == 0 {
+		return nil
+	}
+
+	// Archives that can't be read are judged by their own type only
+	entries, _ := util.ArchiveEntries(path, maxArchiveEntries)
+	for _, entry := range entries {
+		if pattern := match(p.deny, entry.ContentType); pattern != "" {
+			return fmt.Errorf("upload refused: %s contains %s (%s), which is blocked by FSM_DENY_TYPES (%s)",
+				filename, entry.Name, entry.ContentType, pattern)
+		}
+	}
+	return nil
+}
+
+// checkArchived checks files added to an archive named filename like the entries
+// of uploaded archives, for archives that can't be inspected once written
+func (p *typePolicy) checkArchived(filename string, files []util.DirFile) error {
+	if p == nil || len(p.deny) == 0 {
+		return nil
+	}
+	for _, file := range files {
+		entry, err := util.FileArchiveEntry(file.Path, file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		if pattern := match(p.deny, entry.ContentType); pattern != "" {
+			return fmt.Errorf("upload refused: %s contains %s (%s), which is blocked by FSM_DENY_TYPES (%s)",
+				filename, entry.Name, entry.ContentType, pattern)
+		}
+	}
+	return nil
+}
+
+// checkReader checks data uploaded as filename by its leading bytes and extension.
+// The returned reader yields the full data. Archives aren't inspected.
+func (p *typePolicy) checkReader(r io.Reader, filename string) (io.Reader, error) {
+	if p == nil {
+		return r, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	head = head[:n]
+	body := io.MultiReader(bytes.NewReader(head), r)
+
+	// Rewind seekable readers so backends keep a seekable body
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err == nil {
+			body = r
+		}
+	}
+	return body, p.check(filename, util.SniffContentType(head))
+}
+
+// check checks the sniffed content type and the one implied by the extension of filename
+func (p *typePolicy) check(filename string, sniffed string) error {
+	byName := util.GetContentType(filename)
+	for _, contentType := range []string{sniffed, byName} {
+		if pattern := match(p.deny, contentType); pattern != "" {
+			return fmt.Errorf("upload refused: %s is %s, which is blocked by FSM_DENY_TYPES (%s)", filename, contentType, pattern)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+
+	// The sniffed type is authoritative, the extension only counts if the content gives no signal
+	contentType := sniffed
+	if contentType == "" || strings.HasPrefix(contentType, "text/plain") {
+		contentType = byName
+	}
+	if match(p.allow, contentType) == "" {
+		return fmt.Errorf("upload refused: %s is %s, which is not allowed by FSM_ALLOW_TYPES (%s)",
+			filename, contentType, strings.Join(p.allow, ", "))
+	}
+	return nil
+}
+
+// match returns the first pattern matching contentType, or an empty string if none does
+func match(patterns []string, contentType string) string {
+	for _, pattern := range patterns {
+		if util.MatchContentType(pattern, contentType) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// parseTypeList parses a comma-separated list of content type patterns
+func parseTypeList(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+func TestServicePolicyBeforeConversion(t *testing.T) {
+	const docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	path := filepath.Join(t.TempDir(), "report.docx")
+	if err := os.WriteFile(path, []byte("document"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		err   string
+	}{
+		{name: "denied document", deny: []string{docx}, err: "blocked by FSM_DENY_TYPES"},
+		{name: "denied PDF", deny: []string{"application/pdf"}, err: "blocked by FSM_DENY_TYPES"},
+		{name: "allowed PDF only", allow: []string{"application/pdf"}, err: "FSM_ALLOW_TYPES"},
+		{name: "allowed", deny: []string{"image/*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &recordingStorage{}
+			config := &Config{StorageType: "test", OfficeToPDF: true, OfficeConverter: testConverter(t), AllowTypes: tt.allow, DenyTypes: tt.deny}
+			result, err := NewServiceWithStorage(config, backend).UploadFileDetailed(context.Background(), path, "report.docx")
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasSuffix(result.ObjectKey, ".pdf") {
+					t.Errorf("object key = %s, want the converted PDF", result.ObjectKey)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("UploadFileDetailed() = %v, want an error containing %q", err, tt.err)
+			}
+			if n := backend.uploads.Load(); n != 0 {
+				t.Errorf("%d refused uploads reached the storage", n)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckArchived(t *testing.T) {
+	dir := t.TempDir()
+	var files []util.DirFile
+	for name, content := range map[string]string{"notes.txt": "notes", "tool.sh": "#!/bin/sh\necho hi\n"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, util.DirFile{Name: name, Path: path})
+	}
+
+	s := NewServiceWithStorage(&Config{StorageType: "test", DenyTypes: []string{"executable"}}, &recordingStorage{})
+	if err := s.CheckArchivedFiles("dir.zip", files); err == nil || !strings.Contains(err.Error(), "tool.sh") {
+		t.Errorf("CheckArchivedFiles() = %v, want the script refused", err)
+	}
+	if err := s.CheckArchivedFiles("dir.zip", files[:0]); err != nil {
+		t.Errorf("CheckArchivedFiles() without files = %v", err)
+	}
+
+	// The allowlist applies to the archive, not to the files in it
+	s = NewServiceWithStorage(&Config{StorageType: "test", AllowTypes: []string{"application/zip"}}, &recordingStorage{})
+	if err := s.CheckArchivedFiles("dir.zip", files); err != nil {
+		t.Errorf("CheckArchivedFiles() with an allowlist = %v", err)
+	}
+}
@@ -3,25 +3,47 @@ package qiniu
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
 	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
 // QiniuClient is a wrapper for the Qiniu cloud storage client
 type QiniuClient struct {
-	accessKey  string
-	secretKey  string
 	bucketName string
+	prefix     string // Key prefix of uploaded objects, ends with "/" if set
 	domain     string
-	region     string
 	expiration time.Duration // URL expiration time
+	public     bool          // Return permanent public URLs instead of private URLs
+
+	// Built once and shared by all requests to reuse connections
+	mac           *qbox.Mac
+	uploader      *storage.FormUploader
+	bucketManager *storage.BucketManager
+
+	// Files of at least multipartThreshold bytes are uploaded in parts of partSize
+	// bytes, recording the progress of files in recorder
+	resumeUploader     *storage.ResumeUploaderV2
+	multipartThreshold int64
+	partSize           int64
+	recorder           storage.Recorder
+
+	// Upload token for all keys under the prefix, renewed before it expires
+	mu          sync.Mutex
+	upToken     string
+	upTokenTime time.Time // When the upload token expires
 }
 
 // QiniuConfig contains configuration for the Qiniu cloud storage client
@@ -29,11 +51,28 @@ type QiniuConfig struct {
 	AccessKey     string
 	SecretKey     string
 	BucketName    string
+	Prefix        string // Optional, key prefix of uploaded objects, e.g. "mcp-uploads/"
 	Domain        string // Required, Qiniu requires a custom domain for access
 	Region        string // Storage region, e.g. "z0"(East China), "z1"(North China), "z2"(South China), "na0"(North America), "as0"(Southeast Asia)
 	URLExpiration int64  // URL expiration time in seconds
+	Public        bool   // The bucket is public, return permanent URLs instead of private URLs
+
+	// Files of at least MultipartThreshold bytes are uploaded in parts of PartSize
+	// bytes with the resumable uploader, disabled if zero
+	MultipartThreshold int64
+	PartSize           int64 // Part size of resumable uploads, 8 MiB by default
 }
 
+const (
+	defaultPartSize = 8 << 20
+	maxParts        = 10000 // Maximum number of parts of a resumable upload
+
+	// Upload tokens are valid for two hours and renewed when less than an hour
+	// is left, so that each upload can take at least an hour
+	upTokenLifetime    = 2 * time.Hour
+	upTokenMinValidity = time.Hour
+)
+
 // NewQiniuClient creates a new Qiniu cloud storage client
 func NewQiniuClient(cfg QiniuConfig) (*QiniuClient, error) {
 	if cfg.AccessKey == "" || cfg.SecretKey == "" {
@@ -63,13 +102,43 @@ func NewQiniuClient(cfg QiniuConfig) (*QiniuClient, error) {
 		expiration = time.Duration(cfg.URLExpiration) * time.Second
 	}
 
+	prefix := strings.TrimPrefix(cfg.Prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	partSize := cfg.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < 1<<20 || partSize > 1<<30 {
+		return nil, fmt.Errorf("part size must be between 1 MiB and 1 GiB")
+	}
+
+	// Record the progress of resumable uploads, so that an interrupted upload of
+	// the same file resumes where it stopped
+	recorder, err := storage.NewFileRecorder(filepath.Join(os.TempDir(), "file-store-mcp", "qiniu-checkpoints"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload recorder: %w", err)
+	}
+
+	mac := qbox.NewMac(cfg.AccessKey, cfg.SecretKey)
+	storageConfig := newConfig(cfg.Region)
+
 	return &QiniuClient{
-		accessKey:  cfg.AccessKey,
-		secretKey:  cfg.SecretKey,
-		bucketName: cfg.BucketName,
-		domain:     domain,
-		region:     cfg.Region,
-		expiration: expiration,
+		bucketName:    cfg.BucketName,
+		prefix:        prefix,
+		domain:        domain,
+		expiration:    expiration,
+		public:        cfg.Public,
+		mac:           mac,
+		uploader:      storage.NewFormUploader(storageConfig),
+		bucketManager: storage.NewBucketManager(mac, storageConfig),
+
+		resumeUploader:     storage.NewResumeUploaderV2(storageConfig),
+		multipartThreshold: cfg.MultipartThreshold,
+		partSize:           partSize,
+		recorder:           recorder,
 	}, nil
 }
 
@@ -81,62 +150,36 @@ func (q *QiniuClient) UploadFile(ctx context.Context, path string, filename stri
 		objectKey = uuid.New().String()
 	}
 
-	// Create authentication information
-	mac := qbox.NewMac(q.accessKey, q.secretKey)
-
-	// Create storage configuration
-	cfg := storage.Config{}
-
-	// Set storage region
-	switch q.region {
-	case "z0":
-		cfg.Zone = &storage.ZoneHuadong
-	case "z1":
-		cfg.Zone = &storage.ZoneHuabei
-	case "z2":
-		cfg.Zone = &storage.ZoneHuanan
-	case "na0":
-		cfg.Zone = &storage.ZoneBeimei
-	case "as0":
-		cfg.Zone = &storage.ZoneXinjiapo
-	default:
-		// Default to East China region
-		cfg.Zone = &storage.ZoneHuadong
-	}
+	ret := storage.PutRet{}
 
-	// Use HTTPS
-	cfg.UseHTTPS = true
-	// Use CDN acceleration
-	cfg.UseCdnDomains = true
+	upToken := q.uploadToken()
 
-	// Create form uploader object
-	formUploader := storage.NewFormUploader(&cfg)
-	ret := storage.PutRet{}
+	contentType := util.GetFileContentType(path, filename)
 
-	// Create upload policy
-	putPolicy := storage.PutPolicy{
-		Scope: q.bucketName + ":" + objectKey,
+	// Upload large files in parts, which are retried on their own instead of
+	// restarting a form upload that may time out
+	if info, err := os.Stat(path); err == nil && q.multipartThreshold > 0 && info.Size() >= q.multipartThreshold {
+		err := q.resumeUploader.PutFile(ctx, &ret, upToken, q.prefix+objectKey, path, q.resumeExtra(ctx, filename, contentType, info.Size()))
+		if err != nil {
+			return "", fmt.Errorf("failed to upload file to Qiniu cloud: %w", err)
+		}
+		return q.downloadURL(ctx, objectKey)
 	}
-	upToken := putPolicy.UploadToken(mac)
 
 	// Create upload options
 	putExtra := storage.PutExtra{
-		Params: map[string]string{
-			"x:name": filename,
-		},
-		MimeType: util.GetContentType(filename),
+		Params:   putParams(ctx, filename),
+		MimeType: contentType,
 	}
 
 	// Upload file
-	err := formUploader.PutFile(ctx, &ret, upToken, objectKey, path, &putExtra)
+	err := q.uploader.PutFile(ctx, &ret, upToken, q.prefix+objectKey, path, &putExtra)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to Qiniu cloud: %w", err)
 	}
 
-	// Build file download URL with authentication
-	downloadURL := storage.MakePrivateURL(mac, q.domain, ret.Key, time.Now().Add(q.expiration).Unix())
-
-	return downloadURL, nil
+	// Build file download URL
+	return q.downloadURL(ctx, objectKey)
 }
 
 // Upload uploads data from an io.Reader to Qiniu cloud and returns the download URL
@@ -147,14 +190,181 @@ func (q *QiniuClient) Upload(ctx context.Context, body io.Reader, filename strin
 		objectKey = uuid.New().String()
 	}
 
-	// Create authentication information
-	mac := qbox.NewMac(q.accessKey, q.secretKey)
+	ret := storage.PutRet{}
+
+	upToken := q.uploadToken()
+
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+
+	// Create upload options
+	putExtra := storage.PutExtra{
+		Params:   putParams(ctx, filename),
+		MimeType: contentType,
+	}
+
+	// Read the data up to the resumable upload threshold
+	var data []byte
+	var err error
+	if q.multipartThreshold > 0 {
+		data, err = io.ReadAll(io.LimitReader(body, q.multipartThreshold))
+	} else {
+		data, err = io.ReadAll(body)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read data: %w", err)
+	}
+
+	// Stream larger data in parts instead of buffering all of it
+	if q.multipartThreshold > 0 && int64(len(data)) == q.multipartThreshold {
+		body = io.MultiReader(bytes.NewReader(data), body)
+		err := q.resumeUploader.PutWithoutSize(ctx, &ret, upToken, q.prefix+objectKey, body, q.resumeExtra(ctx, filename, contentType, 0))
+		if err != nil {
+			return "", fmt.Errorf("failed to upload data to Qiniu cloud: %w", err)
+		}
+		return q.downloadURL(ctx, objectKey)
+	}
+
+	// Upload data
+	err = q.uploader.Put(ctx, &ret, upToken, q.prefix+objectKey, bytes.NewReader(data), int64(len(data)), &putExtra)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload data to Qiniu cloud: %w", err)
+	}
+
+	// Build file download URL
+	return q.downloadURL(ctx, objectKey)
+}
 
-	// Create storage configuration
+// Limits reports the 1 GiB limit of a form upload, or the limit of the parts of
+// resumable uploads. Qiniu always serves files from the domain bound to the bucket.
+func (q *QiniuClient) Limits() meta.Limits {
+	maxFileSize := int64(1 << 30)
+	if q.multipartThreshold > 0 {
+		maxFileSize = maxParts * q.partSize
+	}
+	return meta.Limits{MaxFileSize: maxFileSize, CustomDomain: true}
+}
+
+// URLExpiration returns the validity of the private download URLs returned by uploads,
+// or zero for public buckets
+func (q *QiniuClient) URLExpiration() time.Duration {
+	if q.public {
+		return 0
+	}
+	return q.expiration
+}
+
+// Delete removes an object from the Qiniu bucket
+func (q *QiniuClient) Delete(ctx context.Context, objectKey string) error {
+	if err := q.bucketManager.Delete(q.bucketName, q.prefix+objectKey); err != nil {
+		return fmt.Errorf("failed to delete object from Qiniu cloud: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in the Qiniu bucket
+func (q *QiniuClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	if _, err := q.bucketManager.Stat(q.bucketName, q.prefix+objectKey); err != nil {
+		// Qiniu reports missing files with status code 612
+		var info *storage.ErrorInfo
+		if errors.As(err, &info) && info.Code == 612 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object in Qiniu cloud: %w", err)
+	}
+	return true, nil
+}
+
+// List returns up to limit objects whose key starts with prefix, relative to the
+// configured prefix
+func (q *QiniuClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 1000 // Maximum page size of Qiniu
+	}
+
+	entries, _, _, _, err := q.bucketManager.ListFiles(q.bucketName, q.prefix+prefix, "", "", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in Qiniu cloud: %w", err)
+	}
+
+	objects := make([]meta.ObjectInfo, 0, len(entries))
+	for _, entry := range entries {
+		objects = append(objects, meta.ObjectInfo{
+			Key:  strings.TrimPrefix(entry.Key, q.prefix),
+			Size: entry.Fsize,
+			// PutTime is in units of 100 nanoseconds
+			LastModified: time.Unix(0, entry.PutTime*100),
+		})
+	}
+	return objects, nil
+}
+
+// Presign generates a download URL for an object, valid for expiration
+// or the configured URL expiration if zero
+func (q *QiniuClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if expiration <= 0 {
+		expiration = q.expiration
+	}
+
+	return storage.MakePrivateURL(q.mac, q.domain, q.prefix+objectKey, time.Now().Add(expiration).Unix()), nil
+}
+
+// uploadToken returns the cached upload token, creating a new one if it is about
+// to expire. The token allows overwriting any key under the prefix.
+func (q *QiniuClient) uploadToken() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.upToken != "" && time.Until(q.upTokenTime) > upTokenMinValidity {
+		return q.upToken
+	}
+	putPolicy := storage.PutPolicy{
+		Scope:           q.bucketName + ":" + q.prefix,
+		IsPrefixalScope: 1,
+		Expires:         uint64(upTokenLifetime.Seconds()),
+	}
+	q.upToken, q.upTokenTime = putPolicy.UploadToken(q.mac), time.Now().Add(upTokenLifetime)
+	return q.upToken
+}
+
+// downloadURL returns the permanent URL of an uploaded object if the bucket is
+// public, or else a private URL
+func (q *QiniuClient) downloadURL(ctx context.Context, objectKey string) (string, error) {
+	if q.public {
+		return storage.MakePublicURL(q.domain, q.prefix+objectKey), nil
+	}
+	return q.Presign(ctx, objectKey, 0)
+}
+
+// resumeExtra builds the options of a resumable upload of size bytes, or of unknown size if zero
+func (q *QiniuClient) resumeExtra(ctx context.Context, filename string, contentType string, size int64) *storage.RputV2Extra {
+	return &storage.RputV2Extra{
+		CustomVars: putParams(ctx, filename),
+		MimeType:   contentType,
+		// Keep within the maximum number of parts
+		PartSize: max(q.partSize, (size+maxParts-1)/maxParts),
+		Recorder: q.recorder,
+	}
+}
+
+// putParams builds the custom variables (x:params) of an upload: the file name,
+// the request metadata and the tags
+func putParams(ctx context.Context, filename string) map[string]string {
+	params := map[string]string{
+		"x:name": filename,
+	}
+	for key, value := range meta.FromContext(ctx).Fields() {
+		params["x:"+strings.ReplaceAll(key, "-", "_")] = value
+	}
+	return params
+}
+
+// newConfig creates the storage configuration for region
+func newConfig(region string) *storage.Config {
 	cfg := storage.Config{}
 
 	// Set storage region
-	switch q.region {
+	switch region {
 	case "z0":
 		cfg.Zone = &storage.ZoneHuadong
 	case "z1":
@@ -175,38 +385,5 @@ func (q *QiniuClient) Upload(ctx context.Context, body io.Reader, filename strin
 	// Use CDN acceleration
 	cfg.UseCdnDomains = true
 
-	// Create form uploader object
-	formUploader := storage.NewFormUploader(&cfg)
-	ret := storage.PutRet{}
-
-	// Create upload policy
-	putPolicy := storage.PutPolicy{
-		Scope: q.bucketName + ":" + objectKey,
-	}
-	upToken := putPolicy.UploadToken(mac)
-
-	// Create upload options
-	putExtra := storage.PutExtra{
-		Params: map[string]string{
-			"x:name": filename,
-		},
-		MimeType: util.GetContentType(filename),
-	}
-
-	// Read all data from the reader
-	data, err := io.ReadAll(body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read data: %w", err)
-	}
-
-	// Upload data
-	err = formUploader.Put(ctx, &ret, upToken, objectKey, bytes.NewReader(data), int64(len(data)), &putExtra)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload data to Qiniu cloud: %w", err)
-	}
-
-	// Build file download URL with authentication
-	downloadURL := storage.MakePrivateURL(mac, q.domain, ret.Key, time.Now().Add(q.expiration).Unix())
-
-	return downloadURL, nil
+	return &cfg
 }
@@ -0,0 +1,74 @@
+package qiniu
+
+import "time"
+
+// Option sets a field of the QiniuConfig used by New
+type Option func(*QiniuConfig)
+
+// New creates a new Qiniu client from options, an alternative to NewQiniuClient
+func New(opts ...Option) (*QiniuClient, error) {
+	var cfg QiniuConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewQiniuClient(cfg)
+}
+
+// WithBucket sets the bucket name
+func WithBucket(bucket string) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.BucketName = bucket
+	}
+}
+
+// WithCredentials sets the access key
+func WithCredentials(accessKey, secretKey string) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.AccessKey = accessKey
+		cfg.SecretKey = secretKey
+	}
+}
+
+// WithDomain sets the domain bound to the bucket, required by Qiniu
+func WithDomain(domain string) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.Domain = domain
+	}
+}
+
+// WithRegion sets the storage region, e.g. "z0"
+func WithRegion(region string) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.Region = region
+	}
+}
+
+// WithPresignExpiry sets the validity of the private download URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
+
+// WithPrefix sets the key prefix of uploaded objects, e.g. "mcp-uploads/"
+func WithPrefix(prefix string) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.Prefix = prefix
+	}
+}
+
+// WithMultipart sets the size from which files are uploaded in resumable parts
+// of partSize bytes, 0 to always use a single form upload
+func WithMultipart(threshold, partSize int64) Option {
+	return func(cfg *QiniuConfig) {
+		cfg.MultipartThreshold = threshold
+		cfg.PartSize = partSize
+	}
+}
+
+// WithPublicBucket returns permanent URLs of a public bucket instead of private URLs
+func WithPublicBucket() Option {
+	return func(cfg *QiniuConfig) {
+		cfg.Public = true
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates a storage backend from the configuration
+type Factory func(config *Config) (Storage, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+func init() {
+	Register(StorageTypeS3, func(config *Config) (Storage, error) {
+		return initS3StorageWithConfig(config.S3)
+	})
+	Register(StorageTypeR2, func(config *Config) (Storage, error) {
+		return initR2StorageWithConfig(config.R2)
+	})
+	Register(StorageTypeOSS, func(config *Config) (Storage, error) {
+		return initOSSStorageWithConfig(config.OSS)
+	})
+	Register(StorageTypeCOS, func(config *Config) (Storage, error) {
+		return initCOSStorageWithConfig(config.COS)
+	})
+	Register(StorageTypeQiniu, func(config *Config) (Storage, error) {
+		return initQiniuStorageWithConfig(config.Qiniu)
+	})
+	Register(StorageTypeGitHub, func(config *Config) (Storage, error) {
+		return initGitHubStorageWithConfig(config.GitHub)
+	})
+	Register(StorageTypeDropbox, func(config *Config) (Storage, error) {
+		return initDropboxStorageWithConfig(config.Dropbox)
+	})
+	Register(StorageTypeOneDrive, func(config *Config) (Storage, error) {
+		return initOneDriveStorageWithConfig(config.OneDrive)
+	})
+	Register(StorageTypeBox, func(config *Config) (Storage, error) {
+		return initBoxStorageWithConfig(config.Box)
+	})
+	Register(StorageTypeIPFS, func(config *Config) (Storage, error) {
+		return initIPFSStorageWithConfig(config.IPFS)
+	})
+	Register(StorageTypeCloudinary, func(config *Config) (Storage, error) {
+		return initCloudinaryStorageWithConfig(config.Cloudinary)
+	})
+	Register(StorageTypeFirebase, func(config *Config) (Storage, error) {
+		return initFirebaseStorageWithConfig(config.Firebase)
+	})
+	Register(StorageTypeOCI, func(config *Config) (Storage, error) {
+		return initOCIStorageWithConfig(config.OCI)
+	})
+	Register(StorageTypeBOS, func(config *Config) (Storage, error) {
+		return initBOSStorageWithConfig(config.BOS)
+	})
+	Register(StorageTypeStorj, func(config *Config) (Storage, error) {
+		return initStorjStorageWithConfig(config.Storj)
+	})
+	Register(StorageTypeArtifactory, func(config *Config) (Storage, error) {
+		return initArtifactoryStorageWithConfig(config.Artifactory)
+	})
+	Register(StorageTypePlugin, func(config *Config) (Storage, error) {
+		return initPluginStorageWithConfig(config.Plugin)
+	})
+	Register(StorageTypeExec, func(config *Config) (Storage, error) {
+		return initExecStorageWithConfig(config.Exec)
+	})
+	Register(StorageTypeMemory, func(config *Config) (Storage, error) {
+		return initMemoryStorageWithConfig(config.Memory)
+	})
+}
+
+// Register makes a storage backend available under storageType (case-insensitive),
+// selected with FSM_STORAGE_TYPE. Call it from an init function; it panics if the
+// type is empty, the factory is nil or the type is already registered.
+func Register(storageType string, factory Factory) {
+	storageType = strings.ToLower(storageType)
+	if storageType == "" || storageType == StorageTypeEmpty {
+		panic(fmt.Sprintf("storage: invalid storage type %q", storageType))
+	}
+	if factory == nil {
+		panic("storage: Register factory is nil")
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, dup := factories[storageType]; dup {
+		panic(fmt.Sprintf("storage: Register called twice for %s", storageType))
+	}
+	factories[storageType] = factory
+}
+
+// SettingsFactory creates a storage backend from key-value settings, see RegisterSettings
+type SettingsFactory func(settings map[string]string) (Storage, error)
+
+// RegisterSettings makes a storage backend that doesn't need the Config available
+// under storageType, like Register. The factory gets the FSM_<TYPE>_* environment
+// variables, with the prefix removed and lowercased: FSM_MINIO_LOCAL_ENDPOINT is
+// "endpoint" for the type "minio-local". Config.Settings of the type override them.
+func RegisterSettings(storageType string, factory SettingsFactory) {
+	if factory == nil {
+		panic("storage: RegisterSettings factory is nil")
+	}
+	Register(storageType, func(config *Config) (Storage, error) {
+		return factory(backendSettings(config, storageType))
+	})
+}
+
+// backendSettings collects the settings of a backend registered with RegisterSettings
+func backendSettings(config *Config, storageType string) map[string]string {
+	prefix := "FSM_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(storageType)) + "_"
+	settings := make(map[string]string)
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" && value != "" {
+			settings[strings.ToLower(name)] = value
+		}
+	}
+	for key, value := range config.Settings[strings.ToLower(storageType)] {
+		settings[strings.ToLower(key)] = value
+	}
+	return settings
+}
+
+// Registered returns the registered storage types, sorted
+func Registered() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for storageType := range factories {
+		types = append(types, storageType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// IsRegistered reports whether a storage backend is registered for storageType
+func IsRegistered(storageType string) bool {
+	_, ok := lookupFactory(storageType)
+	return ok
+}
+
+func lookupFactory(storageType string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	factory, ok := factories[strings.ToLower(storageType)]
+	return factory, ok
+}
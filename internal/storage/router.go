@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Route sends uploads matching Pattern to the storage of type StorageType instead
+// of the configured one. Patterns are extensions (".pdf"), content types
+// ("application/pdf"), wildcards ("image/*") or "executable".
+type Route struct {
+	Pattern     string
+	StorageType string
+}
+
+// parseRoutes parses comma-separated routing rules, e.g. "image/*=qiniu,.pdf=github",
+// skipping invalid ones
+func parseRoutes(value string) []Route {
+	var routes []Route
+	for _, rule := range strings.Split(value, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		pattern, storageType, ok := strings.Cut(rule, "=")
+		pattern, storageType = strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(strings.TrimSpace(storageType))
+		if !ok || pattern == "" || storageType == "" {
+			log.Warn().Str("route", rule).Msg("Ignoring invalid route, expected <extension or type>=<storage type>")
+			continue
+		}
+		routes = append(routes, Route{Pattern: pattern, StorageType: storageType})
+	}
+	return routes
+}
+
+// routerStorage sends each upload to the storage of the first route matching
+// its extension or content type, or to the configured storage if none does.
+// Operations on existing objects are routed by the extension of the object key.
+type routerStorage struct {
+	fallback     Storage
+	fallbackType string
+	routes       []Route
+	storages     map[string]Storage // Storages of the routes by type
+}
+
+// newRouterStorage routes uploads to the storages of config.Routes, and the others to fallback
+func newRouterStorage(config *Config, fallback Storage) *routerStorage {
+	r := &routerStorage{fallback: fallback, fallbackType: strings.ToLower(config.StorageType), routes: config.Routes, storages: make(map[string]Storage)}
+	var types []string
+	for _, route := range config.Routes {
+		if _, ok := r.storages[route.StorageType]; ok || route.StorageType == strings.ToLower(config.StorageType) {
+			continue
+		}
+		r.storages[route.StorageType] = nil
+		types = append(types, route.StorageType)
+	}
+	for _, named := range openStorages(config, types) {
+		r.storages[named.backend] = named.storage
+	}
+	return r
+}
+
+// route returns the storage for an object named filename with contentType
+func (r *routerStorage) route(filename string, contentType string) (string, Storage) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, route := range r.routes {
+		matched := route.Pattern == ext
+		if !strings.HasPrefix(route.Pattern, ".") {
+			matched = util.MatchContentType(route.Pattern, contentType)
+		}
+		if !matched {
+			continue
+		}
+		if storage, ok := r.storages[route.StorageType]; ok {
+			return route.StorageType, storage
+		}
+		break // Routed to the configured storage
+	}
+	return "", r.fallback
+}
+
+// routeOf returns the storage of the route an upload of filename with contentType
+// matches, or nil if it goes to the configured storage
+func (s *Service) routeOf(filename string, contentType string) *namedStorage {
+	router, ok := s.Storage.(*routerStorage)
+	if !ok {
+		return nil
+	}
+	backend, storage := router.route(filename, contentType)
+	if backend == "" {
+		return nil
+	}
+	return &namedStorage{backend: backend, storage: storage}
+}
+
+// routeReader returns the storage of the route the data of body uploaded as filename
+// matches like routeOf, peeking at its leading bytes, and the reader yielding all the data
+func (s *Service) routeReader(body io.Reader, filename string) (*namedStorage, io.Reader) {
+	if _, ok := s.Storage.(*routerStorage); !ok {
+		return nil, body
+	}
+	contentType, body := util.PeekContentType(body, filename)
+	return s.routeOf(filename, contentType), body
+}
+
+// reachable reports whether objects of the storage of type backend are reached
+// through the configured storage, i.e. it is the configured one or a route's
+func (s *Service) reachable(backend string) bool {
+	if backend == strings.ToLower(s.Config.StorageType) {
+		return true
+	}
+	router, ok := s.Storage.(*routerStorage)
+	if !ok {
+		return false
+	}
+	_, ok = router.storages[backend]
+	return ok
+}
+
+// routeKey returns the storage for an existing object and its type, by the extension of its key
+func (r *routerStorage) routeKey(objectKey string) (string, Storage) {
+	backend, storage := r.route(objectKey, util.GetContentType(objectKey))
+	if backend == "" {
+		backend = r.fallbackType
+	}
+	return backend, storage
+}
+
+// notSupported returns the error for an operation the storage of backend doesn't support
+func notSupported(backend string, operation string) error {
+	return fmt.Errorf("storage %s doesn't support %s: %w", backend, operation, ErrNotSupported)
+}
+
+func (r *routerStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	backend, storage := r.route(filename, util.GetFileContentType(path, filename))
+	if backend != "" {
+		log.Ctx(ctx).Debug().Str("key", filename).Str("backend", backend).Msg("Routing upload")
+	}
+	return storage.UploadFile(ctx, path, filename)
+}
+
+func (r *routerStorage) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	contentType, body := util.PeekContentType(body, filename)
+	backend, storage := r.route(filename, contentType)
+	if backend != "" {
+		log.Ctx(ctx).Debug().Str("key", filename).Str("backend", backend).Msg("Routing upload")
+	}
+	return storage.Upload(ctx, body, filename)
+}
+
+// Supports reports the optional operations of the configured storage. Operations on
+// objects routed to a storage without them return ErrNotSupported naming that storage.
+func (r *routerStorage) Supports(operation string) bool {
+	capabilities := CapabilitiesOf(r.fallback)
+	switch operation {
+	case "presign":
+		return capabilities.Presign
+	case "delete":
+		return capabilities.Delete
+	case "exists":
+		return capabilities.Exists
+	case "list":
+		return capabilities.List
+	}
+	return false
+}
+
+func (r *routerStorage) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	backend, storage := r.routeKey(objectKey)
+	presigner, ok := storage.(Presigner)
+	if !ok || !CapabilitiesOf(storage).Presign {
+		return "", notSupported(backend, "re-signing URLs")
+	}
+	return presigner.Presign(ctx, objectKey, expiration)
+}
+
+func (r *routerStorage) Delete(ctx context.Context, objectKey string) error {
+	backend, storage := r.routeKey(objectKey)
+	deleter, ok := storage.(Deleter)
+	if !ok || !CapabilitiesOf(storage).Delete {
+		return notSupported(backend, "deleting files")
+	}
+	return deleter.Delete(ctx, objectKey)
+}
+
+func (r *routerStorage) Exists(ctx context.Context, objectKey string) (bool, error) {
+	backend, storage := r.routeKey(objectKey)
+	exister, ok := storage.(Exister)
+	if !ok || !CapabilitiesOf(storage).Exists {
+		return false, notSupported(backend, "checking files")
+	}
+	return exister.Exists(ctx, objectKey)
+}
+
+// List lists the objects of the configured storage only
+func (r *routerStorage) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	lister, ok := r.fallback.(Lister)
+	if !ok {
+		return nil, notSupported(r.fallbackType, "listing files")
+	}
+	return lister.List(ctx, prefix, limit)
+}
+
+// URLExpiration returns the URL expiration of the configured storage
+func (r *routerStorage) URLExpiration() time.Duration {
+	if expirer, ok := r.fallback.(URLExpirer); ok {
+		return expirer.URLExpiration()
+	}
+	return 0
+}
+
+// Limits returns the limits of the configured storage
+func (r *routerStorage) Limits() Limits {
+	if reporter, ok := r.fallback.(LimitsReporter); ok {
+		return reporter.Limits()
+	}
+	return Limits{}
+}
+
+// InitError reports whether the configured storage failed to initialize
+func (r *routerStorage) InitError() error {
+	if failed, ok := r.fallback.(initFailer); ok {
+		return failed.InitError()
+	}
+	return nil
+}
+
+// Close closes the configured storage and the storages of the routes
+func (r *routerStorage) Close() error {
+	for backend, storage := range r.storages {
+		if closer, ok := storage.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warn().Err(err).Str("backend", backend).Msg("Failed to close storage")
+			}
+		}
+	}
+	if closer, ok := r.fallback.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/memory"
+)
+
+func TestRouterUnsupportedOperations(t *testing.T) {
+	fallback := memory.NewMemoryStorage(memory.MemoryConfig{})
+	router := &routerStorage{
+		fallback:     fallback,
+		fallbackType: "memory",
+		routes:       []Route{{Pattern: ".pdf", StorageType: "github"}},
+		storages:     map[string]Storage{"github": &recordingStorage{}},
+	}
+	s := NewServiceWithStorage(&Config{StorageType: "memory", NoDelete: true, FileFormat: "{filename}{ext}"}, router)
+	ctx := context.Background()
+
+	// The routed storage can't check for existing objects, so uploads to it pass
+	path := filepath.Join(t.TempDir(), "a.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadFileDetailed(ctx, path, "a.pdf"); err != nil {
+		t.Errorf("UploadFileDetailed() routed to a storage without exists = %v", err)
+	}
+
+	// Operations on its objects name it
+	checks := map[string]error{
+		"Presign": func() error { _, err := router.Presign(ctx, "a.pdf", 0); return err }(),
+		"Delete":  router.Delete(ctx, "a.pdf"),
+		"Exists":  func() error { _, err := router.Exists(ctx, "a.pdf"); return err }(),
+	}
+	for operation, err := range checks {
+		if !errors.Is(err, ErrNotSupported) || !strings.Contains(err.Error(), "storage github") {
+			t.Errorf("%s() = %v, want not supported by github", operation, err)
+		}
+	}
+
+	// Overwrites on the configured storage are still refused
+	if _, err := s.UploadFileDetailed(ctx, path, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadFileDetailed(ctx, path, "a.txt"); !errors.Is(err, ErrNoDelete) {
+		t.Errorf("UploadFileDetailed() of an existing object = %v, want ErrNoDelete", err)
+	}
+}
+
+// failingStorage fails every upload
+type failingStorage struct{}
+
+func (failingStorage) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func (failingStorage) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func TestServiceRecordsServingBackend(t *testing.T) {
+	configured := memory.NewMemoryStorage(memory.MemoryConfig{BaseURL: "https://s3.example.com"})
+	routed := memory.NewMemoryStorage(memory.MemoryConfig{BaseURL: "https://github.example.com"})
+	router := &routerStorage{
+		fallback:     configured,
+		fallbackType: "s3",
+		routes:       []Route{{Pattern: ".pdf", StorageType: "github"}, {Pattern: ".zip", StorageType: "qiniu"}},
+		storages:     map[string]Storage{"github": routed, "qiniu": failingStorage{}},
+	}
+	config := &Config{StorageType: "s3", FileFormat: "{filename}{ext}", AuditLog: filepath.Join(t.TempDir(), "audit.jsonl")}
+	s := NewServiceWithStorage(config, router)
+	defer s.Close()
+	s.failover = []namedStorage{{backend: "oss", storage: memory.NewMemoryStorage(memory.MemoryConfig{BaseURL: "https://oss.example.com"})}}
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "a.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uploads := []struct {
+		name    string
+		upload  func() (*UploadResult, error)
+		backend string
+	}{
+		{name: "configured", upload: func() (*UploadResult, error) { return s.UploadDetailed(ctx, strings.NewReader("text"), "a.txt") }, backend: "s3"},
+		{name: "routed file", upload: func() (*UploadResult, error) { return s.UploadFileDetailed(ctx, path, "a.pdf") }, backend: "github"},
+		{name: "routed data", upload: func() (*UploadResult, error) { return s.UploadDetailed(ctx, strings.NewReader("%PDF-1.4\n"), "b.pdf") }, backend: "github"},
+		{name: "failed over", upload: func() (*UploadResult, error) { return s.UploadFileDetailed(ctx, path, "c.zip") }, backend: "oss"},
+	}
+	for _, upload := range uploads {
+		t.Run(upload.name, func(t *testing.T) {
+			result, err := upload.upload()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Backend != upload.backend {
+				t.Errorf("backend = %s, want %s", result.Backend, upload.backend)
+			}
+
+			// Objects reached through the configured storage are found by their URL
+			objectKey, err := s.ObjectKeyOf(result.URL)
+			if upload.backend == "oss" {
+				if err == nil || !strings.Contains(err.Error(), "oss") {
+					t.Errorf("ObjectKeyOf() = %s, %v, want an error naming the failover storage", objectKey, err)
+				}
+				return
+			}
+			if err != nil || objectKey != result.ObjectKey {
+				t.Errorf("ObjectKeyOf() = %s, %v, want %s", objectKey, err, result.ObjectKey)
+			}
+		})
+	}
+}
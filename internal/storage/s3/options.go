@@ -0,0 +1,137 @@
+package s3
+
+import "time"
+
+// Option sets a field of the S3Config used by New
+type Option func(*S3Config)
+
+// New creates a new S3 client from options, an alternative to NewS3Client
+//
+//	client, err := s3.New(s3.WithBucket("my-bucket"), s3.WithRegion("us-east-1"), s3.WithPresignExpiry(time.Hour))
+func New(opts ...Option) (*S3Client, error) {
+	var cfg S3Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewS3Client(cfg)
+}
+
+// WithBucket sets the bucket name
+func WithBucket(bucket string) Option {
+	return func(cfg *S3Config) {
+		cfg.BucketName = bucket
+	}
+}
+
+// WithProvider selects the preset of an S3-compatible provider, e.g. "wasabi",
+// "digitalocean" or "scaleway"
+func WithProvider(provider string) Option {
+	return func(cfg *S3Config) {
+		cfg.Provider = provider
+	}
+}
+
+// WithRegion sets the region
+func WithRegion(region string) Option {
+	return func(cfg *S3Config) {
+		cfg.Region = region
+	}
+}
+
+// WithEndpoint sets a custom endpoint for S3-compatible services
+func WithEndpoint(endpoint string) Option {
+	return func(cfg *S3Config) {
+		cfg.Endpoint = endpoint
+	}
+}
+
+// WithPathStyle addresses buckets in the path instead of the host name, e.g. for MinIO
+func WithPathStyle() Option {
+	return func(cfg *S3Config) {
+		cfg.UsePathStyle = true
+	}
+}
+
+// WithCredentials sets static credentials; session is the optional session token
+func WithCredentials(accessKeyID, secretKey, session string) Option {
+	return func(cfg *S3Config) {
+		cfg.AccessKeyID = accessKeyID
+		cfg.SecretKey = secretKey
+		cfg.Session = session
+	}
+}
+
+// WithPresignExpiry sets the validity of the presigned download URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *S3Config) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of uploaded objects
+func WithCacheControl(cacheControl string) Option {
+	return func(cfg *S3Config) {
+		cfg.CacheControl = cacheControl
+	}
+}
+
+// WithSSE encrypts uploaded objects with server-side encryption: "AES256" (SSE-S3),
+// or "aws:kms" (SSE-KMS) with the optional KMS key ID
+func WithSSE(sse, kmsKeyID string) Option {
+	return func(cfg *S3Config) {
+		cfg.SSE = sse
+		cfg.KMSKeyID = kmsKeyID
+	}
+}
+
+// WithStorageClass sets the storage class of uploaded objects, e.g. "STANDARD_IA"
+func WithStorageClass(storageClass string) Option {
+	return func(cfg *S3Config) {
+		cfg.StorageClass = storageClass
+	}
+}
+
+// WithPrefix sets the key prefix of uploaded objects, e.g. "mcp-uploads/"
+func WithPrefix(prefix string) Option {
+	return func(cfg *S3Config) {
+		cfg.Prefix = prefix
+	}
+}
+
+// WithPublicURL returns permanent URLs on publicDomain, e.g. a CDN in front of
+// the bucket, instead of presigned URLs
+func WithPublicURL(publicDomain string) Option {
+	return func(cfg *S3Config) {
+		cfg.PublicDomain = publicDomain
+	}
+}
+
+// WithPublicBucket returns unsigned URLs of a bucket that allows anonymous reads
+// instead of presigned URLs
+func WithPublicBucket() Option {
+	return func(cfg *S3Config) {
+		cfg.PublicBucket = true
+	}
+}
+
+// WithAccelerate uploads through the S3 Transfer Acceleration endpoint
+func WithAccelerate() Option {
+	return func(cfg *S3Config) {
+		cfg.UseAccelerate = true
+	}
+}
+
+// WithRequesterPays acknowledges that requests are charged to the requester,
+// for requester-pays buckets
+func WithRequesterPays() Option {
+	return func(cfg *S3Config) {
+		cfg.RequesterPays = true
+	}
+}
+
+// WithHeaders adds headers to upload requests, e.g. headers the bucket policy requires
+func WithHeaders(headers map[string]string) Option {
+	return func(cfg *S3Config) {
+		cfg.Headers = headers
+	}
+}
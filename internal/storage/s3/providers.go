@@ -0,0 +1,75 @@
+package s3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Providers of S3-compatible storage with a preset, selected by S3Config.Provider
+const (
+	ProviderAWS          = "aws"
+	ProviderWasabi       = "wasabi"
+	ProviderDigitalOcean = "digitalocean"
+	ProviderScaleway     = "scaleway"
+)
+
+// sigV4MaxURLExpiration is the longest validity of SigV4 presigned URLs, 7 days
+const sigV4MaxURLExpiration = 604800
+
+// providerPreset describes the conventions of an S3-compatible provider
+type providerPreset struct {
+	region   string // Default region
+	endpoint string // Endpoint of a region, %s is replaced by the region
+	// Only send checksums when an operation requires them, the provider doesn't
+	// support the checksum algorithms of current SDKs
+	checksumWhenRequired bool
+}
+
+var providerPresets = map[string]providerPreset{
+	ProviderAWS:          {},
+	ProviderWasabi:       {region: "us-east-1", endpoint: "https://s3.%s.wasabisys.com", checksumWhenRequired: true},
+	ProviderDigitalOcean: {region: "nyc3", endpoint: "https://%s.digitaloceanspaces.com", checksumWhenRequired: true},
+	ProviderScaleway:     {region: "fr-par", endpoint: "https://s3.%s.scw.cloud", checksumWhenRequired: true},
+}
+
+// providerAliases maps other common names of the providers to their preset
+var providerAliases = map[string]string{
+	"do":     ProviderDigitalOcean,
+	"spaces": ProviderDigitalOcean,
+	"scw":    ProviderScaleway,
+}
+
+// Providers lists the names of the provider presets
+func Providers() []string {
+	return []string{ProviderAWS, ProviderWasabi, ProviderDigitalOcean, ProviderScaleway}
+}
+
+// withProvider returns the configuration with the settings of its provider preset
+// filled in: the default region, the region's endpoint and the checksum behavior.
+// Settings given explicitly take precedence. Providers presign with SigV4, so the
+// URL expiration is capped at 7 days.
+func (cfg S3Config) withProvider() (S3Config, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		return cfg, nil
+	}
+	if alias, ok := providerAliases[name]; ok {
+		name = alias
+	}
+	preset, ok := providerPresets[name]
+	if !ok {
+		return cfg, fmt.Errorf("unknown S3 provider %q, expected one of %s", cfg.Provider, strings.Join(Providers(), ", "))
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = preset.region
+	}
+	if cfg.Endpoint == "" && preset.endpoint != "" {
+		cfg.Endpoint = fmt.Sprintf(preset.endpoint, cfg.Region)
+	}
+	cfg.ChecksumWhenRequired = cfg.ChecksumWhenRequired || preset.checksumWhenRequired
+	if cfg.URLExpiration <= 0 || cfg.URLExpiration > sigV4MaxURLExpiration {
+		cfg.URLExpiration = sigV4MaxURLExpiration
+	}
+	return cfg, nil
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// r2MaxURLExpiration is the longest validity of R2 presigned URLs, 7 days
+const r2MaxURLExpiration = 604800
+
+// R2Config contains configuration for Cloudflare R2
+type R2Config struct {
+	AccountID   string
+	AccessKeyID string
+	SecretKey   string
+	BucketName  string
+	// Optional jurisdiction of the bucket, e.g. "eu" or "fedramp"
+	Jurisdiction string
+	// Optional public URL of the bucket, its r2.dev subdomain (e.g. "pub-xxxx.r2.dev")
+	// or a custom domain, https if no scheme is given. Uploads return public URLs instead of presigned URLs.
+	PublicDomain string
+	// Presigned URL expiration in seconds, at most 7 days
+	URLExpiration int64
+	// Optional Cache-Control header for uploaded objects
+	CacheControl string
+}
+
+// NewR2Client creates a client for Cloudflare R2 through its S3-compatible API
+func NewR2Client(cfg R2Config) (*S3Client, error) {
+	if cfg.AccountID == "" {
+		return nil, fmt.Errorf("R2 account ID is required")
+	}
+	return NewS3Client(cfg.S3Config())
+}
+
+// S3Config returns the S3 configuration of the R2 bucket: the account endpoint,
+// the "auto" region, path-style requests and checksums only where required,
+// since R2 doesn't support all the checksum algorithms of current SDKs
+func (cfg R2Config) S3Config() S3Config {
+	host := cfg.AccountID
+	if cfg.Jurisdiction != "" {
+		host += "." + strings.ToLower(cfg.Jurisdiction)
+	}
+	publicDomain := cfg.PublicDomain
+	if publicDomain != "" && !strings.Contains(publicDomain, "://") {
+		publicDomain = "https://" + publicDomain
+	}
+	expiration := cfg.URLExpiration
+	if expiration <= 0 || expiration > r2MaxURLExpiration {
+		expiration = r2MaxURLExpiration
+	}
+	return S3Config{
+		BucketName:           cfg.BucketName,
+		Region:               "auto",
+		Endpoint:             "https://" + host + ".r2.cloudflarestorage.com",
+		AccessKeyID:          cfg.AccessKeyID,
+		SecretKey:            cfg.SecretKey,
+		URLExpiration:        expiration,
+		CacheControl:         cfg.CacheControl,
+		PublicDomain:         publicDomain,
+		UsePathStyle:         true,
+		ChecksumWhenRequired: true,
+	}
+}
@@ -2,17 +2,26 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/google/uuid"
 
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
 	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
@@ -20,17 +29,39 @@ import (
 type S3Client struct {
 	client     *s3.Client
 	bucketName string
+	prefix     string // Key prefix of uploaded objects, ends with "/" if set
 	region     string
 	endpoint   string
 	// Add fields for generating signed URLs
 	accessKey  string
 	secretKey  string
 	expiration time.Duration // URL expiration time
+	// Cache-Control header for uploaded objects
+	cacheControl string
+	// Public URL of the bucket, returned by uploads instead of presigned URLs
+	publicDomain string
+	// Server-side encryption of uploaded objects
+	sse      types.ServerSideEncryption
+	kmsKeyID string
+	// Storage class of uploaded objects, the bucket's default if empty
+	storageClass types.StorageClass
+	// Presigns download URLs on the regional endpoint, also with Transfer Acceleration
+	presignClient *s3.PresignClient
+	// Requests are charged to the requester, for requester-pays buckets
+	requestPayer types.RequestPayer
+	// Options of upload requests, adding the configured headers
+	putOptions []func(*s3.Options)
 }
 
 // S3Config contains configuration for the S3 client
 type S3Config struct {
-	BucketName  string
+	// Optional provider preset of an S3-compatible service, e.g. "wasabi",
+	// filling in the region, endpoint and conventions of the provider
+	Provider   string
+	BucketName string
+	// Optional key prefix of uploaded objects, e.g. "mcp-uploads/". Object keys
+	// passed to and returned by the client are relative to it.
+	Prefix      string
 	Region      string
 	Endpoint    string
 	AccessKeyID string
@@ -38,17 +69,87 @@ type S3Config struct {
 	Session     string
 	// Add URL expiration configuration (in seconds)
 	URLExpiration int64
+	// Optional Cache-Control header for uploaded objects, e.g. "public, max-age=31536000, immutable"
+	CacheControl string
+	// Optional public URL of the bucket, e.g. a CDN or custom domain, returned by
+	// uploads instead of presigned URLs. https is assumed without a scheme.
+	PublicDomain string
+	// The bucket allows anonymous reads, so uploads return unsigned URLs of the
+	// bucket instead of presigned URLs that expire. PublicDomain takes precedence.
+	PublicBucket bool
+	// Address buckets in the path instead of the host name, for MinIO and other
+	// servers without virtual-hosted-style buckets. Presigned URLs use it too.
+	UsePathStyle bool
+	// Only send checksums when an operation requires them, for S3-compatible services
+	// that don't support the checksum algorithms of current SDKs
+	ChecksumWhenRequired bool
+	// Upload through the S3 Transfer Acceleration endpoint (s3-accelerate.amazonaws.com),
+	// which must be enabled on the bucket. Download URLs stay on the regional endpoint.
+	UseAccelerate bool
+	// Acknowledge that requests are charged to the requester (x-amz-request-payer),
+	// needed to access requester-pays buckets
+	RequesterPays bool
+	// Optional extra headers of upload requests, e.g. headers enforced by the bucket policy
+	Headers map[string]string
+	// Optional server-side encryption of uploaded objects: "AES256" (SSE-S3),
+	// "aws:kms" (SSE-KMS) or "aws:kms:dsse", required by some bucket policies
+	SSE string
+	// Optional KMS key ID or ARN for SSE-KMS, the bucket's default key if empty.
+	// Setting it without SSE selects SSE-KMS.
+	KMSKeyID string
+	// Optional storage class of uploaded objects, e.g. "STANDARD_IA" or
+	// "INTELLIGENT_TIERING" for files that are rarely downloaded
+	StorageClass string
+}
+
+// serverSideEncryption returns the server-side encryption header of cfg.SSE,
+// accepting "s3" and "kms" as short names
+func (cfg S3Config) serverSideEncryption() (types.ServerSideEncryption, error) {
+	switch strings.ToLower(cfg.SSE) {
+	case "":
+		if cfg.KMSKeyID != "" {
+			return types.ServerSideEncryptionAwsKms, nil
+		}
+		return "", nil
+	case "aes256", "s3", "sse-s3":
+		if cfg.KMSKeyID != "" {
+			return "", fmt.Errorf("a KMS key ID requires SSE-KMS, not %s", cfg.SSE)
+		}
+		return types.ServerSideEncryptionAes256, nil
+	case "aws:kms", "kms", "sse-kms":
+		return types.ServerSideEncryptionAwsKms, nil
+	case "aws:kms:dsse", "dsse", "dsse-kms":
+		return types.ServerSideEncryptionAwsKmsDsse, nil
+	}
+	return "", fmt.Errorf("unsupported server-side encryption %q, expected AES256, aws:kms or aws:kms:dsse", cfg.SSE)
 }
 
 // NewS3Client creates a new S3 client
 func NewS3Client(cfg S3Config) (*S3Client, error) {
+	cfg, err := cfg.withProvider()
+	if err != nil {
+		return nil, err
+	}
+	sse, err := cfg.serverSideEncryption()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.UseAccelerate && (cfg.Endpoint != "" || cfg.UsePathStyle) {
+		return nil, fmt.Errorf("transfer acceleration is only available on AWS, without a custom endpoint or path-style addressing")
+	}
+
 	// Configuration options
 	var optFns []func(*config.LoadOptions) error
 
 	// Add region configuration
 	optFns = append(optFns, config.WithRegion(cfg.Region))
-	optFns = append(optFns, config.WithRequestChecksumCalculation(0))
-	optFns = append(optFns, config.WithResponseChecksumValidation(0))
+	if cfg.ChecksumWhenRequired {
+		optFns = append(optFns, config.WithRequestChecksumCalculation(aws.RequestChecksumCalculationWhenRequired))
+		optFns = append(optFns, config.WithResponseChecksumValidation(aws.ResponseChecksumValidationWhenRequired))
+	} else {
+		optFns = append(optFns, config.WithRequestChecksumCalculation(0))
+		optFns = append(optFns, config.WithResponseChecksumValidation(0))
+	}
 
 	// Add static credentials provider if credentials are provided
 	if cfg.AccessKeyID != "" && cfg.SecretKey != "" {
@@ -65,8 +166,11 @@ func NewS3Client(cfg S3Config) (*S3Client, error) {
 
 	// Create S3 client options
 	s3Options := s3.Options{
-		Region:      cfg.Region,
-		Credentials: awsCfg.Credentials,
+		Region:                     cfg.Region,
+		Credentials:                awsCfg.Credentials,
+		UsePathStyle:               cfg.UsePathStyle,
+		RequestChecksumCalculation: awsCfg.RequestChecksumCalculation,
+		ResponseChecksumValidation: awsCfg.ResponseChecksumValidation,
 	}
 
 	// Use custom endpoint if provided
@@ -74,7 +178,10 @@ func NewS3Client(cfg S3Config) (*S3Client, error) {
 		s3Options.BaseEndpoint = aws.String(cfg.Endpoint)
 	}
 
-	// Create S3 client
+	// Create S3 client; download URLs are presigned without acceleration so they
+	// point at the bucket's region
+	presignClient := s3.NewPresignClient(s3.New(s3Options))
+	s3Options.UseAccelerate = cfg.UseAccelerate
 	client := s3.New(s3Options)
 
 	// Set default expiration if not provided
@@ -83,17 +190,84 @@ func NewS3Client(cfg S3Config) (*S3Client, error) {
 		expiration = time.Duration(cfg.URLExpiration) * time.Second
 	}
 
+	prefix := strings.TrimPrefix(cfg.Prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	publicDomain := cfg.PublicDomain
+	if publicDomain != "" && !strings.Contains(publicDomain, "://") {
+		publicDomain = "https://" + publicDomain
+	}
+	if publicDomain == "" && cfg.PublicBucket {
+		if publicDomain, err = cfg.bucketURL(); err != nil {
+			return nil, err
+		}
+	}
+
+	var requestPayer types.RequestPayer
+	if cfg.RequesterPays {
+		requestPayer = types.RequestPayerRequester
+	}
+	var putOptions []func(*s3.Options)
+	if len(cfg.Headers) > 0 {
+		putOptions = append(putOptions, s3.WithAPIOptions(func(stack *middleware.Stack) error {
+			for name, value := range cfg.Headers {
+				if err := smithyhttp.SetHeaderValue(name, value)(stack); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+
 	return &S3Client{
-		client:     client,
-		bucketName: cfg.BucketName,
-		region:     cfg.Region,
-		endpoint:   cfg.Endpoint,
-		accessKey:  cfg.AccessKeyID,
-		secretKey:  cfg.SecretKey,
-		expiration: expiration,
+		client:        client,
+		presignClient: presignClient,
+		bucketName:    cfg.BucketName,
+		prefix:        prefix,
+		region:        cfg.Region,
+		endpoint:      cfg.Endpoint,
+		accessKey:     cfg.AccessKeyID,
+		secretKey:     cfg.SecretKey,
+		expiration:    expiration,
+		cacheControl:  cfg.CacheControl,
+		publicDomain:  strings.TrimSuffix(publicDomain, "/"),
+		sse:           sse,
+		kmsKeyID:      cfg.KMSKeyID,
+		storageClass:  types.StorageClass(strings.ToUpper(cfg.StorageClass)),
+		requestPayer:  requestPayer,
+		putOptions:    putOptions,
 	}, nil
 }
 
+// bucketURL returns the URL objects of the bucket are read from without signing:
+// the bucket's host on the endpoint, or its path with path-style addressing
+func (cfg S3Config) bucketURL() (string, error) {
+	if cfg.BucketName == "" {
+		return "", fmt.Errorf("bucket name cannot be empty")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		if cfg.Region == "" {
+			return "", fmt.Errorf("region cannot be empty")
+		}
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	} else if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %s: %w", cfg.Endpoint, err)
+	}
+	if cfg.UsePathStyle {
+		u.Path += "/" + cfg.BucketName
+	} else {
+		u.Host = cfg.BucketName + "." + u.Host
+	}
+	return u.String(), nil
+}
+
 // UploadFile uploads a local file to S3 and returns the download URL
 func (s *S3Client) UploadFile(ctx context.Context, path string, filename string) (string, error) {
 	// Open the file
@@ -110,33 +284,13 @@ func (s *S3Client) UploadFile(ctx context.Context, path string, filename string)
 	}
 
 	// Upload the file to S3
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucketName),
-		Key:         aws.String(objectKey),
-		Body:        file,
-		ContentType: aws.String(util.GetContentType(filename)),
-		// Remove public ACL as it's not supported by many S3 compatible services
-		// ACL:         types.ObjectCannedACLPublicRead,
-	})
+	_, err = s.client.PutObject(ctx, s.newPutObjectInput(ctx, objectKey, file, util.GetFileContentType(path, filename)), s.putOptions...)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to S3: %w", err)
 	}
 
-	// Generate a presigned URL for the uploaded object
-	presignClient := s3.NewPresignClient(s.client)
-	presignedReq, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucketName),
-		Key:    aws.String(objectKey),
-	}, func(opts *s3.PresignOptions) {
-		opts.Expires = s.expiration
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
-	}
-
-	return presignedReq.URL, nil
+	return s.downloadURL(ctx, objectKey)
 }
 
 // Upload uploads data from an io.Reader to S3 and returns the download URL
@@ -147,27 +301,41 @@ func (s *S3Client) Upload(ctx context.Context, body io.Reader, filename string)
 		objectKey = uuid.New().String()
 	}
 
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+
 	// Upload the data to S3
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucketName),
-		Key:         aws.String(objectKey),
-		Body:        body,
-		ContentType: aws.String(util.GetContentType(filename)),
-		// Remove public ACL as it's not supported by many S3 compatible services
-		// ACL:         types.ObjectCannedACLPublicRead,
-	})
+	_, err := s.client.PutObject(ctx, s.newPutObjectInput(ctx, objectKey, body, contentType), s.putOptions...)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to upload data to S3: %w", err)
 	}
 
-	// Generate a presigned URL for the uploaded object
-	presignClient := s3.NewPresignClient(s.client)
-	presignedReq, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucketName),
-		Key:    aws.String(objectKey),
+	return s.downloadURL(ctx, objectKey)
+}
+
+// downloadURL returns the public URL of an uploaded object if the bucket has a
+// public domain, or else a presigned URL
+func (s *S3Client) downloadURL(ctx context.Context, objectKey string) (string, error) {
+	if s.publicDomain != "" {
+		return s.publicDomain + "/" + (&url.URL{Path: s.prefix + objectKey}).EscapedPath(), nil
+	}
+	return s.Presign(ctx, objectKey, 0)
+}
+
+// Presign generates a download URL for an object, valid for expiration
+// or the configured URL expiration if zero
+func (s *S3Client) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if expiration <= 0 {
+		expiration = s.expiration
+	}
+
+	presignedReq, err := s.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(s.prefix + objectKey),
+		RequestPayer: s.requestPayer,
 	}, func(opts *s3.PresignOptions) {
-		opts.Expires = s.expiration
+		opts.Expires = expiration
 	})
 
 	if err != nil {
@@ -176,3 +344,118 @@ func (s *S3Client) Upload(ctx context.Context, body io.Reader, filename string)
 
 	return presignedReq.URL, nil
 }
+
+// Limits reports the 5 GiB limit of a single PutObject request
+func (s *S3Client) Limits() meta.Limits {
+	return meta.Limits{MaxFileSize: 5 << 30, CustomDomain: s.publicDomain != ""}
+}
+
+// URLExpiration returns the validity of the presigned URLs returned by uploads,
+// or zero for buckets with a public domain
+func (s *S3Client) URLExpiration() time.Duration {
+	if s.publicDomain != "" {
+		return 0
+	}
+	return s.expiration
+}
+
+// Delete removes an object from S3
+func (s *S3Client) Delete(ctx context.Context, objectKey string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(s.prefix + objectKey),
+		RequestPayer: s.requestPayer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object from S3: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in S3
+func (s *S3Client) Exists(ctx context.Context, objectKey string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(s.prefix + objectKey),
+		RequestPayer: s.requestPayer,
+	})
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object in S3: %w", err)
+	}
+	return true, nil
+}
+
+// List returns up to limit objects whose key starts with prefix, relative to the
+// configured prefix
+func (s *S3Client) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:       aws.String(s.bucketName),
+		Prefix:       aws.String(s.prefix + prefix),
+		RequestPayer: s.requestPayer,
+	}
+	if limit > 0 {
+		input.MaxKeys = aws.Int32(int32(limit))
+	}
+
+	output, err := s.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in S3: %w", err)
+	}
+
+	objects := make([]meta.ObjectInfo, 0, len(output.Contents))
+	for _, object := range output.Contents {
+		objects = append(objects, meta.ObjectInfo{
+			Key:          strings.TrimPrefix(aws.ToString(object.Key), s.prefix),
+			Size:         aws.ToInt64(object.Size),
+			LastModified: aws.ToTime(object.LastModified),
+		})
+	}
+	return objects, nil
+}
+
+// newPutObjectInput builds the PutObject request for an object
+func (s *S3Client) newPutObjectInput(ctx context.Context, objectKey string, body io.Reader, contentType string) *s3.PutObjectInput {
+	input := &s3.PutObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(s.prefix + objectKey),
+		Body:         body,
+		ContentType:  aws.String(contentType),
+		RequestPayer: s.requestPayer,
+		// Remove public ACL as it's not supported by many S3 compatible services
+		// ACL:         types.ObjectCannedACLPublicRead,
+	}
+
+	// Set Content-Disposition so downloads get the original filename
+	if disposition := meta.FromContext(ctx).ContentDisposition; disposition != "" {
+		input.ContentDisposition = aws.String(disposition)
+	}
+
+	// Set Cache-Control to improve CDN behavior
+	if s.cacheControl != "" {
+		input.CacheControl = aws.String(s.cacheControl)
+	}
+
+	// Store the object in a cheaper storage class, e.g. for short-lived shared files
+	if s.storageClass != "" {
+		input.StorageClass = s.storageClass
+	}
+
+	// Encrypt the object at rest, for buckets whose policy rejects unencrypted puts
+	if s.sse != "" {
+		input.ServerSideEncryption = s.sse
+		if s.kmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+		}
+	}
+
+	// Store the request metadata and tags as user metadata (x-amz-meta-*)
+	if fields := meta.FromContext(ctx).Fields(); len(fields) > 0 {
+		input.Metadata = fields
+	}
+
+	return input
+}
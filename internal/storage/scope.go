@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Scope restricts the uploads of a caller of a shared server, e.g. the holder of an API key
+type Scope struct {
+	Name   string // Name of the API key, recorded in the audit log
+	Prefix string // Prefix of the object keys of all uploads, e.g. "team-a/"
+	Quota  int64  // Bytes that may be uploaded per day (UTC), unlimited if zero
+
+	// Storage types uploads may go to, including routes, failover storages and
+	// mirrors, all if empty
+	Storages []string
+}
+
+type scopeKey struct{}
+
+// allowsStorage reports whether uploads of the scope may go to the storage of type backend
+func (scope Scope) allowsStorage(backend string) bool {
+	if len(scope.Storages) == 0 {
+		return true
+	}
+	for _, allowed := range scope.Storages {
+		if strings.EqualFold(allowed, backend) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStorage refuses an upload of the caller to the storage of routed, or the
+// configured storage if nil, unless its scope allows it
+func (s *Service) checkStorage(ctx context.Context, routed *namedStorage) error {
+	scope, _ := ScopeFromContext(ctx)
+	backend := strings.ToLower(s.Config.StorageType)
+	if routed != nil {
+		backend = routed.backend
+	}
+	if !scope.allowsStorage(backend) {
+		return fmt.Errorf("upload refused: API key %s may not upload to storage %s", scope.Name, backend)
+	}
+	return nil
+}
+
+// WithScope returns a context restricting uploads to scope
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope of the caller, if any
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	return scope, ok
+}
+
+// quotaTracker counts the bytes uploaded per scope today
+type quotaTracker struct {
+	mu   sync.Mutex
+	day  time.Time
+	used map[string]int64
+	// seed returns the bytes a scope uploaded since a time before the server
+	// started, so restarts don't reset the quotas
+	seed func(name string, since time.Time) int64
+}
+
+func newQuotaTracker(seed func(name string, since time.Time) int64) *quotaTracker {
+	return &quotaTracker{used: make(map[string]int64), seed: seed}
+}
+
+// reserve counts size bytes against the quota of scope, refusing the upload if it
+// would exceed it. Uploads of unknown size pass a size of zero and are only
+// refused once the quota is used up.
+func (t *quotaTracker) reserve(scope Scope, size int64) error {
+	if scope.Quota <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	used := t.usedLocked(scope.Name)
+	if used >= scope.Quota || used+size > scope.Quota {
+		return fmt.Errorf("upload refused: API key %s would exceed its daily quota of %s, %s used today",
+			scope.Name, util.FormatSize(scope.Quota), util.FormatSize(used))
+	}
+	t.used[scope.Name] = used + size
+	return nil
+}
+
+// add counts size more bytes against the quota of scope, or gives back a
+// reservation if negative
+func (t *quotaTracker) add(scope Scope, size int64) {
+	if scope.Quota <= 0 || size == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.used[scope.Name] = t.usedLocked(scope.Name) + size
+}
+
+// usedLocked returns the bytes uploaded by name today, starting a new count at midnight.
+// The caller holds t.mu.
+func (t *quotaTracker) usedLocked(name string) int64 {
+	if today := time.Now().UTC().Truncate(24 * time.Hour); !today.Equal(t.day) {
+		t.day = today
+		clear(t.used)
+	}
+	used, ok := t.used[name]
+	if !ok && t.seed != nil {
+		used = t.seed(name, t.day)
+		t.used[name] = used
+	}
+	return used
+}
+
+// auditUsage returns a seed function summing the successful uploads recorded
+// in the audit log at path
+func auditUsage(path string) func(name string, since time.Time) int64 {
+	return func(name string, since time.Time) int64 {
+		entries, err := audit.ReadAll(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to read audit log, quota usage starts at zero")
+			return 0
+		}
+		var used int64
+		for _, entry := range (audit.Query{Since: since, Result: audit.ResultSuccess}).Filter(entries) {
+			if entry.APIKey == name {
+				used += entry.Size
+			}
+		}
+		return used
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/memory"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	tracker := newQuotaTracker(func(name string, since time.Time) int64 {
+		if name == "seeded" {
+			return 60
+		}
+		return 0
+	})
+	scope := Scope{Name: "a", Quota: 100}
+
+	if err := tracker.reserve(scope, 60); err != nil {
+		t.Fatalf("reserve() = %v", err)
+	}
+	if err := tracker.reserve(scope, 50); err == nil || !strings.Contains(err.Error(), "daily quota") {
+		t.Errorf("reserve() = %v, want the quota exceeded", err)
+	}
+
+	// A failed upload gives back its reservation
+	tracker.add(scope, -60)
+	if err := tracker.reserve(scope, 100); err != nil {
+		t.Errorf("reserve() after giving back = %v", err)
+	}
+
+	// Uploads of unknown size are only refused once the quota is used up
+	if err := tracker.reserve(scope, 0); err == nil {
+		t.Error("reserve() passed an upload with the quota used up")
+	}
+
+	// Scopes are counted apart, starting at the usage recorded before
+	if err := tracker.reserve(Scope{Name: "seeded", Quota: 100}, 50); err == nil {
+		t.Error("reserve() ignored the seeded usage")
+	}
+	if err := tracker.reserve(Scope{Name: "b", Quota: 100}, 50); err != nil {
+		t.Errorf("reserve() of another scope = %v", err)
+	}
+
+	// Scopes without a quota are unlimited
+	if err := tracker.reserve(Scope{Name: "unlimited"}, 1<<40); err != nil {
+		t.Errorf("reserve() without a quota = %v", err)
+	}
+}
+
+func TestQuotaTrackerNewDay(t *testing.T) {
+	tracker := newQuotaTracker(nil)
+	scope := Scope{Name: "a", Quota: 100}
+	if err := tracker.reserve(scope, 100); err != nil {
+		t.Fatal(err)
+	}
+	tracker.day = tracker.day.Add(-24 * time.Hour)
+	if err := tracker.reserve(scope, 100); err != nil {
+		t.Errorf("reserve() on a new day = %v", err)
+	}
+}
+
+func TestServiceScope(t *testing.T) {
+	backend := &recordingStorage{}
+	s := NewServiceWithStorage(&Config{StorageType: "test"}, backend)
+	ctx := WithScope(context.Background(), Scope{Name: "team-a", Prefix: "team-a/", Quota: 10})
+
+	result, err := s.UploadDetailed(ctx, strings.NewReader("0123456789"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(result.ObjectKey, "team-a/") {
+		t.Errorf("object key = %s, want it under team-a/", result.ObjectKey)
+	}
+
+	if _, err := s.UploadDetailed(ctx, strings.NewReader("x"), "b.txt"); err == nil || !strings.Contains(err.Error(), "daily quota") {
+		t.Errorf("UploadDetailed() = %v, want the quota exceeded", err)
+	}
+	if n := backend.uploads.Load(); n != 1 {
+		t.Errorf("%d uploads reached the storage, want 1", n)
+	}
+}
+
+func TestServiceScopeStorages(t *testing.T) {
+	router := &routerStorage{
+		fallback:     memory.NewMemoryStorage(memory.MemoryConfig{}),
+		fallbackType: "s3",
+		routes:       []Route{{Pattern: ".pdf", StorageType: "github"}, {Pattern: ".zip", StorageType: "qiniu"}},
+		storages:     map[string]Storage{"github": &recordingStorage{}, "qiniu": failingStorage{}},
+	}
+	mirror := &recordingStorage{}
+	s := NewServiceWithStorage(&Config{StorageType: "s3", FileFormat: "{filename}{ext}"}, router)
+	s.mirrors = []namedStorage{{backend: "r2", storage: mirror}}
+	s.failover = []namedStorage{{backend: "oss", storage: memory.NewMemoryStorage(memory.MemoryConfig{})}}
+	ctx := WithScope(context.Background(), Scope{Name: "ci", Storages: []string{"S3", "qiniu"}})
+
+	// Uploads to an allowed storage pass, without the mirror the key can't reach
+	result, err := s.UploadDetailed(ctx, strings.NewReader("text"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Backend != "s3" || len(result.Mirrors) != 0 || mirror.uploads.Load() != 0 {
+		t.Errorf("uploaded to %s and %d mirrors, want only s3", result.Backend, mirror.uploads.Load())
+	}
+
+	// Uploads routed to another storage are refused
+	path := filepath.Join(t.TempDir(), "b.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	refused := map[string]error{
+		"file": func() error { _, err := s.UploadFileDetailed(ctx, path, "b.pdf"); return err }(),
+		"data": func() error { _, err := s.UploadDetailed(ctx, strings.NewReader("%PDF-1.4\n"), "c.pdf"); return err }(),
+	}
+	for name, err := range refused {
+		if err == nil || !strings.Contains(err.Error(), "may not upload to storage github") {
+			t.Errorf("upload of %s routed to github = %v, want it refused", name, err)
+		}
+	}
+
+	// Failover storages the key can't reach are skipped
+	if _, err := s.UploadFileDetailed(ctx, path, "d.zip"); err == nil {
+		t.Error("UploadFileDetailed() failed over to a storage the key can't reach")
+	}
+	result, err = s.UploadFileDetailed(WithScope(context.Background(), Scope{Name: "ops"}), path, "e.zip")
+	if err != nil || result.Backend != "oss" {
+		t.Errorf("UploadFileDetailed() without storages = %v, want it failed over to oss", err)
+	}
+}
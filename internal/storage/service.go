@@ -5,98 +5,684 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"mime"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
 )
 
 type Service struct {
 	Storage Storage
 	Config  *Config
+
+	auditLog    *audit.Logger
+	stats       *statsRecorder
+	idempotency *idempotencyCache
+	policy      *typePolicy
+	quotas      *quotaTracker
+	denylist    *hashDenylist
+	watermark   *watermarker
+	mirrors     []namedStorage
+	failover    []namedStorage
 }
 
 // NewService creates a new service using environment variables for configuration
 func NewService() *Service {
-	config := NewConfigFromEnv()
-	return &Service{
-		Storage: NewStorage(config),
-		Config:  config,
-	}
+	return NewServiceWithConfig(NewConfigFromEnv())
 }
 
 // NewServiceWithConfig creates a new service using the provided configuration
 func NewServiceWithConfig(config *Config) *Service {
-	return &Service{
-		Storage: NewStorage(config),
-		Config:  config,
+	return NewServiceWithStorage(config, NewStorage(config))
+}
+
+// NewServiceWithStorage creates a new service uploading to an already initialized storage
+func NewServiceWithStorage(config *Config, storage Storage) *Service {
+	s := &Service{
+		Storage:     storage,
+		Config:      config,
+		stats:       newStatsRecorder(),
+		idempotency: newIdempotencyCache(config.IdempotencyWindow),
+		policy:      newTypePolicy(config.AllowTypes, config.DenyTypes),
+		quotas:      newQuotaTracker(nil),
+		denylist:    newHashDenylist(config.DenyHashes),
+		watermark:   newWatermarker(config),
+		mirrors:     openStorages(config, config.Mirrors),
+		failover:    openStorages(config, config.Failover),
 	}
+
+	if config.AuditLog != "" {
+		auditLog, err := audit.Open(config.AuditLog)
+		if err != nil {
+			log.Error().Err(err).Str("path", config.AuditLog).Msg("Failed to open audit log, uploads will not be audited")
+		} else {
+			s.auditLog = auditLog
+			s.quotas.seed = auditUsage(config.AuditLog)
+		}
+	}
+
+	return s
+}
+
+// Close releases resources held by the service, e.g. the audit log and plugin processes
+func (s *Service) Close() error {
+	if closer, ok := s.Storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close storage")
+		}
+	}
+	s.closeStorages()
+	return s.auditLog.Close()
+}
+
+// UploadResult describes an uploaded object
+type UploadResult struct {
+	URL       string    `json:"url"`
+	ObjectKey string    `json:"object_key"`
+	Backend   string    `json:"backend"` // Storage type
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // When the URL expires, zero if it doesn't
+
+	// Thumbnail uploaded next to an image or video, if enabled (see Config.Thumbnails)
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	ThumbnailKey string `json:"thumbnail_key,omitempty"`
+
+	// Text recognized in an image, and the text file uploaded next to it, if enabled (see Config.OCR)
+	OCRText    string `json:"ocr_text,omitempty"`
+	OCRTextURL string `json:"ocr_text_url,omitempty"`
+
+	// Copies of the upload on the mirror storages, if configured (see Config.Mirrors)
+	Mirrors []MirrorResult `json:"mirrors,omitempty"`
 }
 
 // UploadFile uploads a file to the configured storage service
 // Uses the default format or a format specified by environment variable
 func (s *Service) UploadFile(ctx context.Context, path string) (string, error) {
-	// Get format from environment variable, default to empty string
-	format := getEnv("FSM_FILE_FORMAT", "")
+	return resultURL(s.UploadFileDetailed(ctx, path, filepath.Base(path)))
+}
+
+// UploadFileWithName uploads a file using filename instead of the local file name
+// to build the object key, e.g. the name taken from a download URL
+func (s *Service) UploadFileWithName(ctx context.Context, path string, filename string) (string, error) {
+	return resultURL(s.UploadFileDetailed(ctx, path, filename))
+}
+
+// UploadFileWithFormat uploads a file with a custom format string
+func (s *Service) UploadFileWithFormat(ctx context.Context, path string, format string) (string, error) {
+	return resultURL(s.uploadFile(ctx, path, filepath.Base(path), format))
+}
+
+// UploadFileDetailed uploads a file like UploadFileWithName and describes the uploaded object
+func (s *Service) UploadFileDetailed(ctx context.Context, path string, filename string) (*UploadResult, error) {
+	return s.uploadFile(ctx, path, filename, s.Config.FileFormat)
+}
+
+// UploadFileDetailedWithFormat uploads a file like UploadFileDetailed with a custom format string
+func (s *Service) UploadFileDetailedWithFormat(ctx context.Context, path string, filename string, format string) (*UploadResult, error) {
+	return s.uploadFile(ctx, path, filename, format)
+}
+
+// resultURL returns the URL of an upload result
+func resultURL(result *UploadResult, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return result.URL, nil
+}
+
+// uploadFile formats the object key for filename and uploads the local file at path
+func (s *Service) uploadFile(ctx context.Context, path string, filename string, format string) (*UploadResult, error) {
+	source := path
 	if len(format) == 0 {
-		format = "{timestamp}-{filename}{ext}"
+		format = DefaultFileFormat
+	}
+
+	// Infer a missing extension from the file content
+	if filepath.Ext(filename) == "" {
+		filename += util.ExtensionByType(util.GetFileContentType(path, filename))
+	}
+
+	// Refuse blocked content types before anything is transformed or uploaded, so
+	// a denied document can't get through as the PDF it is converted into
+	scope, _ := ScopeFromContext(ctx)
+	r := &uploadRecord{source: audit.SourceFromContext(ctx, source), path: path}
+	if err := s.policy.checkFile(path, filename); err != nil {
+		r.objectKey, r.err = scope.Prefix+FormatObjectKey(filename, format), err
+		s.record(ctx, r)
+		return nil, err
+	}
+
+	// Hash the file before any transform, to refuse denied files whatever they are
+	// converted into, and so a retry of the same upload reuses the object key of the
+	// first attempt instead of creating another object
+	if s.idempotency != nil || s.denylist != nil {
+		if size, sum, err := util.HashFile(path); err == nil {
+			r.size, r.sha256 = size, sum
+		}
+	}
+	if s.denylist != nil {
+		err := s.denylist.check(ctx, filename, r.sha256)
+		if r.sha256 == "" {
+			err = fmt.Errorf("upload refused: failed to hash %s for the hash denylist", filename)
+		}
+		if err != nil {
+			r.objectKey, r.err = scope.Prefix+FormatObjectKey(filename, format), err
+			s.record(ctx, r)
+			return nil, err
+		}
+	}
+
+	// Convert text in legacy encodings to UTF-8 if enabled
+	if s.Config.TextTranscode {
+		transcoded, err := transcodeTextFile(ctx, path, filename)
+		if err != nil {
+			return nil, err
+		}
+		if transcoded != "" {
+			defer os.Remove(transcoded)
+			path = transcoded
+		}
+	}
+
+	// Convert office documents to PDF if enabled, uploading the original if that fails
+	if s.Config.OfficeToPDF && util.IsOfficeFile(filename) {
+		converted, err := convertOfficeFile(ctx, path, filename, s.Config.OfficeConverter)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("source", source).Msg("Failed to convert document to PDF, uploading it as is")
+			addNote(ctx, fmt.Sprintf("not converted to PDF: %v", err))
+		} else {
+			defer os.Remove(converted)
+			path = converted
+			filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".pdf"
+		}
 	}
 
-	// Get the filename
-	filename := filepath.Base(path)
+	// Format the object key using the FormatObjectKey function, within the prefix of the caller
+	formattedFilename := scope.Prefix + FormatObjectKey(filename, format)
 
-	// Format the object key using the FormatObjectKey function
-	formattedFilename := FormatObjectKey(filename, format)
+	// Stamp the watermark onto images, if configured
+	watermarked, err := s.watermark.apply(ctx, path, filename)
+	if err != nil {
+		r.objectKey, r.err = formattedFilename, err
+		s.record(ctx, r)
+		return nil, err
+	}
+	if watermarked != "" {
+		defer os.Remove(watermarked)
+		path = watermarked
+	}
+	r.path = path
+
+	// The upload result describes the original file, not what it was transformed into
+	if path != source && r.sha256 == "" {
+		if size, sum, err := util.HashFile(source); err == nil {
+			r.size, r.sha256 = size, sum
+		}
+	}
+
+	// Check what the file was converted into as well
+	if path != source {
+		if err := s.policy.checkFile(path, filename); err != nil {
+			r.objectKey, r.err = formattedFilename, err
+			s.record(ctx, r)
+			return nil, err
+		}
+	}
+
+	// The key identifies the original file, so retries match however it is transformed
+	var idempotencyKey string
+	if s.idempotency != nil && r.sha256 != "" {
+		idempotencyKey = IdempotencyKey(r.sha256, strings.ToLower(s.Config.StorageType)+":"+scope.Prefix+format+":"+filename, s.uploadMetadata(ctx))
+	}
+
+	entry := s.idempotency.acquire(idempotencyKey, formattedFilename)
+	if entry != nil {
+		if entry.result != nil {
+			log.Ctx(ctx).Info().Str("source", r.source).Str("key", entry.objectKey).Msg("Upload already done, returning its result")
+			result := *entry.result
+			entry.release(nil)
+			return &result, nil
+		}
+		formattedFilename = entry.objectKey
+	}
+
+	// Refuse overwrites in no-delete mode and count the file against the daily quota of the caller
+	size := r.size
+	if r.sha256 == "" || path != source {
+		if fileInfo, err := os.Stat(path); err == nil {
+			size = fileInfo.Size()
+		}
+	}
+	r.routed = s.routeOf(formattedFilename, util.GetFileContentType(path, formattedFilename))
+	err = s.checkStorage(ctx, r.routed)
+	if err == nil {
+		err = s.checkOverwrite(ctx, formattedFilename)
+	}
+	if err == nil {
+		err = s.quotas.reserve(scope, size)
+	}
+	if err != nil {
+		r.objectKey, r.err = formattedFilename, err
+		s.record(ctx, r)
+		entry.release(nil)
+		return nil, err
+	}
 
 	// Upload the file with the formatted key
-	return s.Storage.UploadFile(ctx, path, formattedFilename)
+	start := time.Now()
+	uploadCtx := s.newUploadContext(ctx, filename, idempotencyKey)
+	url, err := s.Storage.UploadFile(uploadCtx, path, formattedFilename)
+	err = s.wrapError(OperationPut, err)
+	if err != nil {
+		url, r.served, err = s.uploadFailover(ctx, formattedFilename, err, func(storage Storage) (string, error) {
+			return storage.UploadFile(uploadCtx, path, formattedFilename)
+		})
+	}
+	r.objectKey = formattedFilename
+	r.duration = time.Since(start)
+	r.url, r.err = url, err
+	if err != nil {
+		s.quotas.add(scope, -size)
+	}
+	s.record(ctx, r)
+	if err == nil {
+		r.mirrors = s.uploadMirrors(ctx, path, formattedFilename, filename, idempotencyKey)
+	}
+	s.uploadThumbnail(ctx, r, filename)
+	s.extractText(ctx, r, filename)
+	entry.release(r.result())
+	return r.result(), err
 }
 
-// UploadFileWithFormat uploads a file with a custom format string
-func (s *Service) UploadFileWithFormat(ctx context.Context, path string, format string) (string, error) {
-	if len(format) == 0 {
-		format = "{timestamp}-{filename}{ext}"
+// uploadRecord describes a finished upload for logging, statistics and auditing
+type uploadRecord struct {
+	source    string
+	path      string // Local file that was uploaded, if any
+	objectKey string
+	size      int64
+	sha256    string
+	duration  time.Duration
+	url       string
+	expiresAt time.Time
+	backend   string
+	err       error
+
+	thumbnailURL string
+	thumbnailKey string
+	ocrText      string
+	ocrTextURL   string
+	mirrors      []MirrorResult
+
+	routed *namedStorage // Storage of the route the upload matched, nil for the configured storage
+	served *namedStorage // Failover storage that took the upload, nil for the configured storage
+}
+
+// result describes the uploaded object, or nil if the upload failed
+func (r *uploadRecord) result() *UploadResult {
+	if r.err != nil {
+		return nil
 	}
+	return &UploadResult{
+		URL:       r.url,
+		ObjectKey: r.objectKey,
+		Backend:   r.backend,
+		Size:      r.size,
+		SHA256:    r.sha256,
+		ExpiresAt: r.expiresAt,
 
-	// Get the filename
-	filename := filepath.Base(path)
+		ThumbnailURL: r.thumbnailURL,
+		ThumbnailKey: r.thumbnailKey,
+		OCRText:      r.ocrText,
+		OCRTextURL:   r.ocrTextURL,
+		Mirrors:      r.mirrors,
+	}
+}
 
-	// Format the object key using the FormatObjectKey function
-	formattedFilename := FormatObjectKey(filename, format)
+// record logs a finished upload and adds it to the statistics and the audit log
+func (s *Service) record(ctx context.Context, r *uploadRecord) {
+	logger := log.Ctx(ctx)
+	if r.err != nil {
+		logger.Error().Err(r.err).Str("source", r.source).Str("key", r.objectKey).Msg("Upload failed")
+	} else {
+		logger.Debug().Str("source", r.source).Str("key", r.objectKey).Dur("duration", r.duration).Msg("Upload succeeded")
+	}
 
-	// Upload the file with the formatted key
-	return s.Storage.UploadFile(ctx, path, formattedFilename)
+	// Hash local files for the upload result and the audit log, unless already done
+	if r.path != "" && r.err == nil && r.sha256 == "" {
+		size, sum, err := util.HashFile(r.path)
+		if err != nil {
+			logger.Warn().Err(err).Str("path", r.path).Msg("Failed to hash uploaded file")
+		}
+		r.size, r.sha256 = size, sum
+	} else if r.path != "" && r.err != nil {
+		if fileInfo, err := os.Stat(r.path); err == nil {
+			r.size = fileInfo.Size()
+		}
+	}
+
+	backend, storage := strings.ToLower(s.Config.StorageType), s.Storage
+	if r.served != nil {
+		backend, storage = r.served.backend, r.served.storage
+	} else if r.routed != nil {
+		backend, storage = r.routed.backend, r.routed.storage
+	}
+	if r.err == nil {
+		s.checkThresholds(ctx, r)
+		if expirer, ok := storage.(URLExpirer); ok {
+			if expiration := expirer.URLExpiration(); expiration > 0 {
+				r.expiresAt = time.Now().Add(expiration)
+			}
+		}
+	}
+
+	r.backend = backend
+	s.stats.record(backend, r.size, r.duration, r.err)
+
+	if s.auditLog == nil {
+		return
+	}
+	caller := audit.CallerFromContext(ctx)
+	scope, _ := ScopeFromContext(ctx)
+	entry := audit.Entry{
+		ID:           uuid.New().String(),
+		Time:         time.Now(),
+		Source:       r.source,
+		ObjectKey:    r.objectKey,
+		Backend:      backend,
+		Size:         r.size,
+		SHA256:       r.sha256,
+		DurationMS:   r.duration.Milliseconds(),
+		Transport:    caller.Transport,
+		Session:      caller.Session,
+		RequestID:    caller.RequestID,
+		Tool:         caller.Tool,
+		Client:       caller.Client,
+		APIKey:       scope.Name,
+		RemoteAddr:   caller.RemoteAddr,
+		ForwardedFor: caller.ForwardedFor,
+		Result:       audit.ResultSuccess,
+		URL:          r.url,
+		ExpiresAt:    r.expiresAt,
+	}
+	if r.err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = r.err.Error()
+	}
+
+	if err := s.auditLog.Write(entry); err != nil {
+		logger.Error().Err(err).Msg("Failed to write audit log")
+	}
 }
 
-// Upload uploads data from an io.Reader to the configured storage service
-func (s *Service) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
-	// Get format from environment variable, default to empty string
-	format := getEnv("FSM_FILE_FORMAT", "")
-	if len(format) == 0 {
-		format = "{timestamp}-{filename}{ext}"
+// checkThresholds warns about uploads that were slow or large, which usually means
+// the chosen backend or file is making tool calls take minutes
+func (s *Service) checkThresholds(ctx context.Context, r *uploadRecord) {
+	var notes []string
+	if threshold := s.Config.LargeFileThreshold; threshold > 0 && r.size > threshold {
+		log.Ctx(ctx).Warn().Str("key", r.objectKey).Int64("size", r.size).Int64("threshold", threshold).Msg("Large file uploaded")
+		notes = append(notes, fmt.Sprintf("large file: %s exceeds %s", util.FormatSize(r.size), util.FormatSize(threshold)))
+	}
+	if threshold := s.Config.SlowUploadThreshold; threshold > 0 && r.duration > threshold {
+		log.Ctx(ctx).Warn().Str("key", r.objectKey).Dur("duration", r.duration).Dur("threshold", threshold).Msg("Slow upload")
+		notes = append(notes, fmt.Sprintf("slow upload: took %s, more than %s", r.duration.Round(time.Second), threshold))
 	}
 
-	// Format the object key using the FormatObjectKey function
-	formattedFilename := FormatObjectKey(filename, format)
+	if s.Config.UploadNotes {
+		for _, note := range notes {
+			addNote(ctx, note)
+		}
+	}
+}
 
-	// Upload the data with the formatted key
-	return s.Storage.Upload(ctx, body, formattedFilename)
+// uploadMetadata returns the metadata stored with the objects uploaded in ctx that
+// doesn't change between retries, i.e. the tags and the content disposition
+func (s *Service) uploadMetadata(ctx context.Context) map[string]string {
+	metadata := make(map[string]string)
+	for key, value := range TagsFromContext(ctx) {
+		metadata["tag:"+key] = value
+	}
+	if s.Config.ContentDisposition != "" {
+		metadata["content-disposition"] = strings.ToLower(s.Config.ContentDisposition)
+	}
+	return metadata
+}
+
+// newUploadContext attaches the metadata of an upload of filename to ctx
+func (s *Service) newUploadContext(ctx context.Context, filename string, idempotencyKey string) context.Context {
+	caller := audit.CallerFromContext(ctx)
+	m := &meta.Meta{
+		OriginalName:   filename,
+		Tool:           caller.Tool,
+		Session:        caller.Session,
+		Client:         caller.Client,
+		RequestID:      caller.RequestID,
+		Tags:           TagsFromContext(ctx),
+		IdempotencyKey: idempotencyKey,
+	}
+
+	// Let downloads get the human-readable filename instead of the object key
+	switch disposition := strings.ToLower(s.Config.ContentDisposition); disposition {
+	case "inline", "attachment":
+		m.ContentDisposition = mime.FormatMediaType(disposition, map[string]string{"filename": filename})
+	}
+
+	return meta.NewContext(ctx, m)
+}
+
+// convertOfficeFile converts an office document into a PDF temp file, returning its path
+func convertOfficeFile(ctx context.Context, path string, filename string, command []string) (string, error) {
+	dst, err := os.CreateTemp("", "office-*.pdf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	dst.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, officeConvertTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := util.ConvertToPDF(ctx, path, dst.Name(), command); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	log.Ctx(ctx).Debug().Str("filename", filename).Dur("duration", time.Since(start)).Msg("Converted document to PDF")
+	return dst.Name(), nil
+}
+
+// transcodeTextFile converts a text file that isn't UTF-8 into a UTF-8 temp file.
+// It returns the temp file path, or an empty string if no conversion is needed.
+func transcodeTextFile(ctx context.Context, path string, filename string) (string, error) {
+	if !util.IsTextContentType(util.GetFileContentType(path, filename)) {
+		return "", nil
+	}
+
+	charset, err := util.DetectFileCharset(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect charset: %w", err)
+	}
+	if charset == "" || charset == util.CharsetUTF8 {
+		return "", nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "transcode-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer dst.Close()
+
+	if err := util.TranscodeToUTF8(dst, src, charset); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to transcode %s from %s: %w", filename, charset, err)
+	}
+	log.Ctx(ctx).Debug().Str("file", filename).Str("charset", charset).Msg("Transcoded text file to UTF-8")
+	return dst.Name(), nil
+}
+
+// Upload uploads data from an io.Reader to the configured storage service
+func (s *Service) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return resultURL(s.upload(ctx, body, filename, s.Config.FileFormat))
 }
 
 // UploadWithFormat uploads data from an io.Reader with a custom format string
 func (s *Service) UploadWithFormat(ctx context.Context, body io.Reader, filename string, format string) (string, error) {
+	return resultURL(s.upload(ctx, body, filename, format))
+}
+
+// UploadDetailed uploads data like Upload and describes the uploaded object
+func (s *Service) UploadDetailed(ctx context.Context, body io.Reader, filename string) (*UploadResult, error) {
+	return s.upload(ctx, body, filename, s.Config.FileFormat)
+}
+
+// upload formats the object key for filename and uploads the data read from body
+func (s *Service) upload(ctx context.Context, body io.Reader, filename string, format string) (*UploadResult, error) {
 	if len(format) == 0 {
-		format = "{timestamp}-{filename}{ext}"
+		format = DefaultFileFormat
+	}
+
+	// Infer a missing extension from the data
+	if filepath.Ext(filename) == "" {
+		var contentType string
+		contentType, body = util.PeekContentType(body, filename)
+		filename += util.ExtensionByType(contentType)
 	}
 
-	// Format the object key using the FormatObjectKey function
-	formattedFilename := FormatObjectKey(filename, format)
+	// Format the object key using the FormatObjectKey function, within the prefix of the caller
+	scope, _ := ScopeFromContext(ctx)
+	formattedFilename := scope.Prefix + FormatObjectKey(filename, format)
+
+	// Refuse blocked content types before anything is uploaded. The size isn't
+	// known yet, so it counts against the quota afterwards.
+	routed, body := s.routeReader(body, formattedFilename)
+	body, err := s.policy.checkReader(body, filename)
+	if err == nil {
+		err = s.checkStorage(ctx, routed)
+	}
+	if err == nil {
+		err = s.checkOverwrite(ctx, formattedFilename)
+	}
+	if err == nil {
+		err = s.quotas.reserve(scope, 0)
+	}
+	if err != nil {
+		s.record(ctx, &uploadRecord{source: audit.SourceFromContext(ctx, filename), objectKey: formattedFilename, err: err, routed: routed})
+		return nil, err
+	}
+
+	// Keep a copy of the data for the mirrors, which upload it once the configured
+	// storage has it, and for the failover storages, which upload it if it fails.
+	// With a hash denylist, all the data is copied and checked before the upload.
+	var copyFile *os.File
+	if s.denylist != nil || len(s.mirrors) > 0 || len(s.failover) > 0 {
+		file, err := os.CreateTemp("", "copy-*")
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to create temp file, the upload will not be mirrored or failed over")
+		} else {
+			defer os.Remove(file.Name())
+			defer file.Close()
+			copyFile = file
+		}
+	}
+	if s.denylist != nil {
+		err := fmt.Errorf("upload refused: failed to create temp file for the hash denylist")
+		if copyFile != nil {
+			err = s.denylist.spool(ctx, body, copyFile, filename)
+			body = copyFile
+		}
+		if err != nil {
+			s.record(ctx, &uploadRecord{source: audit.SourceFromContext(ctx, filename), objectKey: formattedFilename, err: err})
+			return nil, err
+		}
+	} else if copyFile != nil {
+		body = io.TeeReader(body, copyFile)
+	}
+
+	// Measure and hash the data on the fly for the statistics and the audit log
+	hashed := util.NewHashingReader(body)
 
 	// Upload the data with the formatted key
-	return s.Storage.Upload(ctx, body, formattedFilename)
+	start := time.Now()
+	uploadCtx := s.newUploadContext(ctx, filename, "")
+	url, err := s.Storage.Upload(uploadCtx, hashed, formattedFilename)
+	err = s.wrapError(OperationPut, err)
+	var served *namedStorage
+	if err != nil && copyFile != nil && len(s.failover) > 0 {
+		// Read the rest of the data into the copy, the failed upload may not have
+		if _, copyErr := io.Copy(io.Discard, hashed); copyErr == nil {
+			url, served, err = s.uploadFailover(ctx, formattedFilename, err, func(storage Storage) (string, error) {
+				return storage.UploadFile(uploadCtx, copyFile.Name(), formattedFilename)
+			})
+		}
+	}
+	if err == nil {
+		s.quotas.add(scope, hashed.Size())
+	}
+	r := &uploadRecord{
+		source:    audit.SourceFromContext(ctx, filename),
+		objectKey: formattedFilename,
+		size:      hashed.Size(),
+		sha256:    hashed.Sum(),
+		duration:  time.Since(start),
+		url:       url,
+		err:       err,
+		routed:    routed,
+		served:    served,
+	}
+	s.record(ctx, r)
+	if err == nil && copyFile != nil {
+		r.mirrors = s.uploadMirrors(ctx, copyFile.Name(), formattedFilename, filename, "")
+	}
+	return r.result(), err
+}
+
+// CheckArchivedFiles refuses files blocked by the content type policy that are
+// about to be written into an archive uploaded as filename, for archives whose
+// entries can't be inspected at upload, e.g. encrypted ones
+func (s *Service) CheckArchivedFiles(filename string, files []util.DirFile) error {
+	return s.policy.checkArchived(filename, files)
 }
 
+// CheckContent verifies that the content of the file at path matches the extension of filename.
+// Depending on the configured policy, a mismatch yields a warning message or an error.
+func (s *Service) CheckContent(ctx context.Context, path string, filename string) (string, error) {
+	policy := strings.ToLower(s.Config.ContentMismatch)
+	if policy == MismatchPolicyOff {
+		return "", nil
+	}
+
+	sniffed, err := util.SniffFileContentType(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if util.ContentMatchesExtension(filename, sniffed) {
+		return "", nil
+	}
+
+	msg := fmt.Sprintf("content of %s looks like %s, which doesn't match its extension (%s)",
+		filename, sniffed, util.GetContentType(filename))
+	if policy == MismatchPolicyReject {
+		return "", fmt.Errorf("upload refused: %s", msg)
+	}
+	log.Ctx(ctx).Warn().Str("file", filename).Str("sniffed", sniffed).Msg("File content doesn't match its extension")
+	return msg, nil
+}
+
+// DefaultFileFormat is the object key format of uploads if Config.FileFormat is empty
+const DefaultFileFormat = "{timestamp}-{filename}{ext}"
+
 // FormatObjectKey formats the object key based on the provided format string
 // Supports the following placeholders:
 // {filename} - original filename without extension
@@ -0,0 +1,298 @@
+package storage
+
+// Setting describes an environment variable used to configure a storage backend
+type Setting struct {
+	Env         string
+	Description string
+	Default     string
+	Required    bool
+	Secret      bool // Credentials that must not be printed
+}
+
+// BackendInfo describes a supported storage backend and its settings
+type BackendInfo struct {
+	Type     string
+	Name     string
+	Settings []Setting
+}
+
+// Backends lists all supported storage backends with their settings
+var Backends = []BackendInfo{
+	{
+		Type: StorageTypeS3,
+		Name: "AWS S3 and compatible services",
+		Settings: []Setting{
+			{Env: "FSM_S3_BUCKET", Description: "S3 bucket name", Required: true},
+			{Env: "FSM_S3_PREFIX", Description: "Key prefix of uploaded objects, e.g. mcp-uploads/"},
+			{Env: "FSM_S3_PROVIDER", Description: "Preset of an S3-compatible provider: aws, wasabi, digitalocean or scaleway"},
+			{Env: "FSM_S3_REGION", Description: "AWS region, required unless a provider preset sets a default"},
+			{Env: "FSM_S3_ACCESS_KEY", Description: "AWS access key ID", Required: true, Secret: true},
+			{Env: "FSM_S3_SECRET_KEY", Description: "AWS secret access key", Required: true, Secret: true},
+			{Env: "FSM_S3_ENDPOINT", Description: "Custom endpoint for S3-compatible services, e.g. Cloudflare R2"},
+			{Env: "FSM_S3_SESSION", Description: "AWS session token", Secret: true},
+			{Env: "FSM_S3_FORCE_PATH_STYLE", Description: "Address buckets in the path instead of the host name, e.g. for MinIO", Default: "false"},
+			{Env: "FSM_S3_USE_ACCELERATE", Description: "Upload through the S3 Transfer Acceleration endpoint", Default: "false"},
+			{Env: "FSM_S3_REQUESTER_PAYS", Description: "Acknowledge requester-pays charges (x-amz-request-payer)", Default: "false"},
+			{Env: "FSM_S3_HEADERS", Description: "Extra headers of upload requests, e.g. x-amz-tagging=team%3Dai,x-custom=value"},
+			{Env: "FSM_S3_URL_EXPIRATION", Description: "Presigned URL expiration time in seconds", Default: "604800"},
+			{Env: "FSM_S3_PUBLIC_URL", Description: "Public URL of the bucket, e.g. a CDN, returned instead of presigned URLs"},
+			{Env: "FSM_S3_USE_PRESIGN", Description: "Return presigned URLs, false returns unsigned URLs of a public bucket", Default: "true"},
+			{Env: "FSM_S3_CACHE_CONTROL", Description: "Cache-Control header for uploaded objects"},
+			{Env: "FSM_S3_SSE", Description: "Server-side encryption of uploaded objects: AES256, aws:kms or aws:kms:dsse"},
+			{Env: "FSM_S3_KMS_KEY_ID", Description: "KMS key ID or ARN for SSE-KMS, the bucket's default key if unset"},
+			{Env: "FSM_S3_STORAGE_CLASS", Description: "Storage class of uploaded objects, e.g. STANDARD_IA or INTELLIGENT_TIERING"},
+		},
+	},
+	{
+		Type: StorageTypeR2,
+		Name: "Cloudflare R2",
+		Settings: []Setting{
+			{Env: "FSM_R2_ACCOUNT_ID", Description: "Cloudflare account ID", Required: true},
+			{Env: "FSM_R2_ACCESS_KEY", Description: "R2 API token access key ID", Required: true, Secret: true},
+			{Env: "FSM_R2_SECRET_KEY", Description: "R2 API token secret access key", Required: true, Secret: true},
+			{Env: "FSM_R2_BUCKET", Description: "R2 bucket name", Required: true},
+			{Env: "FSM_R2_PUBLIC_DOMAIN", Description: "Public bucket URL, pub-xxxx.r2.dev or a custom domain; uploads return public instead of presigned URLs"},
+			{Env: "FSM_R2_JURISDICTION", Description: "Jurisdiction of the bucket, e.g. eu or fedramp"},
+			{Env: "FSM_R2_URL_EXPIRATION", Description: "Presigned URL expiration time in seconds, at most 7 days", Default: "604800"},
+			{Env: "FSM_R2_CACHE_CONTROL", Description: "Cache-Control header for uploaded objects"},
+		},
+	},
+	{
+		Type: StorageTypeOSS,
+		Name: "Alibaba Cloud OSS",
+		Settings: []Setting{
+			{Env: "FSM_OSS_ENDPOINT", Description: "OSS endpoint, e.g. oss-cn-hangzhou.aliyuncs.com", Required: true},
+			{Env: "FSM_OSS_PUBLIC_ENDPOINT", Description: "Endpoint of download URLs if FSM_OSS_ENDPOINT is internal, derived from it by default"},
+			{Env: "FSM_OSS_ACCESS_KEY", Description: "OSS access key ID", Required: true, Secret: true},
+			{Env: "FSM_OSS_SECRET_KEY", Description: "OSS access key secret", Required: true, Secret: true},
+			{Env: "FSM_OSS_BUCKET", Description: "OSS bucket name", Required: true},
+			{Env: "FSM_OSS_DOMAIN", Description: "Custom domain for the OSS bucket"},
+			{Env: "FSM_OSS_DOMAIN_IS_PUBLIC", Description: "The custom domain serves objects without signatures, false signs URLs for it", Default: "true"},
+			{Env: "FSM_OSS_URL_EXPIRATION", Description: "Signed URL expiration time in seconds", Default: "604800"},
+			{Env: "FSM_OSS_CACHE_CONTROL", Description: "Cache-Control header for uploaded objects"},
+			{Env: "FSM_OSS_STS_TOKEN", Description: "Security token of temporary STS credentials", Secret: true},
+			{Env: "FSM_OSS_ROLE_ARN", Description: "ARN of a RAM role to assume with the access key"},
+			{Env: "FSM_OSS_ROLE_SESSION_NAME", Description: "Session name of the assumed role", Default: "file-store-mcp"},
+			{Env: "FSM_OSS_ROLE_DURATION", Description: "Validity of the role credentials, e.g. 1h", Default: "1h"},
+			{Env: "FSM_OSS_STS_ENDPOINT", Description: "STS endpoint, e.g. sts-vpc.cn-hangzhou.aliyuncs.com", Default: "sts.aliyuncs.com"},
+			{Env: "FSM_OSS_MULTIPART_THRESHOLD", Description: "Size from which files are uploaded in resumable parts, 0 to disable", Default: "100MB"},
+			{Env: "FSM_OSS_PART_SIZE", Description: "Part size of multipart uploads", Default: "10MB"},
+			{Env: "FSM_OSS_CHECKPOINT_DIR", Description: "Directory of the checkpoints of interrupted multipart uploads"},
+		},
+	},
+	{
+		Type: StorageTypeCOS,
+		Name: "Tencent Cloud COS",
+		Settings: []Setting{
+			{Env: "FSM_COS_BUCKET", Description: "COS bucket name", Required: true},
+			{Env: "FSM_COS_PREFIX", Description: "Key prefix of uploaded objects, e.g. mcp-uploads/"},
+			{Env: "FSM_COS_REGION", Description: "COS region, e.g. ap-guangzhou", Required: true},
+			{Env: "FSM_COS_APP_ID", Description: "Tencent Cloud App ID", Required: true},
+			{Env: "FSM_COS_ACCESS_KEY", Description: "Secret ID", Required: true, Secret: true},
+			{Env: "FSM_COS_SECRET_KEY", Description: "Secret Key", Required: true, Secret: true},
+			{Env: "FSM_COS_SESSION_TOKEN", Description: "Session token of temporary keys", Secret: true},
+			{Env: "FSM_COS_DOMAIN", Description: "Custom domain for the COS bucket"},
+			{Env: "FSM_COS_USE_HTTPS", Description: "Whether to use HTTPS", Default: "true"},
+			{Env: "FSM_COS_USE_ACCELERATE", Description: "Whether to use global acceleration", Default: "false"},
+			{Env: "FSM_COS_URL_EXPIRATION", Description: "Presigned URL expiration time in seconds", Default: "604800"},
+			{Env: "FSM_COS_CACHE_CONTROL", Description: "Cache-Control header for uploaded objects"},
+		},
+	},
+	{
+		Type: StorageTypeQiniu,
+		Name: "Qiniu Cloud Storage",
+		Settings: []Setting{
+			{Env: "FSM_QINIU_ACCESS_KEY", Description: "Qiniu access key", Required: true, Secret: true},
+			{Env: "FSM_QINIU_SECRET_KEY", Description: "Qiniu secret key", Required: true, Secret: true},
+			{Env: "FSM_QINIU_BUCKET", Description: "Qiniu bucket name", Required: true},
+			{Env: "FSM_QINIU_PREFIX", Description: "Key prefix of uploaded objects, e.g. mcp-uploads/"},
+			{Env: "FSM_QINIU_DOMAIN", Description: "Custom domain for the Qiniu bucket", Required: true},
+			{Env: "FSM_QINIU_REGION", Description: "Storage region: z0, z1, z2, na0 or as0", Default: "z0"},
+			{Env: "FSM_QINIU_URL_EXPIRATION", Description: "Signed URL expiration time in seconds", Default: "604800"},
+			{Env: "FSM_QINIU_PRIVATE", Description: "Whether the bucket is private, false to return permanent public URLs", Default: "true"},
+			{Env: "FSM_QINIU_MULTIPART_THRESHOLD", Description: "Size from which files are uploaded in resumable parts, 0 to disable", Default: "100MB"},
+			{Env: "FSM_QINIU_PART_SIZE", Description: "Part size of resumable uploads", Default: "8MB"},
+		},
+	},
+	{
+		Type: StorageTypeGitHub,
+		Name: "GitHub repository",
+		Settings: []Setting{
+			{Env: "FSM_GITHUB_TOKEN", Description: "GitHub personal access token with repo (or public_repo) scope, required unless a GitHub App is set", Secret: true},
+			{Env: "FSM_GITHUB_OWNER", Description: "Repository owner", Required: true},
+			{Env: "FSM_GITHUB_REPO", Description: "Repository name", Required: true},
+			{Env: "FSM_GITHUB_BRANCH", Description: "Branch name", Default: "main"},
+			{Env: "FSM_GITHUB_PATH", Description: "File storage path within the repository"},
+			{Env: "FSM_GITHUB_DOMAIN", Description: "Custom domain for GitHub content"},
+			{Env: "FSM_GITHUB_GIT_DATA_THRESHOLD", Description: "Size from which files are committed with the Git Data API instead of the contents API, 0 to disable", Default: "10MB"},
+			{Env: "FSM_GITHUB_LFS", Description: "Store the files in Git LFS and commit pointers to them", Default: "false"},
+			{Env: "FSM_GITHUB_TIMEOUT", Description: "Timeout of a single GitHub API request, including uploads", Default: "10m"},
+			{Env: "FSM_GITHUB_MAX_RETRIES", Description: "How often requests failed with a server error or rate limit are retried, 0 to disable", Default: "3"},
+			{Env: "FSM_GITHUB_RELEASE", Description: "Tag of a release to upload the files to as assets instead of committing them; created if missing"},
+			{Env: "FSM_GITHUB_APP_ID", Description: "GitHub App ID, to authenticate as an app installation instead of with a token"},
+			{Env: "FSM_GITHUB_APP_INSTALLATION_ID", Description: "Installation ID of the GitHub App on the repository owner"},
+			{Env: "FSM_GITHUB_APP_PRIVATE_KEY", Description: "Path of the GitHub App's PEM private key"},
+		},
+	},
+	{
+		Type: StorageTypeDropbox,
+		Name: "Dropbox",
+		Settings: []Setting{
+			{Env: "FSM_DROPBOX_TOKEN", Description: "Dropbox access token with files.content.write and sharing.write scopes, required unless a refresh token is set", Secret: true},
+			{Env: "FSM_DROPBOX_FOLDER", Description: "Folder the files are uploaded to, e.g. /uploads"},
+			{Env: "FSM_DROPBOX_REFRESH_TOKEN", Description: "Refresh token of the app, to get short-lived access tokens instead of using a fixed one", Secret: true},
+			{Env: "FSM_DROPBOX_APP_KEY", Description: "App key, required with a refresh token"},
+			{Env: "FSM_DROPBOX_APP_SECRET", Description: "App secret, not needed for refresh tokens issued with PKCE", Secret: true},
+		},
+	},
+	{
+		Type: StorageTypeOneDrive,
+		Name: "OneDrive and SharePoint",
+		Settings: []Setting{
+			{Env: "FSM_ONEDRIVE_CLIENT_ID", Description: "Application (client) ID of the app registration, required unless an access token is set"},
+			{Env: "FSM_ONEDRIVE_CLIENT_SECRET", Description: "Client secret of the app registration, required unless a refresh token of a public client is set", Secret: true},
+			{Env: "FSM_ONEDRIVE_TENANT_ID", Description: "Directory (tenant) ID, or common or consumers for personal accounts", Default: "common"},
+			{Env: "FSM_ONEDRIVE_REFRESH_TOKEN", Description: "Refresh token of a signed-in user, to upload as the user instead of as the app", Secret: true},
+			{Env: "FSM_ONEDRIVE_TOKEN", Description: "Fixed access token, instead of the app registration", Secret: true},
+			{Env: "FSM_ONEDRIVE_DRIVE_ID", Description: "Drive ID, required when uploading as the app unless a site ID is set"},
+			{Env: "FSM_ONEDRIVE_SITE_ID", Description: "SharePoint site ID, to upload to the site's document library"},
+			{Env: "FSM_ONEDRIVE_FOLDER", Description: "Folder the files are uploaded to, e.g. uploads"},
+			{Env: "FSM_ONEDRIVE_LINK_SCOPE", Description: "Scope of the sharing links: anonymous or organization", Default: "anonymous"},
+		},
+	},
+	{
+		Type: StorageTypeBox,
+		Name: "Box",
+		Settings: []Setting{
+			{Env: "FSM_BOX_CONFIG", Description: "Path of the JSON configuration of a JWT app, downloaded from the developer console"},
+			{Env: "FSM_BOX_CLIENT_ID", Description: "Client ID of an app using client credentials, instead of a JWT app configuration"},
+			{Env: "FSM_BOX_CLIENT_SECRET", Description: "Client secret of the app using client credentials", Secret: true},
+			{Env: "FSM_BOX_ENTERPRISE_ID", Description: "Enterprise ID, to act as the app's service account; read from the JWT app configuration if unset"},
+			{Env: "FSM_BOX_USER_ID", Description: "User ID, to act as a managed or app user instead of the service account"},
+			{Env: "FSM_BOX_TOKEN", Description: "Fixed access token, e.g. a developer token, instead of an app", Secret: true},
+			{Env: "FSM_BOX_FOLDER_ID", Description: "ID of the folder the files are uploaded to", Default: "0"},
+			{Env: "FSM_BOX_LINK_ACCESS", Description: "Who can open the shared links: open, company or collaborators", Default: "open"},
+			{Env: "FSM_BOX_LINK_EXPIRATION", Description: "Shared link expiration time in seconds, never if 0", Default: "0"},
+		},
+	},
+	{
+		Type: StorageTypeIPFS,
+		Name: "IPFS through a pinning service",
+		Settings: []Setting{
+			{Env: "FSM_IPFS_TOKEN", Description: "Bearer token of the pinning service, e.g. a Pinata JWT", Secret: true},
+			{Env: "FSM_IPFS_ENDPOINT", Description: "Upload URL of the pinning service", Default: "https://api.pinata.cloud/pinning/pinFileToIPFS"},
+			{Env: "FSM_IPFS_RAW_UPLOAD", Description: "Send the file as the request body instead of a multipart form, e.g. for web3.storage", Default: "false"},
+			{Env: "FSM_IPFS_GATEWAY", Description: "Gateway the returned URLs point to", Default: "https://ipfs.io"},
+		},
+	},
+	{
+		Type: StorageTypeCloudinary,
+		Name: "Cloudinary",
+		Settings: []Setting{
+			{Env: "FSM_CLOUDINARY_CLOUD_NAME", Description: "Cloud name of the Cloudinary account", Required: true},
+			{Env: "FSM_CLOUDINARY_API_KEY", Description: "API key signing the uploads"},
+			{Env: "FSM_CLOUDINARY_API_SECRET", Description: "API secret signing the uploads", Secret: true},
+			{Env: "FSM_CLOUDINARY_UPLOAD_PRESET", Description: "Upload preset; an unsigned preset allows uploads without the API key and secret"},
+			{Env: "FSM_CLOUDINARY_FOLDER", Description: "Folder the assets are stored in"},
+			{Env: "FSM_CLOUDINARY_TRANSFORMATION", Description: "Transformation applied to the returned URLs of images and videos, e.g. q_auto,f_auto"},
+		},
+	},
+	{
+		Type: StorageTypeFirebase,
+		Name: "Firebase Cloud Storage",
+		Settings: []Setting{
+			{Env: "FSM_FIREBASE_CREDENTIALS", Description: "Path of the JSON key file of a service account; GOOGLE_APPLICATION_CREDENTIALS if unset", Required: true},
+			{Env: "FSM_FIREBASE_BUCKET", Description: "Storage bucket, e.g. my-project.firebasestorage.app", Required: true},
+			{Env: "FSM_FIREBASE_PREFIX", Description: "Path prefix of the object names"},
+		},
+	},
+	{
+		Type: StorageTypeOCI,
+		Name: "Oracle OCI Object Storage",
+		Settings: []Setting{
+			{Env: "FSM_OCI_TENANCY", Description: "OCID of the tenancy", Required: true},
+			{Env: "FSM_OCI_USER", Description: "OCID of the user owning the API key", Required: true},
+			{Env: "FSM_OCI_FINGERPRINT", Description: "Fingerprint of the API key", Required: true},
+			{Env: "FSM_OCI_KEY_FILE", Description: "Path of the PEM private key of the API key", Required: true},
+			{Env: "FSM_OCI_REGION", Description: "Region, e.g. us-ashburn-1", Required: true},
+			{Env: "FSM_OCI_BUCKET", Description: "Bucket name", Required: true},
+			{Env: "FSM_OCI_NAMESPACE", Description: "Object Storage namespace of the tenancy; looked up if unset"},
+			{Env: "FSM_OCI_ENDPOINT", Description: "Custom Object Storage endpoint"},
+			{Env: "FSM_OCI_URL_EXPIRATION", Description: "Pre-authenticated request URL expiration time in seconds", Default: "604800"},
+		},
+	},
+	{
+		Type: StorageTypeBOS,
+		Name: "Baidu Object Storage",
+		Settings: []Setting{
+			{Env: "FSM_BOS_ACCESS_KEY", Description: "Access key ID", Required: true, Secret: true},
+			{Env: "FSM_BOS_SECRET_KEY", Description: "Secret access key", Required: true, Secret: true},
+			{Env: "FSM_BOS_BUCKET", Description: "Bucket name", Required: true},
+			{Env: "FSM_BOS_ENDPOINT", Description: "Endpoint of the bucket's region, e.g. gz.bcebos.com", Default: "bj.bcebos.com"},
+			{Env: "FSM_BOS_DOMAIN", Description: "Custom domain for public download URLs"},
+			{Env: "FSM_BOS_URL_EXPIRATION", Description: "Presigned URL expiration time in seconds", Default: "604800"},
+			{Env: "FSM_BOS_CACHE_CONTROL", Description: "Cache-Control header for uploaded objects"},
+		},
+	},
+	{
+		Type: StorageTypeStorj,
+		Name: "Storj DCS",
+		Settings: []Setting{
+			{Env: "FSM_STORJ_ACCESS_GRANT", Description: "Serialized access grant", Required: true, Secret: true},
+			{Env: "FSM_STORJ_BUCKET", Description: "Bucket name", Required: true},
+			{Env: "FSM_STORJ_PREFIX", Description: "Key prefix of uploaded objects, e.g. uploads/"},
+			{Env: "FSM_STORJ_AUTH_SERVICE", Description: "Auth service share links are registered with", Default: "auth.storjshare.io:7777"},
+			{Env: "FSM_STORJ_LINKSHARE", Description: "Linksharing service the returned URLs point to", Default: "https://link.storjshare.io"},
+			{Env: "FSM_STORJ_URL_EXPIRATION", Description: "Share link expiration time in seconds", Default: "604800"},
+		},
+	},
+	{
+		Type: StorageTypeArtifactory,
+		Name: "Artifactory or Nexus generic repository",
+		Settings: []Setting{
+			{Env: "FSM_ARTIFACTORY_URL", Description: "Base URL of the repositories, e.g. https://example.jfrog.io/artifactory", Required: true},
+			{Env: "FSM_ARTIFACTORY_REPOSITORY", Description: "Generic repository key", Required: true},
+			{Env: "FSM_ARTIFACTORY_PATH", Description: "Path in the repository the files are deployed to"},
+			{Env: "FSM_ARTIFACTORY_API_KEY", Description: "Artifactory API key", Secret: true},
+			{Env: "FSM_ARTIFACTORY_TOKEN", Description: "Access token, sent as a bearer token", Secret: true},
+			{Env: "FSM_ARTIFACTORY_USERNAME", Description: "Username, e.g. for Nexus"},
+			{Env: "FSM_ARTIFACTORY_PASSWORD", Description: "Password", Secret: true},
+		},
+	},
+	{
+		Type: StorageTypePlugin,
+		Name: "External plugin executable",
+		Settings: []Setting{
+			{Env: "FSM_PLUGIN_COMMAND", Description: "Path of the plugin executable", Required: true},
+			{Env: "FSM_PLUGIN_ARGS", Description: "Arguments for the plugin, separated by spaces"},
+			{Env: "FSM_PLUGIN_TIMEOUT", Description: "Timeout of a plugin request, e.g. 60s", Default: "60s"},
+		},
+	},
+	{
+		Type: StorageTypeExec,
+		Name: "Command run once per upload",
+		Settings: []Setting{
+			{Env: "FSM_EXEC_COMMAND", Description: "Path of the command", Required: true},
+			{Env: "FSM_EXEC_ARGS", Description: "Arguments for the command, separated by spaces"},
+			{Env: "FSM_EXEC_TIMEOUT", Description: "Timeout of an upload, e.g. 60s", Default: "60s"},
+			{Env: "FSM_EXEC_STDIN", Description: "Pass the file content on stdin instead of the JSON request", Default: "false"},
+		},
+	},
+	{
+		Type: StorageTypeMemory,
+		Name: "In-memory storage for testing",
+		Settings: []Setting{
+			{Env: "FSM_MEMORY_BASE_URL", Description: "URL the uploads are served at; sse and http servers serve them under /files/", Default: "http://localhost:8080/files"},
+		},
+	},
+}
+
+// LookupBackend returns the description of the storage backend of the given type
+func LookupBackend(storageType string) (BackendInfo, bool) {
+	for _, backend := range Backends {
+		if backend.Type == storageType {
+			return backend, true
+		}
+	}
+	return BackendInfo{}, false
+}
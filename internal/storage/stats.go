@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjzar/file-store-mcp/internal/audit"
+)
+
+// BackendStats summarizes the uploads to one storage backend
+type BackendStats struct {
+	Backend      string    `json:"backend"`
+	Uploads      int64     `json:"uploads"`
+	Failures     int64     `json:"failures"`
+	Bytes        int64     `json:"bytes"`
+	AvgLatencyMS float64   `json:"avg_latency_ms"`
+	ErrorRate    float64   `json:"error_rate"`
+	LastError    string    `json:"last_error,omitempty"`
+	LastErrorAt  time.Time `json:"last_error_at,omitzero"`
+}
+
+// Stats summarizes uploads since the server started and, if the audit log is enabled,
+// over the whole upload history
+type Stats struct {
+	Since      time.Time      `json:"since"`
+	SinceStart []BackendStats `json:"since_start"`
+	History    []BackendStats `json:"history,omitempty"`
+}
+
+// backendCounter accumulates the uploads to one backend
+type backendCounter struct {
+	uploads     int64
+	failures    int64
+	bytes       int64
+	latency     time.Duration
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func (c *backendCounter) add(size int64, duration time.Duration, errMsg string, at time.Time) {
+	c.uploads++
+	c.latency += duration
+	if errMsg != "" {
+		c.failures++
+		if !at.Before(c.lastErrorAt) {
+			c.lastError, c.lastErrorAt = errMsg, at
+		}
+		return
+	}
+	c.bytes += size
+}
+
+// statsRecorder keeps the in-memory upload counters of the running server
+type statsRecorder struct {
+	mu       sync.Mutex
+	since    time.Time
+	counters map[string]*backendCounter
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{
+		since:    time.Now(),
+		counters: make(map[string]*backendCounter),
+	}
+}
+
+// record adds a finished upload to the counters of backend
+func (r *statsRecorder) record(backend string, size int64, duration time.Duration, err error) {
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counterFor(r.counters, backend).add(size, duration, errMsg, time.Now())
+}
+
+// snapshot returns the current counters as a sorted summary
+func (r *statsRecorder) snapshot() []BackendStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return summarize(r.counters)
+}
+
+// Stats returns the upload statistics of the service
+func (s *Service) Stats() (*Stats, error) {
+	stats := &Stats{
+		Since:      s.stats.since,
+		SinceStart: s.stats.snapshot(),
+	}
+	if s.Config.AuditLog == "" {
+		return stats, nil
+	}
+
+	entries, err := audit.ReadAll(s.Config.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+	counters := make(map[string]*backendCounter)
+	for _, entry := range entries {
+		var errMsg string
+		if entry.Result != audit.ResultSuccess {
+			errMsg = entry.Error
+			if errMsg == "" {
+				errMsg = entry.Result
+			}
+		}
+		duration := time.Duration(entry.DurationMS) * time.Millisecond
+		counterFor(counters, entry.Backend).add(entry.Size, duration, errMsg, entry.Time)
+	}
+	stats.History = summarize(counters)
+
+	return stats, nil
+}
+
+func counterFor(counters map[string]*backendCounter, backend string) *backendCounter {
+	c, ok := counters[backend]
+	if !ok {
+		c = &backendCounter{}
+		counters[backend] = c
+	}
+	return c
+}
+
+func summarize(counters map[string]*backendCounter) []BackendStats {
+	result := make([]BackendStats, 0, len(counters))
+	for backend, c := range counters {
+		stats := BackendStats{
+			Backend:     backend,
+			Uploads:     c.uploads,
+			Failures:    c.failures,
+			Bytes:       c.bytes,
+			LastError:   c.lastError,
+			LastErrorAt: c.lastErrorAt,
+		}
+		if c.uploads > 0 {
+			stats.AvgLatencyMS = float64(c.latency.Milliseconds()) / float64(c.uploads)
+			stats.ErrorRate = float64(c.failures) / float64(c.uploads)
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Backend < result[j].Backend })
+	return result
+}
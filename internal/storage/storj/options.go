@@ -0,0 +1,46 @@
+package storj
+
+import "time"
+
+// Option sets a field of the StorjConfig used by New
+type Option func(*StorjConfig)
+
+// New creates a new Storj client from options, an alternative to NewStorjClient
+func New(opts ...Option) (*StorjClient, error) {
+	var cfg StorjConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewStorjClient(cfg)
+}
+
+// WithAccessGrant sets the serialized access grant
+func WithAccessGrant(accessGrant string) Option {
+	return func(cfg *StorjConfig) {
+		cfg.AccessGrant = accessGrant
+	}
+}
+
+// WithBucket sets the bucket name and the key prefix of uploaded objects
+func WithBucket(bucket, prefix string) Option {
+	return func(cfg *StorjConfig) {
+		cfg.Bucket = bucket
+		cfg.Prefix = prefix
+	}
+}
+
+// WithLinksharing sets the auth service share links are registered with and the
+// linksharing service the URLs point to, Storj's hosted ones by default
+func WithLinksharing(authService, linkshare string) Option {
+	return func(cfg *StorjConfig) {
+		cfg.AuthService = authService
+		cfg.Linkshare = linkshare
+	}
+}
+
+// WithPresignExpiry sets the validity of the share URLs
+func WithPresignExpiry(expiry time.Duration) Option {
+	return func(cfg *StorjConfig) {
+		cfg.URLExpiration = int64(expiry.Seconds())
+	}
+}
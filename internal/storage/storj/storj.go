@@ -0,0 +1,257 @@
+package storj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"storj.io/uplink"
+	"storj.io/uplink/edge"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/meta"
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// Defaults of Storj's hosted auth and linksharing services
+const (
+	DefaultAuthService = "auth.storjshare.io:7777"
+	DefaultLinkshare   = "https://link.storjshare.io"
+)
+
+// StorjClient uploads files to Storj DCS and shares them through linksharing
+type StorjClient struct {
+	access     *uplink.Access
+	project    *uplink.Project
+	bucket     string
+	prefix     string
+	edge       *edge.Config
+	linkshare  string
+	expiration time.Duration
+}
+
+// StorjConfig contains configuration for the Storj DCS client
+type StorjConfig struct {
+	AccessGrant string // Serialized access grant, with the encryption passphrase baked in
+	Bucket      string
+	Prefix      string // Optional, key prefix of uploaded objects, e.g. "uploads/"
+
+	AuthService   string // Auth service the share links are registered with, e.g. "auth.storjshare.io:7777"
+	Linkshare     string // Linksharing service the returned URLs point to, e.g. "https://link.storjshare.io"
+	URLExpiration int64  // Share link expiration time in seconds
+}
+
+// NewStorjClient creates a new Storj DCS client
+func NewStorjClient(cfg StorjConfig) (*StorjClient, error) {
+	if cfg.AccessGrant == "" {
+		return nil, fmt.Errorf("storj access grant cannot be empty")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("storj bucket cannot be empty")
+	}
+
+	access, err := uplink.ParseAccess(cfg.AccessGrant)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storj access grant: %w", err)
+	}
+	// Opening a project doesn't dial the satellite, connections are made per request
+	project, err := uplink.OpenProject(context.Background(), access)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storj project: %w", err)
+	}
+
+	prefix := strings.TrimPrefix(cfg.Prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	authService := cfg.AuthService
+	if authService == "" {
+		authService = DefaultAuthService
+	}
+	linkshare := strings.TrimSuffix(cfg.Linkshare, "/")
+	if linkshare == "" {
+		linkshare = DefaultLinkshare
+	}
+	if !strings.Contains(linkshare, "://") {
+		linkshare = "https://" + linkshare
+	}
+
+	// Set default expiration if not provided
+	expiration := time.Hour * 24 * 7 // 7 days default
+	if cfg.URLExpiration > 0 {
+		expiration = time.Duration(cfg.URLExpiration) * time.Second
+	}
+
+	return &StorjClient{
+		access:     access,
+		project:    project,
+		bucket:     cfg.Bucket,
+		prefix:     prefix,
+		edge:       &edge.Config{AuthServiceAddress: authService},
+		linkshare:  linkshare,
+		expiration: expiration,
+	}, nil
+}
+
+// UploadFile uploads a local file to Storj and returns its share URL
+func (s *StorjClient) UploadFile(ctx context.Context, path string, filename string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return s.upload(ctx, file, util.GetFileContentType(path, filename), filename)
+}
+
+// Upload uploads data from an io.Reader to Storj and returns its share URL.
+// Uploads are streamed, so the size doesn't need to be known.
+func (s *StorjClient) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	// Detect the content type, sniffing the data if the extension is unknown
+	contentType, body := util.PeekContentType(body, filename)
+	return s.upload(ctx, body, contentType, filename)
+}
+
+// upload streams body to the object filename and returns its share URL
+func (s *StorjClient) upload(ctx context.Context, body io.Reader, contentType string, filename string) (string, error) {
+	// Format the object key using the provided format
+	objectKey := filename
+	if len(objectKey) == 0 {
+		objectKey = uuid.New().String()
+	}
+
+	upload, err := s.project.UploadObject(ctx, s.bucket, s.prefix+objectKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to storj: %w", wrapError(err))
+	}
+	if _, err := io.Copy(upload, body); err != nil {
+		_ = upload.Abort()
+		return "", fmt.Errorf("failed to upload file to storj: %w", wrapError(err))
+	}
+
+	// Store the content type, request metadata and tags as custom metadata, which
+	// linksharing serves as the Content-Type header
+	custom := uplink.CustomMetadata{"Content-Type": contentType}
+	m := meta.FromContext(ctx)
+	if m.ContentDisposition != "" {
+		custom["Content-Disposition"] = m.ContentDisposition
+	}
+	for key, value := range m.Fields() {
+		custom[key] = value
+	}
+	if err := upload.SetCustomMetadata(ctx, custom); err != nil {
+		_ = upload.Abort()
+		return "", fmt.Errorf("failed to set object metadata: %w", err)
+	}
+	if err := upload.Commit(); err != nil {
+		return "", fmt.Errorf("failed to upload file to storj: %w", wrapError(err))
+	}
+
+	return s.Presign(ctx, objectKey, 0)
+}
+
+// Presign shares an object through linksharing and returns the raw download URL,
+// valid for expiration or the configured URL expiration if zero. The share is a
+// download-only access restricted to the object, registered with the auth service.
+func (s *StorjClient) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	if expiration <= 0 {
+		expiration = s.expiration
+	}
+
+	shared, err := s.access.Share(
+		uplink.Permission{AllowDownload: true, NotAfter: time.Now().Add(expiration)},
+		uplink.SharePrefix{Bucket: s.bucket, Prefix: s.prefix + objectKey},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to restrict storj access: %w", wrapError(err))
+	}
+	credentials, err := s.edge.RegisterAccess(ctx, shared, &edge.RegisterAccessOptions{Public: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to register share with the storj auth service: %w", err)
+	}
+	return edge.JoinShareURL(s.linkshare, credentials.AccessKeyID, s.bucket, s.prefix+objectKey, &edge.ShareURLOptions{Raw: true})
+}
+
+// Limits reports no size limit, Storj splits large objects into segments
+func (s *StorjClient) Limits() meta.Limits {
+	return meta.Limits{CustomDomain: s.linkshare != DefaultLinkshare}
+}
+
+// URLExpiration returns the validity of the share URLs returned by uploads
+func (s *StorjClient) URLExpiration() time.Duration {
+	return s.expiration
+}
+
+// Delete removes an object from Storj
+func (s *StorjClient) Delete(ctx context.Context, objectKey string) error {
+	if _, err := s.project.DeleteObject(ctx, s.bucket, s.prefix+objectKey); err != nil {
+		return fmt.Errorf("failed to delete object from storj: %w", wrapError(err))
+	}
+	return nil
+}
+
+// Exists reports whether an object exists in Storj
+func (s *StorjClient) Exists(ctx context.Context, objectKey string) (bool, error) {
+	if _, err := s.project.StatObject(ctx, s.bucket, s.prefix+objectKey); err != nil {
+		if errors.Is(err, uplink.ErrObjectNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object in storj: %w", wrapError(err))
+	}
+	return true, nil
+}
+
+// List returns up to limit objects whose key starts with prefix, relative to the
+// configured prefix
+func (s *StorjClient) List(ctx context.Context, prefix string, limit int) ([]meta.ObjectInfo, error) {
+	// Listing prefixes must end with '/', so list the enclosing one and filter
+	listPrefix := s.prefix + prefix
+	if i := strings.LastIndex(listPrefix, "/"); i >= 0 {
+		listPrefix = listPrefix[:i+1]
+	} else {
+		listPrefix = ""
+	}
+
+	iterator := s.project.ListObjects(ctx, s.bucket, &uplink.ListObjectsOptions{
+		Prefix:    listPrefix,
+		Recursive: true,
+		System:    true,
+	})
+	var objects []meta.ObjectInfo
+	for iterator.Next() {
+		object := iterator.Item()
+		if object.IsPrefix || !strings.HasPrefix(object.Key, s.prefix+prefix) {
+			continue
+		}
+		objects = append(objects, meta.ObjectInfo{
+			Key:          strings.TrimPrefix(object.Key, s.prefix),
+			Size:         object.System.ContentLength,
+			LastModified: object.System.Created,
+		})
+		if limit > 0 && len(objects) == limit {
+			break
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list objects in storj: %w", wrapError(err))
+	}
+	return objects, nil
+}
+
+// Close closes the project and its connections
+func (s *StorjClient) Close() error {
+	return s.project.Close()
+}
+
+// wrapError marks permission errors as authentication errors, so they point at the access grant
+func wrapError(err error) error {
+	if errors.Is(err, uplink.ErrPermissionDenied) {
+		return fmt.Errorf("%w: %w", meta.ErrAuth, err)
+	}
+	return err
+}
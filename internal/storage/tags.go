@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Limits of user-supplied tags, kept within what every backend accepts as object metadata
+const (
+	MaxTags        = 10
+	MaxTagKeyLen   = 64
+	MaxTagValueLen = 256
+)
+
+type tagsKey struct{}
+
+// WithTags returns a context whose uploads are tagged with tags, stored by the
+// backends as object metadata
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	if len(tags) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+// TagsFromContext returns the tags carried by ctx, if any
+func TagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// ValidateTags checks tags against the limits
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("too many tags: %d, at most %d are allowed", len(tags), MaxTags)
+	}
+	for key, value := range tags {
+		if key == "" || len(key) > MaxTagKeyLen {
+			return fmt.Errorf("tag key %q must be 1 to %d characters long", key, MaxTagKeyLen)
+		}
+		if len(value) > MaxTagValueLen {
+			return fmt.Errorf("value of tag %q is longer than %d characters", key, MaxTagValueLen)
+		}
+	}
+	return nil
+}
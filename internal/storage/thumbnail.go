@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// thumbnailTimeout bounds the time spent generating a thumbnail, e.g. by ffmpeg
+const thumbnailTimeout = 30 * time.Second
+
+// ThumbnailKey returns the object key of the thumbnail of the object, stored next
+// to it with the extension of the thumbnail format, e.g. "photos/cat.thumb.jpg"
+func ThumbnailKey(objectKey string, ext string) string {
+	return strings.TrimSuffix(objectKey, filepath.Ext(objectKey)) + ".thumb" + ext
+}
+
+// uploadThumbnail generates a thumbnail of an uploaded image or video and uploads
+// it next to the object. A thumbnail that can't be made doesn't fail the upload,
+// it is left out of the result with a note.
+func (s *Service) uploadThumbnail(ctx context.Context, r *uploadRecord, filename string) {
+	if !s.Config.Thumbnails || r.err != nil || r.path == "" {
+		return
+	}
+	contentType := util.GetFileContentType(r.path, filename)
+	if !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "video/") {
+		return
+	}
+
+	size := s.Config.ThumbnailSize
+	if size <= 0 {
+		size = defaultThumbnailSize
+	}
+	thumbCtx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+	var data []byte
+	var ext string
+	var err error
+	if strings.HasPrefix(contentType, "image/") {
+		data, ext, err = util.Thumbnail(r.path, size)
+	} else {
+		data, ext, err = util.VideoThumbnail(thumbCtx, r.path, size)
+	}
+	if errors.Is(err, util.ErrNoThumbnail) {
+		log.Ctx(ctx).Debug().Str("key", r.objectKey).Str("type", contentType).Msg("No thumbnail for upload")
+		return
+	}
+
+	key := ThumbnailKey(r.objectKey, ext)
+	if err == nil {
+		err = s.checkStorage(ctx, s.routeOf(key, util.GetContentType(key)))
+	}
+	if err == nil {
+		err = s.checkOverwrite(ctx, key)
+	}
+	if err == nil {
+		thumbName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".thumb" + ext
+		r.thumbnailURL, err = s.Storage.Upload(s.newUploadContext(ctx, thumbName, ""), bytes.NewReader(data), key)
+		err = s.wrapError(OperationPut, err)
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to upload thumbnail")
+		addNote(ctx, fmt.Sprintf("no thumbnail: %v", err))
+		r.thumbnailURL = ""
+		return
+	}
+	r.thumbnailKey = key
+	log.Ctx(ctx).Debug().Str("key", key).Int("size", len(data)).Msg("Uploaded thumbnail")
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/sjzar/file-store-mcp/pkg/util"
+)
+
+// watermarker stamps the configured watermark onto uploaded images
+type watermarker struct {
+	watermark *util.Watermark
+	err       error // Why the watermark couldn't be loaded, refusing image uploads
+}
+
+// newWatermarker returns the watermarker of the configuration, or nil if no watermark is set
+func newWatermarker(config *Config) *watermarker {
+	if config.WatermarkText == "" && config.WatermarkImage == "" {
+		return nil
+	}
+	opacity := config.WatermarkOpacity
+	if opacity == 0 {
+		opacity = defaultWatermarkOpacity
+	}
+	watermark, err := util.NewWatermark(config.WatermarkText, config.WatermarkImage, config.WatermarkPosition, opacity)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid watermark, image uploads will be refused")
+	}
+	return &watermarker{watermark: watermark, err: err}
+}
+
+// apply writes the image at path with the watermark to a temp file and returns its
+// path, or an empty string if path isn't an image. Images that can't be watermarked
+// are refused, except for formats without an encoder, which are uploaded as they are.
+func (w *watermarker) apply(ctx context.Context, path string, filename string) (string, error) {
+	if w == nil || !strings.HasPrefix(util.GetFileContentType(path, filename), "image/") {
+		return "", nil
+	}
+	if w.err != nil {
+		return "", fmt.Errorf("upload refused: invalid watermark: %w", w.err)
+	}
+
+	dst, err := os.CreateTemp("", "watermark-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	dst.Close()
+	err = w.watermark.Apply(path, dst.Name())
+	if errors.Is(err, util.ErrUnsupportedImage) {
+		os.Remove(dst.Name())
+		log.Ctx(ctx).Warn().Str("filename", filename).Msg("Image format can't be watermarked, uploading it as is")
+		addNote(ctx, "not watermarked: "+err.Error())
+		return "", nil
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("upload refused: failed to watermark %s: %w", filename, err)
+	}
+	return dst.Name(), nil
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/sjzar/file-store-mcp/cmd/filestore"
 )
 
 func main() {
+	// stdout is reserved for the JSON-RPC stream in stdio mode
+	log.SetOutput(os.Stderr)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	filestore.Execute()
 }
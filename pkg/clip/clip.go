@@ -1,18 +1,88 @@
 package clip
 
 import (
+	"path/filepath"
 	"time"
 )
 
+// 剪贴板文件的来源
+type Source string
+
+const (
+	// 剪贴板中的文件引用 (CF_HDROP / NSURL)
+	SourceFileRef Source = "file_ref"
+	// text/uri-list 格式的 URI 列表
+	SourceURIList Source = "uri_list"
+	// 剪贴板文本中的文件路径
+	SourceTextPath Source = "text_path"
+	// 根据剪贴板文本中的文件名搜索
+	SourceSearch Source = "search"
+)
+
+// 返回来源的可读描述
+func (s Source) String() string {
+	switch s {
+	case SourceFileRef:
+		return "file reference"
+	case SourceURIList:
+		return "URI list"
+	case SourceTextPath:
+		return "text path"
+	case SourceSearch:
+		return "filename search"
+	default:
+		return string(s)
+	}
+}
+
+// 剪贴板中检测到的文件
+type File struct {
+	Path   string `json:"path"`
+	Source Source `json:"source"`
+	// 文件名搜索命中的目录，仅 Source 为 SourceSearch 时有值
+	Dir string `json:"dir,omitempty"`
+}
+
 // 定义统一的文件获取接口
 type FileFinder interface {
-	// 从剪贴板获取文件路径，无论剪贴板中是文件引用还是文本
-	GetFiles(timeout time.Duration) ([]string, error)
+	// 从剪贴板获取文件，无论剪贴板中是文件引用还是文本
+	GetFiles(timeout time.Duration) ([]File, error)
 }
 
 // 统一的对外接口函数
 func GetFiles(timeoutSeconds int) ([]string, error) {
+	files, err := GetFilesWithSource(timeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths, nil
+}
+
+// 获取剪贴板文件及其来源
+func GetFilesWithSource(timeoutSeconds int) ([]File, error) {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 	finder := newFileFinder()
 	return finder.GetFiles(timeout)
 }
+
+// 将文本写入剪贴板，替换剪贴板中原有的内容
+func SetText(text string) error {
+	return writeText(text)
+}
+
+// 将路径列表包装为指定来源的文件列表
+func newFiles(paths []string, source Source) []File {
+	files := make([]File, 0, len(paths))
+	for _, path := range paths {
+		file := File{Path: path, Source: source}
+		if source == SourceSearch {
+			file.Dir = filepath.Dir(path)
+		}
+		files = append(files, file)
+	}
+	return files
+}
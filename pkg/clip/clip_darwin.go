@@ -21,7 +21,7 @@ func newFileFinder() FileFinder {
 }
 
 // 从剪贴板获取文件路径
-func (f *darwinAppleScriptFinder) GetFiles(timeout time.Duration) ([]string, error) {
+func (f *darwinAppleScriptFinder) GetFiles(timeout time.Duration) ([]File, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
@@ -123,7 +123,7 @@ end try
 	// 获取输出并分割为列表
 	output := strings.TrimSpace(stdout.String())
 	if output == "" {
-		return []string{}, nil
+		return []File{}, nil
 	}
 
 	// 使用特殊分隔符分割结果
@@ -138,5 +138,5 @@ end try
 		}
 	}
 
-	return filteredPaths, nil
+	return newFiles(filteredPaths, SourceSearch), nil
 }
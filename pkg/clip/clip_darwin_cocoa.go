@@ -144,11 +144,11 @@ func newFileFinder() FileFinder {
 }
 
 // 从剪贴板获取文件路径
-func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]string, error) {
+func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]File, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	resultChan := make(chan []string, 1)
+	resultChan := make(chan []File, 1)
 	errChan := make(chan error, 1)
 
 	go func() {
@@ -167,7 +167,7 @@ func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]string, error) {
 				results[i] = C.GoString(cString)
 			}
 
-			resultChan <- results
+			resultChan <- newFiles(results, SourceFileRef)
 			return
 		}
 
@@ -175,7 +175,7 @@ func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]string, error) {
 		cText := C.getClipboardText()
 		if cText == nil {
 			// 剪贴板为空，返回空结果
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
@@ -188,7 +188,7 @@ func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]string, error) {
 
 		if len(fileNames) == 0 {
 			// 没有有效的文件名，返回空结果
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
@@ -227,7 +227,7 @@ func (f *darwinCocoaFinder) GetFiles(timeout time.Duration) ([]string, error) {
 			}
 		}
 
-		resultChan <- results
+		resultChan <- newFiles(results, SourceSearch)
 	}()
 
 	select {
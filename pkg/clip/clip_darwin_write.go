@@ -0,0 +1,20 @@
+//go:build darwin
+// +build darwin
+
+package clip
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// 通过 pbcopy 将文本写入剪贴板
+func writeText(text string) error {
+	cmd := exec.Command("pbcopy")
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("写入剪贴板失败: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -21,28 +21,21 @@ func newFileFinder() FileFinder {
 }
 
 // 从剪贴板获取文件路径
-func (f *linuxFinder) GetFiles(timeout time.Duration) ([]string, error) {
+func (f *linuxFinder) GetFiles(timeout time.Duration) ([]File, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	resultChan := make(chan []string, 1)
+	resultChan := make(chan []File, 1)
 	errChan := make(chan error, 1)
 
 	go func() {
 		// 首先尝试从剪贴板获取文件 URI
-		var cmd *exec.Cmd
-		var output []byte
-		var err error
-
-		// 尝试使用 xclip 获取 URI 列表 (x11)
-		cmd = exec.Command("xclip", "-selection", "clipboard", "-t", "text/uri-list", "-o")
-		output, err = cmd.Output()
-
-		// 如果 xclip 失败，尝试使用 wl-paste (Wayland)
-		if err != nil {
-			cmd = exec.Command("wl-paste", "-t", "text/uri-list")
-			output, err = cmd.Output()
-		}
+		// 依次尝试 xclip (x11)、wl-paste (Wayland)、X11 协议和桌面门户
+		output, err := readClipboard(withPortal(timeout, "text/uri-list",
+			commandReader("xclip", "-selection", "clipboard", "-t", "text/uri-list", "-o"),
+			commandReader("wl-paste", "-t", "text/uri-list"),
+			func() ([]byte, error) { return readX11Selection("text/uri-list", timeout) },
+		)...)
 
 		// 如果成功获取 URI 列表
 		if err == nil && len(output) > 0 {
@@ -77,38 +70,30 @@ func (f *linuxFinder) GetFiles(timeout time.Duration) ([]string, error) {
 				}
 
 				if len(paths) > 0 {
-					resultChan <- paths
+					resultChan <- newFiles(paths, SourceURIList)
 					return
 				}
 			}
 		}
 
 		// 如果没有文件引用，尝试获取剪贴板文本
-		// 尝试使用 xclip 获取文本
-		cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
-		output, err = cmd.Output()
-
-		// 如果 xclip 失败，尝试使用 xsel
-		if err != nil {
-			cmd = exec.Command("xsel", "--clipboard", "--output")
-			output, err = cmd.Output()
-		}
-
-		// 如果 xsel 失败，尝试使用 wl-paste
-		if err != nil {
-			cmd = exec.Command("wl-paste")
-			output, err = cmd.Output()
-		}
+		// 依次尝试 xclip、xsel、wl-paste、X11 协议和桌面门户
+		output, err = readClipboard(withPortal(timeout, "text/plain;charset=utf-8",
+			commandReader("xclip", "-selection", "clipboard", "-o"),
+			commandReader("xsel", "--clipboard", "--output"),
+			commandReader("wl-paste"),
+			func() ([]byte, error) { return readX11Selection("UTF8_STRING", timeout) },
+		)...)
 
 		// 如果获取剪贴板文本失败，返回空结果
 		if err != nil {
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
 		clipboardText := strings.TrimSpace(string(output))
 		if clipboardText == "" {
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
@@ -123,7 +108,7 @@ func (f *linuxFinder) GetFiles(timeout time.Duration) ([]string, error) {
 			}
 		}
 
-		resultChan <- validPaths
+		resultChan <- newFiles(validPaths, SourceTextPath)
 	}()
 
 	select {
@@ -136,6 +121,81 @@ func (f *linuxFinder) GetFiles(timeout time.Duration) ([]string, error) {
 	}
 }
 
+// 剪贴板读取方式
+type clipboardReader func() ([]byte, error)
+
+// 通过外部命令读取剪贴板
+func commandReader(name string, args ...string) clipboardReader {
+	return func() ([]byte, error) {
+		return exec.Command(name, args...).Output()
+	}
+}
+
+// 桌面门户读取的最长等待时间
+const maxPortalTimeout = 5 * time.Second
+
+// 仅在沙盒中或没有图形会话时追加桌面门户读取方式
+// 普通桌面上门户可能弹出授权对话框，此时不使用；门户使用更短的超时，以便仍能回退到其他方式
+func withPortal(timeout time.Duration, mimeType string, readers ...clipboardReader) []clipboardReader {
+	if !inSandbox() && (os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != "") {
+		return readers
+	}
+	portalTimeout := min(timeout/3, maxPortalTimeout)
+	return append(readers, func() ([]byte, error) { return readPortalSelection(mimeType, portalTimeout) })
+}
+
+// 依次尝试各读取方式，返回第一个成功的结果
+// 在 Flatpak/Snap 沙盒中外部命令和 X11 通常不可用，此时优先使用最后的桌面门户
+func readClipboard(readers ...clipboardReader) ([]byte, error) {
+	if inSandbox() && len(readers) > 1 {
+		last := len(readers) - 1
+		readers = append([]clipboardReader{readers[last]}, readers[:last]...)
+	}
+
+	err := fmt.Errorf("没有可用的剪贴板读取方式")
+	for _, read := range readers {
+		var output []byte
+		output, err = read()
+		if err == nil {
+			return output, nil
+		}
+	}
+	return nil, err
+}
+
+// 将文本写入剪贴板
+// 依次尝试 wl-copy (Wayland)、xclip 和 xsel (x11)，这些工具会在后台持有剪贴板内容
+func writeText(text string) error {
+	writers := [][]string{
+		{"xclip", "-selection", "clipboard", "-in"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		writers = append([][]string{{"wl-copy"}}, writers...)
+	}
+
+	err := fmt.Errorf("没有可用的剪贴板写入方式，请安装 xclip、xsel 或 wl-clipboard")
+	for _, args := range writers {
+		if _, lookErr := exec.LookPath(args[0]); lookErr != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err = cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// 判断是否运行在 Flatpak 或 Snap 沙盒中
+func inSandbox() bool {
+	if _, err := os.Stat("/.flatpak-info"); err == nil {
+		return true
+	}
+	return os.Getenv("SNAP") != ""
+}
+
 // 解析剪贴板文本为可能的文件路径
 func parseFilePaths(text string) []string {
 	if text == "" {
@@ -0,0 +1,147 @@
+//go:build linux
+// +build linux
+
+package clip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/uuid"
+)
+
+// XDG 桌面门户相关常量
+const (
+	portalBusName          = "org.freedesktop.portal.Desktop"
+	portalObjectPath       = "/org/freedesktop/portal/desktop"
+	portalRemoteDesktop    = "org.freedesktop.portal.RemoteDesktop"
+	portalClipboard        = "org.freedesktop.portal.Clipboard"
+	portalRequestInterface = "org.freedesktop.portal.Request"
+	portalSessionInterface = "org.freedesktop.portal.Session"
+)
+
+// 通过 XDG 桌面门户读取剪贴板，适用于 Flatpak/Snap 等沙盒环境
+// 剪贴板接口依附于 RemoteDesktop 会话，首次使用时桌面环境会弹窗请求授权
+func readPortalSelection(mimeType string, timeout time.Duration) ([]byte, error) {
+	// 整个读取过程共用一个截止时间
+	deadline := time.Now().Add(timeout)
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("连接 D-Bus 会话总线失败: %w", err)
+	}
+	defer conn.Close()
+
+	portal := conn.Object(portalBusName, portalObjectPath)
+
+	// 创建 RemoteDesktop 会话
+	results, err := portalRequest(conn, time.Until(deadline), func(token string) *dbus.Call {
+		return portal.Call(portalRemoteDesktop+".CreateSession", 0, map[string]dbus.Variant{
+			"handle_token":         dbus.MakeVariant(token),
+			"session_handle_token": dbus.MakeVariant(newPortalToken()),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建桌面门户会话失败: %w", err)
+	}
+
+	var sessionHandle string
+	if v, ok := results["session_handle"]; ok {
+		sessionHandle, _ = v.Value().(string)
+	}
+	if sessionHandle == "" {
+		return nil, fmt.Errorf("桌面门户未返回会话句柄")
+	}
+	session := dbus.ObjectPath(sessionHandle)
+	defer conn.Object(portalBusName, session).Call(portalSessionInterface+".Close", 0)
+
+	// 剪贴板访问需要在会话启动前申请
+	if err := portal.Call(portalClipboard+".RequestClipboard", 0, session, map[string]dbus.Variant{}).Err; err != nil {
+		return nil, fmt.Errorf("申请剪贴板访问失败: %w", err)
+	}
+
+	// 启动会话，等待用户授权
+	results, err = portalRequest(conn, time.Until(deadline), func(token string) *dbus.Call {
+		return portal.Call(portalRemoteDesktop+".Start", 0, session, "", map[string]dbus.Variant{
+			"handle_token": dbus.MakeVariant(token),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("启动桌面门户会话失败: %w", err)
+	}
+	if v, ok := results["clipboard_enabled"]; ok {
+		if enabled, _ := v.Value().(bool); !enabled {
+			return nil, fmt.Errorf("桌面门户未授权访问剪贴板")
+		}
+	}
+
+	// 读取指定格式的剪贴板内容
+	var fd dbus.UnixFD
+	if err := portal.Call(portalClipboard+".SelectionRead", 0, session, mimeType).Store(&fd); err != nil {
+		return nil, fmt.Errorf("读取门户剪贴板失败: %w", err)
+	}
+
+	file := os.NewFile(uintptr(fd), "portal-clipboard")
+	defer file.Close()
+	_ = file.SetReadDeadline(deadline)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取门户剪贴板失败: %w", err)
+	}
+	return data, nil
+}
+
+// 调用门户方法并等待 Request 对象的 Response 信号
+func portalRequest(conn *dbus.Conn, timeout time.Duration, call func(token string) *dbus.Call) (map[string]dbus.Variant, error) {
+	token := newPortalToken()
+
+	// 在调用前订阅信号，避免错过响应
+	// 请求路径格式: /org/freedesktop/portal/desktop/request/SENDER/TOKEN
+	sender := strings.ReplaceAll(strings.TrimPrefix(conn.Names()[0], ":"), ".", "_")
+	requestPath := dbus.ObjectPath(fmt.Sprintf("%s/request/%s/%s", portalObjectPath, sender, token))
+
+	matchOptions := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(requestPath),
+		dbus.WithMatchInterface(portalRequestInterface),
+		dbus.WithMatchMember("Response"),
+	}
+	if err := conn.AddMatchSignal(matchOptions...); err != nil {
+		return nil, err
+	}
+	defer conn.RemoveMatchSignal(matchOptions...)
+
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	if err := call(token).Err; err != nil {
+		return nil, err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case <-deadline:
+			return nil, fmt.Errorf("等待桌面门户响应超时")
+		case signal := <-signals:
+			if signal.Path != requestPath || len(signal.Body) < 2 {
+				continue
+			}
+			if code, _ := signal.Body[0].(uint32); code != 0 {
+				return nil, fmt.Errorf("请求被拒绝或取消 (code: %d)", code)
+			}
+			results, _ := signal.Body[1].(map[string]dbus.Variant)
+			return results, nil
+		}
+	}
+}
+
+// 生成门户请求使用的 token，只能包含字母、数字和下划线
+func newPortalToken() string {
+	return "fsm_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -0,0 +1,98 @@
+//go:build linux
+// +build linux
+
+package clip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// 剪贴板内容的最大读取长度 (单位为 4 字节)
+const x11MaxPropertyLength = 1 << 20
+
+// 通过 X11 协议直接读取剪贴板，在 xclip/xsel 不可用时作为兜底
+func readX11Selection(target string, timeout time.Duration) ([]byte, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("连接 X11 显示服务失败: %w", err)
+	}
+	defer conn.Close()
+
+	// 超时后关闭连接，使 WaitForEvent 返回
+	timer := time.AfterFunc(timeout, conn.Close)
+	defer timer.Stop()
+
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+
+	// 创建一个不可见窗口用于接收剪贴板数据
+	win, err := xproto.NewWindowId(conn)
+	if err != nil {
+		return nil, fmt.Errorf("创建 X11 窗口失败: %w", err)
+	}
+	err = xproto.CreateWindowChecked(conn, screen.RootDepth, win, screen.Root,
+		0, 0, 1, 1, 0, xproto.WindowClassInputOutput, screen.RootVisual, 0, nil).Check()
+	if err != nil {
+		return nil, fmt.Errorf("创建 X11 窗口失败: %w", err)
+	}
+	defer xproto.DestroyWindow(conn, win)
+
+	selection, err := internAtom(conn, "CLIPBOARD")
+	if err != nil {
+		return nil, err
+	}
+	targetAtom, err := internAtom(conn, target)
+	if err != nil {
+		return nil, err
+	}
+	property, err := internAtom(conn, "FSM_CLIPBOARD")
+	if err != nil {
+		return nil, err
+	}
+	incr, err := internAtom(conn, "INCR")
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求剪贴板所有者将内容转换为目标格式
+	xproto.ConvertSelection(conn, win, selection, targetAtom, property, xproto.TimeCurrentTime)
+
+	for {
+		ev, xerr := conn.WaitForEvent()
+		if ev == nil && xerr == nil {
+			return nil, fmt.Errorf("读取 X11 剪贴板超时")
+		}
+		if xerr != nil {
+			return nil, fmt.Errorf("读取 X11 剪贴板失败: %s", xerr.Error())
+		}
+
+		notify, ok := ev.(xproto.SelectionNotifyEvent)
+		if !ok {
+			continue
+		}
+		if notify.Property == xproto.AtomNone {
+			return nil, fmt.Errorf("剪贴板中没有 %s 格式的内容", target)
+		}
+
+		reply, err := xproto.GetProperty(conn, true, win, property, xproto.GetPropertyTypeAny, 0, x11MaxPropertyLength).Reply()
+		if err != nil {
+			return nil, fmt.Errorf("读取 X11 剪贴板属性失败: %w", err)
+		}
+		if reply.Type == incr {
+			return nil, fmt.Errorf("剪贴板内容过大，不支持 INCR 传输")
+		}
+		return reply.Value, nil
+	}
+}
+
+// 获取指定名称的 X11 Atom
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return xproto.AtomNone, fmt.Errorf("获取 X11 Atom %s 失败: %w", name, err)
+	}
+	return reply.Atom, nil
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
@@ -36,18 +37,22 @@ var (
 	openClipboard              = user32.NewProc("OpenClipboard")
 	closeClipboard             = user32.NewProc("CloseClipboard")
 	getClipboardData           = user32.NewProc("GetClipboardData")
+	setClipboardData           = user32.NewProc("SetClipboardData")
+	emptyClipboard             = user32.NewProc("EmptyClipboard")
 	isClipboardFormatAvailable = user32.NewProc("IsClipboardFormatAvailable")
+	globalAlloc                = kernel32.NewProc("GlobalAlloc")
+	globalFree                 = kernel32.NewProc("GlobalFree")
 	globalLock                 = kernel32.NewProc("GlobalLock")
 	globalUnlock               = kernel32.NewProc("GlobalUnlock")
 	dragQueryFileW             = shell32.NewProc("DragQueryFileW")
 )
 
 // 从剪贴板获取文件路径
-func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
+func (f *windowsFinder) GetFiles(timeout time.Duration) ([]File, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	resultChan := make(chan []string, 1)
+	resultChan := make(chan []File, 1)
 	errChan := make(chan error, 1)
 
 	go func() {
@@ -93,7 +98,7 @@ func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
 				paths = append(paths, path)
 			}
 
-			resultChan <- paths
+			resultChan <- newFiles(paths, SourceFileRef)
 			return
 		}
 
@@ -101,7 +106,7 @@ func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
 		isFormatAvailable, _, _ = isClipboardFormatAvailable.Call(uintptr(CF_UNICODETEXT))
 		if isFormatAvailable == 0 {
 			// 剪贴板中没有文本，返回空结果
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
@@ -121,7 +126,7 @@ func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
 		// 获取剪贴板文本
 		clipboardText := syscall.UTF16ToString((*[1 << 20]uint16)(unsafe.Pointer(ptr))[:])
 		if clipboardText == "" {
-			resultChan <- []string{}
+			resultChan <- []File{}
 			return
 		}
 
@@ -136,7 +141,7 @@ func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
 			}
 		}
 
-		resultChan <- validPaths
+		resultChan <- newFiles(validPaths, SourceTextPath)
 	}()
 
 	select {
@@ -149,6 +154,49 @@ func (f *windowsFinder) GetFiles(timeout time.Duration) ([]string, error) {
 	}
 }
 
+// 将文本以 CF_UNICODETEXT 格式写入剪贴板
+func writeText(text string) error {
+	// 剪贴板与调用线程绑定，需要固定在同一个系统线程上操作
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	data, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return fmt.Errorf("转换剪贴板文本失败: %w", err)
+	}
+
+	ret, _, _ := openClipboard.Call(0)
+	if ret == 0 {
+		return fmt.Errorf("打开剪贴板失败")
+	}
+	defer closeClipboard.Call()
+
+	if ret, _, _ := emptyClipboard.Call(); ret == 0 {
+		return fmt.Errorf("清空剪贴板失败")
+	}
+
+	size := uintptr(len(data) * 2)
+	h, _, _ := globalAlloc.Call(GMEM_MOVEABLE, size)
+	if h == 0 {
+		return fmt.Errorf("分配剪贴板内存失败")
+	}
+
+	ptr, _, _ := globalLock.Call(h)
+	if ptr == 0 {
+		globalFree.Call(h)
+		return fmt.Errorf("锁定剪贴板内存失败")
+	}
+	copy(unsafe.Slice((*uint16)(unsafe.Pointer(ptr)), len(data)), data)
+	globalUnlock.Call(h)
+
+	// 写入成功后内存归系统所有，不能再释放
+	if ret, _, _ := setClipboardData.Call(CF_UNICODETEXT, h); ret == 0 {
+		globalFree.Call(h)
+		return fmt.Errorf("写入剪贴板失败")
+	}
+	return nil
+}
+
 // 解析剪贴板文本为可能的文件路径
 func parseFilePaths(text string) []string {
 	if text == "" {
@@ -0,0 +1,65 @@
+package clip
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 按 1 起始的序号或文件名通配符挑选剪贴板文件，保持剪贴板中的顺序
+// 返回错误表示选择有歧义，例如序号越界或通配符没有匹配
+func Select(files []File, selectors []string) ([]File, error) {
+	picked := make([]bool, len(files))
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+
+		if index, err := strconv.Atoi(selector); err == nil {
+			if index < 1 || index > len(files) {
+				return nil, fmt.Errorf("index %d is out of range", index)
+			}
+			picked[index-1] = true
+			continue
+		}
+
+		matched := false
+		for i, file := range files {
+			ok, err := filepath.Match(selector, filepath.Base(file.Path))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", selector, err)
+			}
+			if !ok {
+				ok, _ = filepath.Match(selector, file.Path)
+			}
+			if ok {
+				picked[i] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("pattern %q matches no clipboard file", selector)
+		}
+	}
+
+	selected := make([]File, 0, len(files))
+	for i, file := range files {
+		if picked[i] {
+			selected = append(selected, file)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("selection matches no clipboard file")
+	}
+	return selected, nil
+}
+
+// 返回文件路径及其检测方式的描述
+func (f File) Describe() string {
+	if f.Source == SourceSearch && f.Dir != "" {
+		return fmt.Sprintf("%s, from %s in %s", f.Path, f.Source, f.Dir)
+	}
+	return fmt.Sprintf("%s, from %s", f.Path, f.Source)
+}
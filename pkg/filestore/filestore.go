@@ -0,0 +1,298 @@
+// Package filestore uploads files to cloud storage (AWS S3 and compatible services,
+// Alibaba Cloud OSS, Tencent Cloud COS, Qiniu Cloud and GitHub) and returns
+// download URLs. It is the library behind file-store-mcp, for embedding the
+// uploads in other Go programs and MCP servers.
+//
+//	client, err := filestore.New(&filestore.Config{
+//		StorageType: filestore.StorageTypeS3,
+//		S3: filestore.S3Config{
+//			BucketName:  "my-bucket",
+//			Region:      "us-east-1",
+//			AccessKeyID: "...",
+//			SecretKey:   "...",
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	url, err := client.UploadFile(ctx, "report.pdf")
+package filestore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/sjzar/file-store-mcp/internal/mcp"
+	"github.com/sjzar/file-store-mcp/internal/storage"
+	"github.com/sjzar/file-store-mcp/internal/storage/cos"
+	"github.com/sjzar/file-store-mcp/internal/storage/github"
+	"github.com/sjzar/file-store-mcp/internal/storage/memory"
+	"github.com/sjzar/file-store-mcp/internal/storage/oss"
+	"github.com/sjzar/file-store-mcp/internal/storage/plugin"
+	"github.com/sjzar/file-store-mcp/internal/storage/qiniu"
+	"github.com/sjzar/file-store-mcp/internal/storage/s3"
+)
+
+// Config contains the configuration of the client and of all storage backends.
+// Only the settings of the selected StorageType are used.
+type Config = storage.Config
+
+// Backend configurations
+type (
+	S3Config     = s3.S3Config
+	OSSConfig    = oss.OSSConfig
+	COSConfig    = cos.COSConfig
+	QiniuConfig  = qiniu.QiniuConfig
+	GitHubConfig = github.GitHubConfig
+	PluginConfig = plugin.PluginConfig
+	ExecConfig   = plugin.ExecConfig
+	MemoryConfig = memory.MemoryConfig
+)
+
+// Storage is a storage backend, implement it to upload to a custom backend (see WithStorage)
+type Storage = storage.Storage
+
+// Factory creates a storage backend from the configuration, see Register
+type Factory = storage.Factory
+
+// Register makes a custom storage backend available under storageType, to be
+// selected with Config.StorageType or FSM_STORAGE_TYPE. Call it from an init
+// function; it panics if the type is already registered.
+func Register(storageType string, factory Factory) {
+	storage.Register(storageType, factory)
+}
+
+// SettingsFactory creates a storage backend from key-value settings, see RegisterSettings
+type SettingsFactory = storage.SettingsFactory
+
+// RegisterSettings makes a custom storage backend available under storageType like
+// Register, with its settings from the FSM_<TYPE>_* environment variables (prefix
+// removed, lowercased) or Config.Settings instead of the whole Config
+func RegisterSettings(storageType string, factory SettingsFactory) {
+	storage.RegisterSettings(storageType, factory)
+}
+
+// Presigner is implemented by storages that can generate a fresh download URL for an object
+type Presigner = storage.Presigner
+
+// Deleter is implemented by storages that can delete uploaded objects
+type Deleter = storage.Deleter
+
+// Exister is implemented by storages that can check whether an object exists
+type Exister = storage.Exister
+
+// Lister is implemented by storages that can list their objects
+type Lister = storage.Lister
+
+// UploadResult describes an uploaded object: URL, object key, backend, size,
+// SHA-256 checksum and when the URL expires
+type UploadResult = storage.UploadResult
+
+// URLExpirer is implemented by storages whose download URLs expire
+type URLExpirer = storage.URLExpirer
+
+// Info describes the configured storage and its limits, see Client.Info
+type Info = storage.Info
+
+// Limits describes the limits of a storage backend; implement LimitsReporter to report them
+type Limits = storage.Limits
+
+// LimitsReporter is implemented by storages that know their limits
+type LimitsReporter = storage.LimitsReporter
+
+// ObjectInfo describes a stored object, see Client.List
+type ObjectInfo = storage.ObjectInfo
+
+// Capabilities lists the optional operations supported by a storage
+type Capabilities = storage.Capabilities
+
+// BackendError describes a failed storage operation: the backend, the operation,
+// the provider's error code and a hint on how to fix it. Use errors.As to get it.
+type BackendError = storage.BackendError
+
+// ErrNotSupported is returned for operations the storage doesn't implement
+var ErrNotSupported = storage.ErrNotSupported
+
+// ErrNoDelete is returned for deletions and overwrites in no-delete mode
+var ErrNoDelete = storage.ErrNoDelete
+
+// Scope restricts the uploads of a caller to an object key prefix and a daily quota
+type Scope = storage.Scope
+
+// WithScope returns a context restricting the uploads made with it to scope,
+// e.g. for the users of a shared server
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return storage.WithScope(ctx, scope)
+}
+
+// Upload statistics, see Client.Stats
+type (
+	Stats        = storage.Stats
+	BackendStats = storage.BackendStats
+)
+
+// Storage types
+const (
+	StorageTypeS3          = storage.StorageTypeS3
+	StorageTypeR2          = storage.StorageTypeR2
+	StorageTypeOSS         = storage.StorageTypeOSS
+	StorageTypeCOS         = storage.StorageTypeCOS
+	StorageTypeQiniu       = storage.StorageTypeQiniu
+	StorageTypeGitHub      = storage.StorageTypeGitHub
+	StorageTypeDropbox     = storage.StorageTypeDropbox
+	StorageTypeOneDrive    = storage.StorageTypeOneDrive
+	StorageTypeBox         = storage.StorageTypeBox
+	StorageTypeIPFS        = storage.StorageTypeIPFS
+	StorageTypeCloudinary  = storage.StorageTypeCloudinary
+	StorageTypeFirebase    = storage.StorageTypeFirebase
+	StorageTypeOCI         = storage.StorageTypeOCI
+	StorageTypeBOS         = storage.StorageTypeBOS
+	StorageTypeStorj       = storage.StorageTypeStorj
+	StorageTypeArtifactory = storage.StorageTypeArtifactory
+	StorageTypePlugin      = storage.StorageTypePlugin
+	StorageTypeExec        = storage.StorageTypeExec
+	StorageTypeMemory      = storage.StorageTypeMemory
+)
+
+// Content mismatch policies, see Config.ContentMismatch
+const (
+	MismatchPolicyOff    = storage.MismatchPolicyOff
+	MismatchPolicyWarn   = storage.MismatchPolicyWarn
+	MismatchPolicyReject = storage.MismatchPolicyReject
+)
+
+// Option customizes a Client
+type Option func(*options)
+
+type options struct {
+	storage Storage
+}
+
+// WithStorage uploads to the given storage instead of the one selected by Config.StorageType
+func WithStorage(storage Storage) Option {
+	return func(o *options) {
+		o.storage = storage
+	}
+}
+
+// Client uploads files to the configured storage. It is safe for concurrent use.
+type Client struct {
+	service *storage.Service
+}
+
+// New creates a client for config. Unlike the server, an invalid storage
+// configuration is reported as an error instead of failing every upload.
+func New(config *Config, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	store := o.storage
+	if store == nil {
+		var err error
+		if store, err = storage.OpenStorage(config); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{service: storage.NewServiceWithStorage(config, store)}, nil
+}
+
+// NewFromEnv creates a client configured by the FSM_* environment variables, like the server
+func NewFromEnv(opts ...Option) (*Client, error) {
+	return New(NewConfigFromEnv(), opts...)
+}
+
+// NewConfigFromEnv reads the configuration from the FSM_* environment variables
+func NewConfigFromEnv() *Config {
+	return storage.NewConfigFromEnv()
+}
+
+// Close releases the resources held by the client, e.g. the audit log
+func (c *Client) Close() error {
+	return c.service.Close()
+}
+
+// UploadFile uploads a local file and returns its download URL. The object key
+// is built from the file name and Config.FileFormat.
+func (c *Client) UploadFile(ctx context.Context, path string) (string, error) {
+	return c.service.UploadFile(ctx, path)
+}
+
+// UploadFileAs uploads a local file, building the object key from filename
+// instead of the local file name
+func (c *Client) UploadFileAs(ctx context.Context, path string, filename string) (string, error) {
+	return c.service.UploadFileWithName(ctx, path, filename)
+}
+
+// UploadFileDetailed uploads a local file like UploadFileAs and describes the uploaded object
+func (c *Client) UploadFileDetailed(ctx context.Context, path string, filename string) (*UploadResult, error) {
+	return c.service.UploadFileDetailed(ctx, path, filename)
+}
+
+// Upload uploads the data read from body as filename and returns its download URL
+func (c *Client) Upload(ctx context.Context, body io.Reader, filename string) (string, error) {
+	return c.service.Upload(ctx, body, filename)
+}
+
+// UploadDetailed uploads the data read from body like Upload and describes the uploaded object
+func (c *Client) UploadDetailed(ctx context.Context, body io.Reader, filename string) (*UploadResult, error) {
+	return c.service.UploadDetailed(ctx, body, filename)
+}
+
+// CheckContent verifies that the content of the file at path matches the extension
+// of filename. Depending on Config.ContentMismatch, a mismatch yields a warning or an error.
+func (c *Client) CheckContent(ctx context.Context, path string, filename string) (string, error) {
+	return c.service.CheckContent(ctx, path, filename)
+}
+
+// Presign generates a fresh download URL for an uploaded object, valid for expiration
+// or the configured URL expiration if zero
+func (c *Client) Presign(ctx context.Context, objectKey string, expiration time.Duration) (string, error) {
+	return c.service.Presign(ctx, objectKey, expiration)
+}
+
+// Delete removes an uploaded object, if the storage supports it
+func (c *Client) Delete(ctx context.Context, objectKey string) error {
+	return c.service.Delete(ctx, objectKey)
+}
+
+// Exists reports whether an object exists, if the storage supports it
+func (c *Client) Exists(ctx context.Context, objectKey string) (bool, error) {
+	return c.service.Exists(ctx, objectKey)
+}
+
+// List returns up to limit objects whose key starts with prefix, if the storage supports it
+func (c *Client) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	return c.service.List(ctx, prefix, limit)
+}
+
+// Capabilities reports the optional operations (Presign, Delete, Exists, List) the
+// storage supports. The others return an error wrapping ErrNotSupported.
+func (c *Client) Capabilities() Capabilities {
+	return c.service.Capabilities()
+}
+
+// Info describes the storage: max file size, URL expiration, custom domain and capabilities
+func (c *Client) Info() Info {
+	return c.service.Info()
+}
+
+// Stats returns the upload statistics since the client was created, and from
+// the audit log if Config.AuditLog is set
+func (c *Client) Stats() (*Stats, error) {
+	return c.service.Stats()
+}
+
+// RegisterTools adds the file-store-mcp tools (upload_files, upload_clipboard_files,
+// upload_url_files, upload_directory, the chunked upload tools, download_files, delete_files unless
+// deleting is disabled, get_upload_status, get_upload_stats and storage_info) to an
+// existing MCP server
+func (c *Client) RegisterTools(srv *server.MCPServer) {
+	mcp.Register(srv, c.service)
+}
@@ -0,0 +1,87 @@
+// Package filestoretest provides an in-memory storage served over HTTP, for
+// testing code that uploads with filestore, or calls the file-store-mcp tools,
+// without cloud credentials.
+//
+//	client, srv := filestoretest.NewClient(t)
+//	url, err := client.UploadFile(ctx, "testdata/report.pdf")
+//	...
+//	object, ok := srv.Storage.Object("report.pdf")
+//
+// URLs returned by uploads can be downloaded from the test server. To test the
+// MCP tools, connect an in-process client (client.NewInProcessClient from
+// mcp-go) to the server returned by NewMCPServer.
+package filestoretest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/sjzar/file-store-mcp/internal/storage/memory"
+	"github.com/sjzar/file-store-mcp/pkg/filestore"
+)
+
+// Storage keeps uploads in memory; inspect them with Object and Objects
+type Storage = memory.MemoryStorage
+
+// Object is an uploaded object held in memory
+type Object = memory.Object
+
+// NewStorage creates an empty in-memory storage whose URLs start with baseURL.
+// It serves the objects itself as an http.Handler.
+func NewStorage(baseURL string) *Storage {
+	return memory.NewMemoryStorage(memory.MemoryConfig{BaseURL: baseURL})
+}
+
+// Server is an HTTP test server serving the objects of an in-memory storage
+type Server struct {
+	*httptest.Server
+	Storage *Storage
+}
+
+// NewServer starts a server whose storage returns URLs pointing at it.
+// Call Close when done.
+func NewServer() *Server {
+	srv := &Server{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.Storage.ServeHTTP(w, r)
+	}))
+	srv.Storage = NewStorage(srv.URL)
+	return srv
+}
+
+// ClientConfig returns a client configuration selecting the in-memory storage of srv
+func (srv *Server) ClientConfig() *filestore.Config {
+	return &filestore.Config{
+		StorageType: filestore.StorageTypeMemory,
+		Memory:      filestore.MemoryConfig{BaseURL: srv.URL},
+	}
+}
+
+// NewClient starts a server and returns a client uploading to its storage.
+// Both are closed when the test ends.
+func NewClient(tb testing.TB) (*filestore.Client, *Server) {
+	tb.Helper()
+	srv := NewServer()
+	tb.Cleanup(srv.Close)
+
+	client, err := filestore.New(srv.ClientConfig(), filestore.WithStorage(srv.Storage))
+	if err != nil {
+		tb.Fatalf("filestoretest: failed to create client: %v", err)
+	}
+	tb.Cleanup(func() { _ = client.Close() })
+	return client, srv
+}
+
+// NewMCPServer returns an MCP server with the file-store-mcp tools registered,
+// uploading to the in-memory storage of a test server. Both are closed when the
+// test ends.
+func NewMCPServer(tb testing.TB) (*server.MCPServer, *Server) {
+	tb.Helper()
+	client, srv := NewClient(tb)
+	mcpServer := server.NewMCPServer("file-store-mcp-test", "test", server.WithToolCapabilities(false))
+	client.RegisterTools(mcpServer)
+	return mcpServer, srv
+}
@@ -0,0 +1,327 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveEntry describes a file inside an archive
+type ArchiveEntry struct {
+	Name        string
+	ContentType string // Sniffed from the content, or from the name if the content gives no signal
+}
+
+// ArchiveEntries lists up to limit files of the zip, tar or gzip-compressed archive
+// at path with their content types. Files that aren't archives yield no entries.
+func ArchiveEntries(path string, limit int) ([]ArchiveEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return zipEntries(file, info.Size(), limit)
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		defer gz.Close()
+
+		// A compressed single file is listed as one entry
+		content := bufio.NewReaderSize(gz, sniffLen)
+		if decompressed, _ := content.Peek(sniffLen); !isTar(decompressed) {
+			name := gz.Name
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+			return []ArchiveEntry{newArchiveEntry(name, content)}, nil
+		}
+		return tarEntries(content, limit)
+	case isTar(head):
+		return tarEntries(file, limit)
+	}
+	return nil, nil
+}
+
+// isTar reports whether head starts with a POSIX or GNU tar header
+func isTar(head []byte) bool {
+	return len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar"))
+}
+
+func zipEntries(r io.ReaderAt, size int64, limit int) ([]ArchiveEntry, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	var entries []ArchiveEntry
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from zip archive: %w", f.Name, err)
+		}
+		entries = append(entries, newArchiveEntry(f.Name, rc))
+		rc.Close()
+	}
+	return entries, nil
+}
+
+func tarEntries(r io.Reader, limit int) ([]ArchiveEntry, error) {
+	archive := tar.NewReader(r)
+	var entries []ArchiveEntry
+	for limit <= 0 || len(entries) < limit {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		entries = append(entries, newArchiveEntry(header.Name, archive))
+	}
+	return entries, nil
+}
+
+// FileArchiveEntry describes the local file at path as the archive entry name,
+// with the content type ArchiveEntries would report for it
+func FileArchiveEntry(path string, name string) (ArchiveEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	defer file.Close()
+	return newArchiveEntry(name, file), nil
+}
+
+// newArchiveEntry sniffs the content type of an entry from its leading bytes
+func newArchiveEntry(name string, r io.Reader) ArchiveEntry {
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(r, head)
+	contentType := SniffContentType(head[:n])
+	if contentType == "" || contentType == "text/plain; charset=utf-8" {
+		contentType = DetectContentType(name, head[:n])
+	}
+	return ArchiveEntry{Name: name, ContentType: contentType}
+}
+
+// ErrNotArchive is returned by ExtractArchive for files that aren't zip, tar or gzip archives
+var ErrNotArchive = errors.New("not a zip, tar or gzip archive")
+
+// ArchiveLimits bounds what ExtractArchive unpacks, to guard against archive bombs
+type ArchiveLimits struct {
+	MaxFiles int   // Most files extracted
+	MaxSize  int64 // Most bytes extracted in total
+}
+
+// ExtractedFile is a file unpacked from an archive
+type ExtractedFile struct {
+	Name string // Path of the file in the archive, with forward slashes
+	Path string // Path of the extracted file
+	Size int64
+}
+
+// ExtractArchive unpacks the files of the zip, tar or gzip-compressed archive at
+// path into outDir. With include, only files whose path or name in the archive
+// matches one of the glob patterns are unpacked. Archives with entries pointing
+// outside of outDir are refused, links and other special files are skipped.
+func ExtractArchive(path string, outDir string, include []string, limits ArchiveLimits) ([]ExtractedFile, error) {
+	for _, pattern := range include {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	x := &extractor{outDir: outDir, include: include, limits: limits, seen: make(map[string]bool)}
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		err = x.zip(file, info.Size())
+		return x.files, err
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		defer gz.Close()
+
+		// A compressed single file is extracted as one file
+		content := bufio.NewReaderSize(gz, sniffLen)
+		if decompressed, _ := content.Peek(sniffLen); !isTar(decompressed) {
+			name := filepath.Base(gz.Name)
+			if gz.Name == "" {
+				name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+			err = x.extract(name, content)
+			return x.files, err
+		}
+		err = x.tar(content)
+		return x.files, err
+	case isTar(head):
+		err = x.tar(file)
+		return x.files, err
+	}
+	return nil, ErrNotArchive
+}
+
+// extractor unpacks the entries of an archive within the limits
+type extractor struct {
+	outDir  string
+	include []string
+	limits  ArchiveLimits
+	files   []ExtractedFile
+	size    int64
+	seen    map[string]bool
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+	for _, f := range archive.File {
+		if !f.Mode().IsRegular() || !x.included(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from zip archive: %w", f.Name, err)
+		}
+		err = x.extract(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !x.included(header.Name) {
+			continue
+		}
+		if err := x.extract(header.Name, archive); err != nil {
+			return err
+		}
+	}
+}
+
+// included reports whether the entry matches the include patterns
+func (x *extractor) included(name string) bool {
+	if len(x.include) == 0 {
+		return true
+	}
+	name = strings.ReplaceAll(name, "\\", "/")
+	for _, pattern := range x.include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extract writes an entry below outDir
+func (x *extractor) extract(name string, r io.Reader) error {
+	name = strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "./")
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("archive refused: entry %q points outside of the archive", name)
+	}
+	if x.seen[name] {
+		return nil
+	}
+	x.seen[name] = true
+	if x.limits.MaxFiles > 0 && len(x.files) >= x.limits.MaxFiles {
+		return fmt.Errorf("archive refused: it has more than %d files, select some with include patterns", x.limits.MaxFiles)
+	}
+
+	target := filepath.Join(x.outDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if x.limits.MaxSize > 0 {
+		// Read one byte past the limit to tell a file reaching it from one exceeding it
+		r = io.LimitReader(r, x.limits.MaxSize-x.size+1)
+	}
+	n, err := io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	x.size += n
+	if x.limits.MaxSize > 0 && x.size > x.limits.MaxSize {
+		return fmt.Errorf("archive refused: its content exceeds %s", FormatSize(x.limits.MaxSize))
+	}
+	x.files = append(x.files, ExtractedFile{Name: name, Path: target, Size: n})
+	return nil
+}
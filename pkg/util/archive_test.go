@@ -0,0 +1,243 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testEntry is a file or link written into a test archive
+type testEntry struct {
+	name     string
+	content  string
+	linkname string // Makes the entry a symbolic link if set
+}
+
+func writeZip(t *testing.T, entries ...testEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		content := entry.content
+		if entry.linkname != "" {
+			header.SetMode(os.ModeSymlink | 0o777)
+			content = entry.linkname
+		}
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeTestFile(t, "test.zip", buf.Bytes())
+}
+
+func tarBytes(t *testing.T, entries ...testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if entry.linkname != "" {
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, entry.linkname, 0
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if entry.linkname == "" {
+			archive.Write([]byte(entry.content))
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Name = name
+	gz.Write(data)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// extractedNames returns the names of the extracted files
+func extractedNames(files []ExtractedFile) string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestExtractArchiveRefusesPathTraversal(t *testing.T) {
+	names := []string{
+		"../evil.txt",
+		"a/../../evil.txt",
+		"/etc/evil.txt",
+		"..\\evil.txt",
+		"a\\..\\..\\evil.txt",
+	}
+	for _, name := range names {
+		entries := []testEntry{{name: "ok.txt", content: "ok"}, {name: name, content: "evil"}}
+		for format, path := range map[string]string{
+			"zip": writeZip(t, entries...),
+			"tar": writeTestFile(t, "test.tar", tarBytes(t, entries...)),
+		} {
+			t.Run(format+" "+name, func(t *testing.T) {
+				// Extract into a directory of its own, so files written next to it show
+				outDir := filepath.Join(t.TempDir(), "a", "b", "out")
+				_, err := ExtractArchive(path, outDir, nil, ArchiveLimits{})
+				if err == nil || !strings.Contains(err.Error(), "outside of the archive") {
+					t.Fatalf("ExtractArchive() = %v, want the entry refused", err)
+				}
+				for _, path := range []string{filepath.Join(outDir, "..", "evil.txt"), filepath.Join(outDir, "..", "..", "evil.txt"), "/etc/evil.txt"} {
+					if _, err := os.Stat(path); err == nil {
+						t.Errorf("%s was written", path)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveSkipsLinks(t *testing.T) {
+	for format, path := range map[string]string{
+		"zip": writeZip(t, testEntry{name: "passwd", linkname: "/etc/passwd"}, testEntry{name: "a.txt", content: "a"}),
+		"tar": writeTestFile(t, "test.tar", tarBytes(t, testEntry{name: "passwd", linkname: "/etc/passwd"}, testEntry{name: "a.txt", content: "a"})),
+	} {
+		t.Run(format, func(t *testing.T) {
+			outDir := t.TempDir()
+			files, err := ExtractArchive(path, outDir, nil, ArchiveLimits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extractedNames(files); got != "a.txt" {
+				t.Errorf("extracted %q, want only a.txt", got)
+			}
+			if _, err := os.Lstat(filepath.Join(outDir, "passwd")); err == nil {
+				t.Error("the link was extracted")
+			}
+		})
+	}
+}
+
+func TestExtractArchiveLimits(t *testing.T) {
+	entries := []testEntry{
+		{name: "a.txt", content: strings.Repeat("a", 10)},
+		{name: "dir/b.txt", content: strings.Repeat("b", 10)},
+		{name: "dir/c.txt", content: strings.Repeat("c", 10)},
+	}
+	tarPath := writeTestFile(t, "test.tar", tarBytes(t, entries...))
+	tests := []struct {
+		name   string
+		limits ArchiveLimits
+		err    string
+	}{
+		{name: "unlimited", limits: ArchiveLimits{}},
+		{name: "at the limits", limits: ArchiveLimits{MaxFiles: 3, MaxSize: 30}},
+		{name: "too many files", limits: ArchiveLimits{MaxFiles: 2}, err: "more than 2 files"},
+		{name: "too large", limits: ArchiveLimits{MaxSize: 29}, err: "exceeds"},
+	}
+	for _, tt := range tests {
+		for format, path := range map[string]string{"zip": writeZip(t, entries...), "tar": tarPath} {
+			t.Run(format+" "+tt.name, func(t *testing.T) {
+				files, err := ExtractArchive(path, t.TempDir(), nil, tt.limits)
+				if tt.err == "" {
+					if err != nil || len(files) != 3 {
+						t.Errorf("ExtractArchive() = %d files, %v, want 3 files", len(files), err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ExtractArchive() = %v, want an error containing %q", err, tt.err)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveBomb(t *testing.T) {
+	// 64 MiB of zeros compress to a few KiB; only the limit plus one byte is written
+	gz := gzipBytes(t, "zeros.bin", make([]byte, 64<<20))
+	outDir := t.TempDir()
+	_, err := ExtractArchive(writeTestFile(t, "zeros.bin.gz", gz), outDir, nil, ArchiveLimits{MaxSize: 1 << 20})
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("ExtractArchive() = %v, want the archive refused", err)
+	}
+	if info, err := os.Stat(filepath.Join(outDir, "zeros.bin")); err == nil && info.Size() > 1<<20+1 {
+		t.Errorf("wrote %d bytes, beyond the limit", info.Size())
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	entries := []testEntry{
+		{name: "docs/readme.md", content: "# readme"},
+		{name: "docs/guide.md", content: "# guide"},
+		{name: "src/main.go", content: "package main"},
+		{name: "./src/main.go", content: "duplicate"},
+	}
+	tests := []struct {
+		name    string
+		path    string
+		include []string
+		want    string
+	}{
+		{name: "zip", path: writeZip(t, entries...), want: "docs/readme.md,docs/guide.md,src/main.go"},
+		{name: "tar", path: writeTestFile(t, "test.tar", tarBytes(t, entries...)), want: "docs/readme.md,docs/guide.md,src/main.go"},
+		{name: "tar.gz", path: writeTestFile(t, "test.tar.gz", gzipBytes(t, "", tarBytes(t, entries...))), want: "docs/readme.md,docs/guide.md,src/main.go"},
+		{name: "include by path", path: writeZip(t, entries...), include: []string{"docs/*"}, want: "docs/readme.md,docs/guide.md"},
+		{name: "include by name", path: writeZip(t, entries...), include: []string{"*.go"}, want: "src/main.go"},
+		{name: "gzip", path: writeTestFile(t, "notes.txt.gz", gzipBytes(t, "../../notes.txt", []byte("notes"))), want: "notes.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			files, err := ExtractArchive(tt.path, outDir, tt.include, ArchiveLimits{MaxFiles: 10, MaxSize: 1 << 20})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extractedNames(files); got != tt.want {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+			for _, file := range files {
+				if !strings.HasPrefix(file.Path, outDir+string(filepath.Separator)) {
+					t.Errorf("%s extracted to %s, outside of %s", file.Name, file.Path, outDir)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchiveErrors(t *testing.T) {
+	if _, err := ExtractArchive(writeTestFile(t, "a.txt", []byte("plain text")), t.TempDir(), nil, ArchiveLimits{}); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("ExtractArchive() of a text file = %v, want ErrNotArchive", err)
+	}
+	if _, err := ExtractArchive(writeZip(t, testEntry{name: "a.txt"}), t.TempDir(), []string{"["}, ArchiveLimits{}); err == nil {
+		t.Error("ExtractArchive() accepted an invalid pattern")
+	}
+}
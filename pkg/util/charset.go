@@ -0,0 +1,179 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetSampleLen is the number of bytes inspected to detect a text encoding
+const charsetSampleLen = 64 * 1024
+
+// Charset name constants, as used in the Content-Type charset parameter
+const (
+	CharsetUTF8    = "utf-8"
+	CharsetUTF16LE = "utf-16le"
+	CharsetUTF16BE = "utf-16be"
+	CharsetGBK     = "gbk"
+	CharsetGB18030 = "gb18030"
+	CharsetSJIS    = "shift_jis"
+)
+
+// charsetEncodings maps detectable charsets to their decoders.
+// GBK is decoded as GB18030, its superset.
+var charsetEncodings = map[string]encoding.Encoding{
+	CharsetUTF16LE: textunicode.UTF16(textunicode.LittleEndian, textunicode.UseBOM),
+	CharsetUTF16BE: textunicode.UTF16(textunicode.BigEndian, textunicode.UseBOM),
+	CharsetGBK:     simplifiedchinese.GB18030,
+	CharsetGB18030: simplifiedchinese.GB18030,
+	CharsetSJIS:    japanese.ShiftJIS,
+}
+
+// DetectCharset guesses the encoding of a text sample. It recognizes UTF-8,
+// UTF-16 (with or without BOM), GBK/GB18030 and Shift-JIS, returning an empty
+// string when the sample doesn't look like text in any of them.
+func DetectCharset(sample []byte) string {
+	return detectCharset(sample, len(sample) >= charsetSampleLen)
+}
+
+// detectCharset guesses the encoding of a sample, which may be cut off in the
+// middle of a multi-byte sequence if truncated is set
+func detectCharset(sample []byte, truncated bool) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte("\xef\xbb\xbf")):
+		return CharsetUTF8
+	case bytes.HasPrefix(sample, []byte("\xff\xfe")):
+		return CharsetUTF16LE
+	case bytes.HasPrefix(sample, []byte("\xfe\xff")):
+		return CharsetUTF16BE
+	}
+
+	if charset := detectUTF16(sample); charset != "" {
+		return charset
+	}
+
+	// The sample may end in the middle of a multi-byte sequence
+	trimmed := sample
+	if truncated && len(trimmed) > 3 {
+		trimmed = trimmed[:len(trimmed)-3]
+	}
+	if utf8.Valid(trimmed) {
+		return CharsetUTF8
+	}
+
+	// Prefer Shift-JIS when it decodes cleanly into text containing kana,
+	// since GBK decodes most Shift-JIS byte sequences into valid but meaningless Hanzi
+	sjis, sjisOK := decodeSample(japanese.ShiftJIS, trimmed)
+	if sjisOK && containsKana(sjis) {
+		return CharsetSJIS
+	}
+	if _, ok := decodeSample(simplifiedchinese.GB18030, trimmed); ok {
+		return CharsetGBK
+	}
+	if sjisOK {
+		return CharsetSJIS
+	}
+	return ""
+}
+
+// detectUTF16 recognizes BOM-less UTF-16 by the pattern of zero bytes ASCII text produces
+func detectUTF16(sample []byte) string {
+	if len(sample) < 4 {
+		return ""
+	}
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	half := len(sample) / 2
+	switch {
+	case oddZeros > half*3/4 && evenZeros < half/8:
+		return CharsetUTF16LE
+	case evenZeros > half*3/4 && oddZeros < half/8:
+		return CharsetUTF16BE
+	}
+	return ""
+}
+
+// decodeSample decodes a sample and reports whether it decoded without errors into printable text
+func decodeSample(enc encoding.Encoding, sample []byte) (string, bool) {
+	decoded, err := enc.NewDecoder().Bytes(sample)
+	if err != nil {
+		return "", false
+	}
+	text := string(decoded)
+	for _, r := range text {
+		if r == utf8.RuneError || (unicode.IsControl(r) && !unicode.IsSpace(r)) {
+			return text, false
+		}
+	}
+	return text, true
+}
+
+// containsKana reports whether text contains Japanese hiragana or katakana
+func containsKana(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectFileCharset detects the encoding of a local text file
+func DetectFileCharset(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sample := make([]byte, charsetSampleLen)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return DetectCharset(sample[:n]), nil
+}
+
+// WithCharset sets the charset parameter of a textual content type
+func WithCharset(contentType string, charset string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || charset == "" || !isTextType(mediaType) {
+		return contentType
+	}
+	params["charset"] = charset
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// IsTextContentType reports whether a content type denotes textual content
+func IsTextContentType(contentType string) bool {
+	return isTextType(normalizeMediaType(contentType))
+}
+
+// TranscodeToUTF8 writes the content of r, encoded in charset, to w as UTF-8
+func TranscodeToUTF8(w io.Writer, r io.Reader, charset string) error {
+	enc, ok := charsetEncodings[charset]
+	if !ok {
+		return fmt.Errorf("unsupported charset: %s", charset)
+	}
+	_, err := io.Copy(w, transform.NewReader(r, enc.NewDecoder()))
+	return err
+}
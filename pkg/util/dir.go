@@ -0,0 +1,132 @@
+package util
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DirFile is a regular file found in a directory by WalkDir
+type DirFile struct {
+	Name string // Path of the file relative to the directory, with forward slashes
+	Path string // Absolute path of the file
+	Size int64
+}
+
+// WalkDir lists the regular files below the directory root. With include, only
+// files whose relative path or name matches one of the glob patterns are listed;
+// files and directories matching an exclude pattern are skipped, e.g. ".git" or
+// "*.log". Links and other special files are skipped. The files are bounded by
+// limits like the content of an archive.
+func WalkDir(root string, include []string, exclude []string, limits ArchiveLimits) ([]DirFile, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var files []DirFile
+	var size int64
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if matchAny(exclude, name) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (len(include) > 0 && !matchAny(include, name)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if limits.MaxFiles > 0 && len(files) >= limits.MaxFiles {
+			return fmt.Errorf("directory refused: it has more than %d files, select some with include or exclude patterns", limits.MaxFiles)
+		}
+		size += info.Size()
+		if limits.MaxSize > 0 && size > limits.MaxSize {
+			return fmt.Errorf("directory refused: its files exceed %s, select some with include or exclude patterns", FormatSize(limits.MaxSize))
+		}
+		files = append(files, DirFile{Name: name, Path: p, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// matchAny reports whether the relative path name, or its base name, matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ZipFiles writes a zip archive of files to w, each under its relative name.
+// With a password, the files are encrypted with AES-256; their names aren't.
+func ZipFiles(w io.Writer, files []DirFile, password string) error {
+	archive := zip.NewWriter(w)
+	add := addZipFile
+	if password != "" {
+		add = func(archive *zip.Writer, file DirFile) error { return addEncryptedZipFile(archive, file, password) }
+	}
+	for _, file := range files {
+		if err := add(archive, file); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// addZipFile compresses a file into the archive, keeping its modification time and mode
+func addZipFile(archive *zip.Writer, file DirFile) error {
+	in, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(file.Name, "/")
+	header.Method = zip.Deflate
+	out, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", file.Name, err)
+	}
+	return nil
+}
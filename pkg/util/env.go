@@ -0,0 +1,17 @@
+package util
+
+import (
+	"os"
+	"strings"
+)
+
+// GetEnvBool gets a boolean environment variable, true for "true", "1" or "yes",
+// or returns a default value if it isn't set
+func GetEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	value = strings.ToLower(value)
+	return value == "true" || value == "1" || value == "yes"
+}
@@ -0,0 +1,27 @@
+package util
+
+import "testing"
+
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		value        string
+		defaultValue bool
+		want         bool
+	}{
+		{value: "", defaultValue: true, want: true},
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "TRUE", want: true},
+		{value: "1", want: true},
+		{value: "Yes", want: true},
+		{value: "false", defaultValue: true, want: false},
+		{value: "0", defaultValue: true, want: false},
+		{value: "on", want: false},
+	}
+	for _, tt := range tests {
+		t.Setenv("FSM_TEST_BOOL", tt.value)
+		if got := GetEnvBool("FSM_TEST_BOOL", tt.defaultValue); got != tt.want {
+			t.Errorf("GetEnvBool() of %q with default %v = %v, want %v", tt.value, tt.defaultValue, got, tt.want)
+		}
+	}
+}
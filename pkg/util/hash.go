@@ -0,0 +1,55 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// HashFile returns the size and hex-encoded SHA-256 digest of a local file
+func HashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashingReader computes the size and SHA-256 digest of the data read through it
+type HashingReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+}
+
+// NewHashingReader wraps r to hash the data read from it
+func NewHashingReader(r io.Reader) *HashingReader {
+	return &HashingReader{r: r, hash: sha256.New()}
+}
+
+// Read implements io.Reader
+func (h *HashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.size += int64(n)
+	h.hash.Write(p[:n])
+	return n, err
+}
+
+// Size returns the number of bytes read so far
+func (h *HashingReader) Size() int64 {
+	return h.size
+}
+
+// Sum returns the hex-encoded SHA-256 digest of the data read so far
+func (h *HashingReader) Sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
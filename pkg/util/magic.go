@@ -0,0 +1,189 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// executableSignatures lists magic numbers of executable formats
+// that http.DetectContentType does not recognize
+var executableSignatures = []struct {
+	magic       []byte
+	contentType string
+}{
+	{[]byte("MZ"), "application/vnd.microsoft.portable-executable"},
+	{[]byte("\x7fELF"), "application/x-elf"},
+	{[]byte("\xfe\xed\xfa\xce"), "application/x-mach-binary"},
+	{[]byte("\xfe\xed\xfa\xcf"), "application/x-mach-binary"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xca\xfe\xba\xbe"), "application/x-mach-binary"},
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// executableTypes are executable and script types known by extension only
+var executableTypes = map[string]bool{
+	"application/x-sh":              true,
+	"text/x-sh":                     true,
+	"application/x-msdos-program":   true,
+	"application/x-msi":             true,
+	"application/x-bat":             true,
+	"application/x-dosexec":         true,
+	"application/x-apple-diskimage": true,
+}
+
+// contentTypeAliases normalizes media types that have several common names
+var contentTypeAliases = map[string]string{
+	"application/x-gzip":           "application/gzip",
+	"application/x-rar-compressed": "application/vnd.rar",
+	"audio/wave":                   "audio/wav",
+	"audio/x-wav":                  "audio/wav",
+	"image/vnd.microsoft.icon":     "image/x-icon",
+	"application/x-pdf":            "application/pdf",
+	"application/x-msdownload":     "application/vnd.microsoft.portable-executable",
+	"application/x-executable":     "application/x-elf",
+}
+
+// zipContainers are formats stored as zip archives
+var zipContainers = map[string]bool{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+	"application/vnd.oasis.opendocument.spreadsheet":                            true,
+	"application/vnd.oasis.opendocument.presentation":                           true,
+	"application/epub+zip":                    true,
+	"application/vnd.android.package-archive": true,
+	"application/java-archive":                true,
+}
+
+// isoMediaContainers are formats based on the ISO base media file format (ftyp box)
+var isoMediaContainers = map[string]bool{
+	"video/mp4":       true,
+	"video/quicktime": true,
+	"audio/mp4":       true,
+	"image/heic":      true,
+	"image/heif":      true,
+	"image/avif":      true,
+}
+
+// SniffContentType detects the content type from the leading bytes of the content only,
+// returning an empty string when the content gives no reliable signal
+func SniffContentType(head []byte) string {
+	for _, sig := range executableSignatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return sig.contentType
+		}
+	}
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" {
+		return ""
+	}
+	return contentType
+}
+
+// SniffFileContentType detects the content type of a local file from its leading bytes
+func SniffFileContentType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return SniffContentType(head[:n]), nil
+}
+
+// IsExecutableType reports whether the content type denotes an executable or script
+func IsExecutableType(contentType string) bool {
+	mediaType := normalizeMediaType(contentType)
+	if executableTypes[mediaType] {
+		return true
+	}
+	for _, sig := range executableSignatures {
+		if sig.contentType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchContentType reports whether contentType matches pattern: a media type like
+// "application/zip", a wildcard like "image/*" or "*", or "executable" for all
+// executables and scripts. Parameters and aliases are ignored.
+func MatchContentType(pattern string, contentType string) bool {
+	mediaType := normalizeMediaType(contentType)
+	if mediaType == "" {
+		return false
+	}
+	switch pattern = strings.ToLower(strings.TrimSpace(pattern)); {
+	case pattern == "*" || pattern == "*/*":
+		return true
+	case pattern == "executable":
+		return IsExecutableType(mediaType)
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+	}
+	return normalizeMediaType(pattern) == mediaType
+}
+
+// ContentMatchesExtension reports whether the sniffed content type is consistent
+// with the type implied by the file extension. Unknown types on either side are
+// treated as consistent, since there is nothing to compare.
+func ContentMatchesExtension(fileName string, sniffed string) bool {
+	expected := normalizeMediaType(GetContentType(fileName))
+	actual := normalizeMediaType(sniffed)
+	if expected == "application/octet-stream" || actual == "" || expected == actual {
+		return true
+	}
+
+	switch {
+	case strings.HasPrefix(actual, "text/") && isTextType(expected):
+		// Textual content, including scripts with a shebang, is consistent with any textual format
+		return true
+	case IsExecutableType(actual):
+		// Executables only match executable extensions
+		return IsExecutableType(expected)
+	case actual == "application/zip":
+		return zipContainers[expected]
+	case isoMediaContainers[actual]:
+		return isoMediaContainers[expected]
+	}
+	return false
+}
+
+// isTextType reports whether a media type is textual
+func isTextType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/toml",
+		"application/javascript", "image/svg+xml", "application/rtf":
+		return true
+	}
+	return false
+}
+
+// normalizeMediaType strips parameters and resolves aliases
+func normalizeMediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, ok := contentTypeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
@@ -0,0 +1,168 @@
+package util
+
+import (
+	"mime"
+	"strings"
+)
+
+// builtinContentTypes extends the system MIME database with common types
+// that are missing or inconsistent across platforms
+var builtinContentTypes = map[string]string{
+	// Images
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".bmp":  "image/bmp",
+	".ico":  "image/x-icon",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".avif": "image/avif",
+
+	// Documents
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".rtf":  "application/rtf",
+	".epub": "application/epub+zip",
+
+	// Text and data
+	".txt":      "text/plain",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".csv":      "text/csv",
+	".tsv":      "text/tab-separated-values",
+	".html":     "text/html",
+	".htm":      "text/html",
+	".css":      "text/css",
+	".js":       "text/javascript",
+	".mjs":      "text/javascript",
+	".json":     "application/json",
+	".xml":      "application/xml",
+	".yaml":     "application/yaml",
+	".yml":      "application/yaml",
+	".toml":     "application/toml",
+	".log":      "text/plain",
+
+	// Archives
+	".zip": "application/zip",
+	".gz":  "application/gzip",
+	".tgz": "application/gzip",
+	".tar": "application/x-tar",
+	".bz2": "application/x-bzip2",
+	".xz":  "application/x-xz",
+	".7z":  "application/x-7z-compressed",
+	".rar": "application/vnd.rar",
+
+	// Audio
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+	".flac": "audio/flac",
+	".aac":  "audio/aac",
+	".m4a":  "audio/mp4",
+	".opus": "audio/opus",
+
+	// Video
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".avi":  "video/x-msvideo",
+	".mkv":  "video/x-matroska",
+	".wmv":  "video/x-ms-wmv",
+
+	// Fonts
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+
+	// Binaries
+	".wasm": "application/wasm",
+	".exe":  "application/vnd.microsoft.portable-executable",
+	".dll":  "application/vnd.microsoft.portable-executable",
+	".apk":  "application/vnd.android.package-archive",
+	".dmg":  "application/x-apple-diskimage",
+}
+
+func init() {
+	for ext, contentType := range builtinContentTypes {
+		_ = mime.AddExtensionType(ext, contentType)
+	}
+}
+
+// RegisterContentTypes adds or overrides extension to content type mappings.
+// Extensions may be given with or without the leading dot.
+func RegisterContentTypes(types map[string]string) error {
+	for ext, contentType := range types {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if err := mime.AddExtensionType(ext, strings.TrimSpace(contentType)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseContentTypes parses a mapping list such as ".md=text/markdown,heic=image/heic"
+func ParseContentTypes(value string) map[string]string {
+	types := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		ext, contentType, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(ext) == "" || strings.TrimSpace(contentType) == "" {
+			continue
+		}
+		types[strings.TrimSpace(ext)] = strings.TrimSpace(contentType)
+	}
+	return types
+}
+
+// preferredExtensions picks the conventional extension for types with several candidates
+var preferredExtensions = map[string]string{
+	"image/jpeg":               ".jpg",
+	"image/tiff":               ".tiff",
+	"text/plain":               ".txt",
+	"text/html":                ".html",
+	"text/markdown":            ".md",
+	"text/javascript":          ".js",
+	"application/yaml":         ".yaml",
+	"application/gzip":         ".gz",
+	"audio/mpeg":               ".mp3",
+	"audio/mp4":                ".m4a",
+	"video/mp4":                ".mp4",
+	"application/octet-stream": "",
+}
+
+// ExtensionByType returns the file extension (with dot) for a content type,
+// or an empty string if the type is unknown
+func ExtensionByType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	exts, err := mime.ExtensionsByType(mediaType)
+	if err != nil || len(exts) == 0 {
+		return ""
+	}
+	return exts[0]
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// OCRImage recognizes the text in the image at path with Tesseract. languages are
+// the Tesseract language codes joined with "+", e.g. "eng+chi_sim", or empty for
+// the default of Tesseract.
+func OCRImage(ctx context.Context, path string, languages string) (string, error) {
+	tesseract, err := exec.LookPath("tesseract")
+	if err != nil {
+		return "", errors.New("tesseract is not installed")
+	}
+	args := []string{path, "stdout"}
+	if languages != "" {
+		args = append(args, "-l", languages)
+	}
+	cmd := exec.CommandContext(ctx, tesseract, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// officeExtensions are the document formats converted to PDF by ConvertToPDF
+var officeExtensions = map[string]bool{
+	".doc": true, ".docx": true, ".odt": true, ".rtf": true,
+	".xls": true, ".xlsx": true, ".ods": true,
+	".ppt": true, ".pptx": true, ".odp": true,
+}
+
+// IsOfficeFile reports whether filename is a word processing, spreadsheet or presentation document
+func IsOfficeFile(filename string) bool {
+	return officeExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// ConvertToPDF converts the office document at path into the PDF file outFile.
+// command is the converter to run, with "{input}" and "{output}" in its arguments
+// replaced by the paths. If command is empty, LibreOffice is run headless.
+func ConvertToPDF(ctx context.Context, path string, outFile string, command []string) error {
+	if len(command) > 0 {
+		args := make([]string, 0, len(command)-1)
+		for _, arg := range command[1:] {
+			args = append(args, strings.NewReplacer("{input}", path, "{output}", outFile).Replace(arg))
+		}
+		if err := runConverter(exec.CommandContext(ctx, command[0], args...)); err != nil {
+			return err
+		}
+		if _, err := os.Stat(outFile); err != nil {
+			return fmt.Errorf("converter didn't write the PDF: %w", err)
+		}
+		return nil
+	}
+	return convertWithLibreOffice(ctx, path, outFile)
+}
+
+// convertWithLibreOffice converts path with soffice, using a profile of its own so
+// it doesn't clash with a LibreOffice instance the user has open
+func convertWithLibreOffice(ctx context.Context, path string, outFile string) error {
+	soffice, err := exec.LookPath("soffice")
+	if err != nil {
+		if soffice, err = exec.LookPath("libreoffice"); err != nil {
+			return errors.New("LibreOffice (soffice) is not installed, set FSM_OFFICE_CONVERTER to use another converter")
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "office-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	profile := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, "profile"))}).String()
+	outDir := filepath.Join(dir, "out")
+
+	cmd := exec.CommandContext(ctx, soffice, "-env:UserInstallation="+profile,
+		"--headless", "--norestore", "--convert-to", "pdf", "--outdir", outDir, path)
+	if err := runConverter(cmd); err != nil {
+		return err
+	}
+
+	// soffice names the PDF after the input file, which may lack an extension
+	matches, _ := filepath.Glob(filepath.Join(outDir, "*.pdf"))
+	if len(matches) != 1 {
+		return errors.New("LibreOffice didn't write a PDF, the document may be damaged or password-protected")
+	}
+	return os.Rename(matches[0], outFile)
+}
+
+func runConverter(cmd *exec.Cmd) error {
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	err := cmd.Run()
+	if err != nil && output.Len() > 0 {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(cmd.Path), err, bytes.TrimSpace(output.Bytes()))
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", filepath.Base(cmd.Path), err)
+	}
+	return nil
+}
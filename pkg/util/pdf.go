@@ -0,0 +1,128 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func init() {
+	// Don't create a pdfcpu config directory in the home of the user
+	api.DisableConfigDir()
+}
+
+// PDFPage is a single page extracted from a PDF file
+type PDFPage struct {
+	Number int    // 1-based page number in the original document
+	Path   string // Single page PDF file
+}
+
+// ParsePageSelection validates a page selection such as "1-3,7,10-"
+func ParsePageSelection(selection string) ([]string, error) {
+	pages, err := api.ParsePageSelection(strings.ReplaceAll(selection, " ", ""))
+	if err != nil || len(pages) == 0 {
+		return nil, fmt.Errorf("invalid page selection %q, expected pages and ranges like \"1-3,7,10-\"", selection)
+	}
+	return pages, nil
+}
+
+// ExtractPDFPages writes the selected pages of the PDF file at path to outFile
+func ExtractPDFPages(path string, selection string, outFile string) error {
+	pages, err := ParsePageSelection(selection)
+	if err != nil {
+		return err
+	}
+	pageCount, err := api.PageCountFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if _, err := selectPages(pageCount, pages, selection); err != nil {
+		return err
+	}
+	if err := api.TrimFile(path, outFile, pages, model.NewDefaultConfiguration()); err != nil {
+		return fmt.Errorf("failed to extract pages %s: %w", selection, err)
+	}
+	return nil
+}
+
+// SplitPDFPages writes each selected page of the PDF file at path to a single page
+// PDF file in outDir, named after the original file and the page number. An empty
+// selection splits all pages.
+func SplitPDFPages(path string, selection string, outDir string) ([]PDFPage, error) {
+	var selected []string
+	if selection != "" {
+		var err error
+		if selected, err = ParsePageSelection(selection); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.EXTRACTPAGES
+	ctx, err := api.ReadValidateAndOptimize(file, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	numbers, err := selectPages(ctx.PageCount, selected, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	pages := make([]PDFPage, 0, len(numbers))
+	for _, number := range numbers {
+		r, err := api.ExtractPage(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract page %d: %w", number, err)
+		}
+		pagePath := filepath.Join(outDir, fmt.Sprintf("%s-page-%d.pdf", base, number))
+		if err := writeFile(pagePath, r); err != nil {
+			return nil, err
+		}
+		pages = append(pages, PDFPage{Number: number, Path: pagePath})
+	}
+	return pages, nil
+}
+
+// selectPages returns the sorted numbers of the selected pages of a document with
+// pageCount pages, all pages if selected is empty
+func selectPages(pageCount int, selected []string, selection string) ([]int, error) {
+	pageSet, err := api.PagesForPageSelection(pageCount, selected, true, false)
+	if err != nil {
+		return nil, err
+	}
+	numbers := make([]int, 0, len(pageSet))
+	for number, ok := range pageSet {
+		if ok {
+			numbers = append(numbers, number)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("page selection %q matches none of the %d pages", selection, pageCount)
+	}
+	slices.Sort(numbers)
+	return numbers, nil
+}
+
+func writeFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets removed by Redact
+const Redacted = "REDACTED"
+
+// minSecretLength is the length below which registered secrets aren't redacted,
+// so short values don't mangle unrelated text
+const minSecretLength = 8
+
+var (
+	// Query parameters of signed URLs and tokens passed in URLs: S3 (X-Amz-*),
+	// OSS (Signature, OSSAccessKeyId, x-oss-*), COS (q-ak, q-signature, sign),
+	// Qiniu (token) and generic ones
+	secretQueryParam = regexp.MustCompile(`(?i)([?&;](?:x-amz-signature|x-amz-credential|x-amz-security-token|signature|ossaccesskeyid|accesskeyid|security-token|x-oss-signature|x-oss-credential|x-oss-security-token|x-cos-security-token|q-ak|q-signature|q-key-time|q-sign-time|sign|token|access_token|api_key|apikey|key)=)[^&\s"'<>]+`)
+
+	// Authorization headers: AWS SigV4 credentials and signatures, bearer tokens
+	// and GitHub's "token" scheme
+	secretAuthParam  = regexp.MustCompile(`(?i)\b((?:credential|signature)=)[^,&\s"']+`)
+	secretAuthHeader = regexp.MustCompile(`(?i)((?:authorization|x-cos-security-token|x-amz-security-token|x-oss-security-token)["']?\s*[:=]\s*["']?(?:bearer |token |basic |qbox |qiniu )?)[^\s"',]+`)
+	secretBearer     = regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]{8,}`)
+
+	// Credentials recognizable by their format: GitHub tokens and AWS access key IDs
+	secretToken = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|(?:AKIA|ASIA)[0-9A-Z]{16})\b`)
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // Sorted longest first, so overlapping secrets are removed whole
+)
+
+// RegisterSecrets makes Redact remove the given values, e.g. the configured
+// access keys, wherever they appear. Values shorter than 8 characters are ignored.
+func RegisterSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+		known := false
+		for _, secret := range secrets {
+			if secret == value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact removes access keys, tokens and signed URL parameters from s, such as
+// the request URLs SDK errors echo, so it is safe to log or show to the user
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	secretsMu.RUnlock()
+
+	s = secretQueryParam.ReplaceAllString(s, "${1}"+Redacted)
+	s = secretAuthHeader.ReplaceAllString(s, "${1}"+Redacted)
+	s = secretAuthParam.ReplaceAllString(s, "${1}"+Redacted)
+	s = secretBearer.ReplaceAllString(s, "${1}"+Redacted)
+	return secretToken.ReplaceAllString(s, Redacted)
+}
+
+// RedactError returns err with its message redacted, still wrapping err, or nil
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// NewRedactWriter returns a writer redacting every write before passing it to w,
+// for log outputs that receive one log line per write
+func NewRedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	redacted := Redact(string(p))
+	if redacted == string(p) {
+		return r.w.Write(p)
+	}
+	if _, err := io.WriteString(r.w, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
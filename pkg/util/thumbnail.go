@@ -0,0 +1,116 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// maxThumbnailPixels bounds the size of images decoded for a thumbnail
+const maxThumbnailPixels = 64 << 20
+
+// ErrNoThumbnail is returned for content no thumbnail can be generated of, e.g.
+// an image format without a decoder or a video without ffmpeg installed
+var ErrNoThumbnail = errors.New("no thumbnail for this content")
+
+// Thumbnail scales the image at path down to fit into maxSize x maxSize pixels.
+// It returns the thumbnail as PNG for images that may be transparent and as
+// JPEG otherwise, with the matching file extension.
+func Thumbnail(path string, maxSize int) ([]byte, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	config, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, "", ErrNoThumbnail
+	}
+	if config.Width*config.Height > maxThumbnailPixels {
+		return nil, "", fmt.Errorf("image of %dx%d pixels is too large for a thumbnail", config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, "", err
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	thumb := scaleDown(src, maxSize)
+	var buf bytes.Buffer
+	if format == "png" || format == "gif" {
+		err = png.Encode(&buf, thumb)
+		return buf.Bytes(), ".png", err
+	}
+	err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+	return buf.Bytes(), ".jpg", err
+}
+
+// VideoThumbnail extracts a frame near the start of the video at path with
+// ffmpeg, scaled down to fit into maxSize x maxSize pixels, as JPEG
+func VideoThumbnail(ctx context.Context, path string, maxSize int) ([]byte, string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, "", ErrNoThumbnail
+	}
+	size := strconv.Itoa(maxSize)
+	cmd := exec.CommandContext(ctx, ffmpeg, "-nostdin", "-loglevel", "error",
+		"-ss", "1", "-i", path, "-frames:v", "1",
+		"-vf", "scale="+size+":"+size+":force_original_aspect_ratio=decrease",
+		"-f", "image2", "-c:v", "mjpeg", "pipe:1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("ffmpeg failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if stdout.Len() == 0 {
+		// Videos shorter than the seek position yield no frame
+		return nil, "", ErrNoThumbnail
+	}
+	return stdout.Bytes(), ".jpg", nil
+}
+
+// scaleDown shrinks src to fit into maxSize x maxSize pixels, averaging the source
+// pixels covered by each thumbnail pixel. Smaller images are returned as they are.
+func scaleDown(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSize && h <= maxSize || w == 0 || h == 0 {
+		return src
+	}
+	tw, th := maxSize, h*maxSize/w
+	if h > w {
+		tw, th = w*maxSize/h, maxSize
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := bounds.Min.Y+ty*h/th, bounds.Min.Y+(ty+1)*h/th
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := bounds.Min.X+tx*w/tw, bounds.Min.X+(tx+1)*w/tw
+			var r, g, b, a, n uint64
+			for y := y0; y < max(y1, y0+1); y++ {
+				for x := x0; x < max(x1, x0+1); x++ {
+					pr, pg, pb, pa := src.At(x, y).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			// Average premultiplied values, then convert back to non-premultiplied
+			c := color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}
+			dst.Set(tx, ty, c)
+		}
+	}
+	return dst
+}
@@ -1,28 +1,93 @@
 package util
 
-import "path/filepath"
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
 
-// GetContentType returns the content type based on file extension
+// sniffLen is the number of bytes http.DetectContentType considers
+const sniffLen = 512
+
+// GetContentType returns the content type based on file extension,
+// using the built-in table, user overrides and the system MIME database
 func GetContentType(fileName string) string {
 	ext := filepath.Ext(fileName)
-	switch ext {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".pdf":
-		return "application/pdf"
-	case ".txt":
-		return "text/plain"
-	case ".html":
-		return "text/html"
-	case ".mp4":
-		return "video/mp4"
-	case ".mp3":
-		return "audio/mpeg"
-	default:
+	if ext == "" {
 		return "application/octet-stream"
 	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// DetectContentType returns the content type based on file extension,
+// falling back to sniffing the leading bytes of the content when the extension is unknown
+func DetectContentType(fileName string, head []byte) string {
+	contentType := GetContentType(fileName)
+	if contentType != "application/octet-stream" || len(head) == 0 {
+		return contentType
+	}
+	return http.DetectContentType(head)
+}
+
+// GetFileContentType returns the content type of a local file, sniffing its content if needed.
+// Textual types carry the detected charset.
+func GetFileContentType(path string, fileName string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return GetContentType(fileName)
+	}
+	defer file.Close()
+
+	head := make([]byte, charsetSampleLen)
+	n, err := io.ReadFull(file, head)
+	complete := err == io.EOF || err == io.ErrUnexpectedEOF
+	head = head[:n]
+
+	sniffHead := head
+	if len(sniffHead) > sniffLen {
+		sniffHead = sniffHead[:sniffLen]
+	}
+	return withDetectedCharset(DetectContentType(fileName, sniffHead), head, !complete)
+}
+
+// PeekContentType returns the content type of the data in r, sniffing its content if needed.
+// The returned reader yields the full data, including any bytes consumed while sniffing.
+func PeekContentType(r io.Reader, fileName string) (string, io.Reader) {
+	contentType := GetContentType(fileName)
+	if contentType != "application/octet-stream" && !IsTextContentType(contentType) {
+		return contentType, r
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	complete := err == io.EOF || err == io.ErrUnexpectedEOF
+	head = head[:n]
+	contentType = withDetectedCharset(DetectContentType(fileName, head), head, !complete)
+
+	// Rewind seekable readers so callers keep a seekable body
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, serr := seeker.Seek(int64(-n), io.SeekCurrent); serr == nil {
+			return contentType, r
+		}
+	}
+
+	if complete {
+		// The whole content fits into head
+		return contentType, bytes.NewReader(head)
+	}
+	return contentType, io.MultiReader(bytes.NewReader(head), r)
+}
+
+// withDetectedCharset adds the charset detected from sample to textual content types
+func withDetectedCharset(contentType string, sample []byte, truncated bool) string {
+	if !IsTextContentType(contentType) {
+		return contentType
+	}
+	return WithCharset(contentType, detectCharset(sample, truncated))
 }
@@ -0,0 +1,186 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Watermark sizes relative to the watermarked image
+const (
+	watermarkTextHeight = 0.04 // Height of the text, as a fraction of the image height
+	watermarkImageWidth = 0.2  // Maximum width of an image watermark, as a fraction of the image width
+	watermarkMargin     = 0.02 // Distance from the edges, as a fraction of the shorter side
+)
+
+// ErrUnsupportedImage is returned for images in formats that can't be watermarked
+var ErrUnsupportedImage = errors.New("unsupported image format, only JPEG and PNG can be watermarked")
+
+// Watermark is a text or image stamped onto images
+type Watermark struct {
+	Text     string
+	Image    image.Image // Drawn instead of the text if set
+	Position string      // One of the Watermark* positions, bottom-right if empty
+	Opacity  float64     // 0 is invisible, 1 opaque
+}
+
+// NewWatermark returns the watermark with the given text, or the image read from
+// imagePath if not empty
+func NewWatermark(text string, imagePath string, position string, opacity float64) (*Watermark, error) {
+	position = strings.ToLower(strings.TrimSpace(position))
+	switch position {
+	case "":
+		position = WatermarkBottomRight
+	case WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+	default:
+		return nil, fmt.Errorf("invalid watermark position %q, expected top-left, top-right, bottom-left, bottom-right or center", position)
+	}
+	if opacity <= 0 || opacity > 1 {
+		return nil, fmt.Errorf("invalid watermark opacity %v, expected a value above 0 up to 1", opacity)
+	}
+
+	w := &Watermark{Text: text, Position: position, Opacity: opacity}
+	if imagePath != "" {
+		file, err := os.Open(imagePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open watermark image: %w", err)
+		}
+		defer file.Close()
+		if w.Image, _, err = image.Decode(file); err != nil {
+			return nil, fmt.Errorf("failed to decode watermark image: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// Apply writes the image at path with the watermark to outFile, in the same format.
+// It returns ErrUnsupportedImage for images other than JPEG and PNG.
+func (w *Watermark) Apply(path string, outFile string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, format, err := image.DecodeConfig(file)
+	if err != nil || format != "jpeg" && format != "png" {
+		return ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxThumbnailPixels {
+		return fmt.Errorf("image of %dx%d pixels is too large to watermark", config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	dst := image.NewNRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	mark, err := w.render(dst.Bounds())
+	if err != nil {
+		return err
+	}
+	at := w.place(dst.Bounds(), mark.Bounds())
+	alpha := image.NewUniform(color.Alpha{A: uint8(w.Opacity * 255)})
+	draw.DrawMask(dst, mark.Bounds().Add(at), mark, mark.Bounds().Min, alpha, image.Point{}, draw.Over)
+
+	out, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	if format == "png" {
+		err = png.Encode(out, dst)
+	} else {
+		err = jpeg.Encode(out, dst, &jpeg.Options{Quality: 90})
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// render draws the watermark at its size for an image with the given bounds
+func (w *Watermark) render(bounds image.Rectangle) (image.Image, error) {
+	if w.Image != nil {
+		width := max(int(float64(bounds.Dx())*watermarkImageWidth), 1)
+		src := w.Image.Bounds()
+		if src.Dx() <= width {
+			return w.Image, nil
+		}
+		height := max(src.Dy()*width/src.Dx(), 1)
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), w.Image, src, draw.Src, nil)
+		return scaled, nil
+	}
+
+	parsed, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+	size := max(float64(bounds.Dy())*watermarkTextHeight, 10)
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	// White text with a dark outline, readable on light and dark images
+	outline := max(int(size/16), 1)
+	textBounds, advance := font.BoundString(face, w.Text)
+	width := advance.Ceil() + 2*outline
+	height := (textBounds.Max.Y - textBounds.Min.Y).Ceil() + 2*outline
+	mark := image.NewNRGBA(image.Rect(0, 0, width, height))
+	origin := fixed.P(outline, outline-textBounds.Min.Y.Floor())
+	for dy := -outline; dy <= outline; dy++ {
+		for dx := -outline; dx <= outline; dx++ {
+			drawer := &font.Drawer{Dst: mark, Src: image.NewUniform(color.NRGBA{A: 160}), Face: face,
+				Dot: origin.Add(fixed.P(dx, dy))}
+			drawer.DrawString(w.Text)
+		}
+	}
+	drawer := &font.Drawer{Dst: mark, Src: image.White, Face: face, Dot: origin}
+	drawer.DrawString(w.Text)
+	return mark, nil
+}
+
+// place returns where the watermark goes on the image
+func (w *Watermark) place(bounds image.Rectangle, mark image.Rectangle) image.Point {
+	margin := int(float64(min(bounds.Dx(), bounds.Dy())) * watermarkMargin)
+	left, top := bounds.Min.X+margin, bounds.Min.Y+margin
+	right, bottom := bounds.Max.X-margin-mark.Dx(), bounds.Max.Y-margin-mark.Dy()
+	switch w.Position {
+	case WatermarkTopLeft:
+		return image.Pt(left, top)
+	case WatermarkTopRight:
+		return image.Pt(right, top)
+	case WatermarkBottomLeft:
+		return image.Pt(left, bottom)
+	case WatermarkCenter:
+		return image.Pt(bounds.Min.X+(bounds.Dx()-mark.Dx())/2, bounds.Min.Y+(bounds.Dy()-mark.Dy())/2)
+	default:
+		return image.Pt(right, bottom)
+	}
+}
@@ -0,0 +1,174 @@
+package util
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// WinZip AES encryption (AE-2) with 256-bit keys, which 7-Zip, WinZip, WinRAR and
+// libarchive can open, unlike unzip and the zip support built into Windows
+const (
+	zipMethodAES       = 99
+	zipExtraAES        = 0x9901
+	zipAESStrength256  = 3
+	zipAESKeySize      = 32
+	zipAESSaltSize     = 16
+	zipAESVerifierSize = 2
+	zipAESAuthSize     = 10
+	zipAESIterations   = 1000
+)
+
+// addEncryptedZipFile compresses a file and encrypts it with password into the
+// archive. The encrypted data is written to a temp file first, since the
+// archive needs its size before it.
+func addEncryptedZipFile(archive *zip.Writer, file DirFile, password string) error {
+	in, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "zipcrypt-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := encryptZipData(tmp, in, password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", file.Name, err)
+	}
+	encryptedSize, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(file.Name, "/")
+	header.Method = zipMethodAES
+	header.Flags |= 0x1 // Encrypted
+	header.CRC32 = 0    // AE-2 authenticates the data instead
+	header.CompressedSize64 = uint64(encryptedSize)
+	header.UncompressedSize64 = uint64(size)
+	header.Extra = zipAESExtra(zip.Deflate)
+	out, err := archive.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, tmp); err != nil {
+		return fmt.Errorf("failed to add %s to the archive: %w", file.Name, err)
+	}
+	return nil
+}
+
+// encryptZipData writes the data of an AES-encrypted zip entry to w: the salt,
+// the password verifier, the deflated and encrypted data of r and the
+// authentication code. It returns the number of bytes read from r.
+func encryptZipData(w io.Writer, r io.Reader, password string) (int64, error) {
+	salt := make([]byte, zipAESSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return 0, err
+	}
+	aesKey, macKey, verifier, err := zipAESKeys(password, salt)
+	if err != nil {
+		return 0, err
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(append(salt, verifier...)); err != nil {
+		return 0, err
+	}
+
+	mac := hmac.New(sha1.New, macKey)
+	encrypted := &cipher.StreamWriter{S: newZipAESStream(block), W: io.MultiWriter(w, mac)}
+	compressor, err := flate.NewWriter(encrypted, flate.DefaultCompression)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(compressor, r)
+	if err != nil {
+		return 0, err
+	}
+	if err := compressor.Close(); err != nil {
+		return 0, err
+	}
+	_, err = w.Write(mac.Sum(nil)[:zipAESAuthSize])
+	return size, err
+}
+
+// zipAESKeys derives the encryption key, the authentication key and the password
+// verifier of an AES-encrypted zip entry
+func zipAESKeys(password string, salt []byte) (aesKey []byte, macKey []byte, verifier []byte, err error) {
+	key, err := pbkdf2.Key(sha1.New, password, salt, zipAESIterations, 2*zipAESKeySize+zipAESVerifierSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return key[:zipAESKeySize], key[zipAESKeySize : 2*zipAESKeySize], key[2*zipAESKeySize:], nil
+}
+
+// zipAESExtra returns the extra field marking an entry as AE-2 encrypted with
+// 256-bit keys, compressed with method
+func zipAESExtra(method uint16) []byte {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], zipExtraAES)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], 2) // AE-2
+	copy(extra[6:], "AE")
+	extra[8] = zipAESStrength256
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	return extra
+}
+
+// zipAESStream is AES in counter mode as WinZip uses it: the counter is a
+// little-endian integer starting at 1, unlike the big-endian one of cipher.NewCTR
+type zipAESStream struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	used    int
+}
+
+func newZipAESStream(block cipher.Block) *zipAESStream {
+	return &zipAESStream{block: block, used: aes.BlockSize}
+}
+
+func (s *zipAESStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+			s.block.Encrypt(s.stream[:], s.counter[:])
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.stream[s.used]
+		s.used++
+	}
+}
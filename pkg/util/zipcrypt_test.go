@@ -0,0 +1,119 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// decryptZipEntry reads an AES-encrypted entry the way WinZip specifies it
+func decryptZipEntry(t *testing.T, f *zip.File, password string) ([]byte, error) {
+	t.Helper()
+	if f.Method != zipMethodAES || f.Flags&0x1 == 0 || !bytes.Contains(f.Extra, zipAESExtra(zip.Deflate)) {
+		t.Fatalf("%s isn't marked as AES-encrypted", f.Name)
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, verifier := data[:zipAESSaltSize], data[zipAESSaltSize:zipAESSaltSize+zipAESVerifierSize]
+	encrypted, auth := data[zipAESSaltSize+zipAESVerifierSize:len(data)-zipAESAuthSize], data[len(data)-zipAESAuthSize:]
+
+	aesKey, macKey, wantVerifier, err := zipAESKeys(password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verifier, wantVerifier) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(encrypted)
+	if !bytes.Equal(mac.Sum(nil)[:zipAESAuthSize], auth) {
+		t.Fatalf("%s fails authentication", f.Name)
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := make([]byte, len(encrypted))
+	newZipAESStream(block).XORKeyStream(compressed, encrypted)
+	return io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+}
+
+func TestZipFilesEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	large := make([]byte, 300<<10) // Long enough to carry over the low byte of the counter
+	rand.Read(large)
+	contents := map[string][]byte{"a.txt": []byte("secret notes"), "data/large.bin": large, "empty.txt": {}}
+	var files []DirFile
+	for _, name := range []string{"a.txt", "data/large.bin", "empty.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, contents[name], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, DirFile{Name: name, Path: path, Size: int64(len(contents[name]))})
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "files.zip")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ZipFiles(out, files, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if len(archive.File) != len(files) {
+		t.Fatalf("archive has %d files, want %d", len(archive.File), len(files))
+	}
+	for _, f := range archive.File {
+		got, err := decryptZipEntry(t, f, "correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if !bytes.Equal(got, contents[f.Name]) || f.UncompressedSize64 != uint64(len(got)) {
+			t.Errorf("%s decrypted to %d bytes, want its %d bytes", f.Name, len(got), len(contents[f.Name]))
+		}
+		if _, err := decryptZipEntry(t, f, "wrong"); err == nil {
+			t.Errorf("%s passed the verifier with a wrong password", f.Name)
+		}
+		if _, err := f.Open(); err == nil {
+			t.Errorf("%s can be read without the password", f.Name)
+		}
+	}
+
+	// Cross-check with libarchive, which implements WinZip AES independently
+	bsdtar, err := exec.LookPath("bsdtar")
+	if err != nil {
+		return
+	}
+	extracted := t.TempDir()
+	if output, err := exec.Command(bsdtar, "-xf", archivePath, "-C", extracted, "--passphrase", "correct horse").CombinedOutput(); err != nil {
+		t.Fatalf("bsdtar failed: %v: %s", err, output)
+	}
+	for name, content := range contents {
+		if got, err := os.ReadFile(filepath.Join(extracted, filepath.FromSlash(name))); err != nil || !bytes.Equal(got, content) {
+			t.Errorf("bsdtar extracted %s wrong: %v", name, err)
+		}
+	}
+}
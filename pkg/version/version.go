@@ -7,18 +7,49 @@ import (
 	"strings"
 )
 
+// Set via ldflags, e.g.
+// -X github.com/sjzar/file-store-mcp/pkg/version.Version=v1.0.0
+// -X github.com/sjzar/file-store-mcp/pkg/version.Commit=abc1234
+// -X github.com/sjzar/file-store-mcp/pkg/version.BuildDate=2025-01-01T00:00:00Z
 var (
 	Version   = "(dev)"
+	Commit    = ""
+	BuildDate = ""
 	buildInfo = debug.BuildInfo{}
 )
 
 func init() {
 	if bi, ok := debug.ReadBuildInfo(); ok {
 		buildInfo = *bi
-		if len(bi.Main.Version) > 0 {
+		if len(bi.Main.Version) > 0 && Version == "(dev)" {
 			Version = bi.Main.Version
 		}
+		// Fall back to the VCS information stamped by the go command
+		if Commit == "" {
+			Commit = buildSetting("vcs.revision")
+		}
+		if BuildDate == "" {
+			BuildDate = buildSetting("vcs.time")
+		}
+	}
+}
+
+// BuildTags returns the build tags the binary was built with, e.g. "cocoa"
+func BuildTags() []string {
+	tags := buildSetting("-tags")
+	if tags == "" {
+		return nil
 	}
+	return strings.Split(tags, ",")
+}
+
+func buildSetting(key string) string {
+	for _, setting := range buildInfo.Settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
 }
 
 func GetMore(mod bool) string {
@@ -30,3 +61,48 @@ func GetMore(mod bool) string {
 	}
 	return fmt.Sprintf("version %s %s %s/%s\n", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 }
+
+// Info is the version information in a machine-readable form
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit,omitempty"`
+	BuildDate string   `json:"build_date,omitempty"`
+	BuildTags []string `json:"build_tags,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Modules   []string `json:"modules,omitempty"`
+}
+
+// GetInfo returns the version information, with the module dependencies if mod is set
+func GetInfo(mod bool) Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		BuildTags: BuildTags(),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if mod {
+		for _, dep := range buildInfo.Deps {
+			info.Modules = append(info.Modules, dep.Path+"@"+dep.Version)
+		}
+	}
+	return info
+}
+
+// GetDetails returns the version, commit, build date and build tags, one per line
+func GetDetails() string {
+	commit, buildDate, tags := Commit, BuildDate, strings.Join(BuildTags(), ",")
+	if commit == "" {
+		commit = "unknown"
+	}
+	if buildDate == "" {
+		buildDate = "unknown"
+	}
+	if tags == "" {
+		tags = "none"
+	}
+	return fmt.Sprintf("version:    %s\ncommit:     %s\nbuild date: %s\nbuild tags: %s\ngo:         %s %s/%s\n",
+		Version, commit, buildDate, tags, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}